@@ -0,0 +1,98 @@
+package cheerio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Chooses which release file of a package requirements and metadata are read from (see PackageIndex.Policy)
+type ArtifactPolicy interface {
+	// Returns the preferred of the candidate files, or nil if none is acceptable. If ver isn't empty, the candidates are the files of that
+	// release; otherwise they are the files of every release.
+	Select(candidates []*Artifact, ver string) *Artifact
+}
+
+// The policy of indexes that don't set one: the tar, egg or zip of the highest release, pre-releases included
+var DefaultArtifactPolicy ArtifactPolicy = VersionPolicy{}
+
+// Selects a file of the highest release by PEP 440 ordering. Within a release, tars are preferred over eggs over zips, and wheels are only
+// selected with PreferWheels.
+type VersionPolicy struct {
+	PreferWheels    bool // select a wheel, pure-Python ones first, over the other files of a release that has one
+	SkipPreReleases bool // like pip, ignore pre-releases and development releases, unless a package has nothing else (or one is requested)
+}
+
+func (vp VersionPolicy) Select(candidates []*Artifact, ver string) *Artifact {
+	if vp.SkipPreReleases && ver == "" {
+		var finals []*Artifact
+		for _, artifact := range candidates {
+			if v, err := parseVersion(artifact.Version); err != nil || !v.isPrerelease() {
+				finals = append(finals, artifact)
+			}
+		}
+		if len(finals) > 0 {
+			candidates = finals
+		}
+	}
+
+	var best *Artifact
+	bestRank := 0
+	for _, artifact := range candidates {
+		rank := vp.rank(artifact)
+		if rank < 0 {
+			continue
+		}
+		if best == nil {
+			best, bestRank = artifact, rank
+			continue
+		}
+		// later files win ties, as the last listed file of a kind used to be read
+		if cmp := CompareVersions(artifact.Version, best.Version); cmp > 0 || (cmp == 0 && rank <= bestRank) {
+			best, bestRank = artifact, rank
+		}
+	}
+	return best
+}
+
+// Returns how preferred a file is within its release, lowest first, or -1 if it can't be selected
+func (vp VersionPolicy) rank(artifact *Artifact) int {
+	var rank int
+	switch {
+	case artifact.Type == ArtifactWheel && !vp.PreferWheels:
+		return -1
+	case artifact.Type == ArtifactWheel && isPureWheel(artifact):
+		return 0
+	case artifact.Type == ArtifactWheel:
+		return 1
+	case artifact.Type == ArtifactSdist && tarRegexp.MatchString(artifact.Filename):
+		rank = 0
+	case artifact.Type == ArtifactEgg:
+		rank = 1
+	case artifact.Type == ArtifactSdist:
+		rank = 2
+	default:
+		return -1
+	}
+	if vp.PreferWheels {
+		rank += 2
+	}
+	return rank
+}
+
+// Parses a policy from a comma-separated list of preferences: "wheels" (PreferWheels) and "no-prereleases" (SkipPreReleases), e.g.,
+// "wheels,no-prereleases". The empty list is the default policy.
+func ParseVersionPolicy(list string) (VersionPolicy, error) {
+	var vp VersionPolicy
+	for _, name := range strings.Split(list, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "wheels":
+			vp.PreferWheels = true
+		case "no-prereleases":
+			vp.SkipPreReleases = true
+		case "":
+		default:
+			return vp, fmt.Errorf("Unknown artifact preference '%s' (expected wheels or no-prereleases)", name)
+		}
+	}
+	return vp, nil
+}
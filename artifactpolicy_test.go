@@ -0,0 +1,54 @@
+package cheerio
+
+import "testing"
+
+func TestVersionPolicy(t *testing.T) {
+	var artifacts []*Artifact
+	for _, filename := range []string{
+		"pkg-2.0b1.tar.gz",
+		"pkg-1.0.tar.gz",
+		"pkg-1.0-py2.7.egg",
+		"pkg-1.1.zip",
+		"pkg-1.1-py2.7.egg",
+		"pkg-1.1-cp27-cp27mu-manylinux1_x86_64.whl",
+		"pkg-1.1-py2.py3-none-any.whl",
+		"pkg-1.1.win32.exe",
+	} {
+		artifact := &Artifact{Filename: filename}
+		artifact.Type, artifact.Version = artifactTypeAndVersion("pkg", filename)
+		artifacts = append(artifacts, artifact)
+	}
+
+	tests := []struct {
+		policy VersionPolicy
+		ver    string
+		exp    string
+	}{
+		{VersionPolicy{}, "", "pkg-2.0b1.tar.gz"},
+		{VersionPolicy{SkipPreReleases: true}, "", "pkg-1.1-py2.7.egg"},
+		{VersionPolicy{SkipPreReleases: true, PreferWheels: true}, "", "pkg-1.1-py2.py3-none-any.whl"},
+		{VersionPolicy{SkipPreReleases: true}, "2.0b1", "pkg-2.0b1.tar.gz"},
+		{VersionPolicy{}, "1.0", "pkg-1.0.tar.gz"},
+	}
+	for _, test := range tests {
+		var candidates []*Artifact
+		for _, artifact := range artifacts {
+			if test.ver == "" || artifact.Version == test.ver {
+				candidates = append(candidates, artifact)
+			}
+		}
+		if selected := test.policy.Select(candidates, test.ver); selected == nil || selected.Filename != test.exp {
+			t.Errorf("%+v.Select(%q): expected %s, got %+v", test.policy, test.ver, test.exp, selected)
+		}
+	}
+
+	if selected := (VersionPolicy{}).Select(artifacts[5:], ""); selected != nil {
+		t.Errorf("Select: expected no file without PreferWheels among wheels and installers, got %s", selected.Filename)
+	}
+	if policy, err := ParseVersionPolicy("wheels, no-prereleases"); err != nil || !policy.PreferWheels || !policy.SkipPreReleases {
+		t.Errorf("ParseVersionPolicy: unexpected %+v, %v", policy, err)
+	}
+	if _, err := ParseVersionPolicy("eggs"); err == nil {
+		t.Error("ParseVersionPolicy: expected an error for an unknown preference")
+	}
+}
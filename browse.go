@@ -0,0 +1,300 @@
+package cheerio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An interactive, line-oriented explorer of a dependency graph, reading commands from a terminal (see Run). It shows the dependency (or
+// dependent) tree of a package, whose nodes are numbered so they can be expanded, opened, or looked up by number, e.g.:
+//
+//	flask (6 dependencies, 812 dependents)
+//	  [1] click +
+//	  [2] itsdangerous
+//	  [3] jinja2 +
+//
+// Commands are listed by "help".
+type GraphBrowser struct {
+	Graph *PyPIGraph
+
+	// Returns the source repository URL of a package for the "repo" command, e.g., DefaultPyPI.FetchSourceRepoURL. The command is
+	// unavailable if nil.
+	RepoURL func(pkg string) (string, error)
+
+	current    string
+	dependents bool            // whether the tree shows dependents instead of dependencies
+	expanded   map[string]bool // expanded nodes of the tree
+	history    []string        // previously opened packages
+	items      []string        // packages numbered by the last listing
+}
+
+const browseHelp = `Commands:
+  search <query>    list packages matching a query, prefix, glob or /regexp/ (see PyPIGraph.Search)
+  open <pkg|n>      show the tree of a package, or of item n of the last listing
+  <n>               same as open <n>
+  expand <n|all>    expand item n of the tree, or every node up to 3 levels deep
+  collapse          collapse the tree
+  deps, rdeps       show the dependencies or the dependents of packages
+  repo [n]          print the source repository URL of the current package, or of item n
+  export <file>     write the packages and edges of the visible tree to a graph file (see PyPIGraph.WriteFile)
+  back              reopen the previous package
+  help              show this help
+  quit              exit
+`
+
+// Maximum number of search results listed
+const browseSearchLimit = 30
+
+// Depth up to which "expand all" expands the tree
+const browseExpandAllDepth = 3
+
+// Reads commands from in until it is exhausted or "quit" is entered, writing output and prompts to out
+func (b *GraphBrowser) Run(in io.Reader, out io.Writer) error {
+	if b.expanded == nil {
+		b.expanded = make(map[string]bool)
+	}
+	fmt.Fprint(out, "Type 'help' for commands.\n> ")
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if err := b.Exec(line, out); err != nil {
+			fmt.Fprintf(out, "Error: %s\n", err)
+		}
+		fmt.Fprint(out, "> ")
+	}
+	return scanner.Err()
+}
+
+// Runs one command line (see Run)
+func (b *GraphBrowser) Exec(line string, out io.Writer) error {
+	if b.expanded == nil {
+		b.expanded = make(map[string]bool)
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	cmd, arg := fields[0], strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+	if _, err := strconv.Atoi(cmd); err == nil {
+		cmd, arg = "open", cmd
+	}
+
+	switch cmd {
+	case "help":
+		fmt.Fprint(out, browseHelp)
+	case "search":
+		matches, err := b.Graph.Search(arg, browseSearchLimit)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			fmt.Fprintln(out, "No matching packages")
+			if suggestions := b.Graph.Suggest(arg, 5); len(suggestions) > 0 {
+				fmt.Fprintf(out, "Did you mean %s?\n", strings.Join(suggestions, ", "))
+			}
+		}
+		b.items = matches
+		for i, pkg := range matches {
+			fmt.Fprintf(out, "  [%d] %s\n", i+1, pkg)
+		}
+	case "open":
+		pkg, err := b.item(arg)
+		if err != nil {
+			return err
+		}
+		if !b.Graph.Contains(pkg) {
+			if suggestions := b.Graph.Suggest(pkg, 5); len(suggestions) > 0 {
+				return fmt.Errorf("pkg %s is not in the graph; did you mean %s?", pkg, strings.Join(suggestions, ", "))
+			}
+			return fmt.Errorf("pkg %s is not in the graph", pkg)
+		}
+		if b.current != "" && b.current != pkg {
+			b.history = append(b.history, b.current)
+		}
+		b.open(pkg)
+		b.render(out)
+	case "back":
+		if len(b.history) == 0 {
+			return fmt.Errorf("No previous package")
+		}
+		b.open(b.history[len(b.history)-1])
+		b.history = b.history[:len(b.history)-1]
+		b.render(out)
+	case "expand":
+		if b.current == "" {
+			return fmt.Errorf("No package is open")
+		}
+		if arg == "all" {
+			b.expandAll(b.current, browseExpandAllDepth, map[string]bool{b.current: true})
+		} else {
+			pkg, err := b.item(arg)
+			if err != nil {
+				return err
+			}
+			b.expanded[pkg] = true
+		}
+		b.render(out)
+	case "collapse":
+		b.expanded = map[string]bool{b.current: true}
+		b.render(out)
+	case "deps", "rdeps":
+		b.dependents = cmd == "rdeps"
+		if b.current != "" {
+			b.render(out)
+		}
+	case "repo":
+		if b.RepoURL == nil {
+			return fmt.Errorf("Repository URLs are unavailable")
+		}
+		pkg := b.current
+		if arg != "" {
+			var err error
+			if pkg, err = b.item(arg); err != nil {
+				return err
+			}
+		}
+		if pkg == "" {
+			return fmt.Errorf("No package is open")
+		}
+		repoURL, err := b.RepoURL(pkg)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s %s\n", pkg, repoURL)
+	case "export":
+		if b.current == "" || arg == "" {
+			return fmt.Errorf("Usage: export <file> (with a package open)")
+		}
+		graph := b.VisibleGraph()
+		if err := graph.WriteFile(arg); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Wrote %d packages to %s\n", len(graph.sortedPkgs()), arg)
+	default:
+		return fmt.Errorf("Unknown command %q (type 'help' for commands)", cmd)
+	}
+	return nil
+}
+
+// Returns the package named by arg: item n of the last listing if arg is a number, and the package named arg otherwise
+func (b *GraphBrowser) item(arg string) (string, error) {
+	if arg == "" {
+		return "", fmt.Errorf("Missing package name or number")
+	}
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(b.items) {
+			return "", fmt.Errorf("No item %d", n)
+		}
+		return b.items[n-1], nil
+	}
+	return b.Graph.NormalizedName(arg), nil
+}
+
+func (b *GraphBrowser) open(pkg string) {
+	b.current = pkg
+	b.expanded = map[string]bool{pkg: true}
+}
+
+// Returns the children of pkg in the tree, sorted
+func (b *GraphBrowser) children(pkg string) []string {
+	var adj []string
+	if b.dependents {
+		adj = b.Graph.RequiredBy(pkg)
+	} else {
+		adj = b.Graph.Requires(pkg)
+	}
+	children := make([]string, 0, len(adj))
+	for _, child := range adj {
+		if !containsString(children, child) {
+			children = append(children, child)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+func (b *GraphBrowser) expandAll(pkg string, depth int, path map[string]bool) {
+	b.expanded[pkg] = true
+	if depth <= 1 {
+		return
+	}
+	for _, child := range b.children(pkg) {
+		if !path[child] {
+			path[child] = true
+			b.expandAll(child, depth-1, path)
+			delete(path, child)
+		}
+	}
+}
+
+// Calls visit with each node of the visible tree, its parent ("" for the root), and its depth, in display order. Packages already on the path
+// from the root (i.e., cycles) aren't descended into.
+func (b *GraphBrowser) walk(visit func(pkg, parent string, depth int, cycle bool)) {
+	var walk func(pkg, parent string, depth int, path map[string]bool)
+	walk = func(pkg, parent string, depth int, path map[string]bool) {
+		cycle := path[pkg]
+		visit(pkg, parent, depth, cycle)
+		if cycle || !b.expanded[pkg] {
+			return
+		}
+		path[pkg] = true
+		for _, child := range b.children(pkg) {
+			walk(child, pkg, depth+1, path)
+		}
+		delete(path, pkg)
+	}
+	walk(b.current, "", 0, make(map[string]bool))
+}
+
+// Prints the tree of the current package, numbering its nodes
+func (b *GraphBrowser) render(out io.Writer) {
+	deps, found := b.Graph.Lookup(b.current)
+	summary := fmt.Sprintf("%d dependencies", len(deps))
+	if !found {
+		summary = "dependencies unknown"
+	}
+	fmt.Fprintf(out, "%s (%s, %d dependents)\n", b.Graph.DisplayName(b.current), summary, len(b.Graph.RequiredBy(b.current)))
+	if b.dependents {
+		fmt.Fprintln(out, "Dependents:")
+	}
+
+	b.items = b.items[:0]
+	b.walk(func(pkg, parent string, depth int, cycle bool) {
+		if parent == "" {
+			return
+		}
+		b.items = append(b.items, pkg)
+		marker := ""
+		if cycle {
+			marker = " (cycle)"
+		} else if !b.expanded[pkg] && len(b.children(pkg)) > 0 {
+			marker = " +"
+		}
+		fmt.Fprintf(out, "%s[%d] %s%s\n", strings.Repeat("  ", depth), len(b.items), pkg, marker)
+	})
+}
+
+// Returns the subgraph of the packages and edges of the visible tree
+func (b *GraphBrowser) VisibleGraph() *PyPIGraph {
+	graph := newEcosystemGraph(b.Graph.Ecosystem())
+	if b.current == "" {
+		return graph
+	}
+	b.walk(func(pkg, parent string, depth int, cycle bool) {
+		if parent == "" {
+			graph.addPkg(b.Graph.DisplayName(pkg))
+		} else if b.dependents {
+			graph.addEdge(b.Graph.DisplayName(pkg), b.Graph.DisplayName(parent))
+		} else {
+			graph.addEdge(b.Graph.DisplayName(parent), b.Graph.DisplayName(pkg))
+		}
+	})
+	return graph
+}
@@ -0,0 +1,59 @@
+package cheerio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGraphBrowser(t *testing.T) {
+	g := testGraph("app:flask", "app:Click", "flask:click", "flask:jinja2", "jinja2:markupsafe", "docs:flask")
+	dir, err := ioutil.TempDir("", "cheerio-browse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	exported := filepath.Join(dir, "visible")
+
+	b := &GraphBrowser{Graph: g, RepoURL: func(pkg string) (string, error) { return "https://github.com/pallets/" + pkg, nil }}
+	commands := []string{"search fla", "1", "expand 2", "repo 3", "open jinja2", "rdeps", "back", "open nope", "export " + exported, "quit", "help"}
+	var out bytes.Buffer
+	if err := b.Run(strings.NewReader(strings.Join(commands, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{
+		"  [1] flask\n",
+		"flask (2 dependencies, 2 dependents)\n  [1] click\n  [2] jinja2 +\n",
+		"  [1] click\n  [2] jinja2\n    [3] markupsafe\n",
+		"markupsafe https://github.com/pallets/markupsafe\n",
+		"Dependents:\n  [1] app\n  [2] docs\n",
+		"Error: pkg nope is not in the graph",
+	} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("expected output to contain %q, got\n%s", exp, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Commands:") {
+		t.Errorf("expected commands after quit to be ignored")
+	}
+
+	// "back" reopened flask, showing its dependents
+	visible, err := NewPyPIGraph(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edges, exp := visible.Edges(), []Edge{{"app", "flask"}, {"docs", "flask"}}; !reflect.DeepEqual(edges, exp) {
+		t.Errorf("expected the exported graph to hold the dependents of flask, got %v", edges)
+	}
+
+	b.Exec("deps", &out)
+	b.Exec("expand all", &out)
+	if edges, exp := b.VisibleGraph().Edges(), []Edge{{"flask", "click"}, {"flask", "jinja2"}, {"jinja2", "markupsafe"}}; !reflect.DeepEqual(edges, exp) {
+		t.Errorf("VisibleGraph: expected %v, got %v", exp, edges)
+	}
+}
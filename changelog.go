@@ -0,0 +1,158 @@
+package cheerio
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// A source of the packages that changed in an index since a changelog serial, e.g., a PackageIndex
+type ChangeSource interface {
+	// Returns the packages changed after serial, sorted, and the serial of the last change
+	ChangedSince(serial int64) (pkgs []string, last int64, err error)
+}
+
+// A ChangeSource that also tells which of the changed packages were deleted from the index, e.g., a PackageIndex. A GraphDaemon prunes deleted
+// packages from its graph rather than re-crawling them.
+type DeletionSource interface {
+	ChangeSource
+
+	// Like ChangedSince, but also returns the changed packages whose last change deleted them, sorted
+	ChangesSince(serial int64) (changed, deleted []string, last int64, err error)
+}
+
+// Returns the packages with changes (new releases, new files, removals, etc.) after the given changelog serial, sorted, and the serial of the last
+// of those changes, or serial itself if there were none. Uses the changelog_since_serial method of PyPI's XML-RPC API.
+func (p *PackageIndex) ChangedSince(serial int64) ([]string, int64, error) {
+	changed, _, last, err := p.ChangesSince(serial)
+	return changed, last, err
+}
+
+// Maximum number of redirects followed by postXMLRPC
+const maxXMLRPCRedirects = 10
+
+// POSTs an XML-RPC call to the /pypi endpoint of the index. Redirects are resolved and the call POSTed again to their target, as the HTTP client
+// would turn it into a GET on a 301 or 302, e.g., from https://pypi.python.org/pypi to https://pypi.org/pypi.
+func (p *PackageIndex) postXMLRPC(call []byte) (*http.Response, error) {
+	client := *p.client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	uri := p.URI + "/pypi"
+	for redirects := 0; ; redirects++ {
+		resp, err := client.Post(uri, "text/xml", bytes.NewReader(call))
+		if err != nil {
+			return nil, err
+		}
+		location, err := resp.Location()
+		if resp.StatusCode/100 != 3 || err != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if redirects == maxXMLRPCRedirects {
+			return nil, fmt.Errorf("%s: stopped after %d redirects", p.URI+"/pypi", maxXMLRPCRedirects)
+		}
+		uri = location.String()
+	}
+}
+
+// Like ChangedSince, but also returns the changed packages that were deleted ("remove project" events) and not created again since
+func (p *PackageIndex) ChangesSince(serial int64) (changed, deleted []string, last int64, err error) {
+	var req bytes.Buffer
+	fmt.Fprintf(&req, `<?xml version="1.0"?><methodCall><methodName>changelog_since_serial</methodName><params><param><value><int>%d</int></value></param></params></methodCall>`, serial)
+	resp, err := p.postXMLRPC(req.Bytes())
+	if err != nil {
+		return nil, nil, serial, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, serial, fmt.Errorf("%s returned %s for changelog_since_serial", resp.Request.URL, resp.Status)
+	}
+
+	var result xmlrpcResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, serial, fmt.Errorf("Unable to parse changelog since serial %d: %s", serial, err)
+	}
+	if result.Fault != nil {
+		return nil, nil, serial, fmt.Errorf("changelog_since_serial failed: %s", result.Fault.String())
+	}
+
+	// each entry is [name, version, timestamp, action, serial]; whether a package ends up deleted depends on its last entry
+	type change struct {
+		serial  int64
+		deleted bool
+	}
+	changes := make(map[string]change)
+	last = serial
+	for _, entry := range result.Values {
+		fields := entry.Array
+		if len(fields) < 5 {
+			continue
+		}
+		s, _ := strconv.ParseInt(fields[4].String(), 10, 64)
+		if s > last {
+			last = s
+		}
+		name := fields[0].String()
+		if c, in := changes[name]; !in || s >= c.serial {
+			changes[name] = change{serial: s, deleted: isDeletion(fields[3].String(), fields[1].String())}
+		}
+	}
+	changed = make([]string, 0, len(changes))
+	for pkg, c := range changes {
+		changed = append(changed, pkg)
+		if c.deleted {
+			deleted = append(deleted, pkg)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted, last, nil
+}
+
+// Returns whether a changelog action deleted a whole package: "remove project", or "remove" without a version in older changelogs, as opposed to
+// "remove release" or "remove file"
+func isDeletion(action, version string) bool {
+	return action == "remove project" || (action == "remove" && version == "")
+}
+
+// The response to an XML-RPC call returning an array
+type xmlrpcResponse struct {
+	Values []xmlrpcValue `xml:"params>param>value>array>data>value"`
+	Fault  *xmlrpcValue  `xml:"fault>value"`
+}
+
+type xmlrpcValue struct {
+	Int    string        `xml:"int"`
+	I4     string        `xml:"i4"`
+	Str    *string       `xml:"string"`
+	Array  []xmlrpcValue `xml:"array>data>value"`
+	Struct []struct {
+		Name  string      `xml:"name"`
+		Value xmlrpcValue `xml:"value"`
+	} `xml:"struct>member"`
+	Text string `xml:",chardata"` // untyped values are strings
+}
+
+// Returns the value as a string, and a fault struct as its members
+func (v *xmlrpcValue) String() string {
+	switch {
+	case v.Str != nil:
+		return *v.Str
+	case v.Int != "":
+		return v.Int
+	case v.I4 != "":
+		return v.I4
+	case len(v.Struct) > 0:
+		var b bytes.Buffer
+		for i, member := range v.Struct {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s: %s", member.Name, member.Value.String())
+		}
+		return b.String()
+	}
+	return v.Text
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/beyang/cheerio"
 )
@@ -18,6 +27,37 @@ const (
 	Cmd_ReqsDir  = "reqsdir"
 	Cmd_ReqGen   = "reqs-generate"
 	Cmd_TopLevel = "toplevel"
+	Cmd_Path     = "path"
+	Cmd_Cycles   = "cycles"
+	Cmd_Impact   = "impact"
+	Cmd_Top      = "top"
+	Cmd_Stats    = "stats"
+	Cmd_Diff     = "diff"
+	Cmd_Merge    = "merge"
+	Cmd_Subgraph = "subgraph"
+	Cmd_Metadata = "metadata"
+	Cmd_Repos    = "repos"
+	Cmd_RepoPkgs = "repo-pkgs"
+	Cmd_ReqsAt   = "reqs-at"
+	Cmd_Resolve  = "resolve"
+	Cmd_Conflict = "conflicts"
+	Cmd_Versions = "versions"
+	Cmd_Download = "download"
+	Cmd_InfoGen  = "info-generate"
+	Cmd_Unsupp   = "unsupported"
+	Cmd_Licenses = "licenses"
+	Cmd_SBOM     = "sbom"
+	Cmd_Typos    = "typosquats"
+	Cmd_GemGraph = "gemgraph"
+	Cmd_Convert  = "convert"
+	Cmd_Search   = "search"
+	Cmd_Common   = "common"
+	Cmd_Browse   = "browse"
+	Cmd_Serve    = "serve"
+	Cmd_Export   = "export"
+	Cmd_Viz      = "viz"
+	Cmd_Outdated = "outdated"
+	Cmd_BumpImp  = "bump-impact"
 )
 
 var Commands = map[string]func(args []string, flags *flag.FlagSet){
@@ -26,6 +66,37 @@ var Commands = map[string]func(args []string, flags *flag.FlagSet){
 	Cmd_ReqsDir:  mainReqsDir,
 	Cmd_ReqGen:   mainReqGen,
 	Cmd_TopLevel: mainTopLevel,
+	Cmd_Path:     mainPath,
+	Cmd_Cycles:   mainCycles,
+	Cmd_Impact:   mainImpact,
+	Cmd_Top:      mainTop,
+	Cmd_Stats:    mainStats,
+	Cmd_Diff:     mainDiff,
+	Cmd_Merge:    mainMerge,
+	Cmd_Subgraph: mainSubgraph,
+	Cmd_Metadata: mainMetadata,
+	Cmd_Repos:    mainRepos,
+	Cmd_RepoPkgs: mainRepoPkgs,
+	Cmd_ReqsAt:   mainReqsAt,
+	Cmd_Resolve:  mainResolve,
+	Cmd_Conflict: mainConflicts,
+	Cmd_Versions: mainVersions,
+	Cmd_Download: mainDownload,
+	Cmd_InfoGen:  mainInfoGen,
+	Cmd_Unsupp:   mainUnsupported,
+	Cmd_Licenses: mainLicenses,
+	Cmd_SBOM:     mainSBOM,
+	Cmd_Typos:    mainTyposquats,
+	Cmd_GemGraph: mainGemGraph,
+	Cmd_Convert:  mainConvert,
+	Cmd_Search:   mainSearch,
+	Cmd_Common:   mainCommon,
+	Cmd_Browse:   mainBrowse,
+	Cmd_Serve:    mainServe,
+	Cmd_Export:   mainExport,
+	Cmd_Viz:      mainViz,
+	Cmd_Outdated: mainOutdated,
+	Cmd_BumpImp:  mainBumpImpact,
 }
 
 func main() {
@@ -44,6 +115,16 @@ func main() {
 
 	subcommand := flag.Arg(0)
 
+	if file := os.Getenv(cheerio.FixturesEnv); file != "" {
+		fixtures, err := cheerio.OpenFixtures(file, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		log.Printf("[fixtures] HTTP interactions in %s (%s mode)", file, fixtures.Mode)
+		http.DefaultClient.Transport = fixtures.Transport(nil)
+	}
+
 	if cmd, in := Commands[subcommand]; in {
 		flags := flag.NewFlagSet(Cmd_Repo, flag.ExitOnError)
 		cmd(os.Args[1:], flags)
@@ -58,17 +139,47 @@ func main() {
 func mainRepo(args []string, flags *flag.FlagSet) {
 	flags.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
 	}
+	canonical := flags.Bool("canonical", false, "Canonicalize the repository URL, printing where it was found and its score after the URL")
+	verify := flags.Bool("verify", false, "Like -canonical, but also check that the repository exists")
+	ecosystem := ecosystemFlag(flags)
+	redis := flags.String("redis", "", "Cache lookups in the Redis server at this URL, e.g., redis://localhost:6379/0")
 	flags.Parse(args[1:])
 
-	if flags.NArg() < 1 {
+	if flags.NArg() < 1 || ((*canonical || *verify) && *ecosystem != "pypi") {
 		flags.Usage()
 		os.Exit(1)
 	}
 
-	pkg := cheerio.NormalizedPkgName(flags.Arg(0))
+	pkg := flags.Arg(0)
+	if *ecosystem == "pypi" { // names of other ecosystems are looked up as given, e.g., Go module paths are case-sensitive
+		pkg = cheerio.NormalizedPkgName(pkg)
+	}
+
+	if *canonical || *verify {
+		result, err := cheerio.DefaultPyPI.ResolveSourceRepo(pkg, *verify)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+		} else {
+			fmt.Printf("%s\t%s\t%.2f\t%s\n", result.URL, result.Source, result.Score, result.Confidence)
+		}
+		return
+	}
 
-	repo, err := cheerio.DefaultPyPI.FetchSourceRepoURL(pkg)
+	index := loadIndex(*ecosystem)
+	if *redis != "" {
+		cache, err := cheerio.NewRedisCache(*redis)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		defer cache.Close()
+		cached := cheerio.NewCachedIndex(index, cache)
+		cached.Prefix += *ecosystem + ":"
+		index = cached
+	}
+	repo, err := index.FetchSourceRepoURL(pkg)
 	if err != nil {
 		fmt.Printf("Error: %s\n", err)
 	} else {
@@ -76,6 +187,144 @@ func mainRepo(args []string, flags *flag.FlagSet) {
 	}
 }
 
+// Resolves the source repositories of many packages concurrently, printing one result per package in input order.
+func mainRepos(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [<package-name>... | all | -]\n", os.Args[0], args[0])
+		fmt.Fprintln(os.Stderr, "Resolves packages named as arguments, all packages on PyPI (\"all\"), or packages read one per line from stdin (\"-\").")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "tsv", "Output format: 'tsv' (package, repo URL, error) or 'json' (one object per line)")
+	canonical := flags.Bool("canonical", false, "Canonicalize repository URLs and score them (scores are only included in JSON output)")
+	verify := flags.Bool("verify", false, "Canonicalize repository URLs and check that they exist")
+	github := flags.Bool("github", false, "Canonicalize repository URLs and look up GitHub repositories with the GitHub API (set $GITHUB_TOKEN to raise the rate limit; details are only included in JSON output)")
+	concurrency := flags.Int("c", 20, "Number of packages to resolve concurrently (at least 1)")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 || (*format != "tsv" && *format != "json") || *concurrency < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	pkgs := pkgArgs(flags, cheerio.DefaultPyPI)
+
+	type repoResult struct {
+		Pkg        string
+		URL        string                 `json:",omitempty"`
+		Source     cheerio.RepoSource     `json:",omitempty"`
+		Score      float64                `json:",omitempty"`
+		Confidence cheerio.RepoConfidence `json:",omitempty"`
+		GitHub     *cheerio.GitHubRepo    `json:",omitempty"`
+		Error      string                 `json:",omitempty"`
+	}
+	results := make([]repoResult, len(pkgs))
+	var waiter sync.WaitGroup
+	throttle := make(chan int, *concurrency)
+	for p, pkg_ := range pkgs {
+		p, pkg := p, cheerio.NormalizedPkgName(pkg_)
+
+		waiter.Add(1)
+		throttle <- p
+		go func() {
+			defer waiter.Done()
+			defer func() { <-throttle }()
+
+			results[p].Pkg = pkg
+			if *canonical || *verify || *github {
+				result, err := cheerio.DefaultPyPI.ResolveSourceRepo(pkg, *verify)
+				if err != nil {
+					results[p].Error = err.Error()
+					return
+				}
+				if *github {
+					enriched, err := cheerio.DefaultGitHub.Enrich(result)
+					if err != nil {
+						results[p].Error = err.Error()
+					} else {
+						results[p].GitHub = enriched.GitHub
+					}
+				}
+				results[p].URL, results[p].Source, results[p].Score, results[p].Confidence = result.URL, result.Source, result.Score, result.Confidence
+			} else {
+				repo, err := cheerio.DefaultPyPI.FetchSourceRepoURL(pkg)
+				if err != nil {
+					results[p].Error = err.Error()
+				} else {
+					results[p].URL = repo
+				}
+			}
+		}()
+	}
+	waiter.Wait()
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, result := range results {
+		if *format == "json" {
+			enc.Encode(result)
+		} else {
+			errMsg := strings.Replace(strings.Replace(result.Error, "\t", " ", -1), "\n", " ", -1)
+			fmt.Printf("%s\t%s\t%s\n", result.Pkg, result.URL, errMsg)
+		}
+	}
+}
+
+// Returns the packages named as arguments, all packages of pkgIndex if the only argument is "all", or packages read one per line from stdin if it is
+// "-". Exits on error.
+func pkgArgs(flags *flag.FlagSet, pkgIndex cheerio.Index) []string {
+	var pkgs []string
+	switch {
+	case flags.NArg() == 1 && flags.Arg(0) == "all":
+		var err error
+		if pkgs, err = pkgIndex.AllPackages(); err != nil {
+			fmt.Fprintf(os.Stderr, "[FATAL] %s\n", err)
+			os.Exit(1)
+		}
+	case flags.NArg() == 1 && flags.Arg(0) == "-":
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if pkg := strings.TrimSpace(scanner.Text()); pkg != "" {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	default:
+		pkgs = flags.Args()
+	}
+	return pkgs
+}
+
+// Prints the packages published from a repository, using an index of resolved repositories generated by the repos command.
+func mainRepoPkgs(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s -index <repos-tsv-file> [<repo-url>]\n", os.Args[0], args[0])
+		fmt.Fprintln(os.Stderr, "Without a repository URL, lists repositories that publish more than one package.")
+		flags.PrintDefaults()
+	}
+	indexFile := flags.String("index", "", "TSV output of the repos command")
+	flags.Parse(args[1:])
+
+	if *indexFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	idx, err := cheerio.LoadRepoIndex(*indexFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading repo index: %s\n", err)
+		os.Exit(1)
+	}
+
+	if flags.NArg() < 1 {
+		for _, repoURL := range idx.MultiPackageRepos() {
+			pkgs := idx.Packages(repoURL)
+			fmt.Printf("%s (%d): %s\n", repoURL, len(pkgs), strings.Join(pkgs, " "))
+		}
+		return
+	}
+	for _, pkg := range idx.Packages(flags.Arg(0)) {
+		fmt.Println(pkg)
+	}
+}
+
 func mainTopLevel(args []string, flags *flag.FlagSet) {
 	flags.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name>\n", os.Args[0], args[0])
@@ -97,11 +346,46 @@ func mainTopLevel(args []string, flags *flag.FlagSet) {
 	}
 }
 
+func mainMetadata(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [-wheel] <package-name>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	wheel := flags.Bool("wheel", false, "Read the METADATA of the latest wheel, fetching only its zip directory and metadata with range requests")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	pkg := cheerio.NormalizedPkgName(flags.Arg(0))
+
+	var metadata *cheerio.Metadata
+	var err error
+	if *wheel {
+		metadata, err = cheerio.DefaultPyPI.FetchWheelMetadata(pkg, "")
+	} else {
+		metadata, err = cheerio.DefaultPyPI.FetchMetadata(pkg)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(metadata); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding output")
+		os.Exit(1)
+	}
+}
+
 func mainReqsDir(args []string, flags *flag.FlagSet) {
 	flags.Usage = func() {
 		fmt.Fprintf(os.Stderr, "")
 		flags.PrintDefaults()
 	}
+	python := flags.String("python", "", "Only print requirements whose environment markers hold for this Python version (e.g., 3.11)")
+	platform := flags.String("platform", "linux", "Target platform (sys_platform) for -python: linux, darwin, win32, or cygwin")
+	extra := flags.String("extra", "", "Extra to evaluate markers with, for -python")
 	flags.Parse(args[1:])
 	if flags.NArg() < 1 {
 		flags.Usage()
@@ -115,6 +399,19 @@ func mainReqsDir(args []string, flags *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	if *python != "" {
+		env, err := cheerio.NewMarkerEnv(*python, *platform)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		env["extra"] = *extra
+		if reqs, err = cheerio.FilterRequirements(reqs, env); err != nil {
+			fmt.Fprintf(os.Stderr, "Error evaluating markers: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Print requirements out
 	err = json.NewEncoder(os.Stdout).Encode(reqs)
 	if err != nil {
@@ -128,84 +425,1536 @@ func mainReqs(args []string, flags *flag.FlagSet) {
 		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name>\n", os.Args[0], args[0])
 		flags.PrintDefaults()
 	}
-	file := flags.String("graphfile", "", fmt.Sprintf("Path to PyPI dependency graph file.  Defaults to $GOPATH/src/github.com/beyang/cheerio/data/pypi_graph"))
+	file := graphFileFlag(flags)
+	base := flags.Bool("base", false, "Only list dependencies that are required without any extra")
+	extras := flags.String("extras", "", "Comma-separated extras whose dependencies are listed along with the unconditional ones (implies -base)")
+	depsDev := flags.Bool("depsdev", false, "Fetch the dependencies of the latest release from the deps.dev API instead of using the graph file (dependents are not listed)")
 	flags.Parse(args[1:])
 
-	if flags.NArg() < 1 {
+	if flags.NArg() < 1 || (*depsDev && (*base || *extras != "")) {
 		flags.Usage()
 		os.Exit(1)
 	}
 
-	pkg := cheerio.NormalizedPkgName(flags.Arg(0))
-
-	var pypiG *cheerio.PyPIGraph
-	if *file == "" {
-		pypiG = cheerio.DefaultPyPIGraph
-	} else {
-		var err error
-		pypiG, err = cheerio.NewPyPIGraph(*file)
+	var graph cheerio.DependencyGraph = cheerio.DefaultDepsDev
+	if strings.HasSuffix(*file, ".mmap") && !*depsDev {
+		mapped, err := cheerio.OpenMappedGraph(*file)
 		if err != nil {
-			fmt.Printf("Error creating PyPI graph: %s\n", err)
+			fmt.Printf("Error opening graph: %s\n", err)
 			os.Exit(1)
 		}
+		defer mapped.Close()
+		graph = mapped
+	} else if !*depsDev {
+		graph = loadGraph(*file)
 	}
 
-	pkgReq := pypiG.Requires(pkg)
-	pkgReqBy := pypiG.RequiredBy(pkg)
+	pkg := cheerio.NormalizedPkgName(flags.Arg(0))
+	if named, ok := graph.(interface{ NormalizedName(pkg string) string }); ok { // graphs of other ecosystems than PyPI
+		pkg = named.NormalizedName(flags.Arg(0))
+	}
+	pkgReq, found := graph.Lookup(pkg)
+	extrasG, hasExtras := graph.(interface {
+		RequiresWithExtras(pkg string, extras ...string) []string
+	})
+	if hasExtras && *extras != "" {
+		pkgReq = extrasG.RequiresWithExtras(pkg, strings.Split(*extras, ",")...)
+	} else if hasExtras && *base {
+		pkgReq = extrasG.RequiresWithExtras(pkg)
+	}
+	pkgReqBy := graph.RequiredBy(pkg)
+	if pypiG, isPyPIGraph := graph.(*cheerio.PyPIGraph); isPyPIGraph {
+		warnUnknownPkgs(pypiG, pkg)
+	}
+	if !found && len(pkgReqBy) > 0 {
+		fmt.Fprintf(os.Stderr, "the dependencies of pkg %s are unknown\n", pkg)
+	}
 	fmt.Printf("pkg %s uses (%d):\n  %s\nand is used by (%d):\n  %s\n", pkg, len(pkgReq), strings.Join(pkgReq, " "), len(pkgReqBy), strings.Join(pkgReqBy, " "))
 }
 
-// Prints PyPI requirement graph to stdout in the below format. Skips errors (including packages where there is no requires.txt file).
-// Example format:
-//
-// pkg1
-// pkg1:pkg2
-// pkg1:pkg3
-// pkg2
-// pkg2:pkg4
-func mainReqGen(args []string, flags *flag.FlagSet) {
-	pkgIndex := &cheerio.DefaultPyPI
-	pkgs, err := pkgIndex.AllPackages()
+// Prints the dependency chains through which one package (transitively) requires another.
+func mainPath(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <from-package> <to-package>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	max := flags.Int("n", 1, "Maximum number of paths to print (0 prints all paths)")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	pypiG := loadGraph(*file)
+	paths := pypiG.Paths(flags.Arg(0), flags.Arg(1), *max)
+	if len(paths) == 0 {
+		warnUnknownPkgs(pypiG, flags.Arg(0), flags.Arg(1))
+		fmt.Printf("pkg %s does not require %s\n", pypiG.NormalizedName(flags.Arg(0)), pypiG.NormalizedName(flags.Arg(1)))
+		os.Exit(1)
+	}
+	for _, path := range paths {
+		fmt.Println(strings.Join(path, " -> "))
+	}
+}
+
+// Prints each dependency cycle in the PyPI graph on its own line, largest cycles first.
+func mainCycles(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	flags.Parse(args[1:])
+
+	cycles := loadGraph(*file).Cycles()
+	for _, cycle := range cycles {
+		fmt.Printf("(%d) %s\n", len(cycle), strings.Join(cycle, " "))
+	}
+	fmt.Fprintf(os.Stderr, "%d cycles found\n", len(cycles))
+}
+
+// Prints the packages that all of the given packages (transitively) require, or that require all of them.
+func mainCommon(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [-dependents] <package-name> <package-name>...\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	dependents := flags.Bool("dependents", false, "Print the packages that require all of the given packages instead of the packages they all require")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	pypiG := loadGraph(*file)
+	warnUnknownPkgs(pypiG, flags.Args()...)
+	var common []string
+	if *dependents {
+		common = pypiG.CommonRequiredBy(flags.Args()...)
+	} else {
+		common = pypiG.CommonRequires(flags.Args()...)
+	}
+	for _, pkg := range common {
+		fmt.Println(pkg)
+	}
+}
+
+// Explores the PyPI graph interactively: searches packages, expands their dependency or dependent trees, and exports what is shown.
+func mainBrowse(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [<package-name>]\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	flags.Parse(args[1:])
+
+	browser := &cheerio.GraphBrowser{Graph: loadGraph(*file), RepoURL: cheerio.DefaultPyPI.FetchSourceRepoURL}
+	if flags.NArg() > 0 {
+		if err := browser.Exec("open "+flags.Arg(0), os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+	}
+	if err := browser.Run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Prints the number of packages that transitively depend on a package at each depth, i.e., the blast radius of a breaking change to it.
+func mainImpact(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	verbose := flags.Bool("v", false, "List the dependent packages at each depth")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	pypiG := loadGraph(*file)
+	pkg := pypiG.NormalizedName(flags.Arg(0))
+	warnUnknownPkgs(pypiG, pkg)
+	levels := pypiG.Impact(pkg)
+
+	total := 0
+	for d, level := range levels {
+		total += len(level)
+		fmt.Printf("depth %d: %d\n", d+1, len(level))
+		if *verbose {
+			fmt.Printf("  %s\n", strings.Join(level, " "))
+		}
+	}
+	fmt.Printf("pkg %s is transitively used by %d packages\n", pkg, total)
+}
+
+// Prints the packages whose latest release, as recorded in a versioned graph file, requires a package with a specifier that a planned version of it
+// doesn't satisfy, i.e., the dependents that a release would break or leave behind
+func mainBumpImpact(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <versioned-graph-file> <package-name> <new-version>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 3 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	graph, err := cheerio.LoadVersionedPyPIGraph(flags.Arg(0))
 	if err != nil {
-		os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+		fmt.Fprintf(os.Stderr, "Error loading versioned graph: %s\n", err)
+		os.Exit(1)
+	}
+	pkg := cheerio.NormalizedPkgName(flags.Arg(1))
+	incompatible, dependents, err := graph.BumpImpact(pkg, flags.Arg(2))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 		os.Exit(1)
 	}
+	for _, dependent := range incompatible {
+		fmt.Printf("%s@%s\t%s%s", dependent.Pkg, dependent.Version, pkg, dependent.Req.Specifier())
+		if dependent.Req.Extra != "" {
+			fmt.Printf("\t[%s]", dependent.Req.Extra)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%d of %d dependents of pkg %s don't allow version %s\n", len(incompatible), dependents, pkg, flags.Arg(2))
+}
 
-	var stdoutMu sync.Mutex
-	var pkgsCompleteMu sync.Mutex
-	var waiter sync.WaitGroup
-	throttle := make(chan int, 100)
-	pkgsComplete := 0
-	for p, pkg_ := range pkgs {
-		pkg := pkg_
+// Prints the packages whose name starts with or contains a query, or matches a glob (e.g., "django-*") or /regexp/.
+func mainSearch(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [-n N] <query>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	n := flags.Int("n", 50, "Maximum number of packages to print (0 for all)")
+	flags.Parse(args[1:])
 
-		waiter.Add(1)
-		throttle <- p
-		go func() {
-			defer waiter.Done()
-			defer func() { <-throttle }()
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
 
-			reqs, err := pkgIndex.FetchPackageRequirements(pkg)
-			if err != nil {
-				if !strings.Contains(err.Error(), "No file matched pattern") { // ignore archives that don't contain requires.txt
-					os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to parse pkg %s due to error: %s\n", pkg, err))
-				}
-			} else {
-				stdoutMu.Lock()
-				fmt.Println(cheerio.NormalizedPkgName(pkg))
-				for _, req := range reqs {
-					fmt.Printf("%s:%s\n", cheerio.NormalizedPkgName(pkg), cheerio.NormalizedPkgName(req.Name))
-				}
-				stdoutMu.Unlock()
-			}
+	matches, err := loadGraph(*file).Search(flags.Arg(0), *n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	for _, pkg := range matches {
+		fmt.Println(pkg)
+	}
+}
 
-			pkgsCompleteMu.Lock()
-			if pkgsComplete%50 == 0 {
-				log.Printf("[status] %d / %d\n", pkgsComplete, len(pkgs))
-			}
-			pkgsComplete++
-			pkgsCompleteMu.Unlock()
-		}()
+// Prints the most depended-upon packages, ranked either by number of direct reverse dependencies or by PageRank.
+func mainTop(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [-n N] [-by dependents|pagerank|downloads]\n", os.Args[0], args[0])
+		flags.PrintDefaults()
 	}
-	waiter.Wait()
+	file := graphFileFlag(flags)
+	n := flags.Int("n", 100, "Number of packages to print")
+	by := flags.String("by", "dependents", "Ranking to use: 'dependents' (direct reverse dependencies), 'pagerank', or 'downloads' (last month, requires -info)")
+	infoFile := flags.String("info", "", "Package info file generated by info-generate -downloads, for -by downloads")
+	flags.Parse(args[1:])
+
+	var scores []cheerio.PkgScore
+	switch *by {
+	case "dependents":
+		scores = loadGraph(*file).MostRequired(*n)
+	case "pagerank":
+		scores = loadGraph(*file).TopPageRank(*n)
+	case "downloads":
+		if *infoFile == "" {
+			flags.Usage()
+			os.Exit(1)
+		}
+		scores = loadInfoIndex(*infoFile).MostDownloaded(*n)
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+	for i, score := range scores {
+		fmt.Printf("%d\t%s\t%g\n", i+1, score.Pkg, score.Score)
+	}
+}
+
+// Prints summary statistics of a PyPI graph file, e.g., to sanity-check the output of reqs-generate.
+func mainStats(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	verbose := flags.Bool("v", false, "Print degree distributions and orphan packages")
+	flags.Parse(args[1:])
+
+	stats := loadGraph(*file).Stats()
+	fmt.Printf("packages:  %d\n", stats.Packages)
+	fmt.Printf("edges:     %d\n", stats.Edges)
+	fmt.Printf("orphans:   %d\n", len(stats.Orphans))
+	fmt.Printf("max depth: %d\n", stats.MaxDepth)
+	fmt.Printf("SCCs:      %d (%d cycles)\n", stats.SCCs, stats.Cycles)
+	if *verbose {
+		printDistribution("out-degree", stats.OutDegrees)
+		printDistribution("in-degree", stats.InDegrees)
+		fmt.Printf("orphans:\n  %s\n", strings.Join(stats.Orphans, " "))
+	}
+}
+
+func printDistribution(name string, dist map[int]int) {
+	degrees := make([]int, 0, len(dist))
+	for degree := range dist {
+		degrees = append(degrees, degree)
+	}
+	sort.Ints(degrees)
+	fmt.Printf("%s distribution:\n", name)
+	for _, degree := range degrees {
+		fmt.Printf("  %d\t%d\n", degree, dist[degree])
+	}
+}
+
+// Prints the packages and dependency edges added and removed between two PyPI graph files, in the graph file format prefixed by "+" or "-". Exits with
+// status 1 if the graphs differ.
+func mainDiff(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <old-graph-file> <new-graph-file>\n", os.Args[0], args[0])
+	}
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	diff := cheerio.DiffGraphs(loadGraph(flags.Arg(0)), loadGraph(flags.Arg(1)))
+	for _, pkg := range diff.RemovedPkgs {
+		fmt.Printf("-%s\n", pkg)
+	}
+	for _, pkg := range diff.AddedPkgs {
+		fmt.Printf("+%s\n", pkg)
+	}
+	for _, edge := range diff.RemovedEdges {
+		fmt.Printf("-%s:%s\n", edge.Pkg, edge.Dep)
+	}
+	for _, edge := range diff.AddedEdges {
+		fmt.Printf("+%s:%s\n", edge.Pkg, edge.Dep)
+	}
+	fmt.Fprintf(os.Stderr, "packages: +%d -%d, edges: +%d -%d\n", len(diff.AddedPkgs), len(diff.RemovedPkgs), len(diff.AddedEdges), len(diff.RemovedEdges))
+	if !diff.Empty() {
+		os.Exit(1)
+	}
+}
+
+// Merges several PyPI graph files (e.g., shards of a crawl, or a private index and PyPI) into one.
+func mainMerge(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <out-graph-file> <graph-file>...\n", os.Args[0], args[0])
+	}
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var graphs []*cheerio.PyPIGraph
+	for _, file := range flags.Args()[1:] {
+		graphs = append(graphs, loadGraph(file))
+	}
+	if err := cheerio.MergeGraphs(graphs...).WriteFile(flags.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing merged graph: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Converts a graph file (or result store) to another format, chosen by the extension of the output file: the binary format if it ends in
+// ".bin", and the text format otherwise, gzip-compressed if it ends in ".gz".
+func mainConvert(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <in-graph-file> <out-graph-file>\n", os.Args[0], args[0])
+	}
+	flags.Parse(args[1:])
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if err := loadGraph(flags.Arg(0)).WriteFile(flags.Arg(1)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing converted graph: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Prints the dependency edges of the PyPI graph as a CSV (or TSV) edge list, e.g., for pandas or Gephi, optionally writing a node list too, or
+// exports the graph for Neo4j.
+func mainExport(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [-tsv|-parquet] [-nodes <file>] [-info <info-file>] | -cypher | -neo4j <dir>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	tsv := flags.Bool("tsv", false, "Write tab-separated values instead of comma-separated values")
+	nodesFile := flags.String("nodes", "", "Also write the node list (packages with metadata) to this file")
+	infoFile := flags.String("info", "", "Package info file (see info-generate) adding versions, licenses and downloads to the node list")
+	versioned := flags.String("versioned", "", "Export the edges of this versioned graph file (see reqs-generate -versions), with version constraints, instead")
+	parquet := flags.Bool("parquet", false, "Write Parquet files instead of CSV, e.g., for DuckDB or Spark")
+	cypher := flags.Bool("cypher", false, "Print Cypher statements creating the graph in Neo4j (e.g., for cypher-shell) instead")
+	neo4jDir := flags.String("neo4j", "", "Write the graph in the CSV layout of neo4j-admin import to this directory instead")
+	flags.Parse(args[1:])
+
+	if *cypher {
+		if err := loadGraph(*file).WriteCypher(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing Cypher: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *neo4jDir != "" {
+		if err := loadGraph(*file).WriteNeo4jCSV(*neo4jDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing Neo4j import files: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	comma := ','
+	if *tsv {
+		comma = '\t'
+	}
+
+	var err error
+	var graph *cheerio.PyPIGraph // only loaded if edges or nodes are exported from it
+	if *versioned != "" {
+		versionedG, loadErr := cheerio.LoadVersionedPyPIGraph(*versioned)
+		if loadErr != nil {
+			fmt.Fprintf(os.Stderr, "Error loading versioned graph: %s\n", loadErr)
+			os.Exit(1)
+		}
+		if *parquet {
+			err = versionedG.WriteEdgeParquet(os.Stdout)
+		} else {
+			err = versionedG.WriteEdgeCSV(os.Stdout, comma)
+		}
+	} else {
+		graph = loadGraph(*file)
+		if *parquet {
+			err = graph.WriteEdgeParquet(os.Stdout)
+		} else {
+			err = graph.WriteEdgeCSV(os.Stdout, comma)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing edges: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *nodesFile != "" {
+		if graph == nil {
+			graph = loadGraph(*file)
+		}
+		var info cheerio.PackageInfoIndex
+		if *infoFile != "" {
+			info = loadInfoIndex(*infoFile)
+		}
+		f, err := os.Create(*nodesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating node list: %s\n", err)
+			os.Exit(1)
+		}
+		if *parquet {
+			err = graph.WriteNodeParquet(f, info)
+		} else {
+			err = graph.WriteNodeCSV(f, comma, info)
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing node list: %s\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// Writes an interactive HTML view of the dependencies of the given packages (see PyPIGraph.WriteHTML).
+func mainViz(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name>... [-depth N] [-o graph.html]\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	depth := flags.Int("depth", 2, "Maximum number of dependency levels to include (0 includes the full closure)")
+	output := flags.String("o", "", "HTML file to write (defaults to stdout)")
+
+	// flags may follow the package names, as in "viz flask -depth 3"
+	var pkgs []string
+	for rest := args[1:]; ; rest = flags.Args()[1:] {
+		flags.Parse(rest)
+		if flags.NArg() == 0 {
+			break
+		}
+		pkgs = append(pkgs, flags.Arg(0))
+	}
+	if len(pkgs) == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	graph := loadGraph(*file)
+	warnUnknownPkgs(graph, pkgs...)
+	sub := graph.SubgraphOf(pkgs, *depth)
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating %s: %s\n", *output, err)
+			os.Exit(1)
+		}
+		out = f
+	}
+	err := sub.WriteHTML(out, fmt.Sprintf("Dependencies of %s", strings.Join(pkgs, ", ")), pkgs...)
+	if closeErr := out.Close(); err == nil && *output != "" {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing visualization: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Prints the subgraph of the PyPI graph containing the given packages and their dependencies, in the graph file format.
+func mainSubgraph(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name>...\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	depth := flags.Int("depth", 0, "Maximum number of dependency levels to include (0 includes the full closure)")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	sub := loadGraph(*file).SubgraphOf(flags.Args(), *depth)
+	if _, err := sub.WriteTo(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing subgraph: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func graphFileFlag(flags *flag.FlagSet) *string {
+	return flags.String("graphfile", "", "Path to PyPI dependency graph file, s3:// or gs:// URL of one, or path to a result store ending in "+
+		".store (see reqs-generate -store).  "+
+		"Defaults to $GOPATH/src/github.com/beyang/cheerio/data/pypi_graph")
+}
+
+// Returns the PyPI graph stored in file, or the default graph if file is empty. Exits on error.
+func loadGraph(file string) *cheerio.PyPIGraph {
+	if file == "" {
+		return cheerio.DefaultPyPIGraph
+	}
+	if strings.HasSuffix(file, ".store") {
+		store, err := cheerio.OpenResultStore(file)
+		if err != nil {
+			fmt.Printf("Error opening result store: %s\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+		return store.Graph()
+	}
+	pypiG, err := cheerio.NewPyPIGraph(file)
+	if err != nil {
+		fmt.Printf("Error creating PyPI graph: %s\n", err)
+		os.Exit(1)
+	}
+	return pypiG
+}
+
+// Prints "did you mean" suggestions (see PyPIGraph.Suggest) for the packages that aren't in the graph
+func warnUnknownPkgs(graph *cheerio.PyPIGraph, pkgs ...string) {
+	for _, pkg := range pkgs {
+		norm := graph.NormalizedName(pkg)
+		if graph.Contains(norm) {
+			continue
+		}
+		if suggestions := graph.Suggest(norm, 5); len(suggestions) > 0 {
+			fmt.Fprintf(os.Stderr, "pkg %s is not in the graph; did you mean %s?\n", norm, strings.Join(suggestions, ", "))
+		} else {
+			fmt.Fprintf(os.Stderr, "pkg %s is not in the graph\n", norm)
+		}
+	}
+}
+
+func ecosystemFlag(flags *flag.FlagSet) *string {
+	return flags.String("ecosystem", "pypi", "Package ecosystem: 'pypi', 'npm', 'rubygems', 'crates', 'maven', 'packagist', 'go', 'nuget', or 'conda'")
+}
+
+// Returns the default registry of an ecosystem. Exits on error.
+func loadIndex(ecosystem string) cheerio.Index {
+	idx, err := cheerio.EcosystemIndex(ecosystem)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	return idx
+}
+
+// Returns the package info stored in file (generated by info-generate). Exits on error.
+func loadInfoIndex(file string) cheerio.PackageInfoIndex {
+	idx, err := cheerio.LoadPackageInfoIndex(file)
+	if err != nil {
+		fmt.Printf("Error loading package info: %s\n", err)
+		os.Exit(1)
+	}
+	return idx
+}
+
+// Prints the requirements of a release of a package recorded in a versioned graph file (generated with reqs-generate -versions), or the recorded
+// releases if no version is given.
+func mainReqsAt(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <versioned-graph-file> <package-name> [<version>]\n", os.Args[0], args[0])
+	}
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	graph, err := cheerio.LoadVersionedPyPIGraph(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error loading versioned PyPI graph: %s\n", err)
+		os.Exit(1)
+	}
+
+	pkg := cheerio.NormalizedPkgName(flags.Arg(1))
+	if flags.NArg() < 3 {
+		fmt.Println(strings.Join(graph.Versions(pkg), " "))
+		return
+	}
+	for _, req := range graph.RequiresAt(pkg, flags.Arg(2)) {
+		fmt.Printf("%s%s\n", req.Name, req.Specifier())
+	}
+}
+
+// Resolves the requirements in a requirements file to pinned versions, printed in requirements file format
+func mainResolve(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <requirements-file>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	python := flags.String("python", "", "Only consider requirements whose environment markers hold for this Python version (e.g., 3.11)")
+	platform := flags.String("platform", "linux", "Target platform (sys_platform) for -python: linux, darwin, win32, or cygwin")
+	pre := flags.Bool("pre", false, "Consider pre-releases even if a final release satisfies the requirements")
+	depsDev := flags.Bool("depsdev", false, "Fetch release data from the deps.dev API instead of downloading sdists from PyPI")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	reqs, err := cheerio.ParseRequirementsFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing requirements file: %s\n", err)
+		os.Exit(1)
+	}
+
+	resolver := &cheerio.Resolver{Source: cheerio.DefaultPyPI, Prereleases: *pre}
+	if *depsDev {
+		resolver.Source = cheerio.DefaultDepsDev
+	}
+	if *python != "" {
+		if resolver.Env, err = cheerio.NewMarkerEnv(*python, *platform); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	resolution, err := resolver.Resolve(reqs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving requirements: %s\n", err)
+		if resolution != nil {
+			for _, conflict := range resolution.Conflicts {
+				fmt.Fprintf(os.Stderr, "  conflict: %s\n", conflict)
+			}
+		}
+		os.Exit(1)
+	}
+
+	pkgs := make([]string, 0, len(resolution.Pins))
+	for pkg := range resolution.Pins {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		fmt.Printf("%s==%s\n", pkg, resolution.Pins[pkg])
+	}
+}
+
+// Reports packages whose version specifiers in several requirements files are incompatible. Exits with status 1 if there are any.
+func mainConflicts(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <requirements-file>...\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	merged := flags.Bool("merged", false, "Also print the merged requirements of all files")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	sets := make(map[string][]*cheerio.Requirement)
+	for _, file := range flags.Args() {
+		reqs, err := cheerio.ParseRequirementsFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing requirements file %s: %s\n", file, err)
+			os.Exit(1)
+		}
+		sets[file] = reqs
+	}
+
+	reqs, conflicts := cheerio.MergeRequirementSets(sets)
+	if *merged {
+		for _, req := range reqs {
+			fmt.Printf("%s%s\n", req.Name, req.Specifier())
+		}
+	}
+	for _, conflict := range conflicts {
+		fmt.Printf("conflict: %s\n", conflict)
+	}
+	if len(conflicts) > 0 {
+		os.Exit(1)
+	}
+}
+
+// Lists the pinned packages of a requirements file with their pinned and latest versions, and whether their requirements allow the latest version.
+// Exits with status 1 if any of them is outdated.
+func mainOutdated(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <requirements-file>\n", os.Args[0], args[0])
+		fmt.Fprintf(os.Stderr, "Exits with status 1 if a pin is outdated, or else 2 if the latest version of a package couldn't be fetched.\n")
+		flags.PrintDefaults()
+	}
+	all := flags.Bool("all", false, "Also list packages that aren't pinned, with their version specifiers")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	reqs, err := cheerio.ParseRequirementsFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing requirements file: %s\n", err)
+		os.Exit(1)
+	}
+
+	outdated, failed := false, false
+	for _, res := range cheerio.CheckOutdated(cheerio.DefaultPyPI, reqs, *all) {
+		if res.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", res.Err)
+			failed = true
+			continue
+		}
+		version := res.Pinned
+		if version == "" {
+			version = res.Req.Specifier()
+		}
+		upgrade := "blocked"
+		if res.AllowsLatest {
+			upgrade = "allowed"
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", res.Req.Name, version, res.Latest, upgrade)
+		outdated = outdated || res.Outdated
+	}
+	if outdated {
+		os.Exit(1)
+	} else if failed {
+		os.Exit(2)
+	}
+}
+
+// Lists the releases of a package and their distribution files
+func mainVersions(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	files := flags.Bool("files", false, "Print the type, name, and URL of each release's files")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	releases, err := cheerio.DefaultPyPI.Versions(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+	for _, release := range releases {
+		fmt.Println(release.Version)
+		if *files {
+			for _, artifact := range release.Artifacts {
+				yanked := ""
+				if artifact.Yanked {
+					yanked = "\t[yanked] " + artifact.YankedReason
+				}
+				fmt.Printf("  %s\t%s\t%s%s\n", artifact.Type, artifact.Filename, artifact.URL, yanked)
+			}
+		}
+	}
+}
+
+// Downloads a distribution file of a package, verifying its digest
+func mainDownload(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <package-name> <file-name>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	out := flags.String("o", "", "Output file (defaults to the file name in the current directory)")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	dest := *out
+	if dest == "" {
+		dest = flags.Arg(1)
+	}
+	if err := cheerio.DefaultPyPI.DownloadArtifactToFile(flags.Arg(0), flags.Arg(1), dest); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Prints the PackageInfo of packages as JSON lines, for use with commands that take a package info file (e.g., unsupported)
+func mainInfoGen(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [<package-name>... | all | -]\n", os.Args[0], args[0])
+		fmt.Fprintln(os.Stderr, "Crawls packages named as arguments, all packages on PyPI (\"all\"), or packages read one per line from stdin (\"-\").")
+		flags.PrintDefaults()
+	}
+	concurrency := flags.Int("c", 20, "Number of packages to crawl concurrently (at least 1)")
+	downloads := flags.Bool("downloads", false, "Also fetch recent download counts from pypistats.org")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 || *concurrency < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	pkgs := pkgArgs(flags, cheerio.DefaultPyPI)
+
+	var stdoutMu sync.Mutex
+	var waiter sync.WaitGroup
+	throttle := make(chan int, *concurrency)
+	enc := json.NewEncoder(os.Stdout)
+	for p, pkg_ := range pkgs {
+		pkg := pkg_
+
+		waiter.Add(1)
+		throttle <- p
+		go func() {
+			defer waiter.Done()
+			defer func() { <-throttle }()
+
+			info, err := cheerio.DefaultPyPI.FetchPackageInfo(pkg)
+			if err != nil {
+				os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to fetch metadata of pkg %s due to error: %s\n", pkg, err))
+				return
+			}
+			if *downloads {
+				if info.Downloads, err = cheerio.DefaultPyPIStats.RecentDownloads(pkg); err != nil {
+					os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to fetch download counts of pkg %s due to error: %s\n", pkg, err))
+				}
+			}
+			stdoutMu.Lock()
+			enc.Encode(info)
+			stdoutMu.Unlock()
+		}()
+	}
+	waiter.Wait()
+}
+
+// Lists the packages in a package's dependency closure that don't support a Python version
+func mainUnsupported(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s -info <package-info-file> -python <version> <package-name>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	infoFile := flags.String("info", "", "Package info file generated by the info-generate command")
+	python := flags.String("python", "", "Python version, e.g., 3.12")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 || *infoFile == "" || *python == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	idx := loadInfoIndex(*infoFile)
+	for _, pkg := range loadGraph(*file).UnsupportedOn(flags.Arg(0), *python, idx) {
+		info := idx[pkg]
+		fmt.Printf("%s\t%s\t%s\n", pkg, info.RequiresPython, strings.Join(info.PythonVersions, ","))
+	}
+}
+
+// Lists the licenses in a package's dependency closure, with the packages under each
+func mainLicenses(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s -info <package-info-file> <package-name>\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	infoFile := flags.String("info", "", "Package info file generated by the info-generate command")
+	flags.Parse(args[1:])
+
+	if flags.NArg() < 1 || *infoFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	idx := loadInfoIndex(*infoFile)
+	licenses := loadGraph(*file).ClosureLicenses(flags.Arg(0), idx)
+	names := make([]string, 0, len(licenses))
+	for license := range licenses {
+		names = append(names, license)
+	}
+	sort.Strings(names)
+	for _, license := range names {
+		fmt.Printf("%s (%d):\n  %s\n", license, len(licenses[license]), strings.Join(licenses[license], " "))
+	}
+}
+
+// Prints a software bill of materials for the dependency closure of packages or of a requirements file
+func mainSBOM(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [-requirements <requirements-file>] [<package-name>...]\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	reqsFile := flags.String("requirements", "", "Requirements file whose packages are included; versions pinned with == are used")
+	resolve := flags.Bool("resolve", false, "Resolve the requirements file to pin every package (see the resolve command)")
+	infoFile := flags.String("info", "", "Package info file generated by the info-generate command, for license data")
+	name := flags.String("name", "", "Document name (defaults to the first package or the requirements file name)")
+	format := flags.String("format", "spdx", "Output format: 'spdx' (SPDX 2.3 JSON), 'cyclonedx' (CycloneDX 1.5 JSON), or 'cyclonedx-xml' (CycloneDX 1.5 XML)")
+	flags.Parse(args[1:])
+
+	if (flags.NArg() < 1 && *reqsFile == "") || (*format != "spdx" && *format != "cyclonedx" && *format != "cyclonedx-xml") {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	builder := &cheerio.SBOMBuilder{Index: cheerio.DefaultPyPI, Graph: loadGraph(*file), Pins: make(map[string]string)}
+	roots := flags.Args()
+	if *reqsFile != "" {
+		reqs, err := cheerio.ParseRequirementsFile(*reqsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing requirements file: %s\n", err)
+			os.Exit(1)
+		}
+		for _, req := range reqs {
+			roots = append(roots, req.Name)
+			if req.Constraint == "==" && len(req.More) == 0 {
+				builder.Pins[cheerio.NormalizedPkgName(req.Name)] = req.Version
+			}
+		}
+		if *resolve {
+			resolution, err := (&cheerio.Resolver{Source: cheerio.DefaultPyPI}).Resolve(reqs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error resolving requirements: %s\n", err)
+				os.Exit(1)
+			}
+			builder.Pins = resolution.Pins
+		}
+	}
+	if *infoFile != "" {
+		builder.Info = loadInfoIndex(*infoFile)
+	}
+	if *name == "" {
+		if *name = *reqsFile; *name == "" {
+			*name = roots[0]
+		}
+	}
+
+	sbom, err := builder.Build(*name, roots...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	switch *format {
+	case "cyclonedx":
+		err = sbom.WriteCycloneDX(os.Stdout, "json")
+	case "cyclonedx-xml":
+		err = sbom.WriteCycloneDX(os.Stdout, "xml")
+	default:
+		err = sbom.WriteSPDX(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing SBOM: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Prints packages on PyPI whose names are within a small edit distance of the most depended-upon packages in the PyPI graph, as tab-separated
+// candidate, target, edit distance, and number of target dependents.
+func mainTyposquats(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [-n N] [-distance D]\n", os.Args[0], args[0])
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	n := flags.Int("n", 1000, "Number of most depended-upon packages to check names against")
+	distance := flags.Int("distance", 1, "Maximum edit distance from a target name")
+	graphOnly := flags.Bool("graphonly", false, "Only check the packages in the graph file instead of fetching the full package list from PyPI")
+	flags.Parse(args[1:])
+
+	pypiG := loadGraph(*file)
+	var pkgs []string
+	if *graphOnly {
+		for pkg := range pypiG.Req {
+			pkgs = append(pkgs, pkg)
+		}
+	} else {
+		var err error
+		if pkgs, err = cheerio.DefaultPyPI.AllPackages(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching package list: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, c := range cheerio.FindTyposquats(pkgs, pypiG.MostRequired(*n), *distance) {
+		fmt.Printf("%s\t%s\t%d\t%g\n", c.Pkg, c.Target, c.Distance, c.TargetRank)
+	}
+}
+
+// Prints PyPI requirement graph to stdout in the below format. Skips errors (including packages where there is no requires.txt file).
+// Example format:
+//
+// pkg1
+// pkg1:pkg2
+// pkg1:pkg3
+// pkg2
+// pkg2:pkg4
+//
+// With -versions, crawls every release of each package and prints a versioned graph (see cheerio.VersionedPyPIGraph) instead. With -timeline, it
+// prints the dependency changes of each release as JSON lines (see cheerio.DependencyEvent).
+func mainReqGen(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [<package-name>... | -]\n", os.Args[0], args[0])
+		fmt.Fprintln(os.Stderr, "Crawls packages named as arguments, packages read one per line from stdin (\"-\"), or all packages if none are given.")
+		fmt.Fprintln(os.Stderr, "With -seed, crawls the transitive requirements of the seed packages instead.")
+		flags.PrintDefaults()
+	}
+	versions := flags.Bool("versions", false, "Crawl every release of each package, printing a versioned graph")
+	timeline := flags.Bool("timeline", false, "Crawl every release of each package, printing one JSON line per release with its upload time and the "+
+		"requirements it added, removed and changed")
+	popular := flags.String("popular", "", "Package info file with download counts (from info-generate -downloads); popular packages are crawled first")
+	ecosystem := ecosystemFlag(flags)
+	purls := flags.Bool("purl", false, "Name packages by purl (e.g., pkg:npm/express), so graphs of several ecosystems can be merged")
+	ndjson := flags.Bool("ndjson", false, "Print one JSON record per package (requirements or error, and timing) as soon as it is crawled")
+	out := flags.String("o", "", "Write results to a file (NDJSON if it ends in .ndjson or .jsonl, graph format otherwise) or to a Kafka topic "+
+		"through a REST proxy (kafka+http://<host>:<port>/topics/<topic>) instead of stdout")
+	storeFile := flags.String("store", "", "Store results in a result store file (conventionally ending in .store), only crawling packages that "+
+		"aren't stored yet or are older than -max-age")
+	maxAge := flags.Duration("max-age", 7*24*time.Hour, "With -store, age after which stored results are crawled again")
+	storeMetadata := flags.Bool("store-metadata", false, "With -store, also fetch and store the package info (license, Python versions, ...) "+
+		"and source repository URL of each package")
+	metricsAddr := flags.String("metrics-addr", "", "Serve Prometheus metrics of the crawl on http://<addr>/metrics, e.g., :9090")
+	summaryFile := flags.String("summary", "", "Write a JSON summary of the crawl (successes, failures by error category with reasons, slowest "+
+		"packages) to a file, or to stderr if \"-\"")
+	seeds := flags.String("seed", "", "Comma-separated packages whose transitive requirements are crawled instead of package arguments, e.g., django,flask")
+	depth := flags.Int("depth", -1, "With -seed, maximum number of requirements between a seed and a crawled package (-1 for no limit)")
+	followExtras := flags.Bool("follow-extras", false, "With -seed, also crawl requirements for extras, e.g., test and docs dependencies")
+	include := flags.String("include", "", "Comma-separated patterns of packages to crawl: globs like django-* or regexps like /^django-/")
+	exclude := flags.String("exclude", "", "Comma-separated patterns of packages to skip, in the syntax of -include")
+	pkgsFile := flags.String("packages-file", "", "File listing the packages to crawl, one per line (# starts a comment)")
+	reproducible := flags.Bool("reproducible", false, "Print the graph sorted, with a stamp of its size and digest, once the crawl is complete, so "+
+		"crawls of identical data produce identical output")
+	compress := flags.Bool("gzip", false, "Gzip-compress the printed graph (graph files ending in .gz are read transparently)")
+	earlyExit := flags.Bool("early-exit", false, "Stop downloading each sdist once its *.egg-info/ directory has been read")
+	sources := flags.String("sources", "sdist", "Comma-separated sources of PyPI requirements, tried in order until one has them: json (JSON API "+
+		"requires_dist), wheel (wheel METADATA), sdist (requires.txt, setup.cfg, ...)")
+	prefer := flags.String("prefer", "", "Comma-separated preferences for the release file sdist requirements are read from: wheels (a wheel "+
+		"over the sdist of the same release), no-prereleases (skip pre-releases unless there is nothing else)")
+	includeYanked := flags.Bool("include-yanked", false, "Also read yanked (PEP 592) release files")
+	parseMode := flags.String("parse-mode", "lenient", "How malformed lines of requires.txt files are handled: lenient (skip them, keeping their "+
+		"package name if any, with a warning) or strict (fail the package)")
+	mirrorDir := flags.String("mirror", "", "Crawl a local PyPI mirror (a bandersnatch or pip2pi directory, or its file:// URI) instead of PyPI")
+	proxy := flags.String("proxy", "", "Send requests to PyPI through this proxy, e.g., http://proxy:3128 or socks5://localhost:1080 (default $HTTPS_PROXY)")
+	caCert := flags.String("cacert", "", "PEM file of CA certificates to trust in addition to the system's, e.g., the internal CA of a private index")
+	clientCert := flags.String("cert", "", "PEM file of a client certificate to present (with its key, unless -key is given)")
+	clientKey := flags.String("key", "", "PEM file of the key of the -cert client certificate")
+	insecure := flags.Bool("insecure", false, "Don't verify the certificates of servers (unsafe; for testing only)")
+	maxRate := flags.Float64("max-rate", 0, "Maximum number of requests to PyPI started per second (no limit if 0); requests answered 429 or "+
+		"503 are retried with backoff either way")
+	maxBandwidth := flags.Int64("max-bandwidth", 0, "Maximum bytes per second downloaded from PyPI across all requests (no limit if 0)")
+	requestTimeout := flags.Duration("request-timeout", 0, "Give up on requests to PyPI that take longer, including downloads (no limit if 0)")
+	packageTimeout := flags.Duration("package-timeout", 0, "Give up on packages whose requirements take longer to fetch (no limit if 0)")
+	slowListFile := flags.String("slow-list", "", "Skip the packages listed in this file, and add those that exceed -request-timeout or "+
+		"-package-timeout to it, to retry them later with -packages-file and longer timeouts")
+	userAgent := flags.String("user-agent", cheerio.DefaultUserAgent, "User-Agent of requests to PyPI, e.g., with a contact address for large crawls")
+	flags.Parse(args[1:])
+	*versions = *versions || *timeline
+	if *mirrorDir != "" {
+		mirror, err := cheerio.NewLocalMirror(*mirrorDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		cheerio.DefaultPyPI.URI, cheerio.DefaultPyPI.Client = mirror.URI, mirror.Client
+	}
+	cheerio.DefaultPyPI.EarlyExit = *earlyExit
+	cheerio.DefaultPyPI.IncludeYanked = *includeYanked
+	cheerio.DefaultPyPI.Proxy = *proxy
+	cheerio.DefaultPyPI.UserAgent = *userAgent
+	cheerio.DefaultPyPI.Throttle = cheerio.NewThrottle(*maxRate, *maxBandwidth)
+	cheerio.DefaultPyPI.Timeout = *requestTimeout
+	crawlDefaults.PackageTimeout = *packageTimeout
+	if *caCert != "" || *clientCert != "" || *clientKey != "" || *insecure {
+		tlsConfig, err := cheerio.NewTLSConfig(*caCert, *clientCert, *clientKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		tlsConfig.InsecureSkipVerify = *insecure
+		cheerio.DefaultPyPI.TLS = tlsConfig
+	}
+	pypiSources, err := cheerio.ParseRequirementsSources(*sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	cheerio.DefaultPyPI.Sources = pypiSources
+	policy, err := cheerio.ParseVersionPolicy(*prefer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	cheerio.DefaultPyPI.Policy = policy
+	if cheerio.DefaultPyPI.ParseMode, err = cheerio.ParseParseMode(*parseMode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *versions && (*ecosystem != "pypi" || *purls || *ndjson || *out != "" || *storeFile != "" || *seeds != "" || *reproducible || *compress) {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *seeds != "" && (flags.NArg() > 0 || *storeFile != "" || *popular != "" || *pkgsFile != "") {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if (*pkgsFile != "" && flags.NArg() > 0) || ((*reproducible || *compress) && (*ndjson || *out != "" || *storeFile != "")) {
+		flags.Usage()
+		os.Exit(1)
+	}
+	excluded := strings.Split(*exclude, ",")
+	if *slowListFile != "" {
+		slowList, err := cheerio.OpenSlowList(*slowListFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		defer slowList.Close()
+		crawlDefaults.SlowList = slowList
+		excluded = append(excluded, slowList.Packages()...) // package names have no wildcards
+	}
+	filter, err := cheerio.NewEcosystemPackageFilter(*ecosystem, strings.Split(*include, ","), excluded)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	crawlDefaults.Filter = filter
+
+	if *metricsAddr != "" {
+		serveCrawlMetrics(*metricsAddr)
+	}
+	if *summaryFile != "" {
+		if *versions {
+			flags.Usage()
+			os.Exit(1)
+		}
+		crawlDefaults.Summary = &cheerio.CrawlSummary{}
+		defer writeCrawlSummary(*summaryFile)
+	}
+	pkgIndex := loadIndex(*ecosystem)
+	var pkgs []string
+	if *seeds != "" {
+		crawlDefaults.Seeds, crawlDefaults.Depth, crawlDefaults.FollowExtras = strings.Split(*seeds, ","), *depth, *followExtras
+	} else if *pkgsFile != "" {
+		if pkgs, err = cheerio.LoadPackageList(*pkgsFile); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+			os.Exit(1)
+		}
+	} else if pkgs = pkgArgs(flags, pkgIndex); flags.NArg() == 0 {
+		if pkgs, err = pkgIndex.AllPackages(); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+			os.Exit(1)
+		}
+	}
+	if pkgs = filter.Apply(pkgs); *seeds == "" && len(pkgs) == 0 { // an empty package list would crawl all packages
+		log.Printf("[filter] no packages to crawl\n")
+		return
+	}
+	if *popular != "" {
+		loadInfoIndex(*popular).SortByDownloads(pkgs)
+	}
+	if *storeFile != "" {
+		store, err := cheerio.OpenEcosystemResultStore(*storeFile, *ecosystem)
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+			os.Exit(1)
+		}
+		crawlDefaults.Metadata = *storeMetadata
+		stale := store.Stale(pkgs, *maxAge, true)
+		log.Printf("[store] crawling %d of %d packages\n", len(stale), len(pkgs))
+		if len(stale) == 0 { // an empty package list would crawl all packages
+			store.Close()
+			return
+		}
+		crawlToSink(pkgIndex, stale, store)
+		return
+	}
+	if *out != "" {
+		sink, err := cheerio.OpenSink(*out)
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+			os.Exit(1)
+		}
+		crawlToSink(pkgIndex, pkgs, sink)
+		return
+	}
+	if *ndjson {
+		crawlToSink(pkgIndex, pkgs, cheerio.NewNDJSONSink(os.Stdout))
+		return
+	}
+	purlEcosystem := ""
+	if *purls {
+		purlEcosystem = *ecosystem
+	}
+	if *timeline {
+		genVersionedGraph(pkgs, true)
+		return
+	}
+	genGraph(pkgIndex, pkgs, *versions, *reproducible, *compress, purlEcosystem)
+}
+
+// Serves queries on a PyPI graph over HTTP, re-crawling the packages that changed on PyPI periodically and swapping in the updated graph.
+func mainServe(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s -graphfile <graph-file> [-addr localhost:8080] [-interval 1h] [-o <graph-file>]\n", os.Args[0], args[0])
+		fmt.Fprintf(os.Stderr, "The watch API is served if $%s is set to the bearer token its requests must carry.\n", cheerio.WatchTokenEnv)
+		flags.PrintDefaults()
+	}
+	file := graphFileFlag(flags)
+	addr := flags.String("addr", "localhost:8080", "Address to serve queries on (e.g., :8080 to serve them on all interfaces)")
+	interval := flags.Duration("interval", cheerio.DefaultRefreshInterval, "Interval between refreshes")
+	out := flags.String("o", "", "File to write the graph to after each refresh, e.g., the graph file, so restarts resume from it")
+	pruneInterval := flags.Duration("prune-interval", 0, "Interval between checks of the graph against PyPI's package list, which prune "+
+		"deleted packages whose deletion was missed (0 to never check)")
+	watchesFile := flags.String("watches", "", "File to save the watches registered through the watch API to, so they survive restarts")
+	flags.Parse(args[1:])
+
+	daemon, err := cheerio.NewGraphDaemon(cheerio.DefaultPyPI, cheerio.DefaultPyPI, loadGraph(*file))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s (generate it with reqs-generate)\n", err)
+		os.Exit(1)
+	}
+	daemon.Interval, daemon.PruneInterval, daemon.Crawl = *interval, *pruneInterval, crawlDefaults
+	if token := os.Getenv(cheerio.WatchTokenEnv); token != "" {
+		watches := &cheerio.Watches{}
+		if *watchesFile != "" {
+			if watches, err = cheerio.LoadWatches(*watchesFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		watches.Token = token
+		daemon.Watches = watches
+	}
+	if *out != "" {
+		daemon.OnRefresh = func(graph *cheerio.PyPIGraph) {
+			var err error
+			if cheerio.IsObjectURL(*out) { // uploads replace objects atomically
+				err = graph.WriteFile(*out)
+			} else {
+				tmp := *out + ".tmp"
+				if err = graph.WriteFile(tmp); err == nil {
+					err = os.Rename(tmp, *out)
+				}
+			}
+			if err != nil {
+				os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to write graph: %s\n", err))
+			}
+		}
+	}
+	go daemon.Run(context.Background())
+
+	log.Printf("[serve] serving queries on %s", *addr)
+	if err := http.ListenAndServe(*addr, daemon); err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+		os.Exit(1)
+	}
+}
+
+// Options shared by the crawls of this process, e.g., metrics and seeds (see crawlOptions)
+var crawlDefaults cheerio.CrawlOptions
+
+// Counts the HTTP requests and crawled packages of this process, and serves the counts on http://<addr>/metrics in the background
+func serveCrawlMetrics(addr string) {
+	crawlDefaults.Metrics = &cheerio.CrawlMetrics{}
+	http.DefaultClient.Transport = crawlDefaults.Metrics.Transport(http.DefaultClient.Transport)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", crawlDefaults.Metrics)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[FATAL] unable to serve metrics: %s\n", err))
+			os.Exit(1)
+		}
+	}()
+}
+
+// Writes the summary of the crawls of this process to file, or to stderr if file is "-"
+func writeCrawlSummary(file string) {
+	w := os.Stderr
+	if file != "-" {
+		f, err := os.Create(file)
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to write crawl summary: %s\n", err))
+			return
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := crawlDefaults.Summary.Report().WriteJSON(w); err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to write crawl summary: %s\n", err))
+	}
+}
+
+// Returns the options of a crawl of pkgs (ignored if crawlDefaults has seeds)
+func crawlOptions(pkgs []string) *cheerio.CrawlOptions {
+	opts := crawlDefaults
+	opts.Packages = pkgs
+	return &opts
+}
+
+// Crawls the requirements of pkgs concurrently, writing each result to sink as soon as it is produced
+func crawlToSink(pkgIndex cheerio.Index, pkgs []string, sink cheerio.Sink) {
+	err := cheerio.Crawl(context.Background(), pkgIndex, crawlOptions(pkgs), sink.Write)
+	if closeErr := sink.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+		os.Exit(1)
+	}
+}
+
+// Prints the requirement graph of gems on RubyGems in the same format as reqs-generate
+func mainGemGraph(args []string, flags *flag.FlagSet) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [<gem-name>...]\n", os.Args[0], args[0])
+		fmt.Fprintln(os.Stderr, "Crawls the named gems, or all gems if none are given.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args[1:])
+
+	pkgs := flags.Args()
+	if len(pkgs) == 0 {
+		var err error
+		if pkgs, err = cheerio.DefaultRubyGems.AllPackages(); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+			os.Exit(1)
+		}
+	}
+	genGraph(cheerio.DefaultRubyGems, pkgs, false, false, false, "")
+}
+
+// Crawls the requirements of pkgs concurrently, printing them in the graph file format (or the versioned graph format if versions is set, which
+// requires pkgIndex to be PyPI). If purlEcosystem is set, packages are printed as purls of that ecosystem. If reproducible is set, the graph is
+// printed sorted and stamped once the crawl is complete (see PyPIGraph.WriteStamped). If compress is set, the output is gzip-compressed.
+func genGraph(pkgIndex cheerio.Index, pkgs []string, versions, reproducible, compress bool, purlEcosystem string) {
+	nodeName := func(pkg string) string {
+		if purlEcosystem != "" {
+			if purl, err := cheerio.PackagePURL(purlEcosystem, pkg, ""); err == nil {
+				return cheerio.NormalizedPkgName(purl)
+			}
+		}
+		return cheerio.NormalizedEcosystemPkgName(cheerio.IndexEcosystem(pkgIndex), pkg)
+	}
+
+	if versions {
+		genVersionedGraph(pkgs, false)
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if compress {
+		gz := gzip.NewWriter(os.Stdout)
+		defer gz.Close()
+		out = gz
+	}
+	var sink cheerio.Sink = cheerio.NewGraphSink(out)
+	if reproducible {
+		sorted := cheerio.NewSortedGraphSink(out)
+		sorted.Info = graphInfo(pkgIndex, true)
+		sink = sorted
+	} else if err := cheerio.WriteGraphHeader(out, graphInfo(pkgIndex, false)); err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+		os.Exit(1)
+	}
+	pkgsComplete := 0
+	err := cheerio.Crawl(context.Background(), pkgIndex, crawlOptions(pkgs), func(res cheerio.PackageResult) error {
+		if pkgsComplete%50 == 0 && len(pkgs) > 0 {
+			log.Printf("[status] %d / %d\n", pkgsComplete, len(pkgs))
+		} else if pkgsComplete%50 == 0 {
+			log.Printf("[status] %d\n", pkgsComplete)
+		}
+		pkgsComplete++
+
+		if res.Err != nil {
+			if !errors.Is(res.Err, cheerio.ErrNoRequiresFile) { // ignore archives that don't contain requires.txt
+				os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to parse pkg %s due to error: %s\n", res.Pkg, res.Err))
+			}
+			return nil
+		}
+		if purlEcosystem != "" {
+			res.Pkg = nodeName(res.Pkg)
+			reqs := make([]*cheerio.Requirement, len(res.Requirements))
+			for i, req := range res.Requirements {
+				renamed := *req
+				renamed.Name = nodeName(req.Name)
+				reqs[i] = &renamed
+			}
+			res.Requirements = reqs
+		}
+		return sink.Write(res)
+	})
+	if closeErr := sink.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("[FATAL] %s\n", err))
+		os.Exit(1)
+	}
+}
+
+// Returns the provenance of a crawl of pkgIndex starting now. Reproducible crawls are only stamped with a time if $SOURCE_DATE_EPOCH is set.
+func graphInfo(pkgIndex cheerio.Index, reproducible bool) *cheerio.GraphInfo {
+	info := &cheerio.GraphInfo{}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if secs, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			info.Crawled = time.Unix(secs, 0)
+		}
+	} else if !reproducible {
+		info.Crawled = time.Now()
+	}
+	if pypi, isPyPI := pkgIndex.(*cheerio.PackageIndex); isPyPI {
+		info.IndexURL = pypi.URI
+		serial, err := pypi.LastSerial()
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("[serial] unable to get the last changelog serial of %s: %s\n", pypi.URI, err))
+		}
+		info.Serial = serial
+	} else if ecosystem := cheerio.IndexEcosystem(pkgIndex); ecosystem != "pypi" {
+		info.Ecosystem = ecosystem // names of other ecosystems are normalized differently
+	}
+	return info
+}
+
+// Crawls every release of pkgs on PyPI concurrently, printing them in the versioned graph file format, or as dependency events if timeline is set
+func genVersionedGraph(pkgs []string, timeline bool) {
+	var stdoutMu sync.Mutex
+	var pkgsCompleteMu sync.Mutex
+	var waiter sync.WaitGroup
+	throttle := make(chan int, cheerio.DefaultCrawlConcurrency)
+	pkgsComplete := 0
+	for p, pkg_ := range pkgs {
+		pkg := pkg_
+
+		waiter.Add(1)
+		throttle <- p
+		go func() {
+			defer waiter.Done()
+			defer func() { <-throttle }()
+
+			genVersionedReqs(cheerio.DefaultPyPI, pkg, timeline, &stdoutMu)
+
+			pkgsCompleteMu.Lock()
+			if pkgsComplete%50 == 0 {
+				log.Printf("[status] %d / %d\n", pkgsComplete, len(pkgs))
+			}
+			pkgsComplete++
+			pkgsCompleteMu.Unlock()
+		}()
+	}
+	waiter.Wait()
+}
+
+// Prints the versioned graph entries of every release of a package, or, if timeline is set, its dependency events, with the upload times of its
+// releases from PyPI's JSON API
+func genVersionedReqs(pkgIndex *cheerio.PackageIndex, pkg string, timeline bool, stdoutMu *sync.Mutex) {
+	releases, err := pkgIndex.Versions(pkg)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to list releases of pkg %s due to error: %s\n", pkg, err))
+		return
+	}
+
+	graph := cheerio.NewVersionedPyPIGraph()
+	for _, release := range releases {
+		ver := release.Version
+		reqs, err := pkgIndex.FetchPackageRequirementsAt(pkg, ver)
+		if err != nil {
+			if !errors.Is(err, cheerio.ErrNoRequiresFile) {
+				os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to parse pkg %s version %s due to error: %s\n", pkg, ver, err))
+			}
+			continue
+		}
+		graph.Add(pkg, ver, reqs)
+	}
+
+	if timeline {
+		uploaded := make(map[string]time.Time)
+		if history, err := pkgIndex.ReleaseHistory(pkg); err == nil {
+			for _, release := range history {
+				uploaded[release.Version] = release.Uploaded
+			}
+		} else {
+			os.Stderr.WriteString(fmt.Sprintf("[ERROR] unable to fetch upload times of pkg %s due to error: %s\n", pkg, err))
+		}
+		stdoutMu.Lock()
+		defer stdoutMu.Unlock()
+		enc := json.NewEncoder(os.Stdout)
+		for _, event := range graph.Timeline(pkg, uploaded) {
+			enc.Encode(event)
+		}
+		return
+	}
+
+	stdoutMu.Lock()
+	graph.WriteTo(os.Stdout)
+	stdoutMu.Unlock()
 }
@@ -0,0 +1,212 @@
+package cheerio
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Environment variable holding a comma-separated list of conda channels used by DefaultConda, e.g., "conda-forge,defaults"
+const CondaChannelsEnv = "CHEERIO_CONDA_CHANNELS"
+
+var DefaultConda = NewCondaChannels(strings.Split(envOr(CondaChannelsEnv, "conda-forge,defaults"), ","), "noarch", "linux-64")
+
+// Conda channels, read from their repodata.json files (https://docs.conda.io/projects/conda-build/en/latest/concepts/generating-index.html).
+// Channels are tried in order: a package is taken from the first channel that has it (like conda's strict channel priority).
+type CondaChannels struct {
+	Channels []string // channel names (e.g., "conda-forge" or "defaults") or URLs
+	Subdirs  []string // platform subdirectories, e.g., "noarch" and "linux-64"
+	APIURI   string   // anaconda.org API, for package homepages and repositories
+
+	mu       sync.Mutex
+	repodata map[string]map[string][]*condaRecord // channel URL -> package name -> records
+}
+
+// A package build listed in repodata.json
+type condaRecord struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	BuildNumber int      `json:"build_number"`
+	Depends     []string `json:"depends"`
+}
+
+// Returns CondaChannels reading the given channels and subdirs
+func NewCondaChannels(channels []string, subdirs ...string) *CondaChannels {
+	return &CondaChannels{Channels: channels, Subdirs: subdirs, APIURI: "https://api.anaconda.org"}
+}
+
+// Returns the URL of a channel given by name or URL
+func condaChannelURL(channel string) string {
+	channel = strings.TrimRight(strings.TrimSpace(channel), "/")
+	switch {
+	case strings.Contains(channel, "://"):
+		return channel
+	case channel == "defaults" || channel == "main":
+		return "https://repo.anaconda.com/pkgs/main"
+	}
+	return "https://conda.anaconda.org/" + channel
+}
+
+// Returns the names of all packages in the channels, sorted
+func (c *CondaChannels) AllPackages() ([]string, error) {
+	seen := make(map[string]bool)
+	for _, channel := range c.Channels {
+		records, err := c.channelRecords(channel)
+		if err != nil {
+			return nil, err
+		}
+		for name := range records {
+			seen[name] = true
+		}
+	}
+	pkgs := make([]string, 0, len(seen))
+	for name := range seen {
+		pkgs = append(pkgs, name)
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
+
+// Returns the dependencies of the latest build of a package (highest version, then build number) in the first channel that has it. Dependencies
+// whose version spec is a PEP 440-style list of clauses (e.g., ">=1.21,<2") get Constraint, Version, and More; others, such as "1.21.*|1.22.*",
+// are kept in Range.
+func (c *CondaChannels) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	record, err := c.latest(pkg)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]*Requirement, 0, len(record.Depends))
+	for _, dep := range record.Depends {
+		if req := parseCondaDependency(dep); req != nil {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs, nil
+}
+
+// Returns the source repository URL of a package from its anaconda.org page in the first channel that has it
+func (c *CondaChannels) FetchSourceRepoURL(pkg string) (string, error) {
+	pkg = strings.ToLower(pkg)
+	for _, channel := range c.Channels {
+		records, err := c.channelRecords(channel)
+		if err != nil {
+			return "", err
+		}
+		if _, in := records[pkg]; !in {
+			continue
+		}
+		if strings.Contains(channel, "://") {
+			break
+		}
+		if channel == "defaults" {
+			channel = "anaconda"
+		}
+		var info struct {
+			DevURL       string `json:"dev_url"`
+			SourceGitURL string `json:"source_git_url"`
+			Home         string `json:"home"`
+		}
+		if err := getJSON(&info, fmt.Sprintf("%s/package/%s/%s", c.APIURI, channel, pkg)); err != nil {
+			return "", err
+		}
+		var candidates []repoCandidate
+		for _, u := range []string{info.DevURL, info.SourceGitURL, info.Home} {
+			if canonical, err := CanonicalRepoURL(u); err == nil {
+				candidates = append(candidates, repoCandidate{canonical, RepoFromProjectURL})
+			}
+		}
+		if repoURL, _ := matchRepoURL(candidates); repoURL != "" {
+			return repoURL, nil
+		}
+		return "", fmt.Errorf("Could not parse repo URL from metadata of conda package %s", pkg)
+	}
+	return "", fmt.Errorf("No repository information found for conda package %s", pkg)
+}
+
+// Returns the latest build of a package in the first channel that has it
+func (c *CondaChannels) latest(pkg string) (*condaRecord, error) {
+	pkg = strings.ToLower(pkg)
+	for _, channel := range c.Channels {
+		records, err := c.channelRecords(channel)
+		if err != nil {
+			return nil, err
+		}
+		var latest *condaRecord
+		for _, record := range records[pkg] {
+			if latest == nil {
+				latest = record
+			} else if cmp := CompareVersions(record.Version, latest.Version); cmp > 0 || cmp == 0 && record.BuildNumber > latest.BuildNumber {
+				latest = record
+			}
+		}
+		if latest != nil {
+			return latest, nil
+		}
+	}
+	return nil, fmt.Errorf("[no-files] %w: conda package %s isn't in channels %s", ErrPackageNotFound, pkg, strings.Join(c.Channels, ", "))
+}
+
+// Returns the records of a channel's subdirs by package name, fetching its repodata the first time
+func (c *CondaChannels) channelRecords(channel string) (map[string][]*condaRecord, error) {
+	channelURL := condaChannelURL(channel)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if records, in := c.repodata[channelURL]; in {
+		return records, nil
+	}
+
+	records := make(map[string][]*condaRecord)
+	for _, subdir := range c.Subdirs {
+		var repodata struct {
+			Packages      map[string]*condaRecord `json:"packages"`
+			CondaPackages map[string]*condaRecord `json:"packages.conda"`
+		}
+		if err := getJSON(&repodata, fmt.Sprintf("%s/%s/repodata.json", channelURL, subdir)); err != nil {
+			return nil, err
+		}
+		for _, pkgs := range []map[string]*condaRecord{repodata.Packages, repodata.CondaPackages} {
+			for _, record := range pkgs {
+				name := strings.ToLower(record.Name)
+				records[name] = append(records[name], record)
+			}
+		}
+	}
+	if c.repodata == nil {
+		c.repodata = make(map[string]map[string][]*condaRecord)
+	}
+	c.repodata[channelURL] = records
+	return records, nil
+}
+
+var condaClausesRegexp = regexp.MustCompile(`^(?:(?:===|==|!=|~=|>=|<=|>|<)[^,|<>=!~\s]+,?)+$`)
+
+// Parses a repodata depends entry: "name [version-spec [build-spec]]", e.g., "numpy >=1.21,<2" or "python 3.11.* *_cpython"
+func parseCondaDependency(dep string) *Requirement {
+	fields := strings.Fields(dep)
+	if len(fields) == 0 {
+		return nil
+	}
+	req := &Requirement{Name: fields[0]}
+	if len(fields) < 2 {
+		return req
+	}
+	spec := fields[1]
+	if condaClausesRegexp.MatchString(spec) {
+		if parsed, err := ParseRequirement(req.Name + strings.TrimSuffix(spec, ",")); err == nil {
+			return parsed
+		}
+	}
+	req.Range = spec
+	return req
+}
+
+// Returns the value of an environment variable, or def if it is unset or empty
+func envOr(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
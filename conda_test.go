@@ -0,0 +1,72 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseCondaDependency(t *testing.T) {
+	tests := map[string]string{
+		"python":                      "python",
+		"numpy >=1.21,<2":             "numpy>=1.21,<2",
+		"python >=3.8":                "python>=3.8",
+		"python 3.11.* *_cpython":     "python(3.11.*)",
+		"libgcc-ng >=12":              "libgcc-ng>=12",
+		"openssl >=3.1.4,<4.0a0":      "openssl>=3.1.4,<4.0a0",
+		"pytz 2023.3|2023.3.post1":    "pytz(2023.3|2023.3.post1)",
+		"typing_extensions ==4.8.0 0": "typing_extensions==4.8.0",
+	}
+	for dep, exp := range tests {
+		req := parseCondaDependency(dep)
+		got := req.Name + req.Specifier()
+		if req.Range != "" {
+			got = fmt.Sprintf("%s(%s)", req.Name, req.Range)
+		}
+		if got != exp {
+			t.Errorf("parseCondaDependency(%s): expected %s, got %s", dep, exp, got)
+		}
+	}
+}
+
+func TestCondaChannels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forge/noarch/repodata.json":
+			fmt.Fprint(w, `{"packages": {"requests-2.31.0-pyhd8ed1ab_0.tar.bz2": {"name": "requests", "version": "2.31.0", "build_number": 0,
+				"depends": ["python >=3.7", "urllib3 >=1.21.1,<3", "certifi >=2017.4.17"]}},
+				"packages.conda": {"requests-2.31.0-pyhd8ed1ab_1.conda": {"name": "requests", "version": "2.31.0", "build_number": 1,
+				"depends": ["python >=3.7", "urllib3 >=1.21.1,<3"]}}}`)
+		case "/main/noarch/repodata.json":
+			fmt.Fprint(w, `{"packages": {"requests-2.32.0-py_0.tar.bz2": {"name": "requests", "version": "2.32.0", "depends": []},
+				"six-1.16.0-py_0.tar.bz2": {"name": "six", "version": "1.16.0", "depends": ["python"]}}}`)
+		case "/package/forge/requests":
+			fmt.Fprint(w, `{"dev_url": "https://github.com/psf/requests", "home": "https://requests.readthedocs.io"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := NewCondaChannels([]string{server.URL + "/forge", server.URL + "/main"}, "noarch")
+	c.APIURI = server.URL
+	var idx Index = c
+	if pkgs, err := idx.AllPackages(); err != nil || !reflect.DeepEqual(pkgs, []string{"requests", "six"}) {
+		t.Errorf("AllPackages: got %v, %v", pkgs, err)
+	}
+	// The first channel takes priority, even though the second has a newer version
+	if reqs, err := idx.FetchPackageRequirements("requests"); err != nil || len(reqs) != 2 || reqs[1].Specifier() != ">=1.21.1,<3" {
+		t.Errorf("FetchPackageRequirements: got %v, %v", reqs, err)
+	}
+	if reqs, err := idx.FetchPackageRequirements("six"); err != nil || len(reqs) != 1 {
+		t.Errorf("FetchPackageRequirements: got %v, %v", reqs, err)
+	}
+
+	c.Channels = []string{"forge"}
+	c.repodata[condaChannelURL("forge")] = c.repodata[server.URL+"/forge"]
+	if repoURL, err := idx.FetchSourceRepoURL("requests"); err != nil || repoURL != "https://github.com/psf/requests" {
+		t.Errorf("FetchSourceRepoURL: got %s, %v", repoURL, err)
+	}
+}
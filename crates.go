@@ -0,0 +1,240 @@
+package cheerio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Environment variable naming a local clone of the crates.io git index, used by DefaultCrates
+const CratesIndexEnv = "CHEERIO_CRATES_INDEX"
+
+var DefaultCrates = &Crates{IndexURI: "https://index.crates.io", APIURI: "https://crates.io", Dir: os.Getenv(CratesIndexEnv)}
+
+// The crates.io registry of Rust packages. Requirements are read from the registry index (https://doc.rust-lang.org/cargo/reference/registry-index.html),
+// either over HTTP (the sparse index) or from a local clone of the git index, and source repositories from the crates.io API.
+type Crates struct {
+	IndexURI string // sparse index URL
+	APIURI   string
+
+	// Local clone of the git index (https://github.com/rust-lang/crates.io-index). If set, the index is read from it instead of IndexURI, and
+	// AllPackages is supported.
+	Dir string
+}
+
+// An entry of the index: one published version of a crate
+type crateVersion struct {
+	Name   string            `json:"name"`
+	Vers   string            `json:"vers"`
+	Deps   []crateDependency `json:"deps"`
+	Yanked bool              `json:"yanked"`
+}
+
+type crateDependency struct {
+	Name     string `json:"name"`
+	Req      string `json:"req"`
+	Optional bool   `json:"optional"`
+	Kind     string `json:"kind"`    // "normal", "build", or "dev" ("" means normal)
+	Package  string `json:"package"` // the actual crate name if the dependency is renamed
+}
+
+// Returns the names of all crates. Only supported when reading a local clone of the git index, since the sparse index can't be listed.
+func (c *Crates) AllPackages() ([]string, error) {
+	if c.Dir == "" {
+		return nil, fmt.Errorf("Listing all crates requires a local clone of the crates.io git index")
+	}
+	pkgs := make([]string, 0)
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") && path != c.Dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Skip files that aren't crate entries, e.g., config.json
+		if rel, err := filepath.Rel(c.Dir, path); err == nil && filepath.ToSlash(rel) == crateIndexPath(info.Name()) {
+			pkgs = append(pkgs, info.Name())
+		}
+		return nil
+	})
+	return pkgs, err
+}
+
+// Returns the requirements of the latest non-yanked version of a crate. Development and build dependencies have Extra "dev" and "build", and
+// optional dependencies (enabled by features) have Extra "optional".
+func (c *Crates) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	versions, err := c.fetchIndexEntries(pkg)
+	if err != nil {
+		return nil, err
+	}
+	var latest *crateVersion
+	for _, v := range versions {
+		// compare versions, as a backport may be published after a later version
+		if !v.Yanked && (latest == nil || compareSemver(v.Vers, latest.Vers) > 0) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("[no-files] %w: no unyanked version of crate %s", ErrNoReleases, pkg)
+	}
+
+	reqs := make([]*Requirement, 0, len(latest.Deps))
+	for _, dep := range latest.Deps {
+		req := &Requirement{Name: dep.Name, Range: dep.Req}
+		if dep.Package != "" {
+			req.Name = dep.Package
+		}
+		switch {
+		case dep.Kind == "dev" || dep.Kind == "build":
+			req.Extra = dep.Kind
+		case dep.Optional:
+			req.Extra = "optional"
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// Returns the source repository URL of a crate from the crates.io API
+func (c *Crates) FetchSourceRepoURL(pkg string) (string, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/crates/%s", c.APIURI, url.PathEscape(pkg)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", notFoundIf(resp.StatusCode, fmt.Errorf("crates.io returned %s for crate %s", resp.Status, pkg))
+	}
+	var info struct {
+		Crate struct {
+			Repository string `json:"repository"`
+			Homepage   string `json:"homepage"`
+		} `json:"crate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	repoURL, _ := matchRepoURL([]repoCandidate{{info.Crate.Repository, RepoFromProjectURL}, {info.Crate.Homepage, RepoFromHomePage}})
+	if repoURL == "" {
+		return "", fmt.Errorf("Could not parse repo URL from metadata of crate %s", pkg)
+	}
+	return repoURL, nil
+}
+
+// Returns the index entries of a crate, in publication order
+func (c *Crates) fetchIndexEntries(pkg string) ([]*crateVersion, error) {
+	path := crateIndexPath(pkg)
+	if path == "" {
+		return nil, fmt.Errorf("Invalid crate name %q", pkg)
+	}
+	var data []byte
+	if c.Dir != "" {
+		var err error
+		if data, err = ioutil.ReadFile(filepath.Join(c.Dir, filepath.FromSlash(path))); err != nil {
+			return nil, err
+		}
+	} else {
+		resp, err := http.Get(c.IndexURI + "/" + path)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, notFoundIf(resp.StatusCode, fmt.Errorf("crates.io index returned %s for crate %s", resp.Status, pkg))
+		}
+		if data, err = ioutil.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	versions := make([]*crateVersion, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var v crateVersion
+		if err := json.Unmarshal(scanner.Bytes(), &v); err == nil {
+			versions = append(versions, &v)
+		}
+	}
+	return versions, scanner.Err()
+}
+
+// Returns the path of a crate's file in the index: "1/a", "2/ab", "3/a/abc", or "ab/cd/abcd..." depending on the length of the (lowercased) name;
+// "" for an empty name
+func crateIndexPath(pkg string) string {
+	pkg = strings.ToLower(pkg)
+	switch len(pkg) {
+	case 0:
+		return ""
+	case 1:
+		return "1/" + pkg
+	case 2:
+		return "2/" + pkg
+	case 3:
+		return "3/" + pkg[:1] + "/" + pkg
+	}
+	return pkg[:2] + "/" + pkg[2:4] + "/" + pkg
+}
+
+// Compares two semantic versions (https://semver.org), e.g., "1.0.0-beta.2" and "1.0.0", returning -1, 0 or 1. Build metadata is ignored, and
+// versions whose parts aren't numbers compare after those that are.
+func compareSemver(a, b string) int {
+	a, b = strings.SplitN(a, "+", 2)[0], strings.SplitN(b, "+", 2)[0]
+	aCore, aPre := splitSemverPrerelease(a)
+	bCore, bPre := splitSemverPrerelease(b)
+	if c := compareSemverIdentifiers(strings.Split(aCore, "."), strings.Split(bCore, ".")); c != 0 {
+		return c
+	}
+	// a pre-release precedes its release
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return compareSemverIdentifiers(strings.Split(aPre, "."), strings.Split(bPre, "."))
+}
+
+func splitSemverPrerelease(ver string) (core, pre string) {
+	if i := strings.Index(ver, "-"); i >= 0 {
+		return ver[:i], ver[i+1:]
+	}
+	return ver, ""
+}
+
+// Compares dot-separated identifiers in order: numeric ones numerically, before alphanumeric ones, which compare lexically. A prefix of the
+// other's identifiers comes first.
+func compareSemverIdentifiers(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		aNum, aErr := strconv.Atoi(a[i])
+		bNum, bErr := strconv.Atoi(b[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInts(aNum, bNum); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
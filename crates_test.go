@@ -0,0 +1,108 @@
+package cheerio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const testCrateEntries = `{"name":"serde_json","vers":"1.0.0","deps":[{"name":"serde","req":"^1.0","optional":false,"kind":"normal"}],"yanked":false}
+{"name":"serde_json","vers":"1.0.1","deps":[{"name":"serde","req":"^1.0.100","optional":false,"kind":"normal"},{"name":"indexmap","req":"^1.5","optional":true,"kind":"normal"},{"name":"ryu2","package":"ryu","req":"^1.0","optional":false},{"name":"trybuild","req":"^1.0","optional":false,"kind":"dev"}],"yanked":false}
+{"name":"serde_json","vers":"1.0.2","deps":[],"yanked":true}
+{"name":"serde_json","vers":"0.9.10","deps":[{"name":"serde","req":"^0.9","optional":false,"kind":"normal"}],"yanked":false}
+`
+
+func TestCrateIndexPath(t *testing.T) {
+	for pkg, exp := range map[string]string{"": "", "a": "1/a", "ab": "2/ab", "abc": "3/a/abc", "Serde_JSON": "se/rd/serde_json"} {
+		if path := crateIndexPath(pkg); path != exp {
+			t.Errorf("crateIndexPath(%s): expected %s, got %s", pkg, exp, path)
+		}
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		exp  int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.10", "1.0.9", 1},
+		{"1.1.5", "2.0.0", -1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+		{"1.0.0+build.1", "1.0.0", 0},
+	} {
+		if c := compareSemver(test.a, test.b); c != test.exp {
+			t.Errorf("compareSemver(%s, %s): expected %d, got %d", test.a, test.b, test.exp, c)
+		}
+	}
+}
+
+func TestCrates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/se/rd/serde_json":
+			fmt.Fprint(w, testCrateEntries)
+		case "/api/v1/crates/serde_json":
+			fmt.Fprint(w, `{"crate": {"name": "serde_json", "repository": "https://github.com/serde-rs/json"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var idx Index = &Crates{IndexURI: server.URL, APIURI: server.URL}
+	reqs, err := idx.FetchPackageRequirements("serde_json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range reqs {
+		got = append(got, fmt.Sprintf("%s(%s):%s", req.Name, req.Specifier(), req.Extra))
+	}
+	if exp := []string{"serde(^1.0.100):", "indexmap(^1.5):optional", "ryu(^1.0):", "trybuild(^1.0):dev"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("FetchPackageRequirements: expected %v, got %v", exp, got)
+	}
+	if repoURL, err := idx.FetchSourceRepoURL("serde_json"); err != nil || repoURL != "https://github.com/serde-rs/json" {
+		t.Errorf("FetchSourceRepoURL: got %s, %v", repoURL, err)
+	}
+	if _, err := idx.AllPackages(); err == nil {
+		t.Errorf("AllPackages: expected an error without a local index")
+	}
+	if _, err := idx.FetchPackageRequirements(""); err == nil {
+		t.Errorf("FetchPackageRequirements: expected an error for an empty crate name")
+	}
+}
+
+func TestCratesLocalIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio-crates-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for path, contents := range map[string]string{"config.json": "{}", "se/rd/serde_json": testCrateEntries, "1/a": "", "3/l/log": ""} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &Crates{Dir: dir}
+	pkgs, err := c.AllPackages()
+	sort.Strings(pkgs)
+	if err != nil || !reflect.DeepEqual(pkgs, []string{"a", "log", "serde_json"}) {
+		t.Errorf("AllPackages: got %v, %v", pkgs, err)
+	}
+	if reqs, err := c.FetchPackageRequirements("serde_json"); err != nil || len(reqs) != 4 {
+		t.Errorf("FetchPackageRequirements: got %v, %v", reqs, err)
+	}
+}
@@ -0,0 +1,280 @@
+package cheerio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Default number of packages fetched concurrently by Crawl
+const DefaultCrawlConcurrency = 100
+
+// Options of a crawl. The zero value crawls every package of the index with DefaultCrawlConcurrency workers.
+type CrawlOptions struct {
+	// Packages to crawl; if empty, all packages of the index are crawled
+	Packages []string
+
+	// If set, crawls these packages and their transitive requirements instead of Packages, up to Depth requirements away from the seeds: 0
+	// only crawls the seeds, 1 also their direct requirements, and so on. A negative Depth crawls all transitive requirements. Requirements
+	// for extras (e.g., test or docs extras, or npm dev dependencies) are only followed if FollowExtras is set.
+	Seeds        []string
+	Depth        int
+	FollowExtras bool
+
+	// If set, only packages it selects are crawled, including requirements found from seeds
+	Filter *PackageFilter
+
+	// Maximum number of packages fetched concurrently
+	Concurrency int
+
+	// If set, counts the crawled packages and their errors
+	Metrics *CrawlMetrics
+
+	// If set, every result is added to it
+	Summary *CrawlSummary
+
+	// If set, a package whose requirements take longer to fetch is reported with an error wrapping ErrTimeout, which frees its crawl slot for
+	// the next package. The requests of the abandoned fetch are canceled if the index supports it, as PackageIndex does, also in a CachedIndex;
+	// the fetches of other indexes run on until the index gives up.
+	PackageTimeout time.Duration
+
+	// If set, packages whose fetch timed out (see ErrorCategory), whether per package or per request, are added to it
+	SlowList *SlowList
+
+	// If set, the package info (if the index provides it, as PackageIndex does) and source repository URL of each package whose requirements
+	// were fetched are fetched too, e.g., to keep them in a ResultStore. Failing to fetch them doesn't fail the package.
+	Metadata bool
+}
+
+// The outcome of crawling a single package. Err is set if the requirements could not be fetched.
+type PackageResult struct {
+	Pkg          string
+	Requirements []*Requirement
+	Source       RequirementsSource // where the requirements were read from, if the index reports it (see PackageIndex.Sources)
+	Err          error
+	Started      time.Time
+	Duration     time.Duration
+	Info         *PackageInfo // if CrawlOptions.Metadata is set and it could be fetched
+	RepoURL      string       // if CrawlOptions.Metadata is set and it could be fetched
+}
+
+// Encodes a result as a JSON object with the error as a string and the duration in milliseconds, e.g., {"Pkg":"flask","Requirements":[...],
+// "Source":"wheel","Started":"2024-01-02T15:04:05Z","DurationMs":120}. One such object per line (NDJSON) is the streaming output format of reqs-generate.
+func (r PackageResult) MarshalJSON() ([]byte, error) {
+	record := struct {
+		Pkg          string
+		Requirements []*Requirement     `json:",omitempty"`
+		Source       RequirementsSource `json:",omitempty"`
+		Error        string             `json:",omitempty"`
+		Info         *PackageInfo       `json:",omitempty"`
+		RepoURL      string             `json:",omitempty"`
+		Started      time.Time
+		DurationMs   int64
+	}{r.Pkg, r.Requirements, r.Source, "", r.Info, r.RepoURL, r.Started, int64(r.Duration / time.Millisecond)}
+	if r.Err != nil {
+		record.Error = r.Err.Error()
+	}
+	return json.Marshal(record)
+}
+
+// Crawls the requirements of the packages of a PyPI server. See Crawl.
+func (p *PackageIndex) Crawl(ctx context.Context, opts *CrawlOptions, visit func(pkg PackageResult) error) error {
+	return Crawl(ctx, p, opts, visit)
+}
+
+// Fetches the requirements of packages of idx concurrently, calling visit with each result as soon as it is produced. visit is never called
+// concurrently, so it may write to a shared sink without locking; the order of results is unspecified. Packages whose requirements cannot be
+// fetched are passed to visit with Err set. Crawling stops as soon as visit returns an error or ctx is done, and that error is returned.
+func Crawl(ctx context.Context, idx Index, opts *CrawlOptions, visit func(pkg PackageResult) error) error {
+	if opts == nil {
+		opts = &CrawlOptions{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultCrawlConcurrency
+	}
+
+	if opts.Metrics != nil {
+		opts.Metrics.start()
+	}
+	observe := func(res PackageResult) error {
+		if opts.Metrics != nil {
+			opts.Metrics.observe(res)
+		}
+		if opts.Summary != nil {
+			opts.Summary.Add(res)
+		}
+		if opts.SlowList != nil && isTimeout(res.Err) {
+			if err := opts.SlowList.Add(res.Pkg); err != nil {
+				return err
+			}
+		}
+		return visit(res)
+	}
+	fetch := func(pkg string) PackageResult {
+		return fetchResult(ctx, idx, pkg, opts.PackageTimeout, opts.Metadata)
+	}
+
+	if len(opts.Seeds) > 0 {
+		return crawlFromSeeds(ctx, fetch, IndexEcosystem(idx), opts.Filter.Apply(opts.Seeds), opts.Depth, opts.FollowExtras, opts.Filter,
+			concurrency, observe)
+	}
+	pkgs := opts.Packages
+	if len(pkgs) == 0 {
+		var err error
+		if pkgs, err = idx.AllPackages(); err != nil {
+			return err
+		}
+	}
+	return crawlPackages(ctx, fetch, opts.Filter.Apply(pkgs), concurrency, observe)
+}
+
+// Crawls seeds, then the packages they require, breadth first, up to depth requirements away from the seeds (without limit if depth is
+// negative). Each package is visited once, packages being told apart by the naming rules of ecosystem (see NormalizedEcosystemPkgName).
+// Requirements that filter doesn't select, and requirements for extras unless followExtras is set, aren't crawled.
+func crawlFromSeeds(ctx context.Context, fetch func(pkg string) PackageResult, ecosystem string, seeds []string, depth int, followExtras bool,
+	filter *PackageFilter, concurrency int, visit func(pkg PackageResult) error) error {
+	seen := make(map[string]bool)
+	level := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		if name := NormalizedEcosystemPkgName(ecosystem, seed); !seen[name] {
+			seen[name] = true
+			level = append(level, seed)
+		}
+	}
+
+	for d := 0; len(level) > 0; d++ {
+		var next []string
+		err := crawlPackages(ctx, fetch, level, concurrency, func(res PackageResult) error {
+			if depth < 0 || d < depth {
+				for _, req := range res.Requirements {
+					if req.Extra != "" && !followExtras {
+						continue
+					}
+					if name := NormalizedEcosystemPkgName(ecosystem, req.Name); !seen[name] && filter.Match(req.Name) {
+						seen[name] = true
+						next = append(next, req.Name)
+					}
+				}
+			}
+			return visit(res)
+		})
+		if err != nil {
+			return err
+		}
+		level = next
+	}
+	return nil
+}
+
+// An index that reports where requirements were read from, e.g., PackageIndex
+type sourcedIndex interface {
+	FetchSourcedRequirements(pkg, version string) ([]*Requirement, RequirementsSource, error)
+}
+
+// An index that provides the PackageInfo of packages, e.g., PackageIndex
+type infoIndex interface {
+	FetchPackageInfo(pkg string) (*PackageInfo, error)
+}
+
+// An index whose requests can be canceled, e.g., PackageIndex
+type contextIndex interface {
+	withContext(ctx context.Context) Index
+}
+
+// Fetches the requirements of a package, and its info and repository URL if metadata is set, giving up after timeout (unless it's 0). The
+// requests of a fetch given up are canceled if the index supports it (see contextIndex).
+func fetchResult(ctx context.Context, idx Index, pkg string, timeout time.Duration, metadata bool) PackageResult {
+	started := time.Now()
+	if cancelable, ok := idx.(contextIndex); ok && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		idx = cancelable.withContext(ctx)
+	}
+	fetch := func() PackageResult {
+		var res PackageResult
+		if sourced, ok := idx.(sourcedIndex); ok {
+			res.Requirements, res.Source, res.Err = sourced.FetchSourcedRequirements(pkg, "")
+		} else {
+			res.Requirements, res.Err = idx.FetchPackageRequirements(pkg)
+		}
+		if metadata && res.Err == nil {
+			if infoIdx, ok := idx.(infoIndex); ok {
+				res.Info, _ = infoIdx.FetchPackageInfo(pkg)
+			}
+			res.RepoURL, _ = idx.FetchSourceRepoURL(pkg)
+		}
+		res.Pkg, res.Started, res.Duration = pkg, started, time.Since(started)
+		return res
+	}
+	if timeout <= 0 {
+		return fetch()
+	}
+
+	done := make(chan PackageResult, 1) // buffered so an abandoned fetch can finish
+	go func() { done <- fetch() }()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res
+	case <-timer.C:
+		err := fmt.Errorf("[timeout] %w: fetching pkg %s took longer than %s", ErrTimeout, pkg, timeout)
+		return PackageResult{Pkg: pkg, Err: err, Started: started, Duration: time.Since(started)}
+	}
+}
+
+// Fetches the requirements of pkgs with concurrency workers, calling visit serially with each result (see Crawl)
+func crawlPackages(ctx context.Context, fetch func(pkg string) PackageResult, pkgs []string, concurrency int, visit func(pkg PackageResult) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	todo := make(chan string)
+	results := make(chan PackageResult)
+	var workers sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for pkg := range todo {
+				res := fetch(pkg)
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(todo)
+		for _, pkg := range pkgs {
+			select {
+			case todo <- pkg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	var visitErr error
+	for res := range results {
+		if visitErr != nil {
+			continue // drain the remaining results so the workers can exit
+		}
+		if visitErr = visit(res); visitErr != nil {
+			cancel()
+		}
+	}
+	if visitErr != nil {
+		return visitErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,279 @@
+package cheerio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// An in-memory Index for testing crawls
+type fakeIndex map[string][]*Requirement
+
+func (f fakeIndex) AllPackages() ([]string, error) {
+	pkgs := make([]string, 0, len(f))
+	for pkg := range f {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
+
+func (f fakeIndex) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	if reqs, in := f[pkg]; in {
+		return reqs, nil
+	}
+	return nil, fmt.Errorf("Package %s not found", pkg)
+}
+
+func (f fakeIndex) FetchSourceRepoURL(pkg string) (string, error) {
+	return "", fmt.Errorf("Package %s has no repository", pkg)
+}
+
+func TestCrawl(t *testing.T) {
+	idx := fakeIndex{
+		"a": {{Name: "b"}, {Name: "c"}},
+		"b": {{Name: "c"}},
+		"c": nil,
+	}
+
+	var visited []string
+	err := Crawl(context.Background(), idx, &CrawlOptions{Concurrency: 2}, func(res PackageResult) error {
+		if res.Err != nil {
+			t.Errorf("unexpected error for %s: %s", res.Pkg, res.Err)
+		}
+		if len(res.Requirements) != len(idx[res.Pkg]) {
+			t.Errorf("%s: expected %d requirements, got %d", res.Pkg, len(idx[res.Pkg]), len(res.Requirements))
+		}
+		visited = append(visited, res.Pkg)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(visited)
+	if fmt.Sprint(visited) != "[a b c]" {
+		t.Errorf("expected all packages to be visited, got %v", visited)
+	}
+
+	var failed []string
+	err = Crawl(context.Background(), idx, &CrawlOptions{Packages: []string{"a", "missing"}}, func(res PackageResult) error {
+		if res.Err != nil {
+			failed = append(failed, res.Pkg)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(failed) != "[missing]" {
+		t.Errorf("expected missing package to be reported, got %v", failed)
+	}
+}
+
+func TestCrawlStops(t *testing.T) {
+	idx := fakeIndex{}
+	for i := 0; i < 100; i++ {
+		idx[fmt.Sprintf("pkg%d", i)] = nil
+	}
+
+	stop := fmt.Errorf("stop")
+	visits := 0
+	err := Crawl(context.Background(), idx, &CrawlOptions{Concurrency: 4}, func(res PackageResult) error {
+		visits++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("expected visitor error, got %v", err)
+	}
+	if visits != 1 {
+		t.Errorf("expected crawl to stop after first visit, got %d visits", visits)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Crawl(ctx, idx, nil, func(res PackageResult) error { return nil })
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPackageResultJSON(t *testing.T) {
+	started := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	res := PackageResult{Pkg: "missing", Err: fmt.Errorf("Package missing not found"), Started: started, Duration: 1500 * time.Millisecond}
+	b, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"Pkg":"missing","Error":"Package missing not found","Started":"2024-01-02T15:04:05Z","DurationMs":1500}`
+	if string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+
+	res = PackageResult{Pkg: "flask", Requirements: []*Requirement{{Name: "Werkzeug", Constraint: ">=", Version: "0.7"}}, Started: started}
+	if b, err = json.Marshal(res); err != nil {
+		t.Fatal(err)
+	}
+	exp = `{"Pkg":"flask","Requirements":[{"Name":"Werkzeug","Constraint":"\u003e=","Version":"0.7"}],"Started":"2024-01-02T15:04:05Z","DurationMs":0}`
+	if string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+}
+
+func TestCrawlSeeds(t *testing.T) {
+	idx := fakeIndex{
+		"app":    {{Name: "Web"}, {Name: "db"}, {Name: "pytest", Extra: "test"}},
+		"Web":    {{Name: "http"}},
+		"db":     {{Name: "http"}, {Name: "driver"}},
+		"http":   {{Name: "deep"}},
+		"other":  {{Name: "web"}},
+		"pytest": nil,
+	}
+	tests := []struct {
+		depth        int
+		followExtras bool
+		exp          string
+	}{
+		{0, false, "[app]"},
+		{1, false, "[Web app db]"},
+		{2, false, "[Web app db driver http]"},
+		{-1, false, "[Web app db deep driver http]"},
+		{1, true, "[Web app db pytest]"},
+	}
+	for _, test := range tests {
+		var visited []string
+		opts := &CrawlOptions{Seeds: []string{"app", "APP"}, Depth: test.depth, FollowExtras: test.followExtras}
+		err := Crawl(context.Background(), idx, opts, func(res PackageResult) error {
+			visited = append(visited, res.Pkg)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(visited)
+		if fmt.Sprint(visited) != test.exp {
+			t.Errorf("depth %d, follow extras %v: expected %s, got %v", test.depth, test.followExtras, test.exp, visited)
+		}
+	}
+}
+
+// A fakeIndex with package info and repositories
+type infoFakeIndex struct {
+	fakeIndex
+}
+
+func (f infoFakeIndex) FetchPackageInfo(pkg string) (*PackageInfo, error) {
+	return &PackageInfo{Pkg: pkg, License: "MIT"}, nil
+}
+
+func (f infoFakeIndex) FetchSourceRepoURL(pkg string) (string, error) {
+	return "https://github.com/example/" + pkg, nil
+}
+
+func TestCrawlMetadata(t *testing.T) {
+	idx := infoFakeIndex{fakeIndex{"a": nil}}
+	for _, metadata := range []bool{false, true} {
+		var results []PackageResult
+		err := Crawl(context.Background(), idx, &CrawlOptions{Packages: []string{"a", "missing"}, Metadata: metadata}, func(res PackageResult) error {
+			results = append(results, res)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range results {
+			if fetched := res.Info != nil || res.RepoURL != ""; fetched != (metadata && res.Err == nil) {
+				t.Errorf("Metadata %v: unexpected info %v and repo URL %q for %s", metadata, res.Info, res.RepoURL, res.Pkg)
+			} else if fetched && (res.Info.License != "MIT" || res.RepoURL != "https://github.com/example/a") {
+				t.Errorf("unexpected info %v and repo URL %q for %s", res.Info, res.RepoURL, res.Pkg)
+			}
+		}
+	}
+}
+
+// A fakeIndex whose "slow" package hangs until released
+type slowIndex struct {
+	fakeIndex
+	release chan struct{}
+}
+
+func (s slowIndex) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	if pkg == "slow" {
+		<-s.release
+	}
+	return s.fakeIndex.FetchPackageRequirements(pkg)
+}
+
+func TestCrawlPackageTimeout(t *testing.T) {
+	idx := slowIndex{fakeIndex: fakeIndex{"a": nil, "slow": nil}, release: make(chan struct{})}
+	defer close(idx.release)
+	file := filepath.Join(t.TempDir(), "slow.txt")
+	slowList, err := OpenSlowList(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(map[string]error)
+	opts := &CrawlOptions{Concurrency: 1, PackageTimeout: 20 * time.Millisecond, SlowList: slowList}
+	err = Crawl(context.Background(), idx, opts, func(res PackageResult) error {
+		errs[res.Pkg] = res.Err
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 2 || errs["a"] != nil {
+		t.Errorf("expected both packages to be crawled despite the hung one, got %v", errs)
+	}
+	if !errors.Is(errs["slow"], ErrTimeout) || ErrorCategory(errs["slow"]) != "timeout" {
+		t.Errorf("expected a timeout, got %v", errs["slow"])
+	}
+	slowList.Close()
+
+	if slowList, err = OpenSlowList(file); err != nil {
+		t.Fatal(err)
+	}
+	defer slowList.Close()
+	if !slowList.Contains("slow") || slowList.Contains("a") || len(slowList.Packages()) != 1 {
+		t.Errorf("expected only the hung package to be listed, got %v", slowList.Packages())
+	}
+}
+
+func TestCrawlPackageTimeoutCancels(t *testing.T) {
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	addr, _ := fakeRedis(t)
+	cache, err := NewRedisCache("redis://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	idx := NewCachedIndex(&PackageIndex{URI: server.URL, Logger: NopLogger{}}, cache)
+	opts := &CrawlOptions{Packages: []string{"slow"}, Concurrency: 1, PackageTimeout: 20 * time.Millisecond}
+	var res PackageResult
+	if err := Crawl(context.Background(), idx, opts, func(r PackageResult) error { res = r; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(res.Err, ErrTimeout) {
+		t.Errorf("expected a timeout, got %v", res.Err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Error("expected the request of the abandoned fetch to be canceled")
+	}
+}
@@ -0,0 +1,125 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Number of slowest packages kept by a CrawlSummary by default
+const DefaultSlowestPackages = 20
+
+// Aggregates the results of a crawl for triage: how many packages succeeded, how many failed in each error category (see ErrorCategory), the
+// slowest packages, and every failure with its reason. Pass it in CrawlOptions.Summary, or Add results to it. Safe for concurrent use.
+type CrawlSummary struct {
+	mu        sync.Mutex
+	total     int
+	succeeded int
+	errors    map[string]int
+	sources   map[RequirementsSource]int
+	slowest   []PackageTiming
+	failures  []CrawlFailure
+
+	// Number of slowest packages to keep; DefaultSlowestPackages if 0
+	MaxSlowest int
+}
+
+// How long fetching the requirements of a package took
+type PackageTiming struct {
+	Pkg        string
+	DurationMs int64
+}
+
+// A package whose requirements couldn't be fetched
+type CrawlFailure struct {
+	Pkg      string
+	Category string
+	Reason   string
+}
+
+// Records the result of crawling a package
+func (s *CrawlSummary) Add(res PackageResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total++
+	if res.Err != nil {
+		category := ErrorCategory(res.Err)
+		if s.errors == nil {
+			s.errors = make(map[string]int)
+		}
+		s.errors[category]++
+		s.failures = append(s.failures, CrawlFailure{Pkg: res.Pkg, Category: category, Reason: res.Err.Error()})
+	} else {
+		s.succeeded++
+		if res.Source != "" {
+			if s.sources == nil {
+				s.sources = make(map[RequirementsSource]int)
+			}
+			s.sources[res.Source]++
+		}
+	}
+
+	maxSlowest := s.MaxSlowest
+	if maxSlowest <= 0 {
+		maxSlowest = DefaultSlowestPackages
+	}
+	timing := PackageTiming{Pkg: res.Pkg, DurationMs: int64(res.Duration / time.Millisecond)}
+	i := sort.Search(len(s.slowest), func(i int) bool { return s.slowest[i].DurationMs < timing.DurationMs })
+	if i < maxSlowest {
+		s.slowest = append(s.slowest, PackageTiming{})
+		copy(s.slowest[i+1:], s.slowest[i:])
+		s.slowest[i] = timing
+		if len(s.slowest) > maxSlowest {
+			s.slowest = s.slowest[:maxSlowest]
+		}
+	}
+}
+
+// The machine-readable form of a CrawlSummary
+type CrawlReport struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Errors    map[string]int  // number of failures by error category
+	Slowest   []PackageTiming // slowest first
+	Failures  []CrawlFailure  // sorted by package
+
+	// Number of successes by where their requirements were read from, if the index reports it (see PackageIndex.Sources), to audit data quality
+	Sources map[RequirementsSource]int `json:",omitempty"`
+}
+
+// Returns the summary of the results added so far
+func (s *CrawlSummary) Report() *CrawlReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &CrawlReport{
+		Total:     s.total,
+		Succeeded: s.succeeded,
+		Failed:    len(s.failures),
+		Errors:    make(map[string]int),
+		Slowest:   append([]PackageTiming{}, s.slowest...),
+		Failures:  append([]CrawlFailure{}, s.failures...),
+	}
+	for category, count := range s.errors {
+		report.Errors[category] = count
+	}
+	for source, count := range s.sources {
+		if report.Sources == nil {
+			report.Sources = make(map[RequirementsSource]int)
+		}
+		report.Sources[source] = count
+	}
+	sort.Slice(report.Failures, func(i, j int) bool { return report.Failures[i].Pkg < report.Failures[j].Pkg })
+	return report
+}
+
+// Writes the report as indented JSON
+func (r *CrawlReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,51 @@
+package cheerio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCrawlSummary(t *testing.T) {
+	summary := &CrawlSummary{MaxSlowest: 2}
+	results := []PackageResult{
+		{Pkg: "fast", Duration: 10 * time.Millisecond},
+		{Pkg: "slow", Duration: 900 * time.Millisecond},
+		{Pkg: "wheelonly", Err: fmt.Errorf("[tar/zip] %w for pkg wheelonly", ErrNoSdist), Duration: 300 * time.Millisecond},
+		{Pkg: "gone", Err: fmt.Errorf("%w: gone", ErrPackageNotFound), Duration: 50 * time.Millisecond},
+	}
+	for _, res := range results {
+		summary.Add(res)
+	}
+
+	report := summary.Report()
+	exp := &CrawlReport{
+		Total:     4,
+		Succeeded: 2,
+		Failed:    2,
+		Errors:    map[string]int{"no_sdist": 1, "not_found": 1},
+		Slowest:   []PackageTiming{{"slow", 900}, {"wheelonly", 300}},
+		Failures: []CrawlFailure{
+			{"gone", "not_found", "Package not found: gone"},
+			{"wheelonly", "no_sdist", "[tar/zip] No source distribution for pkg wheelonly"},
+		},
+	}
+	if !reflect.DeepEqual(report, exp) {
+		t.Errorf("expected report %+v, got %+v", exp, report)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded CrawlReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, exp) {
+		t.Errorf("report didn't round-trip through JSON: %s", buf.String())
+	}
+}
@@ -0,0 +1,158 @@
+package cheerio
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CycloneDX hash algorithm names, keyed by the hash names used in Artifact.Digests
+var cycloneDXHashAlgorithms = map[string]string{
+	"md5":    "MD5",
+	"sha1":   "SHA-1",
+	"sha256": "SHA-256",
+	"sha384": "SHA-384",
+	"sha512": "SHA-512",
+}
+
+// A CycloneDX 1.5 BOM. The same structure is marshaled to JSON and XML; fields that are represented differently in the two are duplicated and
+// excluded from the other format.
+type cdxBOM struct {
+	XMLName      xml.Name        `json:"-" xml:"bom"`
+	XMLNS        string          `json:"-" xml:"xmlns,attr"`
+	BOMFormat    string          `json:"bomFormat" xml:"-"`
+	SpecVersion  string          `json:"specVersion" xml:"-"`
+	SerialNumber string          `json:"serialNumber" xml:"serialNumber,attr"`
+	Version      int             `json:"version" xml:"version,attr"`
+	Metadata     cdxMetadata     `json:"metadata" xml:"metadata"`
+	Components   []cdxComponent  `json:"components" xml:"components>component"`
+	Dependencies []cdxDependency `json:"dependencies" xml:"dependencies>dependency"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp" xml:"timestamp"`
+	Tools     cdxTools      `json:"tools" xml:"tools"`
+	Component *cdxComponent `json:"component,omitempty" xml:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxComponent struct {
+	Type               string           `json:"type" xml:"type,attr"`
+	BOMRef             string           `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	Name               string           `json:"name" xml:"name"`
+	Version            string           `json:"version,omitempty" xml:"version,omitempty"`
+	Hashes             []cdxHash        `json:"hashes,omitempty" xml:"hashes>hash,omitempty"`
+	Licenses           []cdxLicense     `json:"licenses,omitempty" xml:"-"`
+	LicenseExpression  string           `json:"-" xml:"licenses>expression,omitempty"`
+	PURL               string           `json:"purl,omitempty" xml:"purl,omitempty"`
+	ExternalReferences []cdxExternalRef `json:"externalReferences,omitempty" xml:"externalReferences>reference,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg" xml:"alg,attr"`
+	Content string `json:"content" xml:",chardata"`
+}
+
+type cdxLicense struct {
+	Expression string `json:"expression"`
+}
+
+type cdxExternalRef struct {
+	Type string `json:"type" xml:"type,attr"`
+	URL  string `json:"url" xml:"url"`
+}
+
+type cdxDependency struct {
+	Ref       string          `json:"ref" xml:"ref,attr"`
+	DependsOn []string        `json:"dependsOn,omitempty" xml:"-"`
+	Deps      []cdxDependency `json:"-" xml:"dependency,omitempty"`
+}
+
+// Writes the SBOM as a CycloneDX 1.5 BOM in JSON or XML format. The BOM's metadata component stands for the SBOM itself and depends on the root
+// packages; components are identified by their purls.
+func (s *SBOM) WriteCycloneDX(w io.Writer, format string) error {
+	bom := s.cycloneDX()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(bom)
+	case "xml":
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(bom); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return fmt.Errorf("Unknown CycloneDX format '%s' (expected json or xml)", format)
+}
+
+func (s *SBOM) cycloneDX() *cdxBOM {
+	sum := sha1.Sum([]byte(s.Name + s.Created.String()))
+	bom := &cdxBOM{
+		XMLNS:        "http://cyclonedx.org/schema/bom/1.5",
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: s.Created.UTC().Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "cheerio"}}},
+			Component: &cdxComponent{Type: "application", BOMRef: "sbom:" + s.Name, Name: s.Name},
+		},
+		Components:   make([]cdxComponent, 0, len(s.Packages)),
+		Dependencies: make([]cdxDependency, 0, len(s.Packages)+1),
+	}
+
+	refs := make(map[string]string)
+	for _, pkg := range s.Packages {
+		refs[pkg.Name] = pkg.PURL()
+	}
+	addDependency := func(ref string, deps []string) {
+		dep := cdxDependency{Ref: ref, DependsOn: make([]string, 0, len(deps))}
+		for _, name := range deps {
+			if depRef, in := refs[name]; in {
+				dep.DependsOn = append(dep.DependsOn, depRef)
+				dep.Deps = append(dep.Deps, cdxDependency{Ref: depRef})
+			}
+		}
+		bom.Dependencies = append(bom.Dependencies, dep)
+	}
+	addDependency(bom.Metadata.Component.BOMRef, s.Roots)
+
+	for _, pkg := range s.Packages {
+		component := cdxComponent{
+			Type:              "library",
+			BOMRef:            refs[pkg.Name],
+			Name:              pkg.Name,
+			Version:           pkg.Version,
+			LicenseExpression: pkg.License,
+			PURL:              refs[pkg.Name],
+		}
+		for _, algorithm := range sortedStringKeys(pkg.Checksums) {
+			if cdxAlgorithm, in := cycloneDXHashAlgorithms[algorithm]; in {
+				component.Hashes = append(component.Hashes, cdxHash{cdxAlgorithm, pkg.Checksums[algorithm]})
+			}
+		}
+		if pkg.License != "" {
+			component.Licenses = []cdxLicense{{pkg.License}}
+		}
+		if pkg.DownloadURL != "" {
+			component.ExternalReferences = []cdxExternalRef{{"distribution", pkg.DownloadURL}}
+		}
+		bom.Components = append(bom.Components, component)
+		addDependency(component.BOMRef, pkg.Requires)
+	}
+	return bom
+}
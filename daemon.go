@@ -0,0 +1,297 @@
+package cheerio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default interval between refreshes of a GraphDaemon
+const DefaultRefreshInterval = time.Hour
+
+// Keeps a dependency graph fresh and serves queries on it continuously: every Interval, it re-crawls the packages that changed in the index since
+// the changelog serial of the graph (see GraphInfo.Serial), builds an updated graph, and atomically swaps it in, so queries always see a complete
+// graph. Implements http.Handler (see ServeHTTP).
+type GraphDaemon struct {
+	Index    Index        // where requirements are crawled from
+	Changes  ChangeSource // where changed packages are listed, e.g., the same PackageIndex; deletions are pruned if it's a DeletionSource
+	Interval time.Duration
+
+	// Interval between checks of the graph against the index's package list, which prune the packages the index no longer lists (see
+	// PruneMissing); never checked if 0
+	PruneInterval time.Duration
+
+	// Options of refresh crawls; the packages to crawl are set on each refresh
+	Crawl CrawlOptions
+
+	// Called with the graph after each refresh that changed it, e.g., to persist it with PyPIGraph.WriteFile
+	OnRefresh func(graph *PyPIGraph)
+
+	// Webhooks called after refreshes that changed watched packages, also registered through the daemon's HTTP API, if set
+	Watches *Watches
+
+	Logger Logger
+
+	graph     atomic.Value // *PyPIGraph
+	refreshMu sync.Mutex   // serializes refreshes
+}
+
+// Returns a daemon serving graph, whose header must record the changelog serial it was crawled at (see GraphInfo)
+func NewGraphDaemon(index Index, changes ChangeSource, graph *PyPIGraph) (*GraphDaemon, error) {
+	if graph.Info() == nil || graph.Info().Serial == 0 {
+		return nil, fmt.Errorf("The graph records no changelog serial, so changes since its crawl are unknown")
+	}
+	d := &GraphDaemon{Index: index, Changes: changes, Interval: DefaultRefreshInterval}
+	d.graph.Store(graph)
+	return d, nil
+}
+
+// Returns the current graph, which mustn't be modified
+func (d *GraphDaemon) Graph() *PyPIGraph {
+	return d.graph.Load().(*PyPIGraph)
+}
+
+// Refreshes the graph right away and then every Interval, and prunes it every PruneInterval, until ctx is done, so a graph file older than
+// Interval isn't served for a whole Interval. Errors are logged, and the refresh or pruning is retried at the next interval.
+func (d *GraphDaemon) Run(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var prune <-chan time.Time
+	if d.PruneInterval > 0 {
+		pruneTicker := time.NewTicker(d.PruneInterval)
+		defer pruneTicker.Stop()
+		prune = pruneTicker.C
+	}
+	d.refresh(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			d.refresh(ctx)
+		case <-prune:
+			if n, err := d.PruneMissing(ctx); err != nil {
+				loggerOr(d.Logger).Logf("daemon", "pruning failed: %s", err)
+			} else if n > 0 {
+				loggerOr(d.Logger).Logf("daemon", "pruned %d packages missing from the index", n)
+			}
+		}
+	}
+}
+
+// Refreshes the graph, logging the outcome
+func (d *GraphDaemon) refresh(ctx context.Context) {
+	if n, err := d.Refresh(ctx); err != nil {
+		loggerOr(d.Logger).Logf("daemon", "refresh failed: %s", err)
+	} else {
+		loggerOr(d.Logger).Logf("daemon", "refreshed %d changed packages, now at serial %d", n, d.Graph().Info().Serial)
+	}
+}
+
+// Re-crawls the packages changed since the serial of the current graph and swaps in the updated graph, returning the number of changed packages.
+// Packages whose requirements can't be fetched keep their previous dependencies. Packages that no longer exist, because Changes reports them
+// deleted or the index doesn't find them (see ErrPackageNotFound), are pruned from the graph with their edges, including those from the
+// packages that require them.
+func (d *GraphDaemon) Refresh(ctx context.Context) (int, error) {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+
+	current := d.Graph()
+	info := *current.Info()
+	var changed, deleted []string
+	var last int64
+	var err error
+	if source, ok := d.Changes.(DeletionSource); ok {
+		changed, deleted, last, err = source.ChangesSince(info.Serial)
+	} else {
+		changed, last, err = d.Changes.ChangedSince(info.Serial)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	tombstones := make(map[string]bool)
+	for _, pkg := range deleted {
+		tombstones[NormalizedPkgName(pkg)] = true
+	}
+	var crawled []string
+	for _, pkg := range changed {
+		if !tombstones[NormalizedPkgName(pkg)] {
+			crawled = append(crawled, pkg)
+		}
+	}
+
+	replaced := make(map[string]bool)
+	results := make([]PackageResult, 0, len(crawled))
+	opts := d.Crawl
+	opts.Packages = crawled
+	started := time.Now()
+	if len(crawled) > 0 {
+		err = Crawl(ctx, d.Index, &opts, func(res PackageResult) error {
+			if errors.Is(res.Err, ErrPackageNotFound) {
+				tombstones[NormalizedPkgName(res.Pkg)] = true
+			} else if res.Err == nil {
+				replaced[NormalizedPkgName(res.Pkg)] = true
+				results = append(results, res)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	graph := current.without(replaced, tombstones)
+	for _, res := range results {
+		reqs := make([]*Requirement, 0, len(res.Requirements))
+		for _, req := range res.Requirements {
+			if !tombstones[NormalizedPkgName(req.Name)] {
+				reqs = append(reqs, req)
+			}
+		}
+		graph.addRequirements(res.Pkg, reqs)
+	}
+	info.Serial, info.Crawled = last, started
+	d.swap(current, graph, &info)
+	return len(changed), nil
+}
+
+// Prunes the packages whose dependencies the graph lists but that the index no longer lists (see Index.AllPackages), e.g., because they were
+// deleted while changelog events were missed, and returns how many were pruned. Nothing is pruned if the index lists no packages at all.
+func (d *GraphDaemon) PruneMissing(ctx context.Context) (int, error) {
+	listed, err := d.Index.AllPackages()
+	if err != nil {
+		return 0, err
+	}
+	if len(listed) == 0 {
+		return 0, fmt.Errorf("The index lists no packages, not pruning")
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	exists := make(map[string]bool, len(listed))
+	for _, pkg := range listed {
+		exists[NormalizedPkgName(pkg)] = true
+	}
+
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	current := d.Graph()
+	tombstones := make(map[string]bool)
+	for pkg := range current.Req {
+		if !exists[pkg] {
+			tombstones[pkg] = true
+		}
+	}
+	if len(tombstones) == 0 {
+		return 0, nil
+	}
+	info := *current.Info()
+	d.swap(current, current.without(nil, tombstones), &info)
+	return len(tombstones), nil
+}
+
+// Swaps in graph, updated from current, with the given header, and notifies OnRefresh and the watches
+func (d *GraphDaemon) swap(current, graph *PyPIGraph, info *GraphInfo) {
+	graph.SetInfo(info)
+	d.graph.Store(graph)
+	if d.OnRefresh != nil {
+		d.OnRefresh(graph)
+	}
+	if d.Watches != nil {
+		d.Watches.Notify(current, graph, info.Serial)
+	}
+}
+
+// Returns a copy of the graph without the dependencies of the replaced packages, which are kept as dependencies of others, and without the
+// deleted packages and any edges to or from them (all normalized)
+func (p *PyPIGraph) without(replaced, deleted map[string]bool) *PyPIGraph {
+	graph := newPyPIGraph()
+	for norm, name := range p.display {
+		if !deleted[norm] {
+			graph.normalize(name)
+		}
+	}
+	for _, pkg := range p.sortedPkgs() {
+		if _, in := p.Req[pkg]; !in || replaced[pkg] || deleted[pkg] {
+			continue
+		}
+		graph.addPkg(pkg)
+		for _, dep := range p.RequiresWithExtras(pkg) {
+			if !deleted[dep] {
+				graph.addEdge(pkg, dep)
+			}
+		}
+		for extra, deps := range p.Extras[pkg] {
+			for _, dep := range deps {
+				if !deleted[dep] {
+					graph.addExtraEdge(pkg, dep, extra)
+				}
+			}
+		}
+	}
+	return graph
+}
+
+// Serves queries on the current graph as JSON:
+//
+//	GET /requires/<pkg>     {"pkg": ..., "found": ..., "requires": [...], "requiredBy": [...]} (see PyPIGraph.Lookup)
+//	GET /search?q=<query>   matching package names (see PyPIGraph.Search)
+//	GET /info               the header of the graph, e.g., its changelog serial (see GraphInfo)
+//
+// and the watch API under /watches if Watches is set (see Watches.ServeHTTP).
+func (d *GraphDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if d.Watches != nil && (r.URL.Path == "/watches" || strings.HasPrefix(r.URL.Path, "/watches/")) {
+		d.Watches.ServeHTTP(w, r)
+		return
+	}
+	graph := d.Graph()
+	var resp interface{}
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/requires/"):
+		pkg := NormalizedPkgName(strings.TrimPrefix(r.URL.Path, "/requires/"))
+		deps, found := graph.Lookup(pkg)
+		if !found && !graph.Contains(pkg) {
+			http.Error(w, fmt.Sprintf("pkg %s is not in the graph", pkg), http.StatusNotFound)
+			return
+		}
+		resp = map[string]interface{}{"pkg": pkg, "found": found, "requires": nonNil(deps), "requiredBy": nonNil(graph.RequiredBy(pkg))}
+	case r.URL.Path == "/search":
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		matches, err := graph.Search(r.URL.Query().Get("q"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp = matches
+	case r.URL.Path == "/info":
+		info := graph.Info()
+		resp = map[string]interface{}{"index": info.IndexURL, "crawled": info.Crawled, "serial": info.Serial, "packages": len(graph.Req)}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,193 @@
+package cheerio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// Lists fixed changes, recording the serial they were asked from
+type fakeChanges struct {
+	pkgs  []string
+	last  int64
+	asked int64
+}
+
+func (f *fakeChanges) ChangedSince(serial int64) ([]string, int64, error) {
+	f.asked = serial
+	if serial >= f.last {
+		return nil, serial, nil
+	}
+	return f.pkgs, f.last, nil
+}
+
+// A fakeIndex in which some packages were removed
+type removedIndex struct {
+	fakeIndex
+	removed map[string]bool
+}
+
+func (r removedIndex) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	if r.removed[pkg] {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)
+	}
+	return r.fakeIndex.FetchPackageRequirements(pkg)
+}
+
+func TestGraphDaemon(t *testing.T) {
+	graph := testGraph("app:flask", "flask:werkzeug", "old:six")
+	if _, err := NewGraphDaemon(fakeIndex{}, &fakeChanges{}, graph); err == nil {
+		t.Errorf("expected an error for a graph without serial")
+	}
+	graph.SetInfo(&GraphInfo{IndexURL: "https://pypi.python.org", Serial: 10})
+
+	idx := removedIndex{fakeIndex{"flask": {{Name: "werkzeug"}, {Name: "click"}}}, map[string]bool{"old": true}}
+	changes := &fakeChanges{pkgs: []string{"app", "flask", "old"}, last: 12}
+	d, err := NewGraphDaemon(idx, changes, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed := 0
+	d.OnRefresh = func(*PyPIGraph) { refreshed++ }
+	d.Logger = NopLogger{}
+
+	if n, err := d.Refresh(context.Background()); err != nil || n != 3 {
+		t.Fatalf("Refresh: expected 3 changed packages, got %d, %v", n, err)
+	}
+	g := d.Graph()
+	if changes.asked != 10 || g.Info().Serial != 12 || g.Info().IndexURL != "https://pypi.python.org" {
+		t.Errorf("unexpected serials: asked %d, graph info %+v", changes.asked, g.Info())
+	}
+	if deps := g.Requires("flask"); !reflect.DeepEqual(deps, []string{"werkzeug", "click"}) {
+		t.Errorf("expected flask's dependencies to be refreshed, got %v", deps)
+	}
+	if deps := g.Requires("app"); !reflect.DeepEqual(deps, []string{"flask"}) {
+		t.Errorf("expected app's dependencies to be kept when they can't be fetched, got %v", deps)
+	}
+	if _, found := g.Lookup("old"); found {
+		t.Errorf("expected the dependencies of a removed package to be dropped")
+	}
+	if deps := graph.Requires("flask"); len(deps) != 1 {
+		t.Errorf("expected the previous graph to be left unchanged, got %v", deps)
+	}
+
+	if n, err := d.Refresh(context.Background()); err != nil || n != 0 || d.Graph() != g || refreshed != 1 {
+		t.Errorf("expected no changes on the second refresh, got %d, %v", n, err)
+	}
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("GET", "/requires/Flask", nil))
+	var resp struct {
+		Pkg        string
+		Found      bool
+		Requires   []string
+		RequiredBy []string
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Pkg != "flask" || !resp.Found || len(resp.Requires) != 2 || !reflect.DeepEqual(resp.RequiredBy, []string{"app"}) {
+		t.Errorf("unexpected response %s", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("GET", "/requires/nope", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown package, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("GET", "/info", nil))
+	var info struct{ Serial int64 }
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil || info.Serial != 12 {
+		t.Errorf("unexpected info response %s", rec.Body.String())
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pypi" || r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<?xml version='1.0'?>
+<methodResponse><params><param><value><array><data>
+<value><array><data><value><string>Flask</string></value><value><string>2.0.0</string></value><value><int>1620000000</int></value>
+<value><string>new release</string></value><value><int>101</int></value></data></array></value>
+<value><array><data><value><string>requests</string></value><value><nil/></value><value><int>1620000001</int></value>
+<value><string>remove</string></value><value><int>103</int></value></data></array></value>
+<value><array><data><value>Flask</value><value><string>2.0.0</string></value><value><int>1620000002</int></value>
+<value><string>add py3 file</string></value><value><int>102</int></value></data></array></value>
+</data></array></value></param></params></methodResponse>`)
+	}))
+	defer server.Close()
+
+	pkgs, last, err := (&PackageIndex{URI: server.URL}).ChangedSince(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pkgs, []string{"Flask", "requests"}) || last != 103 {
+		t.Errorf("expected [Flask requests] up to serial 103, got %v, %d", pkgs, last)
+	}
+	_, deleted, _, err := (&PackageIndex{URI: server.URL}).ChangesSince(100)
+	if err != nil || !reflect.DeepEqual(deleted, []string{"requests"}) {
+		t.Errorf("ChangesSince: expected requests to be deleted, got %v, %v", deleted, err)
+	}
+
+	// the call is POSTed again to the target of a redirect, e.g., from pypi.python.org to pypi.org
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL+"/pypi", http.StatusMovedPermanently))
+	defer redirect.Close()
+	if pkgs, _, err := (&PackageIndex{URI: redirect.URL}).ChangedSince(100); err != nil || len(pkgs) != 2 {
+		t.Errorf("ChangedSince through a redirect: expected 2 packages, got %v, %v", pkgs, err)
+	}
+}
+
+// Lists fixed changes, some of which are deletions
+type fakeDeletions struct {
+	fakeChanges
+	deleted []string
+}
+
+func (f *fakeDeletions) ChangesSince(serial int64) ([]string, []string, int64, error) {
+	changed, last, err := f.ChangedSince(serial)
+	if changed == nil {
+		return nil, nil, last, err
+	}
+	return changed, f.deleted, last, err
+}
+
+func TestGraphDaemonTombstones(t *testing.T) {
+	graph := testGraph("app:flask", "app:gone", "gone:six", "flask:werkzeug", "lib:werkzeug")
+	graph.SetInfo(&GraphInfo{Serial: 10})
+	idx := fakeIndex{"app": {{Name: "flask"}, {Name: "gone"}}, "flask": {{Name: "werkzeug"}}}
+	changes := &fakeDeletions{fakeChanges{pkgs: []string{"app", "gone"}, last: 11}, []string{"gone"}}
+	d, err := NewGraphDaemon(idx, changes, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Logger = NopLogger{}
+
+	if n, err := d.Refresh(context.Background()); err != nil || n != 2 {
+		t.Fatalf("Refresh: expected 2 changed packages, got %d, %v", n, err)
+	}
+	g := d.Graph()
+	if g.Contains("gone") || g.Contains("six") {
+		t.Errorf("expected the deleted package and its edges to be pruned, got %v", g.Edges())
+	}
+	if deps := g.Requires("app"); !reflect.DeepEqual(deps, []string{"flask"}) {
+		t.Errorf("expected the edge to the deleted package to be pruned, got %v", deps)
+	}
+
+	// lib is no longer listed by the index
+	if n, err := d.PruneMissing(context.Background()); err != nil || n != 1 {
+		t.Fatalf("PruneMissing: expected 1 pruned package, got %d, %v", n, err)
+	}
+	if _, found := d.Graph().Lookup("lib"); found || !d.Graph().Contains("werkzeug") {
+		t.Errorf("expected only lib to be pruned, got %v", d.Graph().Edges())
+	}
+	if _, err := (&GraphDaemon{Index: fakeIndex{}}).PruneMissing(context.Background()); err == nil {
+		t.Errorf("PruneMissing: expected an error for an index without packages")
+	}
+}
@@ -0,0 +1,184 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+var DefaultDepsDev = &DepsDev{URI: "https://api.deps.dev"}
+
+// Per-version PyPI dependency data from the deps.dev API (https://docs.deps.dev/api/v3/), which provides it without downloading and extracting
+// sdists. Implements DependencyGraph (for the latest release of each package) and ReleaseSource. Responses are cached, so a DepsDev isn't safe for
+// concurrent use.
+type DepsDev struct {
+	URI    string
+	Logger Logger // receives diagnostics; DefaultLogger if nil
+
+	versions map[string][]string                  // pkg -> versions, oldest first
+	latest   map[string]string                    // pkg -> default version
+	reqs     map[string]map[string][]*Requirement // pkg -> version -> direct requirements
+	reqBy    map[string][]string                  // dep -> packages whose requirements have been fetched that require it
+}
+
+type depsDevVersionKey struct {
+	System  string `json:"system"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type depsDevPackage struct {
+	Versions []struct {
+		VersionKey depsDevVersionKey `json:"versionKey"`
+		IsDefault  bool              `json:"isDefault"`
+	} `json:"versions"`
+}
+
+type depsDevDependencies struct {
+	Nodes []struct {
+		VersionKey depsDevVersionKey `json:"versionKey"`
+		Relation   string            `json:"relation"` // SELF, DIRECT, or INDIRECT
+	} `json:"nodes"`
+	Edges []struct {
+		FromNode    int    `json:"fromNode"`
+		ToNode      int    `json:"toNode"`
+		Requirement string `json:"requirement"`
+	} `json:"edges"`
+	Error string `json:"error"`
+}
+
+// Returns the release versions of a package known to deps.dev, oldest first
+func (d *DepsDev) ReleaseVersions(pkg string) ([]string, error) {
+	pkg = NormalizedPkgName(pkg)
+	if versions, in := d.versions[pkg]; in {
+		return versions, nil
+	}
+
+	var resp depsDevPackage
+	if err := d.get(&resp, "/v3/systems/pypi/packages/%s", pkg); err != nil {
+		return nil, err
+	}
+	versions, latest := make([]string, 0, len(resp.Versions)), ""
+	for _, v := range resp.Versions {
+		versions = append(versions, v.VersionKey.Version)
+		if v.IsDefault {
+			latest = v.VersionKey.Version
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return CompareVersions(versions[i], versions[j]) < 0 })
+	if latest == "" && len(versions) > 0 {
+		latest = versions[len(versions)-1]
+	}
+
+	if d.versions == nil {
+		d.versions, d.latest = make(map[string][]string), make(map[string]string)
+	}
+	d.versions[pkg], d.latest[pkg] = versions, latest
+	return versions, nil
+}
+
+// Returns the direct requirements of a release of a package, as resolved by deps.dev. If version is empty, uses the default (latest) release.
+func (d *DepsDev) FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error) {
+	pkg = NormalizedPkgName(pkg)
+	if version == "" {
+		if _, err := d.ReleaseVersions(pkg); err != nil {
+			return nil, err
+		}
+		if version = d.latest[pkg]; version == "" {
+			return nil, fmt.Errorf("[no-files] %w: deps.dev lists no releases of pkg %s", ErrNoReleases, pkg)
+		}
+	}
+	if reqs, in := d.reqs[pkg][version]; in {
+		return reqs, nil
+	}
+
+	var resp depsDevDependencies
+	if err := d.get(&resp, "/v3/systems/pypi/packages/%s/versions/%s:dependencies", pkg, version); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		loggerOr(d.Logger).Logf("depsdev", "deps.dev reported an error for %s %s: %s", pkg, version, resp.Error)
+	}
+
+	reqs := make([]*Requirement, 0)
+	for _, edge := range resp.Edges {
+		if edge.FromNode != 0 || edge.ToNode < 0 || edge.ToNode >= len(resp.Nodes) {
+			continue
+		}
+		name := resp.Nodes[edge.ToNode].VersionKey.Name
+		req, err := ParseRequirement(name + edge.Requirement)
+		if err != nil {
+			req = &Requirement{Name: name}
+		}
+		reqs = append(reqs, req)
+	}
+
+	if d.reqs == nil {
+		d.reqs, d.reqBy = make(map[string]map[string][]*Requirement), make(map[string][]string)
+	}
+	if d.reqs[pkg] == nil {
+		d.reqs[pkg] = make(map[string][]*Requirement)
+	}
+	d.reqs[pkg][version] = reqs
+	for _, req := range reqs {
+		dep := NormalizedPkgName(req.Name)
+		if !containsString(d.reqBy[dep], pkg) {
+			d.reqBy[dep] = append(d.reqBy[dep], pkg)
+			sort.Strings(d.reqBy[dep])
+		}
+	}
+	return reqs, nil
+}
+
+// Returns the packages required by the latest release of pkg. Errors are logged, and yield no dependencies.
+func (d *DepsDev) Requires(pkg string) []string {
+	deps, _ := d.Lookup(pkg)
+	return deps
+}
+
+// Returns the packages required by the latest release of pkg, and whether they could be fetched. Errors, e.g., for packages unknown to deps.dev
+// (see ErrPackageNotFound), are logged.
+func (d *DepsDev) Lookup(pkg string) (deps []string, found bool) {
+	reqs, err := d.FetchPackageRequirementsAt(pkg, "")
+	if err != nil {
+		loggerOr(d.Logger).Logf("depsdev", "unable to fetch requirements of pkg %s due to error: %s", pkg, err)
+		return nil, false
+	}
+	deps = make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if dep := NormalizedPkgName(req.Name); !containsString(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+	return deps, true
+}
+
+// Returns the packages whose requirements have been fetched through d that require pkg, sorted. deps.dev doesn't list the dependents of a
+// package, so this only reflects packages already visited with Requires or FetchPackageRequirementsAt.
+func (d *DepsDev) RequiredBy(pkg string) []string {
+	return d.reqBy[NormalizedPkgName(pkg)]
+}
+
+// Fetches a deps.dev API endpoint (with the path arguments escaped) and decodes its JSON response into v
+func (d *DepsDev) get(v interface{}, pathFormat string, args ...string) error {
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		escaped[i] = url.PathEscape(arg)
+	}
+	resp, err := http.Get(d.URI + fmt.Sprintf(pathFormat, escaped...))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return notFoundIf(resp.StatusCode, fmt.Errorf("[no-files] deps.dev returned %s for %s", resp.Status, resp.Request.URL.Path))
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,56 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDepsDev(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/systems/pypi/packages/flask":
+			fmt.Fprint(w, `{"versions": [
+				{"versionKey": {"system": "PYPI", "name": "flask", "version": "2.0.0"}, "isDefault": true},
+				{"versionKey": {"system": "PYPI", "name": "flask", "version": "1.1.4"}},
+				{"versionKey": {"system": "PYPI", "name": "flask", "version": "2.1.0rc1"}}]}`)
+		case "/v3/systems/pypi/packages/flask/versions/2.0.0:dependencies":
+			fmt.Fprint(w, `{"nodes": [
+				{"versionKey": {"system": "PYPI", "name": "flask", "version": "2.0.0"}, "relation": "SELF"},
+				{"versionKey": {"system": "PYPI", "name": "werkzeug", "version": "2.0.3"}, "relation": "DIRECT"},
+				{"versionKey": {"system": "PYPI", "name": "Jinja2", "version": "3.1.2"}, "relation": "DIRECT"},
+				{"versionKey": {"system": "PYPI", "name": "markupsafe", "version": "2.1.1"}, "relation": "INDIRECT"}],
+			"edges": [
+				{"fromNode": 0, "toNode": 1, "requirement": ">=2.0"},
+				{"fromNode": 0, "toNode": 2, "requirement": ">=3.0"},
+				{"fromNode": 2, "toNode": 3, "requirement": ">=2.0"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var d DependencyGraph = &DepsDev{URI: server.URL}
+	source := d.(*DepsDev)
+	if versions, err := source.ReleaseVersions("Flask"); err != nil || !reflect.DeepEqual(versions, []string{"1.1.4", "2.0.0", "2.1.0rc1"}) {
+		t.Errorf("ReleaseVersions: got %v, %v", versions, err)
+	}
+	if deps, exp := d.Requires("flask"), []string{"werkzeug", "jinja2"}; !reflect.DeepEqual(deps, exp) {
+		t.Errorf("Requires: expected %v, got %v", exp, deps)
+	}
+	if _, found := d.Lookup("missing"); found {
+		t.Errorf("Lookup: expected a package unknown to deps.dev not to be found")
+	}
+	if reqBy, exp := d.RequiredBy("werkzeug"), []string{"flask"}; !reflect.DeepEqual(reqBy, exp) {
+		t.Errorf("RequiredBy: expected %v, got %v", exp, reqBy)
+	}
+	reqs, err := source.FetchPackageRequirementsAt("flask", "2.0.0")
+	if err != nil || len(reqs) != 2 || reqs[0].Specifier() != ">=2.0" {
+		t.Errorf("FetchPackageRequirementsAt: got %v, %v", reqs, err)
+	}
+	if _, err := source.FetchPackageRequirementsAt("flask", "1.1.4"); err == nil {
+		t.Errorf("FetchPackageRequirementsAt: expected an error for a release without dependency data")
+	}
+}
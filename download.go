@@ -0,0 +1,124 @@
+package cheerio
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Supported digest algorithms, strongest first
+var digestAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha512", sha512.New},
+	{"sha384", sha512.New384},
+	{"sha256", sha256.New},
+	{"sha224", sha256.New224},
+	{"sha1", sha1.New},
+	{"md5", md5.New},
+}
+
+// Downloads a distribution file of a package (by file name, e.g., "Flask-0.10.1.tar.gz") and streams it to w, verifying it against the digest
+// listed on the package's simple index page. Returns a "[digest-mismatch]" error if verification fails; since the data has already been written to
+// w by then, callers must discard it. Use DownloadArtifactToFile to only keep verified files.
+func (p *PackageIndex) DownloadArtifact(pkg, file string, w io.Writer) error {
+	artifact, err := p.findArtifact(pkg, file)
+	if err != nil {
+		return err
+	}
+	return artifact.download(p.client(), w)
+}
+
+// Like DownloadArtifact, but writes the file to dest, which may be an s3:// or gs:// URL, e.g., to share a cache of artifacts (see ObjectStore).
+// The file is only created if it was downloaded and verified successfully.
+func (p *PackageIndex) DownloadArtifactToFile(pkg, file, dest string) error {
+	artifact, err := p.findArtifact(pkg, file)
+	if err != nil {
+		return err
+	}
+	if IsObjectURL(dest) {
+		object := DefaultObjectStore.Create(dest)
+		if err := artifact.download(p.client(), object); err != nil {
+			return err
+		}
+		return object.Close()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), ".cheerio-download-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if err := artifact.download(p.client(), tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// Returns the artifact of pkg with the given file name
+func (p *PackageIndex) findArtifact(pkg, file string) (*Artifact, error) {
+	artifacts, err := p.pkgArtifacts(pkg)
+	if err != nil {
+		return nil, err
+	}
+	for _, artifact := range artifacts {
+		if artifact.Filename == file {
+			return artifact, nil
+		}
+	}
+	return nil, fmt.Errorf("[no-files] no file named %s found for pkg %s", file, pkg)
+}
+
+// Streams the artifact to w, verifying it against the strongest of its digests. Artifacts without a (supported) digest are downloaded unverified,
+// with a warning.
+func (a *Artifact) Download(w io.Writer) error {
+	return a.download(http.DefaultClient, w)
+}
+
+func (a *Artifact) download(client *http.Client, w io.Writer) error {
+	resp, err := client.Get(a.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Downloading %s returned %s", a.URL, resp.Status)
+	}
+
+	var algorithm string
+	var h hash.Hash
+	for _, alg := range digestAlgorithms {
+		if _, in := a.Digests[alg.name]; in {
+			algorithm, h = alg.name, alg.new()
+			break
+		}
+	}
+	if h == nil {
+		DefaultLogger.Logf("download", "no digest to verify %s against", a.Filename)
+		_, err := io.Copy(w, resp.Body)
+		return err
+	}
+
+	if _, err := io.Copy(io.MultiWriter(w, h), resp.Body); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != a.Digests[algorithm] {
+		return fmt.Errorf("[digest-mismatch] %s of %s is %s, but the index lists %s", algorithm, a.Filename, actual, a.Digests[algorithm])
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package cheerio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadArtifact(t *testing.T) {
+	contents := "not really a tarball"
+	good := fmt.Sprintf("%x", sha256.Sum256([]byte(contents)))
+	bad := strings.Repeat("0", 64)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/flask/":
+			fmt.Fprintf(w, `<a href="/files/Flask-1.0.tar.gz#sha256=%s">Flask-1.0.tar.gz</a><br/>`, good)
+			fmt.Fprintf(w, `<a href="/files/Flask-1.1.tar.gz#sha256=%s">Flask-1.1.tar.gz</a><br/>`, bad)
+		case "/files/Flask-1.0.tar.gz", "/files/Flask-1.1.tar.gz":
+			fmt.Fprint(w, contents)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	index := &PackageIndex{URI: server.URL}
+
+	var buf bytes.Buffer
+	if err := index.DownloadArtifact("flask", "Flask-1.0.tar.gz", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != contents {
+		t.Errorf("DownloadArtifact: expected %q, got %q", contents, buf.String())
+	}
+
+	if err := index.DownloadArtifact("flask", "Flask-1.1.tar.gz", &bytes.Buffer{}); err == nil || !strings.HasPrefix(err.Error(), "[digest-mismatch]") {
+		t.Errorf("DownloadArtifact: expected digest mismatch, got %v", err)
+	}
+	if err := index.DownloadArtifact("flask", "Flask-2.0.tar.gz", &bytes.Buffer{}); err == nil {
+		t.Errorf("DownloadArtifact: expected error for missing file")
+	}
+
+	dir, err := ioutil.TempDir("", "cheerio-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "Flask-1.1.tar.gz")
+	if err := index.DownloadArtifactToFile("flask", "Flask-1.1.tar.gz", dest); err == nil {
+		t.Errorf("DownloadArtifactToFile: expected digest mismatch")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("DownloadArtifactToFile: expected no files after failed download, found %d", len(files))
+	}
+
+	dest = filepath.Join(dir, "Flask-1.0.tar.gz")
+	if err := index.DownloadArtifactToFile("flask", "Flask-1.0.tar.gz", dest); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(dest); err != nil || string(b) != contents {
+		t.Errorf("DownloadArtifactToFile: expected %q, got %q (error %v)", contents, b, err)
+	}
+}
@@ -0,0 +1,52 @@
+package cheerio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors returned (wrapped) by the package indexes, so callers can tell failure modes apart with errors.Is, e.g.,
+// errors.Is(err, ErrNoRequiresFile). Error messages keep their "[tag]" prefixes, e.g., "[no-files]", for logs.
+var (
+	// The registry doesn't know the package (or the requested release of it)
+	ErrPackageNotFound = errors.New("Package not found")
+
+	// The package exists but has no release files, e.g., because all of its releases were removed or yanked
+	ErrNoReleases = errors.New("No release files")
+
+	// The package has release files, but none is a source archive (or egg) that metadata can be read from, e.g., it only has wheels
+	ErrNoSdist = errors.New("No source distribution")
+
+	// The package (or the requested release of it) has no wheel that metadata can be read from
+	ErrNoWheel = errors.New("No wheel")
+
+	// The source archive of the package has no requires.txt, and its requirements couldn't be read from pyproject.toml, setup.cfg or setup.py
+	// either
+	ErrNoRequiresFile = errors.New("No requires.txt found")
+
+	// Fetching the package took longer than the deadline of the crawl (see CrawlOptions.PackageTimeout)
+	ErrTimeout = errors.New("Deadline exceeded")
+)
+
+// Wraps err, which reports an unexpected HTTP status, with ErrPackageNotFound if the status is 404 Not Found or 410 Gone
+func notFoundIf(statusCode int, err error) error {
+	if statusCode == http.StatusNotFound || statusCode == http.StatusGone {
+		return fmt.Errorf("%w: %s", ErrPackageNotFound, err)
+	}
+	return err
+}
+
+// A line of a requirements or metadata file that couldn't be parsed
+type ParseError struct {
+	Line   string // the offending line
+	LineNo int    // 1-based line number, or 0 if unknown
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	if e.LineNo > 0 {
+		return fmt.Sprintf("%s on line %d: '%s'", e.Msg, e.LineNo, e.Line)
+	}
+	return fmt.Sprintf("%s: '%s'", e.Msg, e.Line)
+}
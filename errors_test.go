@@ -0,0 +1,45 @@
+package cheerio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	_, err := ParseRequirement("flask >= 1.0 !!")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if parseErr.Line != "flask >= 1.0 !!" || err.Error() != "Unable to parse requirement from string: 'flask >= 1.0 !!'" {
+		t.Errorf("unexpected parse error %q", err)
+	}
+
+	_, err = ParseRequirementLine("-e git+https://github.com/mitsuhiko/flask")
+	if !errors.As(err, &parseErr) || parseErr.Msg != "Editable requirement has no #egg= name" {
+		t.Errorf("expected *ParseError for editable requirement without name, got %v", err)
+	}
+
+	parseErr = &ParseError{Line: "???", LineNo: 3, Msg: "Unable to parse requirement from string"}
+	if exp := "Unable to parse requirement from string on line 3: '???'"; parseErr.Error() != exp {
+		t.Errorf("expected %q, got %q", exp, parseErr.Error())
+	}
+}
+
+func TestErrPackageNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	indexes := map[string]Index{
+		"pypi":     &PackageIndex{URI: server.URL},
+		"npm":      &NPMRegistry{URI: server.URL},
+		"rubygems": &RubyGems{URI: server.URL},
+	}
+	for name, idx := range indexes {
+		if _, err := idx.FetchPackageRequirements("nonexistent"); !errors.Is(err, ErrPackageNotFound) {
+			t.Errorf("%s: expected ErrPackageNotFound, got %v", name, err)
+		}
+	}
+}
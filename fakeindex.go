@@ -0,0 +1,251 @@
+package cheerio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// The operations of a PyPI index that PackageIndex implements, so that code using them can be tested against an in-memory FakePyPI
+type PyPIIndex interface {
+	Index
+	ReleaseSource
+
+	// Returns the releases of a package and their files, oldest first
+	Versions(pkg string) ([]*Release, error)
+
+	// Returns the core metadata of the latest release of a package
+	FetchMetadata(pkg string) (*Metadata, error)
+
+	// Writes a release file of a package to w
+	DownloadArtifact(pkg, file string, w io.Writer) error
+
+	// Returns the latest version of a package, not counting pre-releases unless it only has those
+	LatestVersion(pkg string) (string, error)
+
+	// Returns the releases of a package with their upload times, oldest first
+	ReleaseHistory(pkg string) ([]*ReleaseInfo, error)
+}
+
+var _ PyPIIndex = (*PackageIndex)(nil)
+
+// An in-memory PyPIIndex for tests of code using cheerio, keyed by package name. Lookups of packages it doesn't have fail with
+// ErrPackageNotFound, as those of PackageIndex do. It must not be modified while in use.
+type FakePyPI map[string]*FakePackage
+
+// A package of a FakePyPI
+type FakePackage struct {
+	Files        []*FakeFile
+	Requirements map[string][]*Requirement // by release version
+	Metadata     *Metadata                 // of the latest release; only its name and version if nil
+	RepoURL      string
+}
+
+// A release file of a FakePackage. Its version is read from its name, e.g., "Flask-0.10.1.tar.gz".
+type FakeFile struct {
+	Filename string
+	Data     []byte
+	Yanked   bool
+	Uploaded time.Time
+}
+
+var _ PyPIIndex = FakePyPI(nil)
+
+func (f FakePyPI) AllPackages() ([]string, error) {
+	pkgs := make([]string, 0, len(f))
+	for pkg := range f {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
+
+// Returns the package named pkg, matching names after normalization as PyPI does
+func (f FakePyPI) get(pkg string) (string, *FakePackage, error) {
+	if fake, in := f[pkg]; in {
+		return pkg, fake, nil
+	}
+	for name, fake := range f {
+		if NormalizedPkgName(name) == NormalizedPkgName(pkg) {
+			return name, fake, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)
+}
+
+func (f FakePyPI) ReleaseVersions(pkg string) ([]string, error) {
+	name, fake, err := f.get(pkg)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	versions := make([]string, 0)
+	for _, file := range fake.Files {
+		if _, ver := artifactTypeAndVersion(name, file.Filename); ver != "" && !seen[ver] {
+			seen[ver] = true
+			versions = append(versions, ver)
+		}
+	}
+	for ver := range fake.Requirements {
+		if !seen[ver] {
+			seen[ver] = true
+			versions = append(versions, ver)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return CompareVersions(versions[i], versions[j]) < 0 })
+	return versions, nil
+}
+
+func (f FakePyPI) Versions(pkg string) ([]*Release, error) {
+	name, fake, err := f.get(pkg)
+	if err != nil {
+		return nil, err
+	}
+	versions, _ := f.ReleaseVersions(pkg)
+	releases := make([]*Release, 0, len(versions))
+	for _, ver := range versions {
+		release := &Release{Version: ver}
+		for _, file := range fake.Files {
+			if artifact := fakeArtifact(name, file); artifact.Version == ver {
+				release.Artifacts = append(release.Artifacts, artifact)
+			}
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+// Returns the artifact of a fake file, with a fake:// URL and the digest of its data
+func fakeArtifact(pkg string, file *FakeFile) *Artifact {
+	sum := sha256.Sum256(file.Data)
+	artifact := &Artifact{
+		Filename: file.Filename,
+		URL:      fmt.Sprintf("fake://%s/%s", pkg, file.Filename),
+		Digests:  map[string]string{"sha256": hex.EncodeToString(sum[:])},
+		Yanked:   file.Yanked,
+	}
+	artifact.Type, artifact.Version = artifactTypeAndVersion(pkg, file.Filename)
+	return artifact
+}
+
+// Returns the latest version of a package
+func (f FakePyPI) latest(pkg string) (string, error) {
+	versions, err := f.ReleaseVersions(pkg)
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("[no-files] %w for pkg %s", ErrNoReleases, pkg)
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (f FakePyPI) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	return f.FetchPackageRequirementsAt(pkg, "")
+}
+
+// Returns the requirements of a release (the latest if version is empty). Releases without requirements have none.
+func (f FakePyPI) FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error) {
+	_, fake, err := f.get(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		if version, err = f.latest(pkg); err != nil {
+			return nil, err
+		}
+	}
+	versions, _ := f.ReleaseVersions(pkg)
+	if !containsString(versions, version) {
+		return nil, fmt.Errorf("[no-files] %w for pkg %s version %s", ErrNoReleases, pkg, version)
+	}
+	return fake.Requirements[version], nil
+}
+
+func (f FakePyPI) FetchSourceRepoURL(pkg string) (string, error) {
+	_, fake, err := f.get(pkg)
+	if err != nil {
+		return "", err
+	}
+	if fake.RepoURL == "" {
+		return "", fmt.Errorf("No homepage found in metadata for pkg %s", pkg)
+	}
+	return fake.RepoURL, nil
+}
+
+func (f FakePyPI) FetchMetadata(pkg string) (*Metadata, error) {
+	name, fake, err := f.get(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if fake.Metadata != nil {
+		return fake.Metadata, nil
+	}
+	version, err := f.latest(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{Name: name, Version: version, Classifiers: make([]string, 0), ProjectURLs: make(map[string]string),
+		RequiresDist: make([]string, 0)}, nil
+}
+
+func (f FakePyPI) DownloadArtifact(pkg, file string, w io.Writer) error {
+	_, fake, err := f.get(pkg)
+	if err != nil {
+		return err
+	}
+	for _, fakeFile := range fake.Files {
+		if fakeFile.Filename == file {
+			_, err := w.Write(fakeFile.Data)
+			return err
+		}
+	}
+	return fmt.Errorf("[no-files] no file named %s found for pkg %s", file, pkg)
+}
+
+func (f FakePyPI) LatestVersion(pkg string) (string, error) {
+	versions, err := f.ReleaseVersions(pkg)
+	if err != nil {
+		return "", err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if v, err := parseVersion(versions[i]); err == nil && !v.isPrerelease() {
+			return versions[i], nil
+		}
+	}
+	return f.latest(pkg)
+}
+
+func (f FakePyPI) ReleaseHistory(pkg string) ([]*ReleaseInfo, error) {
+	name, fake, err := f.get(pkg)
+	if err != nil {
+		return nil, err
+	}
+	versions, _ := f.ReleaseVersions(pkg)
+	history := make([]*ReleaseInfo, 0, len(versions))
+	for _, ver := range versions {
+		release := &ReleaseInfo{Version: ver}
+		files, yanked := 0, 0
+		for _, file := range fake.Files {
+			artifact := fakeArtifact(name, file)
+			if artifact.Version != ver {
+				continue
+			}
+			if files++; files == 1 || file.Uploaded.Before(release.Uploaded) {
+				release.Uploaded = file.Uploaded
+			}
+			if !containsArtifactType(release.ArtifactTypes, artifact.Type) {
+				release.ArtifactTypes = append(release.ArtifactTypes, artifact.Type)
+			}
+			if file.Yanked {
+				yanked++
+			}
+		}
+		release.Yanked = files > 0 && yanked == files
+		history = append(history, release)
+	}
+	return history, nil
+}
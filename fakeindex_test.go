@@ -0,0 +1,75 @@
+package cheerio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakePyPI(t *testing.T) {
+	index := FakePyPI{
+		"Flask": {
+			Files: []*FakeFile{
+				{Filename: "Flask-1.0.tar.gz", Data: []byte("old sdist")},
+				{Filename: "Flask-2.0.tar.gz", Data: []byte("sdist")},
+				{Filename: "Flask-2.0-py3-none-any.whl", Data: []byte("wheel")},
+			},
+			Requirements: map[string][]*Requirement{
+				"1.0": {{Name: "Werkzeug", Constraint: "<", Version: "1.0"}},
+				"2.0": {{Name: "Werkzeug", Constraint: ">=", Version: "2.0"}},
+			},
+			RepoURL: "https://github.com/pallets/flask",
+		},
+		"werkzeug": {Requirements: map[string][]*Requirement{"0.9": nil, "2.1": nil}},
+	}
+
+	reqs, err := index.FetchPackageRequirements("flask")
+	if err != nil || len(reqs) != 1 || reqs[0].Version != "2.0" {
+		t.Errorf("expected the requirements of the latest release, got %+v, %v", reqs, err)
+	}
+	if _, err := index.FetchPackageRequirementsAt("flask", "3.0"); !errors.Is(err, ErrNoReleases) {
+		t.Errorf("expected ErrNoReleases for a missing release, got %v", err)
+	}
+	if _, err := index.FetchSourceRepoURL("django"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected ErrPackageNotFound, got %v", err)
+	}
+
+	releases, err := index.Versions("flask")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 2 || releases[1].Version != "2.0" || len(releases[1].Artifacts) != 2 || releases[1].Artifacts[1].Type != ArtifactWheel {
+		t.Errorf("unexpected releases %+v", releases)
+	}
+	var b bytes.Buffer
+	if err := index.DownloadArtifact("flask", "Flask-2.0.tar.gz", &b); err != nil || b.String() != "sdist" {
+		t.Errorf("expected the file's data, got %q, %v", b.String(), err)
+	}
+	if latest, err := index.LatestVersion("werkzeug"); err != nil || latest != "2.1" {
+		t.Errorf("expected latest version 2.1, got %q, %v", latest, err)
+	}
+	if metadata, err := index.FetchMetadata("flask"); err != nil || metadata.Name != "Flask" || metadata.Version != "2.0" {
+		t.Errorf("unexpected metadata %+v, %v", metadata, err)
+	}
+
+	// code written against the interfaces runs against the fake
+	var crawled []string
+	err = Crawl(context.Background(), index, nil, func(res PackageResult) error {
+		if res.Err != nil {
+			t.Errorf("unexpected error for %s: %s", res.Pkg, res.Err)
+		}
+		crawled = append(crawled, res.Pkg)
+		return nil
+	})
+	if err != nil || len(crawled) != 2 {
+		t.Errorf("expected both packages to be crawled, got %v, %v", crawled, err)
+	}
+	resolution, err := (&Resolver{Source: index}).Resolve([]*Requirement{{Name: "flask"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolution.Pins["flask"] != "2.0" || resolution.Pins["werkzeug"] != "2.1" {
+		t.Errorf("unexpected pins %v", resolution.Pins)
+	}
+}
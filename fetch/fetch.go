@@ -6,114 +6,215 @@ import (
 	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
 )
 
+// Returned (wrapped) when no file in an archive matches the requested pattern
+var ErrNoMatch = errors.New("No file matched pattern")
+
 type CompressionType string
 
 const (
-	Zip CompressionType = "zip"
-	Tar                 = "tar"
+	Zip      CompressionType = "zip"
+	Tar                      = "tar"       // gzip- or bzip2-compressed tar archive
+	PlainTar                 = "plain-tar" // uncompressed tar archive, e.g., a .gem file
 )
 
+// Options of RemoteDecompressWith. Tar archives can only be read sequentially, so by default they are read whole to collect every matching member;
+// these options stop the download early instead. Zip archives are read by their directory, so only matching members are fetched anyway.
+type Options struct {
+	// Stop reading a tar archive after the first matching member
+	StopAfterFirstMatch bool
+
+	// Stop reading a tar archive at the first member outside the directory of the first matching member, e.g., once the *.egg-info/ directory
+	// containing a requires.txt has been passed, as archivers write the members of a directory together
+	StopAfterMatchDir bool
+
+	// Client of remote reads, e.g., one using a proxy; http.DefaultClient if nil
+	Client *http.Client
+}
+
+// Returns the contents of the members of a remote archive whose names match pattern, concatenated, or an error wrapping ErrNoMatch if none does.
+// uri may also be a file:// URI or a local path, e.g., of a file in a local mirror, which is read directly.
 func RemoteDecompress(uri string, pattern *regexp.Regexp, compressType CompressionType) ([]byte, error) {
+	return RemoteDecompressWith(uri, pattern, compressType, Options{})
+}
+
+// Like RemoteDecompress, with options
+func RemoteDecompressWith(uri string, pattern *regexp.Regexp, compressType CompressionType, opts Options) ([]byte, error) {
+	var data []byte
+	err := decompress(uri, pattern, compressType, opts, func(name string, member []byte) { data = append(data, member...) })
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Like RemoteDecompressWith, but returns the contents of each matching member by name, e.g., to read several kinds of metadata files in a
+// single download
+func RemoteDecompressMembers(uri string, pattern *regexp.Regexp, compressType CompressionType, opts Options) (map[string][]byte, error) {
+	members := make(map[string][]byte)
+	err := decompress(uri, pattern, compressType, opts, func(name string, member []byte) { members[name] = member })
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// Passes the name and contents of each member of an archive matching pattern to add, in archive order
+func decompress(uri string, pattern *regexp.Regexp, compressType CompressionType, opts Options, add func(name string, member []byte)) error {
 	switch compressType {
 	case Zip:
-		return remoteUnzip(uri, pattern)
+		return remoteUnzip(uri, pattern, opts.Client, add)
 	case Tar:
-		return remoteUntar(uri, pattern)
+		return remoteUntar(uri, pattern, true, opts, add)
+	case PlainTar:
+		return remoteUntar(uri, pattern, false, opts, add)
+	}
+	return fmt.Errorf("Unrecognized compression type: %s", compressType)
+}
+
+// Returns the path of the local file named by uri, a file:// URI or a path without scheme, and whether uri names a local file
+func localPath(uri string) (string, bool) {
+	if strings.HasPrefix(uri, "file://") {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return "", false
+		}
+		return filepath.FromSlash(u.Path), true
 	}
-	return nil, fmt.Errorf("Unrecognized compression type: %s", compressType)
+	return uri, !strings.Contains(uri, "://")
 }
 
-func remoteUntar(uri string, pattern *regexp.Regexp) ([]byte, error) {
-	resp, err := http.Get(uri)
+// Opens a remote or local file for reading
+func open(uri string, client *http.Client) (io.ReadCloser, error) {
+	if path, ok := localPath(uri); ok {
+		return os.Open(path)
+	}
+	resp, err := clientOr(client).Get(uri)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return resp.Body, nil
+}
 
-	var decompressed io.Reader
-	if filepath.Ext(uri) == ".bz2" {
-		decompressed = bzip2.NewReader(resp.Body)
-	} else {
-		decompressed, err = gzip.NewReader(resp.Body)
+// Returns client, or http.DefaultClient if client is nil
+func clientOr(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
+
+func remoteUntar(uri string, pattern *regexp.Regexp, compressed bool, opts Options, add func(name string, member []byte)) error {
+	body, err := open(uri, opts.Client)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var decompressed io.Reader = body
+	if compressed && filepath.Ext(uri) == ".bz2" {
+		decompressed = bzip2.NewReader(body)
+	} else if compressed {
+		decompressed, err = gzip.NewReader(body)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	tr := tar.NewReader(decompressed)
-	var data []byte
 	matched := false
+	matchDir := ""
 	for {
 		hdr, err := tr.Next()
 		if err == io.EOF {
 			break
 		} else if hdr == nil {
-			return nil, fmt.Errorf("Error untarring %s: nil header (may be malformed)", uri)
+			return fmt.Errorf("Error untarring %s: nil header (may be malformed)", uri)
 		}
 
+		if matched && opts.StopAfterMatchDir && path.Dir(hdr.Name) != matchDir {
+			break // closing the body stops the download
+		}
 		if pattern.MatchString(hdr.Name) {
 			buf := bytes.NewBuffer(make([]byte, 0, hdr.Size))
 			io.Copy(buf, tr)
-			data = append(data, buf.Bytes()...)
-			matched = true
+			add(hdr.Name, buf.Bytes())
+			if !matched {
+				matched, matchDir = true, path.Dir(hdr.Name)
+			}
+			if opts.StopAfterFirstMatch {
+				break
+			}
 		}
 	}
 	if !matched {
-		return nil, fmt.Errorf("No file matched pattern %+v", pattern)
+		return fmt.Errorf("%w %+v", ErrNoMatch, pattern)
 	}
-
-	return data, nil
+	return nil
 }
 
-func remoteUnzip(uri string, pattern *regexp.Regexp) ([]byte, error) {
-	resp, err := http.Get(uri)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	zipdata, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	zr, err := zip.NewReader(bytes.NewReader(zipdata), resp.ContentLength)
-	if err != nil {
-		return nil, err
+// Reads the members of a remote zip archive matching pattern. Only the central directory at the end of the archive and the matching members are
+// fetched, with HTTP range requests, so reading the metadata of a large wheel transfers kilobytes rather than the whole file. Local archives are read
+// in place.
+func remoteUnzip(uri string, pattern *regexp.Regexp, client *http.Client, add func(name string, member []byte)) error {
+	var zr *zip.Reader
+	if path, ok := localPath(uri); ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if zr, err = zip.NewReader(f, info.Size()); err != nil {
+			return err
+		}
+	} else {
+		ra, err := openRangeReader(uri, zipTailSize, client)
+		if err != nil {
+			return err
+		}
+		if zr, err = zip.NewReader(ra, ra.size); err != nil {
+			return err
+		}
 	}
 
-	var data []byte
 	matched := false
 	for _, file := range zr.File {
 		if file == nil {
-			return nil, fmt.Errorf("Error unzipping %s: nil file (may be malformed)", uri)
+			return fmt.Errorf("Error unzipping %s: nil file (may be malformed)", uri)
 		}
 
 		if pattern.MatchString(file.Name) {
 			fr, err := file.Open()
 			if err != nil {
-				return nil, err
+				return err
 			}
 			defer fr.Close()
 			filedata, err := ioutil.ReadAll(fr)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			data = append(data, filedata...)
+			add(file.Name, filedata)
 			matched = true
 		}
 	}
 	if !matched {
-		return nil, fmt.Errorf("No file matched pattern %+v", pattern)
+		return fmt.Errorf("%w %+v", ErrNoMatch, pattern)
 	}
-
-	return data, nil
+	return nil
 }
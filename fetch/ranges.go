@@ -0,0 +1,133 @@
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// Bytes read from the end of a remote zip archive by the first request, enough for the central directory of most archives
+const zipTailSize = 64 << 10
+
+// Minimum number of bytes fetched by further range requests, so the local header and data of a member are usually fetched at once. The minimum
+// doubles with each request, up to maxRangeRead, so reading large central directories doesn't take many round trips.
+const (
+	minRangeRead = 32 << 10
+	maxRangeRead = 1 << 20
+)
+
+// An io.ReaderAt over a remote file, fetching the parts that are read with HTTP range requests and caching them. If the server doesn't support
+// range requests, the whole file is downloaded by the first request instead.
+type rangeReader struct {
+	uri      string
+	client   *http.Client
+	size     int64
+	chunks   []rangeChunk
+	readSize int64 // minimum size of the next range request
+}
+
+type rangeChunk struct {
+	off  int64
+	data []byte
+}
+
+var contentRangeRegexp = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+// Opens a remote file, fetching its last tail bytes (or the whole file, if the server doesn't support range requests)
+func openRangeReader(uri string, tail int64, client *http.Client) (*rangeReader, error) {
+	r := &rangeReader{uri: uri, client: clientOr(client), size: -1, readSize: minRangeRead}
+	if err := r.fetch(fmt.Sprintf("bytes=-%d", tail)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Fetches a range of the file and caches it
+func (r *rangeReader) fetch(byteRange string) error {
+	req, err := http.NewRequest("GET", r.uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", byteRange)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		match := contentRangeRegexp.FindStringSubmatch(resp.Header.Get("Content-Range"))
+		if match == nil {
+			return fmt.Errorf("Unexpected Content-Range %q from %s", resp.Header.Get("Content-Range"), r.uri)
+		}
+		off, _ := strconv.ParseInt(match[1], 10, 64)
+		r.size, _ = strconv.ParseInt(match[3], 10, 64)
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		r.chunks = append(r.chunks, rangeChunk{off: off, data: data})
+	case http.StatusOK: // ranges unsupported
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		r.size = int64(len(data))
+		r.chunks = []rangeChunk{{off: 0, data: data}}
+	default:
+		return fmt.Errorf("%s returned %s for range %s", r.uri, resp.Status, byteRange)
+	}
+	return nil
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	want := int64(len(p))
+	if off+want > r.size {
+		want = r.size - off
+	}
+	if n, ok := r.cached(p[:want], off); ok {
+		return r.eof(n, len(p))
+	}
+
+	end := off + want
+	if want < r.readSize {
+		end = off + r.readSize
+	}
+	if end > r.size {
+		end = r.size
+	}
+	if r.readSize < maxRangeRead {
+		r.readSize *= 2
+	}
+	if err := r.fetch(fmt.Sprintf("bytes=%d-%d", off, end-1)); err != nil {
+		return 0, err
+	}
+	if n, ok := r.cached(p[:want], off); ok {
+		return r.eof(n, len(p))
+	}
+	return 0, fmt.Errorf("Range %d-%d of %s wasn't returned", off, end-1, r.uri)
+}
+
+// Copies the bytes at off into p from a cached chunk containing all of them, if any
+func (r *rangeReader) cached(p []byte, off int64) (int, bool) {
+	for _, chunk := range r.chunks {
+		if off >= chunk.off && off+int64(len(p)) <= chunk.off+int64(len(chunk.data)) {
+			return copy(p, chunk.data[off-chunk.off:]), true
+		}
+	}
+	return 0, false
+}
+
+func (r *rangeReader) eof(n, want int) (int, error) {
+	if n < want {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,181 @@
+package cheerio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Environment variable naming a fixtures file that the cheerio command records its HTTP interactions to, or replays them from if it exists
+// (see OpenFixtures)
+const FixturesEnv = "CHEERIO_FIXTURES"
+
+// Whether Fixtures record or replay interactions
+type FixtureMode string
+
+const (
+	FixtureRecord FixtureMode = "record" // send requests and append the interactions to the file
+	FixtureReplay FixtureMode = "replay" // answer requests from the file, without network access
+)
+
+// HTTP interactions with indexes, e.g., with the simple index, the JSON API and artifact downloads, recorded once and replayed later, e.g., in
+// tests and demos without network access. Install them with Transport, e.g., http.DefaultClient.Transport = fixtures.Transport(nil), which
+// covers all indexes but those with a Proxy or TLS settings, as they have transports of their own. Requests are matched by method, URL, Range
+// header and body; a request recorded several times is answered with its recorded responses in order, the last one repeatedly. The file holds
+// one JSON interaction per line. Fixtures are safe for concurrent use.
+type Fixtures struct {
+	Mode FixtureMode
+
+	mu           sync.Mutex
+	file         *os.File // appended to when recording
+	interactions map[string][]*fixtureInteraction
+	replayed     map[string]int
+}
+
+type fixtureInteraction struct {
+	Method string
+	URL    string
+	Range  string `json:",omitempty"`
+	Body   string `json:",omitempty"` // SHA-256 of the request body
+	Status int
+	Header http.Header
+	Data   []byte // the response body
+}
+
+func (i *fixtureInteraction) key() string {
+	return i.Method + " " + i.URL + " " + i.Range + " " + i.Body
+}
+
+// Opens the fixtures in file for mode, or, if mode is empty, for replay if file exists and for recording otherwise
+func OpenFixtures(file string, mode FixtureMode) (*Fixtures, error) {
+	if mode == "" {
+		mode = FixtureRecord
+		if _, err := os.Stat(file); err == nil {
+			mode = FixtureReplay
+		}
+	}
+	f := &Fixtures{Mode: mode, interactions: make(map[string][]*fixtureInteraction), replayed: make(map[string]int)}
+	switch mode {
+	case FixtureRecord:
+		var err error
+		if f.file, err = os.Create(file); err != nil {
+			return nil, err
+		}
+	case FixtureReplay:
+		in, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer in.Close()
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(nil, 1<<30)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			var interaction fixtureInteraction
+			if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", file, lineNo, err)
+			}
+			f.interactions[interaction.key()] = append(f.interactions[interaction.key()], &interaction)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unknown fixture mode '%s' (expected record or replay)", mode)
+	}
+	return f, nil
+}
+
+// Returns a transport that records the interactions of requests made through base (http.DefaultTransport if nil), or replays them
+func (f *Fixtures) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &fixturesTransport{base: base, fixtures: f}
+}
+
+// Closes the file being recorded to
+func (f *Fixtures) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
+
+type fixturesTransport struct {
+	base     http.RoundTripper
+	fixtures *Fixtures
+}
+
+func (t *fixturesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := &fixtureInteraction{Method: req.Method, URL: req.URL.String(), Range: req.Header.Get("Range")}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(body)
+		interaction.Body = hex.EncodeToString(sum[:])
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	f := t.fixtures
+	if f.Mode == FixtureReplay {
+		f.mu.Lock()
+		recorded := f.interactions[interaction.key()]
+		n := f.replayed[interaction.key()]
+		f.replayed[interaction.key()]++
+		f.mu.Unlock()
+		if len(recorded) == 0 {
+			return nil, fmt.Errorf("[fixture] no recorded response for %s %s", req.Method, req.URL)
+		}
+		if n >= len(recorded) {
+			n = len(recorded) - 1
+		}
+		return recorded[n].response(req), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if interaction.Data, err = ioutil.ReadAll(resp.Body); err != nil {
+		return nil, err
+	}
+	interaction.Status, interaction.Header = resp.StatusCode, resp.Header
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	_, err = f.file.Write(append(line, '\n'))
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return interaction.response(req), nil
+}
+
+// Returns the recorded response as the response to req
+func (i *fixtureInteraction) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(i.Data)),
+		ContentLength: int64(len(i.Data)),
+		Request:       req,
+	}
+}
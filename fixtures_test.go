@@ -0,0 +1,69 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFixtures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/foo/":
+			fmt.Fprint(w, `<a href="/files/foo-1.0.tar.gz#sha256=abcd">foo-1.0.tar.gz</a> <a href="/files/foo-2.0.tar.gz">foo-2.0.tar.gz</a>`)
+		case "/pypi/foo/json":
+			fmt.Fprint(w, `{"info": {"requires_dist": ["bar (>=1.0)"]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	file := filepath.Join(t.TempDir(), "fixtures.ndjson")
+
+	// record against the server, then replay with it gone
+	crawl := func(mode FixtureMode) ([]*Release, []*Requirement, error) {
+		fixtures, err := OpenFixtures(file, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fixtures.Close()
+		client := *http.DefaultClient
+		client.Transport = fixtures.Transport(nil)
+		old := http.DefaultClient
+		http.DefaultClient = &client
+		defer func() { http.DefaultClient = old }()
+
+		index := &PackageIndex{URI: server.URL, Sources: []RequirementsSource{SourceJSON}, Logger: NopLogger{}}
+		releases, err := index.Versions("foo")
+		if err != nil {
+			return nil, nil, err
+		}
+		reqs, err := index.FetchPackageRequirements("foo")
+		if err != nil {
+			return nil, nil, err
+		}
+		_, err = index.FetchPackageRequirements("missing")
+		return releases, reqs, err
+	}
+	recordedReleases, recordedReqs, recordedErr := crawl("")
+	server.Close()
+	releases, reqs, err := crawl("")
+	if !reflect.DeepEqual(releases, recordedReleases) || !reflect.DeepEqual(reqs, recordedReqs) || fmt.Sprint(err) != fmt.Sprint(recordedErr) {
+		t.Errorf("expected the replay to match the recording, got %v, %v, %v", releases, reqs, err)
+	}
+	if len(reqs) != 1 || reqs[0].Name != "bar" {
+		t.Errorf("unexpected requirements %+v", reqs)
+	}
+
+	fixtures, err := OpenFixtures(file, FixtureReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: fixtures.Transport(nil)}
+	if _, err := client.Get(server.URL + "/unrecorded"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("expected unrecorded requests to fail, got %v", err)
+	}
+}
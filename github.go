@@ -0,0 +1,120 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Environment variable holding a GitHub API token, used to raise the API rate limit
+const GitHubTokenEnv = "GITHUB_TOKEN"
+
+var DefaultGitHub = &GitHubClient{URI: "https://api.github.com", Token: os.Getenv(GitHubTokenEnv)}
+
+// A client for the parts of the GitHub REST API used to enrich resolved repositories
+type GitHubClient struct {
+	URI   string
+	Token string
+}
+
+// Repository details reported by GitHub
+type GitHubRepo struct {
+	URL           string // canonical URL after following renames and transfers
+	Stars         int
+	Forks         int
+	Archived      bool
+	Fork          bool
+	DefaultBranch string
+	PushedAt      string
+	Description   string
+}
+
+// A resolved repository together with the details GitHub reports for it. GitHub is nil if the repository is not hosted on GitHub.
+type EnrichedRepo struct {
+	*RepoResult
+	GitHub *GitHubRepo `json:",omitempty"`
+}
+
+var githubRepoRegexp = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)$`)
+
+// Looks up a repository on GitHub. repoURL must be a canonical repository URL (see CanonicalRepoURL). Renamed or transferred repositories are
+// followed to their current location.
+func (c *GitHubClient) Repo(repoURL string) (*GitHubRepo, error) {
+	match := githubRepoRegexp.FindStringSubmatch(repoURL)
+	if match == nil {
+		return nil, fmt.Errorf("Not a GitHub repository URL: %s", repoURL)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/%s", c.URI, match[1], match[2]), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	// The API answers requests for renamed repositories with a redirect, which the default client follows
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("[not-found] GitHub API returned %s for %s", resp.Status, repoURL)
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned %s for %s", resp.Status, repoURL)
+	}
+
+	var apiRepo struct {
+		HTMLURL       string `json:"html_url"`
+		Stars         int    `json:"stargazers_count"`
+		Forks         int    `json:"forks_count"`
+		Archived      bool   `json:"archived"`
+		Fork          bool   `json:"fork"`
+		DefaultBranch string `json:"default_branch"`
+		PushedAt      string `json:"pushed_at"`
+		Description   string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiRepo); err != nil {
+		return nil, err
+	}
+	return &GitHubRepo{
+		URL:           apiRepo.HTMLURL,
+		Stars:         apiRepo.Stars,
+		Forks:         apiRepo.Forks,
+		Archived:      apiRepo.Archived,
+		Fork:          apiRepo.Fork,
+		DefaultBranch: apiRepo.DefaultBranch,
+		PushedAt:      apiRepo.PushedAt,
+		Description:   apiRepo.Description,
+	}, nil
+}
+
+// Attaches GitHub details to a resolved repository. If the repository is on GitHub and GitHub knows it, the result is marked verified and its URL is
+// updated to follow any rename; if GitHub reports that it doesn't exist, it is marked unreachable. Repositories hosted elsewhere are returned
+// unchanged.
+func (c *GitHubClient) Enrich(result *RepoResult) (*EnrichedRepo, error) {
+	enriched := &EnrichedRepo{RepoResult: result}
+	if !githubRepoRegexp.MatchString(result.URL) {
+		return enriched, nil
+	}
+
+	ghRepo, err := c.Repo(result.URL)
+	if err != nil {
+		if strings.Contains(err.Error(), "[not-found]") {
+			result.Confidence = RepoUnreachable
+			return enriched, nil
+		}
+		return nil, err
+	}
+	if canonical, err := CanonicalRepoURL(ghRepo.URL); err == nil {
+		result.URL = canonical
+	}
+	result.Confidence = RepoVerified
+	enriched.GitHub = ghRepo
+	return enriched, nil
+}
@@ -0,0 +1,47 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitHubEnrich(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/mitsuhiko/flask":
+			http.Redirect(w, r, "/repositories/596892", http.StatusMovedPermanently)
+		case "/repositories/596892":
+			fmt.Fprint(w, `{"html_url": "https://github.com/pallets/flask", "stargazers_count": 60000, "archived": false, "default_branch": "main"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &GitHubClient{URI: server.URL}
+
+	enriched, err := client.Enrich(&RepoResult{Pkg: "flask", URL: "https://github.com/mitsuhiko/flask", Confidence: RepoUnverified})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enriched.URL != "https://github.com/pallets/flask" || enriched.Confidence != RepoVerified {
+		t.Errorf("Enrich: expected verified repo at new location, got %+v", enriched.RepoResult)
+	}
+	if enriched.GitHub == nil || enriched.GitHub.Stars != 60000 || enriched.GitHub.DefaultBranch != "main" {
+		t.Errorf("Enrich: expected GitHub details, got %+v", enriched.GitHub)
+	}
+
+	enriched, err = client.Enrich(&RepoResult{Pkg: "gone", URL: "https://github.com/nobody/gone", Confidence: RepoUnverified})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enriched.Confidence != RepoUnreachable || enriched.GitHub != nil {
+		t.Errorf("Enrich: expected missing repo to be unreachable, got %+v", enriched)
+	}
+
+	enriched, err = client.Enrich(&RepoResult{Pkg: "coverage", URL: "https://bitbucket.org/ned/coveragepy", Confidence: RepoUnverified})
+	if err != nil || enriched.Confidence != RepoUnverified {
+		t.Errorf("Enrich: expected non-GitHub repo to be unchanged, got %+v, %v", enriched, err)
+	}
+}
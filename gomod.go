@@ -0,0 +1,206 @@
+package cheerio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var DefaultGoProxy = &GoModuleProxy{URI: "https://proxy.golang.org", IndexURI: "https://index.golang.org", PageSize: 2000}
+
+// The Go module mirror (https://proxy.golang.org) and its index. Packages are module paths, e.g., "github.com/pkg/errors", and requirements are
+// read from go.mod files.
+type GoModuleProxy struct {
+	URI      string
+	IndexURI string
+	PageSize int // number of index entries fetched per request
+}
+
+// Returns the paths of all modules known to the index. This pages through the entire index, which takes a long time.
+func (g *GoModuleProxy) AllPackages() ([]string, error) {
+	pkgs := make([]string, 0)
+	seen := make(map[string]bool)
+	since := ""
+	for {
+		resp, err := http.Get(fmt.Sprintf("%s/index?limit=%d&since=%s", g.IndexURI, g.PageSize, since))
+		if err != nil {
+			return nil, err
+		}
+		n, last := 0, ""
+		dec := json.NewDecoder(resp.Body)
+		for dec.More() {
+			var entry struct {
+				Path      string
+				Timestamp string
+			}
+			if err := dec.Decode(&entry); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			n, last = n+1, entry.Timestamp
+			if !seen[entry.Path] {
+				seen[entry.Path] = true
+				pkgs = append(pkgs, entry.Path)
+			}
+		}
+		resp.Body.Close()
+		if n < g.PageSize || last == since {
+			return pkgs, nil
+		}
+		since = last
+	}
+}
+
+// Returns the requirements in the go.mod file of the latest version of a module, with replace directives applied. Requirements marked
+// "// indirect" have Extra "indirect".
+func (g *GoModuleProxy) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	var latest struct {
+		Version string
+	}
+	body, err := g.get(pkg, "@latest")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &latest); err != nil {
+		return nil, err
+	}
+	return g.FetchPackageRequirementsAt(pkg, latest.Version)
+}
+
+// Returns the requirements in the go.mod file of a version of a module (see FetchPackageRequirements)
+func (g *GoModuleProxy) FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error) {
+	gomod, err := g.get(pkg, "@v/"+escapeModulePath(version)+".mod")
+	if err != nil {
+		return nil, err
+	}
+	return ParseGoMod(string(gomod)), nil
+}
+
+var goImportMetaRegexp = regexp.MustCompile(`<meta\s+name="go-import"\s+content="([^"]+)"`)
+
+// Returns the source repository URL of a module, from its path if it is hosted on a known code host, or else from the go-import meta tag served at
+// the path (https://go.dev/ref/mod#vcs-find)
+func (g *GoModuleProxy) FetchSourceRepoURL(pkg string) (string, error) {
+	if repoURL, _ := matchRepoURL([]repoCandidate{{"https://" + pkg, RepoFromProjectURL}}); repoURL != "" {
+		return repoURL, nil
+	}
+
+	resp, err := http.Get("https://" + pkg + "?go-get=1")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	page, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	for _, match := range goImportMetaRegexp.FindAllStringSubmatch(string(page), -1) {
+		// content is "<import-prefix> <vcs> <repo-root>"
+		if fields := strings.Fields(match[1]); len(fields) == 3 && strings.HasPrefix(pkg, fields[0]) && fields[1] != "mod" {
+			return CanonicalRepoURL(fields[2])
+		}
+	}
+	return "", fmt.Errorf("No go-import meta tag found for module %s", pkg)
+}
+
+// Fetches a file of a module from the proxy, e.g., "@latest" or "@v/v1.0.0.mod"
+func (g *GoModuleProxy) get(pkg, file string) ([]byte, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/%s/%s", g.URI, escapeModulePath(pkg), file))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("Go module proxy returned %s for module %s: %s", resp.Status, pkg, strings.TrimSpace(string(body))))
+	}
+	return body, nil
+}
+
+// Escapes a module path or version for the proxy protocol, which replaces each upper-case letter with "!" followed by the lower-case letter
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Parses the requirements of a go.mod file. Replace directives that point to other modules rename the requirements they apply to; replacements
+// with local directories are ignored.
+func ParseGoMod(gomod string) []*Requirement {
+	reqs := make([]*Requirement, 0)
+	replace := make(map[string]string) // "path" or "path@version" -> replacement path
+
+	block := "" // directive of the enclosing "directive (" block, if any
+	scanner := bufio.NewScanner(strings.NewReader(gomod))
+	for scanner.Scan() {
+		line, comment := scanner.Text(), ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			line, comment = line[:i], strings.TrimSpace(line[i+2:])
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		directive := block
+		if block == "" {
+			directive, fields = fields[0], fields[1:]
+			if len(fields) == 1 && fields[0] == "(" {
+				block = directive
+				continue
+			}
+		} else if fields[0] == ")" {
+			block = ""
+			continue
+		}
+
+		switch directive {
+		case "require":
+			if len(fields) >= 2 {
+				req := &Requirement{Name: strings.Trim(fields[0], `"`), Range: fields[1]}
+				if comment == "indirect" || strings.HasPrefix(comment, "indirect;") {
+					req.Extra = "indirect"
+				}
+				reqs = append(reqs, req)
+			}
+		case "replace":
+			// "old [version] => new [version]"
+			arrow := -1
+			for i, field := range fields {
+				if field == "=>" {
+					arrow = i
+				}
+			}
+			if arrow < 1 || arrow+1 >= len(fields) || strings.HasPrefix(fields[arrow+1], ".") || strings.HasPrefix(fields[arrow+1], "/") {
+				continue
+			}
+			old := fields[0]
+			if arrow == 2 {
+				old += "@" + fields[1]
+			}
+			replace[old] = fields[arrow+1]
+		}
+	}
+
+	for _, req := range reqs {
+		if path, in := replace[req.Name+"@"+req.Range]; in {
+			req.Name = path
+		} else if path, in := replace[req.Name]; in {
+			req.Name = path
+		}
+	}
+	return reqs
+}
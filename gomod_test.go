@@ -0,0 +1,80 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const testGoMod = `module github.com/example/app
+
+go 1.21
+
+require github.com/pkg/errors v0.9.1
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	golang.org/x/sys v0.15.0 // indirect
+	example.com/old v1.0.0
+	example.com/local v0.1.0
+)
+
+replace example.com/old v1.0.0 => example.com/new v1.2.0
+
+replace (
+	example.com/local => ../local
+)
+`
+
+func TestParseGoMod(t *testing.T) {
+	var got []string
+	for _, req := range ParseGoMod(testGoMod) {
+		got = append(got, fmt.Sprintf("%s(%s):%s", req.Name, req.Specifier(), req.Extra))
+	}
+	exp := []string{
+		"github.com/pkg/errors(v0.9.1):",
+		"github.com/BurntSushi/toml(v1.3.2):",
+		"golang.org/x/sys(v0.15.0):indirect",
+		"example.com/new(v1.0.0):",
+		"example.com/local(v0.1.0):",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("ParseGoMod: expected %v, got %v", exp, got)
+	}
+}
+
+func TestGoModuleProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index":
+			if r.URL.Query().Get("since") == "" {
+				fmt.Fprint(w, `{"Path":"github.com/example/app","Version":"v1.0.0","Timestamp":"2023-01-01T00:00:00Z"}
+{"Path":"github.com/pkg/errors","Version":"v0.9.1","Timestamp":"2023-01-02T00:00:00Z"}
+`)
+			} else {
+				fmt.Fprint(w, `{"Path":"github.com/example/app","Version":"v1.1.0","Timestamp":"2023-01-03T00:00:00Z"}
+`)
+			}
+		case "/github.com/example/!app/@latest":
+			fmt.Fprint(w, `{"Version":"v1.1.0","Time":"2023-01-03T00:00:00Z"}`)
+		case "/github.com/example/!app/@v/v1.1.0.mod":
+			fmt.Fprint(w, testGoMod)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var idx Index = &GoModuleProxy{URI: server.URL, IndexURI: server.URL, PageSize: 2}
+	if pkgs, err := idx.AllPackages(); err != nil || !reflect.DeepEqual(pkgs, []string{"github.com/example/app", "github.com/pkg/errors"}) {
+		t.Errorf("AllPackages: got %v, %v", pkgs, err)
+	}
+	if reqs, err := idx.FetchPackageRequirements("github.com/example/App"); err != nil || len(reqs) != 5 {
+		t.Errorf("FetchPackageRequirements: got %v, %v", reqs, err)
+	}
+	if repoURL, err := idx.FetchSourceRepoURL("github.com/example/app/v2/sub"); err != nil || repoURL != "https://github.com/example/app" {
+		t.Errorf("FetchSourceRepoURL: got %s, %v", repoURL, err)
+	}
+}
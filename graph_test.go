@@ -0,0 +1,395 @@
+package cheerio
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Builds a PyPIGraph from "pkg:dep" and "pkg:dep:extra" edges
+func testGraph(edges ...string) *PyPIGraph {
+	graph := newPyPIGraph()
+	for _, edge := range edges {
+		split := strings.SplitN(edge, ":", 3)
+		if len(split) == 3 {
+			graph.addExtraEdge(split[0], split[1], split[2])
+		} else {
+			graph.addEdge(split[0], split[1])
+		}
+	}
+	return graph
+}
+
+func TestPath(t *testing.T) {
+	g := testGraph("app:flask", "app:celery", "flask:werkzeug", "flask:jinja2", "jinja2:markupsafe", "celery:kombu", "kombu:markupsafe")
+
+	if path, exp := g.Path("app", "markupsafe"), []string{"app", "flask", "jinja2", "markupsafe"}; !reflect.DeepEqual(path, exp) {
+		t.Errorf("Path: expected %v, got %v", exp, path)
+	}
+	if path := g.Path("flask", "kombu"); path != nil {
+		t.Errorf("Path: expected no path, got %v", path)
+	}
+
+	exp := [][]string{
+		{"app", "flask", "jinja2", "markupsafe"},
+		{"app", "celery", "kombu", "markupsafe"},
+	}
+	if paths := g.Paths("app", "markupsafe", 0); !reflect.DeepEqual(paths, exp) {
+		t.Errorf("Paths: expected %v, got %v", exp, paths)
+	}
+	if paths := g.Paths("app", "markupsafe", 1); len(paths) != 1 {
+		t.Errorf("Paths: expected 1 path, got %v", paths)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	g := testGraph("a:b", "b:c", "c:a", "c:d", "d:e", "e:d", "f:a", "g:g")
+
+	exp := [][]string{{"a", "b", "c"}, {"d", "e"}}
+	if cycles := g.Cycles(); !reflect.DeepEqual(cycles, exp) {
+		t.Errorf("Cycles: expected %v, got %v", exp, cycles)
+	}
+}
+
+func TestTopoSort(t *testing.T) {
+	g := testGraph("app:flask", "flask:werkzeug", "flask:jinja2", "jinja2:markupsafe", "a:b", "b:a", "app:a", "other:flask")
+
+	order, cycles := g.TopoSort("app")
+	if exp := [][]string{{"a", "b"}}; !reflect.DeepEqual(cycles, exp) {
+		t.Errorf("TopoSort: expected cycles %v, got %v", exp, cycles)
+	}
+	if len(order) != 7 {
+		t.Errorf("TopoSort: expected 7 packages in closure of app, got %v", order)
+	}
+	pos := make(map[string]int)
+	for i, pkg := range order {
+		pos[pkg] = i
+	}
+	for pkg := range pos {
+		for _, dep := range g.Req[pkg] {
+			if pos[dep] > pos[pkg] && g.Path(dep, pkg) == nil { // only packages in a cycle may precede their dependencies
+				t.Errorf("TopoSort: %s ordered before its dependency %s in %v", pkg, dep, order)
+			}
+		}
+	}
+	if _, in := pos["other"]; in {
+		t.Errorf("TopoSort: package outside closure in %v", order)
+	}
+}
+
+func TestImpact(t *testing.T) {
+	g := testGraph("app:flask", "app:jinja2", "flask:jinja2", "jinja2:markupsafe", "sphinx:jinja2", "docs:sphinx")
+
+	exp := [][]string{{"jinja2"}, {"app", "flask", "sphinx"}, {"docs"}}
+	if levels := g.Impact("MarkupSafe"); !reflect.DeepEqual(levels, exp) {
+		t.Errorf("Impact: expected %v, got %v", exp, levels)
+	}
+}
+
+func TestCommon(t *testing.T) {
+	g := testGraph("flask:werkzeug", "flask:jinja2", "jinja2:markupsafe", "django:asgiref", "django:markupsafe", "app:flask", "app:django",
+		"docs:flask", "docs:django", "cli:flask")
+
+	if common, exp := g.CommonRequires("Flask", "django"), []string{"markupsafe"}; !reflect.DeepEqual(common, exp) {
+		t.Errorf("CommonRequires: expected %v, got %v", exp, common)
+	}
+	if common, exp := g.CommonRequires("app", "docs", "flask"), []string{"jinja2", "markupsafe", "werkzeug"}; !reflect.DeepEqual(common, exp) {
+		t.Errorf("CommonRequires: expected %v, got %v", exp, common)
+	}
+	if common, exp := g.CommonRequiredBy("flask", "django"), []string{"app", "docs"}; !reflect.DeepEqual(common, exp) {
+		t.Errorf("CommonRequiredBy: expected %v, got %v", exp, common)
+	}
+	if common := g.CommonRequires("werkzeug", "asgiref"); len(common) != 0 {
+		t.Errorf("CommonRequires: expected nothing in common, got %v", common)
+	}
+}
+
+func TestRanking(t *testing.T) {
+	g := testGraph("a:lib", "b:lib", "c:lib", "lib:core", "d:core", "e:other")
+
+	if top := g.MostRequired(1); len(top) != 1 || top[0].Pkg != "lib" || top[0].Score != 3 {
+		t.Errorf("MostRequired: expected lib with 3 dependents, got %+v", top)
+	}
+
+	ranks := g.PageRank(0.85, 1e-12, 100)
+	sum := 0.0
+	for _, rank := range ranks {
+		sum += rank
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("PageRank: expected ranks to sum to 1, got %f", sum)
+	}
+	if top := g.TopPageRank(2); len(top) != 2 || top[0].Pkg != "core" || top[1].Pkg != "lib" {
+		t.Errorf("TopPageRank: expected core then lib, got %+v", top)
+	}
+}
+
+func TestStats(t *testing.T) {
+	g := testGraph("app:flask", "flask:jinja2", "jinja2:markupsafe", "a:b", "b:a", "b:markupsafe")
+	g.Req["lonely"], g.ReqBy["lonely"] = []string{}, []string{}
+
+	stats := g.Stats()
+	if stats.Packages != 7 || stats.Edges != 6 {
+		t.Errorf("Stats: expected 7 packages and 6 edges, got %+v", stats)
+	}
+	if stats.MaxDepth != 3 {
+		t.Errorf("Stats: expected max depth 3, got %d", stats.MaxDepth)
+	}
+	if stats.SCCs != 6 || stats.Cycles != 1 {
+		t.Errorf("Stats: expected 6 SCCs and 1 cycle, got %d and %d", stats.SCCs, stats.Cycles)
+	}
+	if !reflect.DeepEqual(stats.Orphans, []string{"lonely"}) {
+		t.Errorf("Stats: expected orphan lonely, got %v", stats.Orphans)
+	}
+}
+
+func TestDiffGraphs(t *testing.T) {
+	a := testGraph("app:flask", "flask:jinja2", "old:flask")
+	b := testGraph("app:flask", "flask:jinja2", "flask:werkzeug", "new:app")
+
+	diff := DiffGraphs(a, b)
+	exp := &GraphDiff{
+		AddedPkgs:    []string{"new", "werkzeug"},
+		RemovedPkgs:  []string{"old"},
+		AddedEdges:   []Edge{{"flask", "werkzeug"}, {"new", "app"}},
+		RemovedEdges: []Edge{{"old", "flask"}},
+	}
+	if !reflect.DeepEqual(diff, exp) {
+		t.Errorf("DiffGraphs: expected %+v, got %+v", exp, diff)
+	}
+	if !DiffGraphs(b, b).Empty() {
+		t.Errorf("DiffGraphs: expected graph to equal itself")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := testGraph("app:Flask", "flask:jinja2", "flask:jinja2")
+	b := testGraph("Flask:Jinja2", "flask:werkzeug", "other:app")
+	b.addPkg("lonely")
+
+	merged := MergeGraphs(a, b)
+	exp := []Edge{{"app", "flask"}, {"flask", "jinja2"}, {"flask", "werkzeug"}, {"other", "app"}}
+	if edges := merged.Edges(); !reflect.DeepEqual(edges, exp) {
+		t.Errorf("Merge: expected edges %v, got %v", exp, edges)
+	}
+	if deps := merged.Requires("flask"); len(deps) != 2 {
+		t.Errorf("Merge: expected duplicate edges to be dropped, got %v", deps)
+	}
+	if _, in := merged.Req["lonely"]; !in {
+		t.Errorf("Merge: expected package without dependencies to be kept")
+	}
+
+	a.Merge(b)
+	if !DiffGraphs(a, merged).Empty() {
+		t.Errorf("Merge: expected %v, got %v", merged, a)
+	}
+
+	c := testGraph("app:pytest:test")
+	c.addPkg("Other_Pkg")
+	if found, _ := a.Search("other-pkg", 0); len(found) != 0 {
+		t.Fatalf("Search: expected no match before merging, got %v", found)
+	}
+	a.Merge(c)
+	if deps, exp := a.RequiresWithExtras("app"), []string{"flask"}; !reflect.DeepEqual(deps, exp) {
+		t.Errorf("Merge: expected the extra's edge to stay optional, got %v", deps)
+	}
+	if deps, exp := a.RequiresWithExtras("app", "test"), []string{"flask", "pytest"}; !reflect.DeepEqual(deps, exp) {
+		t.Errorf("Merge: expected the extra's edge, got %v", deps)
+	}
+	if name := a.DisplayName("other-pkg"); name != "Other_Pkg" {
+		t.Errorf("Merge: expected display name Other_Pkg, got %s", name)
+	}
+	if found, _ := a.Search("other-pkg", 0); !reflect.DeepEqual(found, []string{"other-pkg"}) {
+		t.Errorf("Merge: expected the search index to be reset, got %v", found)
+	}
+
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\napp:pytest:test\n") {
+		t.Errorf("Merge: expected the extra's edge to be written, got %q", buf.String())
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	g := testGraph("app:flask", "flask:werkzeug", "flask:jinja2", "flask:jinja2")
+	g.addPkg("flask")
+
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "app\napp:flask\nflask\nflask:jinja2\nflask:werkzeug\n"; buf.String() != exp {
+		t.Errorf("WriteTo: expected %q, got %q", exp, buf.String())
+	}
+}
+
+func TestSubgraphOf(t *testing.T) {
+	g := testGraph("app:flask", "flask:jinja2", "jinja2:markupsafe", "jinja2:app", "other:flask")
+
+	sub := g.SubgraphOf([]string{"App"}, 2)
+	exp := []Edge{{"app", "flask"}, {"flask", "jinja2"}, {"jinja2", "app"}}
+	if edges := sub.Edges(); !reflect.DeepEqual(edges, exp) {
+		t.Errorf("SubgraphOf: expected edges %v, got %v", exp, edges)
+	}
+
+	sub = g.SubgraphOf([]string{"app"}, 0)
+	exp = []Edge{{"app", "flask"}, {"flask", "jinja2"}, {"jinja2", "app"}, {"jinja2", "markupsafe"}}
+	if edges := sub.Edges(); !reflect.DeepEqual(edges, exp) {
+		t.Errorf("SubgraphOf: expected edges %v, got %v", exp, edges)
+	}
+}
+
+func TestRequiresWithExtras(t *testing.T) {
+	g := testGraph("flask:werkzeug", "flask:dotenv:dotenv", "flask:asgiref:async", "flask:werkzeug:async", "flask:pytest:test", "flask:asgiref")
+
+	if deps, exp := g.Requires("flask"), []string{"werkzeug", "dotenv", "asgiref", "pytest"}; !reflect.DeepEqual(deps, exp) {
+		t.Errorf("Requires: expected %v, got %v", exp, deps)
+	}
+	if deps, exp := g.RequiresWithExtras("flask"), []string{"werkzeug", "asgiref"}; !reflect.DeepEqual(deps, exp) {
+		t.Errorf("RequiresWithExtras: expected %v, got %v", exp, deps)
+	}
+	if deps, exp := g.RequiresWithExtras("Flask", "test", "dotenv"), []string{"werkzeug", "asgiref", "pytest", "dotenv"}; !reflect.DeepEqual(deps, exp) {
+		t.Errorf("RequiresWithExtras: expected %v, got %v", exp, deps)
+	}
+	if extras, exp := g.ExtrasOf("flask"), []string{"async", "dotenv", "test"}; !reflect.DeepEqual(extras, exp) {
+		t.Errorf("ExtrasOf: expected %v, got %v", exp, extras)
+	}
+
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	exp := "flask\nflask:asgiref\nflask:werkzeug\nflask:asgiref:async\nflask:werkzeug:async\nflask:dotenv:dotenv\nflask:pytest:test\n"
+	if buf.String() != exp {
+		t.Errorf("WriteTo: expected %q, got %q", exp, buf.String())
+	}
+
+	merged := MergeGraphs(g)
+	if deps, exp := merged.RequiresWithExtras("flask"), []string{"werkzeug", "asgiref"}; !reflect.DeepEqual(deps, exp) {
+		t.Errorf("MergeGraphs: expected base deps %v, got %v", exp, deps)
+	}
+}
+
+func TestNormalizedPkgName(t *testing.T) {
+	for name, exp := range map[string]string{
+		"Foo_Bar":                           "foo-bar",
+		"foo.bar":                           "foo-bar",
+		"foo--_.bar":                        "foo-bar",
+		"zope.interface":                    "zope-interface",
+		"requests":                          "requests",
+		"pkg:pypi/Zope.Interface@5.0.post1": "pkg:pypi/zope-interface@5.0.post1",
+		"pkg:npm/lodash.merge":              "pkg:npm/lodash.merge",
+	} {
+		if got := NormalizedPkgName(name); got != exp {
+			t.Errorf("NormalizedPkgName(%q): expected %q, got %q", name, exp, got)
+		}
+	}
+}
+
+func TestNormalizedEcosystemPkgName(t *testing.T) {
+	tests := []struct{ ecosystem, name, exp string }{
+		{"pypi", "Zope.Interface", "zope-interface"},
+		{"", "Foo_Bar", "foo-bar"},
+		{"npm", "socket.io", "socket.io"},
+		{"npm", "Socket-IO", "socket-io"},
+		{"go", "github.com/pkg/errors", "github.com/pkg/errors"},
+		{"maven", "org.apache.commons:commons-lang3", "org.apache.commons:commons-lang3"},
+		{"crates", "serde_json", "serde_json"},
+		{"npm", "pkg:pypi/Zope.Interface", "pkg:pypi/zope-interface"},
+	}
+	for _, test := range tests {
+		if got := NormalizedEcosystemPkgName(test.ecosystem, test.name); got != test.exp {
+			t.Errorf("NormalizedEcosystemPkgName(%q, %q): expected %q, got %q", test.ecosystem, test.name, test.exp, got)
+		}
+	}
+}
+
+func TestEcosystemGraph(t *testing.T) {
+	graph, err := ReadPyPIGraph(strings.NewReader("# cheerio-graph: 1\n# ecosystem: npm\napp:socket.io\nsocket.io:Debug\nsocket-io\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.Ecosystem() != "npm" {
+		t.Errorf("expected ecosystem npm, got %q", graph.Ecosystem())
+	}
+	if deps := graph.Requires("socket.io"); !reflect.DeepEqual(deps, []string{"debug"}) {
+		t.Errorf("expected socket.io to require debug, got %v", deps)
+	}
+	if deps, found := graph.Lookup("socket-io"); !found || len(deps) != 0 {
+		t.Errorf("expected socket-io to be distinct from socket.io, got %v (found: %v)", deps, found)
+	}
+
+	var buf bytes.Buffer
+	if err := graph.WriteMapped(&buf); err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := ReadMappedGraph(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deps := mapped.Requires("Socket.IO"); !reflect.DeepEqual(deps, []string{"debug"}) {
+		t.Errorf("expected mapped socket.io to require debug, got %v", deps)
+	}
+	if sub := graph.SubgraphOf([]string{"app"}, 0); sub.Ecosystem() != "npm" || sub.Contains("socket-io") {
+		t.Errorf("expected npm subgraph without socket-io, got %v", sub.Req)
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	g := testGraph("app:Zope.Interface", "zope_interface:setuptools", "Foo_Bar:zope.interface")
+
+	if deps := g.Requires("foo.bar"); !reflect.DeepEqual(deps, []string{"zope-interface"}) {
+		t.Errorf("expected foo.bar to require zope-interface, got %v", deps)
+	}
+	if deps := g.RequiredBy("ZOPE-INTERFACE"); len(deps) != 2 {
+		t.Errorf("expected zope-interface to have 2 dependents, got %v", deps)
+	}
+	if name := g.DisplayName("zope-interface"); name != "Zope.Interface" {
+		t.Errorf("expected display name Zope.Interface, got %s", name)
+	}
+
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	exp := "app\napp:Zope.Interface\nFoo_Bar\nFoo_Bar:Zope.Interface\nZope.Interface\nZope.Interface:setuptools\n"
+	if buf.String() != exp {
+		t.Errorf("WriteTo: expected %q, got %q", exp, buf.String())
+	}
+	loaded, err := ReadPyPIGraph(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !DiffGraphs(g, loaded).Empty() || loaded.DisplayName("foo-bar") != "Foo_Bar" {
+		t.Errorf("expected the graph to round-trip with its display names")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	g := testGraph("app:flask", "flask:werkzeug")
+	g.addPkg("standalone")
+
+	tests := []struct {
+		pkg      string
+		deps     []string
+		found    bool
+		contains bool
+	}{
+		{"App", []string{"flask"}, true, true},
+		{"standalone", []string{}, true, true},
+		{"werkzeug", nil, false, true},
+		{"missing", nil, false, false},
+	}
+	for _, test := range tests {
+		deps, found := g.Lookup(test.pkg)
+		if !reflect.DeepEqual(deps, test.deps) || found != test.found {
+			t.Errorf("Lookup(%s): expected %v, %v, got %v, %v", test.pkg, test.deps, test.found, deps, found)
+		}
+		if contains := g.Contains(test.pkg); contains != test.contains {
+			t.Errorf("Contains(%s): expected %v, got %v", test.pkg, test.contains, contains)
+		}
+	}
+}
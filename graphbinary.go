@@ -0,0 +1,195 @@
+package cheerio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Version of the binary graph format written by WriteBinary
+const BinaryGraphVersion = 1
+
+// Magic bytes that start binary graph files
+var binaryGraphMagic = []byte("CHGB")
+
+// Serializes the graph in a compact binary format that loads several times faster than the text format. After the magic bytes and the format
+// version, it holds the header fields (see GraphInfo), a table of every package and extra name, and, for each package, its unconditional
+// dependencies followed by its dependencies per extra, all as varint indexes into the name table. Names are thus stored, and allocated when
+// loading, only once. ReadPyPIGraph and NewPyPIGraph detect and read the format.
+func (p *PyPIGraph) WriteBinary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(binaryGraphMagic)
+	writeUvarint(bw, BinaryGraphVersion)
+
+	var header bytes.Buffer
+	if p.info != nil {
+		if err := WriteGraphHeader(&header, p.info); err != nil {
+			return err
+		}
+	}
+	writeString(bw, header.String())
+
+	// intern the names of packages, dependencies, and extras
+	pkgs := make([]string, 0, len(p.Req))
+	index := make(map[string]uint64)
+	for _, pkg := range p.sortedPkgs() {
+		if _, in := p.Req[pkg]; in {
+			pkgs = append(pkgs, pkg)
+		}
+		index[pkg] = 0
+		for extra := range p.Extras[pkg] {
+			index[extra] = 0
+		}
+	}
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeUvarint(bw, uint64(len(names)))
+	for i, name := range names {
+		index[name] = uint64(i)
+		writeString(bw, name)
+	}
+
+	writeUvarint(bw, uint64(len(pkgs)))
+	edges := p.Edges()
+	for _, pkg := range pkgs {
+		writeUvarint(bw, index[pkg])
+		var deps []string
+		for len(edges) > 0 && edges[0].Pkg < pkg {
+			edges = edges[1:]
+		}
+		for len(edges) > 0 && edges[0].Pkg == pkg {
+			if !p.optional[edges[0]] {
+				deps = append(deps, edges[0].Dep)
+			}
+			edges = edges[1:]
+		}
+		writeUvarint(bw, uint64(len(deps)))
+		for _, dep := range deps {
+			writeUvarint(bw, index[dep])
+		}
+
+		extras := p.Extras[pkg]
+		writeUvarint(bw, uint64(len(extras)))
+		for _, extra := range sortedExtras(extras) {
+			writeUvarint(bw, index[extra])
+			deps := append([]string(nil), extras[extra]...)
+			sort.Strings(deps)
+			writeUvarint(bw, uint64(len(deps)))
+			for _, dep := range deps {
+				writeUvarint(bw, index[dep])
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func writeUvarint(w *bufio.Writer, x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutUvarint(buf[:], x)])
+}
+
+func writeString(w *bufio.Writer, s string) {
+	writeUvarint(w, uint64(len(s)))
+	w.WriteString(s)
+}
+
+// Deserializes a graph in the binary format (see WriteBinary), starting with the magic bytes
+func readBinaryPyPIGraph(r *bufio.Reader) (*PyPIGraph, error) {
+	magic := make([]byte, len(binaryGraphMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, binaryGraphMagic) {
+		return nil, fmt.Errorf("Not a binary graph file")
+	}
+	br := &binaryReader{r: r}
+	if version := br.uvarint(); br.err == nil && version != BinaryGraphVersion {
+		return nil, fmt.Errorf("Unsupported binary graph version %d", version)
+	}
+
+	graph := newPyPIGraph()
+	if header := br.string(); header != "" {
+		graph.info = &GraphInfo{}
+		for _, line := range bytes.Split([]byte(header), []byte("\n")) {
+			parseGraphHeaderLine(graph.info, string(line))
+		}
+	}
+
+	var names []string
+	for n := br.count(); n > 0 && br.err == nil; n-- {
+		names = append(names, br.string())
+	}
+	name := func() string {
+		i := br.uvarint()
+		if i >= uint64(len(names)) {
+			if br.err == nil {
+				br.err = fmt.Errorf("Name index %d out of range in binary graph", i)
+			}
+			return ""
+		}
+		return names[i]
+	}
+
+	for pkgs := br.count(); pkgs > 0 && br.err == nil; pkgs-- {
+		pkg := name()
+		graph.addPkg(pkg)
+		for deps := br.count(); deps > 0 && br.err == nil; deps-- {
+			graph.addEdge(pkg, name())
+		}
+		for extras := br.count(); extras > 0 && br.err == nil; extras-- {
+			extra := name()
+			for deps := br.count(); deps > 0 && br.err == nil; deps-- {
+				graph.addExtraEdge(pkg, name(), extra)
+			}
+		}
+	}
+	if br.err != nil {
+		return nil, fmt.Errorf("Corrupt binary graph: %s", br.err)
+	}
+	return graph, nil
+}
+
+// Reads varints and strings, remembering the first error
+type binaryReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (b *binaryReader) uvarint() uint64 {
+	if b.err != nil {
+		return 0
+	}
+	x, err := binary.ReadUvarint(b.r)
+	b.err = err
+	return x
+}
+
+// Reads the number of items that follow. Counts aren't trusted to size allocations, as a corrupt or hostile file may claim any count: every
+// item takes at least a byte, so items are only allocated as they are read, until the input runs out.
+func (b *binaryReader) count() int {
+	n := b.uvarint()
+	if n > 1<<31 {
+		b.err = fmt.Errorf("Implausible count %d", n)
+		return 0
+	}
+	return int(n)
+}
+
+// Reads a length-prefixed string, in chunks rather than into a buffer of the claimed length, so that memory is bounded by the remaining input
+func (b *binaryReader) string() string {
+	n := b.count()
+	if b.err != nil {
+		return ""
+	}
+	var s strings.Builder
+	if _, err := io.CopyN(&s, b.r, int64(n)); err == io.EOF {
+		b.err = io.ErrUnexpectedEOF
+	} else {
+		b.err = err
+	}
+	return s.String()
+}
@@ -0,0 +1,70 @@
+package cheerio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestBinaryGraph(t *testing.T) {
+	graph := testGraph("app:flask", "app:celery", "app:redis:celery", "app:pytest:test", "flask:werkzeug", "celery:kombu", "leaf:leaf")
+	graph.addPkg("standalone")
+	graph.SetInfo(&GraphInfo{IndexURL: "https://pypi.python.org", Serial: 42})
+	var text bytes.Buffer
+	if _, err := graph.WriteTo(&text); err != nil {
+		t.Fatal(err)
+	}
+
+	var bin bytes.Buffer
+	if err := graph.WriteBinary(&bin); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadPyPIGraph(bytes.NewReader(bin.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotText bytes.Buffer
+	if _, err := got.WriteTo(&gotText); err != nil {
+		t.Fatal(err)
+	}
+	if gotText.String() != text.String() {
+		t.Errorf("expected graph\n%s\ngot\n%s", text.String(), gotText.String())
+	}
+	if info := got.Info(); info == nil || info.IndexURL != "https://pypi.python.org" || info.Serial != 42 {
+		t.Errorf("unexpected graph info %+v", info)
+	}
+
+	dir, err := ioutil.TempDir("", "cheerio-graphbinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graph.bin.gz")
+	if err := graph.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = NewPyPIGraph(path); err != nil {
+		t.Fatal(err)
+	}
+	gotText.Reset()
+	got.WriteTo(&gotText)
+	if gotText.String() != text.String() {
+		t.Errorf("expected graph from %s\n%s\ngot\n%s", path, text.String(), gotText.String())
+	}
+
+	// a header claiming to be 2 GB long
+	hostile := append(append([]byte(nil), binaryGraphMagic...), 1, 0x80, 0x80, 0x80, 0x80, 0x08, 'x')
+	for _, corrupt := range [][]byte{bin.Bytes()[:bin.Len()-3], append(binaryGraphMagic, 9), hostile} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if _, err := ReadPyPIGraph(bytes.NewReader(corrupt)); err == nil {
+			t.Errorf("expected an error reading corrupt binary graph %q", corrupt)
+		}
+		if runtime.ReadMemStats(&after); after.TotalAlloc-before.TotalAlloc > 1<<24 {
+			t.Errorf("reading corrupt binary graph %q allocated %d bytes", corrupt, after.TotalAlloc-before.TotalAlloc)
+		}
+	}
+}
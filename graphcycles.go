@@ -0,0 +1,86 @@
+package cheerio
+
+import (
+	"sort"
+)
+
+// Returns the dependency cycles in the graph, i.e., the strongly connected components that contain more than one package. Each cycle is sorted by
+// package name and cycles are ordered by decreasing size.
+func (p *PyPIGraph) Cycles() [][]string {
+	cycles := make([][]string, 0)
+	for _, scc := range p.StronglyConnectedComponents() {
+		if len(scc) > 1 {
+			sort.Strings(scc)
+			cycles = append(cycles, scc)
+		}
+	}
+	sort.SliceStable(cycles, func(i, j int) bool {
+		if len(cycles[i]) != len(cycles[j]) {
+			return len(cycles[i]) > len(cycles[j])
+		}
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+// Returns the strongly connected components of the graph (including single-package components) in reverse topological order: every component
+// appears after all of the components it depends on.
+func (p *PyPIGraph) StronglyConnectedComponents() [][]string {
+	t := &tarjan{
+		graph:   p,
+		index:   make(map[string]int),
+		lowlink: make(map[string]int),
+		onStack: make(map[string]bool),
+		sccs:    make([][]string, 0),
+	}
+	for _, pkg := range p.sortedPkgs() {
+		if _, visited := t.index[pkg]; !visited {
+			t.strongConnect(pkg)
+		}
+	}
+	return t.sccs
+}
+
+// State for Tarjan's strongly connected components algorithm
+type tarjan struct {
+	graph   *PyPIGraph
+	next    int
+	index   map[string]int
+	lowlink map[string]int
+	onStack map[string]bool
+	stack   []string
+	sccs    [][]string
+}
+
+func (t *tarjan) strongConnect(pkg string) {
+	t.index[pkg] = t.next
+	t.lowlink[pkg] = t.next
+	t.next++
+	t.stack = append(t.stack, pkg)
+	t.onStack[pkg] = true
+
+	for _, dep := range t.graph.Req[pkg] {
+		if _, visited := t.index[dep]; !visited {
+			t.strongConnect(dep)
+			if t.lowlink[dep] < t.lowlink[pkg] {
+				t.lowlink[pkg] = t.lowlink[dep]
+			}
+		} else if t.onStack[dep] && t.index[dep] < t.lowlink[pkg] {
+			t.lowlink[pkg] = t.index[dep]
+		}
+	}
+
+	if t.lowlink[pkg] == t.index[pkg] {
+		var scc []string
+		for {
+			top := t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+			t.onStack[top] = false
+			scc = append(scc, top)
+			if top == pkg {
+				break
+			}
+		}
+		t.sccs = append(t.sccs, scc)
+	}
+}
@@ -0,0 +1,58 @@
+package cheerio
+
+// Differences between two PyPIGraphs, e.g., two crawls of the same index taken at different times
+type GraphDiff struct {
+	AddedPkgs    []string
+	RemovedPkgs  []string
+	AddedEdges   []Edge
+	RemovedEdges []Edge
+}
+
+// Compares graph a (the older graph) to graph b (the newer graph). All lists in the result are sorted.
+func DiffGraphs(a, b *PyPIGraph) *GraphDiff {
+	diff := &GraphDiff{
+		AddedPkgs:    make([]string, 0),
+		RemovedPkgs:  make([]string, 0),
+		AddedEdges:   make([]Edge, 0),
+		RemovedEdges: make([]Edge, 0),
+	}
+
+	aPkgs, bPkgs := a.sortedPkgs(), b.sortedPkgs()
+	aPkgSet, bPkgSet := stringSet(aPkgs), stringSet(bPkgs)
+	for _, pkg := range bPkgs {
+		if !aPkgSet[pkg] {
+			diff.AddedPkgs = append(diff.AddedPkgs, pkg)
+		}
+	}
+	for _, pkg := range aPkgs {
+		if !bPkgSet[pkg] {
+			diff.RemovedPkgs = append(diff.RemovedPkgs, pkg)
+		}
+	}
+
+	aEdges, bEdges := a.Edges(), b.Edges()
+	aEdgeSet, bEdgeSet := make(map[Edge]bool), make(map[Edge]bool)
+	for _, edge := range aEdges {
+		aEdgeSet[edge] = true
+	}
+	for _, edge := range bEdges {
+		bEdgeSet[edge] = true
+	}
+	for _, edge := range bEdges {
+		if !aEdgeSet[edge] {
+			diff.AddedEdges = append(diff.AddedEdges, edge)
+		}
+	}
+	for _, edge := range aEdges {
+		if !bEdgeSet[edge] {
+			diff.RemovedEdges = append(diff.RemovedEdges, edge)
+		}
+	}
+
+	return diff
+}
+
+// Returns true if the graphs have the same packages and dependency edges
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedPkgs) == 0 && len(d.RemovedPkgs) == 0 && len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0
+}
@@ -0,0 +1,163 @@
+package cheerio
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Writes the dependency edges of the graph as a CSV edge list with a "source,target,constraint,extra" header, e.g., for pandas, Gephi, or a data
+// warehouse. Each dependency required unconditionally has a row with an empty extra, followed by one row per extra that requires it otherwise.
+// PyPIGraphs don't record version constraints, so the constraint column is empty (see VersionedPyPIGraph.WriteEdgeCSV). comma separates fields,
+// e.g., '\t' for TSV.
+func (p *PyPIGraph) WriteEdgeCSV(w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	cw.Write([]string{"source", "target", "constraint", "extra"})
+	p.visitExtraEdges(func(pkg, dep, extra string) {
+		cw.Write([]string{pkg, dep, "", extra})
+	})
+	cw.Flush()
+	return cw.Error()
+}
+
+// Calls visit with each dependency edge of the graph, sorted by package: first with an empty extra for each dependency required unconditionally,
+// then once per extra for the dependencies required by extras, sorted by extra and dependency
+func (p *PyPIGraph) visitExtraEdges(visit func(pkg, dep, extra string)) {
+	edges := p.Edges()
+	for _, pkg := range p.sortedPkgs() {
+		for len(edges) > 0 && edges[0].Pkg == pkg {
+			if !p.optional[edges[0]] {
+				visit(pkg, edges[0].Dep, "")
+			}
+			edges = edges[1:]
+		}
+		extras := p.Extras[pkg]
+		for _, extra := range sortedExtras(extras) {
+			deps := append([]string(nil), extras[extra]...)
+			sort.Strings(deps)
+			for _, dep := range deps {
+				visit(pkg, dep, extra)
+			}
+		}
+	}
+}
+
+// Writes the packages of the graph as a CSV node list with a header, with their display name (see DisplayName), numbers of direct dependencies
+// and dependents, and extras (separated by ";"). If info is given, the version, license, Requires-Python, and monthly downloads of each package
+// are added from it (see PackageInfo), empty when unknown.
+func (p *PyPIGraph) WriteNodeCSV(w io.Writer, comma rune, info PackageInfoIndex) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	cw.Write(nodeColumns(info != nil))
+	for _, pkg := range p.sortedPkgs() {
+		cw.Write(p.nodeRecord(pkg, info))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Returns the columns of node lists, with package info columns if withInfo
+func nodeColumns(withInfo bool) []string {
+	columns := []string{"id", "name", "requires", "required_by", "extras"}
+	if withInfo {
+		columns = append(columns, "version", "license", "requires_python", "downloads_last_month")
+	}
+	return columns
+}
+
+// Returns the fields of pkg in node lists (see nodeColumns), empty when unknown
+func (p *PyPIGraph) nodeRecord(pkg string, info PackageInfoIndex) []string {
+	requires := ""
+	if deps, found := p.Lookup(pkg); found {
+		requires = strconv.Itoa(len(stringSet(deps)))
+	}
+	record := []string{pkg, p.DisplayName(pkg), requires, strconv.Itoa(len(stringSet(p.RequiredBy(pkg)))), strings.Join(p.ExtrasOf(pkg), ";")}
+	if info == nil {
+		return record
+	}
+	pkgInfo, in := info[pkg]
+	if !in {
+		return append(record, "", "", "", "")
+	}
+	downloads := ""
+	if pkgInfo.Downloads != nil {
+		downloads = strconv.FormatInt(pkgInfo.Downloads.LastMonth, 10)
+	}
+	return append(record, pkgInfo.Version, pkgInfo.License, pkgInfo.RequiresPython, downloads)
+}
+
+// Writes the requirements of each release as a CSV edge list like PyPIGraph.WriteEdgeCSV, with sources written as "pkg@version" and the version
+// constraints of the requirements, e.g., "flask@2.0.0,werkzeug,>=2.0,".
+func (g *VersionedPyPIGraph) WriteEdgeCSV(w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	cw.Write([]string{"source", "target", "constraint", "extra"})
+	pkgs := make([]string, 0, len(g.Req))
+	for pkg := range g.Req {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		for _, ver := range g.Versions(pkg) {
+			for _, req := range g.Req[pkg][ver] {
+				cw.Write([]string{pkg + "@" + ver, NormalizedPkgName(req.Name), req.Specifier(), req.Extra})
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Writes the dependency edges of the graph as a Parquet file with source, target, constraint, and extra columns, e.g., for DuckDB or Spark. Rows
+// are the same as with WriteEdgeCSV, with null constraints and null extras for dependencies required unconditionally.
+func (p *PyPIGraph) WriteEdgeParquet(w io.Writer) error {
+	table := newEdgeParquetTable()
+	p.visitExtraEdges(func(pkg, dep, extra string) {
+		table.add(pkg, dep, "", extra)
+	})
+	return table.write(w)
+}
+
+// Writes the requirements of each release as a Parquet file like PyPIGraph.WriteEdgeParquet, with rows like VersionedPyPIGraph.WriteEdgeCSV
+func (g *VersionedPyPIGraph) WriteEdgeParquet(w io.Writer) error {
+	table := newEdgeParquetTable()
+	pkgs := make([]string, 0, len(g.Req))
+	for pkg := range g.Req {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		for _, ver := range g.Versions(pkg) {
+			for _, req := range g.Req[pkg][ver] {
+				table.add(pkg+"@"+ver, NormalizedPkgName(req.Name), req.Specifier(), req.Extra)
+			}
+		}
+	}
+	return table.write(w)
+}
+
+func newEdgeParquetTable() *parquetTable {
+	return newParquetTable(parquetColumn{Name: "source"}, parquetColumn{Name: "target"}, parquetColumn{Name: "constraint", Optional: true},
+		parquetColumn{Name: "extra", Optional: true})
+}
+
+// Writes the packages of the graph as a Parquet file with the columns of WriteNodeCSV, with integer counts and nulls for unknown values
+func (p *PyPIGraph) WriteNodeParquet(w io.Writer, info PackageInfoIndex) error {
+	columns := make([]parquetColumn, 0)
+	for _, name := range nodeColumns(info != nil) {
+		col := parquetColumn{Name: name, Optional: name != "id" && name != "name"}
+		switch name {
+		case "requires", "required_by", "downloads_last_month":
+			col.Int64 = true
+		}
+		columns = append(columns, col)
+	}
+	table := newParquetTable(columns...)
+	for _, pkg := range p.sortedPkgs() {
+		table.add(p.nodeRecord(pkg, info)...)
+	}
+	return table.write(w)
+}
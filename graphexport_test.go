@@ -0,0 +1,74 @@
+package cheerio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteEdgeCSV(t *testing.T) {
+	g := testGraph("flask:werkzeug", "flask:jinja2", "flask:python-dotenv:dotenv", "flask:asgiref:async", "jinja2:markupsafe")
+
+	var buf bytes.Buffer
+	if err := g.WriteEdgeCSV(&buf, ','); err != nil {
+		t.Fatal(err)
+	}
+	exp := `source,target,constraint,extra
+flask,jinja2,,
+flask,werkzeug,,
+flask,asgiref,,async
+flask,python-dotenv,,dotenv
+jinja2,markupsafe,,
+`
+	if buf.String() != exp {
+		t.Errorf("WriteEdgeCSV: expected\n%s\ngot\n%s", exp, buf.String())
+	}
+
+	versioned := NewVersionedPyPIGraph()
+	werkzeug, _ := ParseRequirement("Werkzeug>=2.0")
+	dotenv, _ := ParseRequirement("python-dotenv")
+	dotenv.Extra = "dotenv"
+	versioned.Add("flask", "2.0.0", []*Requirement{werkzeug, dotenv})
+	versioned.Add("flask", "1.1.0", nil)
+
+	buf.Reset()
+	if err := versioned.WriteEdgeCSV(&buf, '\t'); err != nil {
+		t.Fatal(err)
+	}
+	exp = "source\ttarget\tconstraint\textra\nflask@2.0.0\twerkzeug\t>=2.0\t\nflask@2.0.0\tpython-dotenv\t\tdotenv\n"
+	if buf.String() != exp {
+		t.Errorf("VersionedPyPIGraph.WriteEdgeCSV: expected\n%q\ngot\n%q", exp, buf.String())
+	}
+}
+
+func TestWriteNodeCSV(t *testing.T) {
+	g := testGraph("Flask:werkzeug", "Flask:jinja2", "Flask:python-dotenv:dotenv")
+
+	var buf bytes.Buffer
+	if err := g.WriteNodeCSV(&buf, ',', nil); err != nil {
+		t.Fatal(err)
+	}
+	exp := `id,name,requires,required_by,extras
+flask,Flask,3,0,dotenv
+jinja2,jinja2,,1,
+python-dotenv,python-dotenv,,1,
+werkzeug,werkzeug,,1,
+`
+	if buf.String() != exp {
+		t.Errorf("WriteNodeCSV: expected\n%s\ngot\n%s", exp, buf.String())
+	}
+
+	info := PackageInfoIndex{"flask": {Pkg: "flask", Version: "2.0.0", License: "BSD-3-Clause", RequiresPython: ">=3.6", Downloads: &DownloadCounts{LastMonth: 1000}}}
+	buf.Reset()
+	if err := g.WriteNodeCSV(&buf, ',', info); err != nil {
+		t.Fatal(err)
+	}
+	exp = `id,name,requires,required_by,extras,version,license,requires_python,downloads_last_month
+flask,Flask,3,0,dotenv,2.0.0,BSD-3-Clause,>=3.6,1000
+jinja2,jinja2,,1,,,,,
+python-dotenv,python-dotenv,,1,,,,,
+werkzeug,werkzeug,,1,,,,,
+`
+	if buf.String() != exp {
+		t.Errorf("WriteNodeCSV with info: expected\n%s\ngot\n%s", exp, buf.String())
+	}
+}
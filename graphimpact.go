@@ -0,0 +1,74 @@
+package cheerio
+
+import (
+	"sort"
+)
+
+// Returns every package that transitively requires pkg, grouped by distance: the first level holds the packages that require pkg directly, the second
+// level those that require a package in the first level, and so on. Each package appears only at its shortest distance, and each level is sorted.
+func (p *PyPIGraph) Impact(pkg string) [][]string {
+	return bfsLevels(p.ReqBy, p.NormalizedName(pkg), 0)
+}
+
+// Returns every package that pkg transitively requires, grouped by distance in the same manner as Impact.
+func (p *PyPIGraph) Closure(pkg string) [][]string {
+	return bfsLevels(p.Req, p.NormalizedName(pkg), 0)
+}
+
+// Returns the packages that all of pkgs transitively require, sorted, e.g., what both flask and django depend on
+func (p *PyPIGraph) CommonRequires(pkgs ...string) []string {
+	return p.commonReachable(p.Req, pkgs)
+}
+
+// Returns the packages that transitively require all of pkgs, sorted, e.g., the projects that use both celery and redis
+func (p *PyPIGraph) CommonRequiredBy(pkgs ...string) []string {
+	return p.commonReachable(p.ReqBy, pkgs)
+}
+
+// Returns the intersection of the sets of packages reachable from each of pkgs along edges, sorted
+func (p *PyPIGraph) commonReachable(edges map[string][]string, pkgs []string) []string {
+	common := make([]string, 0)
+	counts := make(map[string]int)
+	for i, pkg := range pkgs {
+		for _, level := range bfsLevels(edges, p.NormalizedName(pkg), 0) {
+			for _, reached := range level {
+				if counts[reached] == i {
+					counts[reached]++
+				}
+			}
+		}
+	}
+	for pkg, count := range counts {
+		if count == len(pkgs) {
+			common = append(common, pkg)
+		}
+	}
+	sort.Strings(common)
+	return common
+}
+
+// Breadth-first search from start along edges, returning the packages discovered at each distance (excluding start itself). Stops after maxDepth
+// levels if maxDepth > 0.
+func bfsLevels(edges map[string][]string, start string, maxDepth int) [][]string {
+	levels := make([][]string, 0)
+	seen := map[string]bool{start: true}
+	frontier := []string{start}
+	for len(frontier) > 0 && (maxDepth <= 0 || len(levels) < maxDepth) {
+		var next []string
+		for _, pkg := range frontier {
+			for _, adj := range edges[pkg] {
+				if !seen[adj] {
+					seen[adj] = true
+					next = append(next, adj)
+				}
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		sort.Strings(next)
+		levels = append(levels, next)
+		frontier = next
+	}
+	return levels
+}
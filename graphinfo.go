@@ -0,0 +1,142 @@
+package cheerio
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version of the graph file format written by this package
+const GraphFormatVersion = 1
+
+// Provenance of a graph file, recorded in a header block of "# key: value" comment lines at the top of the file, e.g.,
+//
+//	# cheerio-graph: 1
+//	# index: https://pypi.python.org
+//	# crawled: 2024-01-02T15:04:05Z
+//	# serial: 21880345
+//	# ecosystem: npm
+//
+// Graph files without a header (format version 0) are still read.
+type GraphInfo struct {
+	FormatVersion int
+	IndexURL      string
+	Crawled       time.Time // when the crawl started
+	Serial        int64     // last changelog serial of the index when the crawl started, or 0 if unknown
+	Ecosystem     string    // ecosystem of the packages (see Ecosystems), whose naming rules normalize them; PyPI if empty
+
+	// Other header fields, e.g., the "packages", "edges" and "sha256" stamp of PyPIGraph.WriteStamped
+	Fields map[string]string
+}
+
+// Returns the provenance recorded in the header of the file the graph was read from, or nil if it has none
+func (p *PyPIGraph) Info() *GraphInfo {
+	return p.info
+}
+
+// Records the provenance written by WriteStamped. Its ecosystem decides how package names are normalized, so it should be set before packages
+// are added.
+func (p *PyPIGraph) SetInfo(info *GraphInfo) {
+	p.info = info
+}
+
+// Returns the ecosystem of the packages of the graph, from its provenance, or "pypi" if it is unknown
+func (p *PyPIGraph) Ecosystem() string {
+	if p.info == nil || p.info.Ecosystem == "" {
+		return "pypi"
+	}
+	return p.info.Ecosystem
+}
+
+// Returns an empty graph of packages of an ecosystem, e.g., one derived from another graph. PyPI graphs get no provenance, as it is the default.
+func newEcosystemGraph(ecosystem string) *PyPIGraph {
+	graph := newPyPIGraph()
+	if ecosystem != "" && !strings.EqualFold(ecosystem, "pypi") {
+		graph.info = &GraphInfo{Ecosystem: ecosystem}
+	}
+	return graph
+}
+
+// Normalizes a package name by the naming rules of the ecosystem of the graph (see NormalizedEcosystemPkgName), as the graph is keyed and
+// queried by normalized names
+func (p *PyPIGraph) NormalizedName(pkg string) string {
+	return NormalizedEcosystemPkgName(p.Ecosystem(), pkg)
+}
+
+// Parses a "# key: value" header line into info, returning false if line isn't one
+func parseGraphHeaderLine(info *GraphInfo, line string) bool {
+	kv := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":", 2)
+	if !strings.HasPrefix(line, "#") || len(kv) != 2 {
+		return false
+	}
+	key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+	switch key {
+	case "cheerio-graph":
+		info.FormatVersion, _ = strconv.Atoi(value)
+	case "index":
+		info.IndexURL = value
+	case "crawled":
+		info.Crawled, _ = time.Parse(time.RFC3339, value)
+	case "serial":
+		info.Serial, _ = strconv.ParseInt(value, 10, 64)
+	case "ecosystem":
+		info.Ecosystem = value
+	default:
+		if info.Fields == nil {
+			info.Fields = make(map[string]string)
+		}
+		info.Fields[key] = value
+	}
+	return true
+}
+
+// Writes the header block of info: the format version, followed by the index URL, crawl time, serial, ecosystem and other fields that are set
+func WriteGraphHeader(w io.Writer, info *GraphInfo) error {
+	lines := []string{fmt.Sprintf("cheerio-graph: %d", GraphFormatVersion)}
+	if info != nil {
+		if info.IndexURL != "" {
+			lines = append(lines, "index: "+info.IndexURL)
+		}
+		if !info.Crawled.IsZero() {
+			lines = append(lines, "crawled: "+info.Crawled.UTC().Format(time.RFC3339))
+		}
+		if info.Serial != 0 {
+			lines = append(lines, fmt.Sprintf("serial: %d", info.Serial))
+		}
+		if info.Ecosystem != "" {
+			lines = append(lines, "ecosystem: "+info.Ecosystem)
+		}
+		keys := make([]string, 0, len(info.Fields))
+		for key := range info.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, key+": "+info.Fields[key])
+		}
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "# %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the last changelog serial of a PyPI server, i.e., the serial of its latest change, from the X-PyPI-Last-Serial header of its simple
+// index. Recorded in graph headers, it tells which changes a crawl may have missed.
+func (p *PackageIndex) LastSerial() (int64, error) {
+	resp, err := p.client().Head(fmt.Sprintf("%s/simple/", p.URI))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	serial := resp.Header.Get("X-PyPI-Last-Serial")
+	if serial == "" {
+		return 0, fmt.Errorf("%s/simple/ returned no X-PyPI-Last-Serial header", p.URI)
+	}
+	return strconv.ParseInt(serial, 10, 64)
+}
@@ -0,0 +1,113 @@
+package cheerio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGraphInfo(t *testing.T) {
+	crawled := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	info := &GraphInfo{IndexURL: "https://pypi.python.org", Crawled: crawled, Serial: 21880345, Fields: map[string]string{"note": "nightly"}}
+
+	var buf bytes.Buffer
+	if err := WriteGraphHeader(&buf, info); err != nil {
+		t.Fatal(err)
+	}
+	exp := "# cheerio-graph: 1\n# index: https://pypi.python.org\n# crawled: 2024-01-02T15:04:05Z\n# serial: 21880345\n# note: nightly\n"
+	if buf.String() != exp {
+		t.Errorf("expected header\n%s\ngot\n%s", exp, buf.String())
+	}
+	buf.WriteString("flask\nflask:werkzeug\n# a comment after the header\n")
+
+	dir, err := ioutil.TempDir("", "cheerio-graphinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graph")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	graph, err := NewPyPIGraph(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := graph.Info()
+	if got == nil || got.FormatVersion != 1 || got.IndexURL != info.IndexURL || !got.Crawled.Equal(crawled) || got.Serial != info.Serial ||
+		len(got.Fields) != 1 || got.Fields["note"] != "nightly" {
+		t.Errorf("unexpected graph info %+v", got)
+	}
+	if len(graph.Requires("flask")) != 1 {
+		t.Errorf("unexpected graph %v", graph.Req)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("flask\nflask:werkzeug\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if graph, err = NewPyPIGraph(path); err != nil {
+		t.Fatal(err)
+	}
+	if graph.Info() != nil {
+		t.Errorf("expected no info for a graph without header, got %+v", graph.Info())
+	}
+}
+
+func TestLastSerial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/simple/" {
+			w.Header().Set("X-PyPI-Last-Serial", "21880345")
+		}
+	}))
+	defer server.Close()
+
+	serial, err := (&PackageIndex{URI: server.URL}).LastSerial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial != 21880345 {
+		t.Errorf("expected serial 21880345, got %d", serial)
+	}
+}
+
+func TestGzipGraph(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio-gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// written by PyPIGraph.WriteFile
+	path := filepath.Join(dir, "pypi_graph.gz")
+	if err := testGraph("app:flask", "flask:werkzeug", "flask:python-dotenv:dotenv").WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	// written by a file sink
+	sinkPath := filepath.Join(dir, "crawl.txt.gz")
+	sink, err := CreateFileSink(sinkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Write(PackageResult{Pkg: "flask", Requirements: []*Requirement{{Name: "werkzeug"}, {Name: "python-dotenv", Extra: "dotenv"}}})
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{path, sinkPath} {
+		if b, _ := ioutil.ReadFile(file); !bytes.HasPrefix(b, gzipMagic) {
+			t.Errorf("%s isn't gzip-compressed", file)
+		}
+		graph, err := NewPyPIGraph(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reqs := graph.RequiresWithExtras("flask", "dotenv"); len(reqs) != 2 {
+			t.Errorf("%s: unexpected requirements of flask %v", file, reqs)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package cheerio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Version of the indexed graph format written by WriteMapped
+const MappedGraphVersion = 1
+
+// Magic bytes that start indexed graph files
+var mappedGraphMagic = []byte("CHGM")
+
+// Sections of an indexed graph file. Lists are stored as a table of count+1 little-endian uint32 starts followed by the items they delimit,
+// so the list of item i is items[starts[i]:starts[i+1]].
+const (
+	mappedHeader    = iota // header text (see WriteGraphHeader)
+	mappedPkgStarts        // package names, sorted
+	mappedPkgNames
+	mappedExtraStarts // extra names, sorted
+	mappedExtraNames
+	mappedReqStarts // package -> dependencies (package indexes), as in PyPIGraph.Req
+	mappedReqs
+	mappedReqByStarts // package -> dependents, as in PyPIGraph.ReqBy
+	mappedReqBys
+	mappedBaseStarts // package -> dependencies required without any extra
+	mappedBases
+	mappedPkgExtraStarts // package -> extra entries
+	mappedPkgExtras      // extra entry -> extra name index
+	mappedExtraDepStarts // extra entry -> dependencies required with the extra
+	mappedExtraDeps
+	mappedListed // one byte per package: 1 if its dependencies are listed, i.e., it is a key of PyPIGraph.Req, and 0 if it is only a dependency
+	mappedSections
+)
+
+// Serializes the graph in an indexed format that MappedGraph queries in place, without loading it: sorted package names are looked up by
+// binary search, and dependencies, dependents, and extras are stored as fixed-width offsets into name tables. Unlike the binary format (see
+// WriteBinary), the file can't be compressed.
+func (p *PyPIGraph) WriteMapped(w io.Writer) error {
+	pkgs := p.sortedPkgs()
+	pkgIndex := make(map[string]uint32, len(pkgs))
+	for i, pkg := range pkgs {
+		pkgIndex[pkg] = uint32(i)
+	}
+	extraIndex := make(map[string]uint32)
+	for _, pkg := range pkgs {
+		for extra := range p.Extras[pkg] {
+			extraIndex[extra] = 0
+		}
+	}
+	extras := make([]string, 0, len(extraIndex))
+	for extra := range extraIndex {
+		extras = append(extras, extra)
+	}
+	sort.Strings(extras)
+	for i, extra := range extras {
+		extraIndex[extra] = uint32(i)
+	}
+
+	var sections [mappedSections]bytes.Buffer
+	if p.info != nil {
+		if err := WriteGraphHeader(&sections[mappedHeader], p.info); err != nil {
+			return err
+		}
+	}
+	writeNames := func(starts, names *bytes.Buffer, list []string) {
+		for _, name := range list {
+			writeUint32(starts, uint32(names.Len()))
+			names.WriteString(name)
+		}
+		writeUint32(starts, uint32(names.Len()))
+	}
+	writeNames(&sections[mappedPkgStarts], &sections[mappedPkgNames], pkgs)
+	writeNames(&sections[mappedExtraStarts], &sections[mappedExtraNames], extras)
+
+	writeList := func(starts, items *bytes.Buffer, list []string) {
+		writeUint32(starts, uint32(items.Len()/4))
+		for _, name := range list {
+			writeUint32(items, pkgIndex[name])
+		}
+	}
+	for _, pkg := range pkgs {
+		if _, listed := p.Req[pkg]; listed {
+			sections[mappedListed].WriteByte(1)
+		} else {
+			sections[mappedListed].WriteByte(0)
+		}
+		writeList(&sections[mappedReqStarts], &sections[mappedReqs], p.Req[pkg])
+		writeList(&sections[mappedReqByStarts], &sections[mappedReqBys], p.ReqBy[pkg])
+		writeList(&sections[mappedBaseStarts], &sections[mappedBases], p.RequiresWithExtras(pkg))
+		writeUint32(&sections[mappedPkgExtraStarts], uint32(sections[mappedPkgExtras].Len()/4))
+		for _, extra := range sortedExtras(p.Extras[pkg]) {
+			writeUint32(&sections[mappedPkgExtras], extraIndex[extra])
+			writeList(&sections[mappedExtraDepStarts], &sections[mappedExtraDeps], p.Extras[pkg][extra])
+		}
+	}
+	writeUint32(&sections[mappedReqStarts], uint32(sections[mappedReqs].Len()/4))
+	writeUint32(&sections[mappedReqByStarts], uint32(sections[mappedReqBys].Len()/4))
+	writeUint32(&sections[mappedBaseStarts], uint32(sections[mappedBases].Len()/4))
+	writeUint32(&sections[mappedPkgExtraStarts], uint32(sections[mappedPkgExtras].Len()/4))
+	writeUint32(&sections[mappedExtraDepStarts], uint32(sections[mappedExtraDeps].Len()/4))
+
+	// magic, version, and the offsets of the sections and of the end of the file
+	var head bytes.Buffer
+	head.Write(mappedGraphMagic)
+	writeUint32(&head, MappedGraphVersion)
+	offset := uint64(head.Len() + 8*(mappedSections+1))
+	for i := range sections {
+		binary.Write(&head, binary.LittleEndian, offset)
+		offset += uint64(sections[i].Len())
+	}
+	binary.Write(&head, binary.LittleEndian, offset)
+
+	if _, err := head.WriteTo(w); err != nil {
+		return err
+	}
+	for i := range sections {
+		if _, err := sections[i].WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeUint32(w *bytes.Buffer, x uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], x)
+	w.Write(buf[:])
+}
+
+// A read-only dependency graph queried in place from a file in the indexed format (see PyPIGraph.WriteMapped), which is memory-mapped where
+// the platform supports it. Opening it is nearly instant and its memory is paged in by the OS as queries touch it, so graphs too large to
+// load into a PyPIGraph can be queried. Package names are normalized. A MappedGraph is safe for concurrent use until it is closed.
+type MappedGraph struct {
+	data     []byte
+	sections [mappedSections][]byte
+	info     *GraphInfo
+	unmap    func() error
+}
+
+// Opens a graph file in the indexed format
+func OpenMappedGraph(file string) (*MappedGraph, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, unmap, err := mmapFile(f)
+	if err != nil {
+		return nil, err
+	}
+	g, err := newMappedGraph(data)
+	if err != nil {
+		unmap()
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	g.unmap = unmap
+	return g, nil
+}
+
+// Reads the whole graph from r, which must be in the indexed format, into memory
+func ReadMappedGraph(r io.Reader) (*MappedGraph, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return newMappedGraph(data)
+}
+
+func newMappedGraph(data []byte) (*MappedGraph, error) {
+	head := len(mappedGraphMagic) + 4 + 8*(mappedSections+1)
+	if len(data) < head || !bytes.Equal(data[:len(mappedGraphMagic)], mappedGraphMagic) {
+		return nil, fmt.Errorf("Not an indexed graph file")
+	}
+	if version := binary.LittleEndian.Uint32(data[len(mappedGraphMagic):]); version != MappedGraphVersion {
+		return nil, fmt.Errorf("Unsupported indexed graph version %d", version)
+	}
+
+	g := &MappedGraph{data: data}
+	offsets := data[len(mappedGraphMagic)+4:]
+	for i := range g.sections {
+		start, end := binary.LittleEndian.Uint64(offsets[8*i:]), binary.LittleEndian.Uint64(offsets[8*(i+1):])
+		if start < uint64(head) || start > end || end > uint64(len(data)) {
+			return nil, fmt.Errorf("Corrupt indexed graph: section %d out of range", i)
+		}
+		g.sections[i] = data[start:end]
+	}
+	for _, starts := range []int{mappedPkgStarts, mappedReqStarts, mappedReqByStarts, mappedBaseStarts, mappedPkgExtraStarts} {
+		if len(g.sections[starts]) != len(g.sections[mappedPkgStarts]) {
+			return nil, fmt.Errorf("Corrupt indexed graph: section %d doesn't match the number of packages", starts)
+		}
+	}
+	if len(g.sections[mappedListed]) != g.Len() {
+		return nil, fmt.Errorf("Corrupt indexed graph: section %d doesn't match the number of packages", mappedListed)
+	}
+	if len(g.sections[mappedPkgStarts]) < 4 || len(g.sections[mappedExtraStarts]) < 4 || len(g.sections[mappedExtraDepStarts]) < 4 {
+		return nil, fmt.Errorf("Corrupt indexed graph: missing name tables")
+	}
+
+	if header := string(g.sections[mappedHeader]); header != "" {
+		g.info = &GraphInfo{}
+		for _, line := range strings.Split(header, "\n") {
+			parseGraphHeaderLine(g.info, line)
+		}
+	}
+	return g, nil
+}
+
+// Returns the provenance recorded in the header of the graph, or nil if it has none
+func (g *MappedGraph) Info() *GraphInfo {
+	return g.info
+}
+
+// Returns the number of packages in the graph
+func (g *MappedGraph) Len() int {
+	return len(g.sections[mappedPkgStarts])/4 - 1
+}
+
+// Returns the i-th item delimited by a table of starts, clamped to the items so a corrupt file can't cause out-of-range accesses
+func (g *MappedGraph) span(starts []byte, items []byte, i, width int) []byte {
+	if 4*(i+1) >= len(starts) {
+		return nil
+	}
+	start, end := int(binary.LittleEndian.Uint32(starts[4*i:]))*width, int(binary.LittleEndian.Uint32(starts[4*(i+1):]))*width
+	if start > end || end > len(items) {
+		return nil
+	}
+	return items[start:end]
+}
+
+func (g *MappedGraph) pkgName(i int) string {
+	return string(g.span(g.sections[mappedPkgStarts], g.sections[mappedPkgNames], i, 1))
+}
+
+// Returns the index of pkg in the sorted package names, or -1 if it isn't in the graph
+func (g *MappedGraph) lookup(pkg string) int {
+	pkg = g.NormalizedName(pkg)
+	n := g.Len()
+	i := sort.Search(n, func(i int) bool { return g.pkgName(i) >= pkg })
+	if i < n && g.pkgName(i) == pkg {
+		return i
+	}
+	return -1
+}
+
+// Normalizes a package name by the naming rules of the ecosystem of the graph, from its header (see PyPIGraph.NormalizedName)
+func (g *MappedGraph) NormalizedName(pkg string) string {
+	ecosystem := ""
+	if g.info != nil {
+		ecosystem = g.info.Ecosystem
+	}
+	return NormalizedEcosystemPkgName(ecosystem, pkg)
+}
+
+// Returns the names of the packages in list, a list of package indexes
+func (g *MappedGraph) pkgNames(list []byte) []string {
+	names := make([]string, 0, len(list)/4)
+	for ; len(list) >= 4; list = list[4:] {
+		names = append(names, g.pkgName(int(binary.LittleEndian.Uint32(list))))
+	}
+	return names
+}
+
+// Returns the packages of a list of pkg, or nil if pkg isn't in the graph
+func (g *MappedGraph) pkgList(pkg string, starts, items int) []string {
+	i := g.lookup(pkg)
+	if i < 0 || (starts == mappedReqStarts && g.sections[mappedListed][i] == 0) {
+		return nil
+	}
+	return g.pkgNames(g.span(g.sections[starts], g.sections[items], i, 4))
+}
+
+func (g *MappedGraph) Requires(pkg string) []string {
+	return g.pkgList(pkg, mappedReqStarts, mappedReqs)
+}
+
+func (g *MappedGraph) RequiredBy(pkg string) []string {
+	return g.pkgList(pkg, mappedReqByStarts, mappedReqBys)
+}
+
+// Returns the packages that pkg requires, and whether the graph lists the dependencies of pkg (see PyPIGraph.Lookup)
+func (g *MappedGraph) Lookup(pkg string) (deps []string, found bool) {
+	i := g.lookup(pkg)
+	if i < 0 || g.sections[mappedListed][i] == 0 {
+		return nil, false
+	}
+	return g.pkgNames(g.span(g.sections[mappedReqStarts], g.sections[mappedReqs], i, 4)), true
+}
+
+// Returns whether pkg is in the graph, either with its dependencies or as a dependency of another package
+func (g *MappedGraph) Contains(pkg string) bool {
+	return g.lookup(pkg) >= 0
+}
+
+// Returns the dependencies of pkg that are required unconditionally or by one of the given extras (see PyPIGraph.RequiresWithExtras)
+func (g *MappedGraph) RequiresWithExtras(pkg string, extras ...string) []string {
+	deps := make([]string, 0)
+	i := g.lookup(pkg)
+	if i < 0 {
+		return deps
+	}
+	deps = append(deps, g.pkgNames(g.span(g.sections[mappedBaseStarts], g.sections[mappedBases], i, 4))...)
+	for _, entry := range g.extraEntries(i) {
+		if containsString(extras, entry.name) {
+			for _, dep := range g.pkgNames(g.span(g.sections[mappedExtraDepStarts], g.sections[mappedExtraDeps], entry.index, 4)) {
+				if !containsString(deps, dep) {
+					deps = append(deps, dep)
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// Returns the extras that pkg declares, sorted
+func (g *MappedGraph) ExtrasOf(pkg string) []string {
+	var extras []string
+	if i := g.lookup(pkg); i >= 0 {
+		for _, entry := range g.extraEntries(i) {
+			extras = append(extras, entry.name)
+		}
+	}
+	return extras
+}
+
+type mappedExtra struct {
+	name  string
+	index int // of the extra entry, in the extra dependency lists
+}
+
+// Returns the extras of the package with index i, in the order they were written
+func (g *MappedGraph) extraEntries(i int) []mappedExtra {
+	starts := g.sections[mappedPkgExtraStarts]
+	if 4*(i+1) >= len(starts) {
+		return nil
+	}
+	first := int(binary.LittleEndian.Uint32(starts[4*i:]))
+	entries := make([]mappedExtra, 0)
+	for j, list := 0, g.span(starts, g.sections[mappedPkgExtras], i, 4); len(list) >= 4; j, list = j+1, list[4:] {
+		name := g.span(g.sections[mappedExtraStarts], g.sections[mappedExtraNames], int(binary.LittleEndian.Uint32(list)), 1)
+		entries = append(entries, mappedExtra{name: string(name), index: first + j})
+	}
+	return entries
+}
+
+// Calls fn with each package of the graph in sorted order, until it returns false
+func (g *MappedGraph) Packages(fn func(pkg string) bool) {
+	for i, n := 0, g.Len(); i < n; i++ {
+		if !fn(g.pkgName(i)) {
+			return
+		}
+	}
+}
+
+// Loads the whole graph into a PyPIGraph, e.g., to use the graph algorithms it supports
+func (g *MappedGraph) Graph() *PyPIGraph {
+	graph := newPyPIGraph()
+	graph.info = g.info
+	for i, n := 0, g.Len(); i < n; i++ {
+		pkg := g.pkgName(i)
+		if g.sections[mappedListed][i] == 1 {
+			graph.addPkg(pkg)
+		}
+		for _, dep := range g.pkgNames(g.span(g.sections[mappedBaseStarts], g.sections[mappedBases], i, 4)) {
+			graph.addEdge(pkg, dep)
+		}
+		for _, entry := range g.extraEntries(i) {
+			for _, dep := range g.pkgNames(g.span(g.sections[mappedExtraDepStarts], g.sections[mappedExtraDeps], entry.index, 4)) {
+				graph.addExtraEdge(pkg, dep, entry.name)
+			}
+		}
+	}
+	return graph
+}
+
+// Unmaps the graph file. The graph can't be queried afterwards.
+func (g *MappedGraph) Close() error {
+	g.sections = [mappedSections][]byte{}
+	g.data = nil
+	if g.unmap == nil {
+		return nil
+	}
+	unmap := g.unmap
+	g.unmap = nil
+	return unmap()
+}
@@ -0,0 +1,86 @@
+package cheerio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMappedGraph(t *testing.T) {
+	graph := testGraph("app:flask", "app:celery", "app:redis:celery", "app:pytest:test", "app:flask:web", "flask:werkzeug", "celery:kombu")
+	graph.addPkg("standalone")
+	graph.SetInfo(&GraphInfo{IndexURL: "https://pypi.python.org", Serial: 42})
+
+	dir, err := ioutil.TempDir("", "cheerio-graphmapped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graph.mmap")
+	if err := graph.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := OpenMappedGraph(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+
+	if mapped.Len() != 8 {
+		t.Errorf("expected 8 packages, got %d", mapped.Len())
+	}
+	for _, pkg := range []string{"app", "App", "flask", "werkzeug", "standalone", "missing"} {
+		if got, exp := mapped.Requires(pkg), graph.Requires(pkg); !reflect.DeepEqual(got, exp) && len(got)+len(exp) > 0 {
+			t.Errorf("Requires(%s): expected %v, got %v", pkg, exp, got)
+		}
+		if got, exp := mapped.RequiredBy(pkg), graph.RequiredBy(pkg); !reflect.DeepEqual(got, exp) && len(got)+len(exp) > 0 {
+			t.Errorf("RequiredBy(%s): expected %v, got %v", pkg, exp, got)
+		}
+	}
+	if deps, found := mapped.Lookup("werkzeug"); found || deps != nil || !mapped.Contains("werkzeug") {
+		t.Errorf("Lookup: expected werkzeug's dependencies to be unknown, got %v, %v", deps, found)
+	}
+	if deps, found := mapped.Lookup("standalone"); !found || len(deps) != 0 {
+		t.Errorf("Lookup: expected standalone to have no dependencies, got %v, %v", deps, found)
+	}
+	if got, exp := mapped.RequiresWithExtras("app"), []string{"flask", "celery"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("RequiresWithExtras: expected %v, got %v", exp, got)
+	}
+	if got, exp := mapped.RequiresWithExtras("app", "test", "web"), []string{"flask", "celery", "pytest"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("RequiresWithExtras(test, web): expected %v, got %v", exp, got)
+	}
+	if got, exp := mapped.ExtrasOf("app"), []string{"celery", "test", "web"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("ExtrasOf: expected %v, got %v", exp, got)
+	}
+	if info := mapped.Info(); info == nil || info.Serial != 42 {
+		t.Errorf("unexpected graph info %+v", info)
+	}
+	var pkgs []string
+	mapped.Packages(func(pkg string) bool {
+		pkgs = append(pkgs, pkg)
+		return len(pkgs) < 3
+	})
+	if exp := []string{"app", "celery", "flask"}; !reflect.DeepEqual(pkgs, exp) {
+		t.Errorf("Packages: expected %v, got %v", exp, pkgs)
+	}
+
+	// NewPyPIGraph loads indexed files too
+	var text, gotText bytes.Buffer
+	graph.WriteTo(&text)
+	loaded, err := NewPyPIGraph(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.WriteTo(&gotText)
+	if gotText.String() != text.String() {
+		t.Errorf("expected graph\n%s\ngot\n%s", text.String(), gotText.String())
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if _, err := ReadMappedGraph(bytes.NewReader(data[:len(data)-5])); err == nil {
+		t.Errorf("expected an error reading a truncated indexed graph")
+	}
+}
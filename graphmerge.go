@@ -0,0 +1,51 @@
+package cheerio
+
+// Adds the packages and dependency edges of other to p. Package names are normalized, so packages that differ only in the case of their names are
+// merged, and duplicate edges are dropped. Edges of extras stay optional, and the display names of both graphs are kept.
+func (p *PyPIGraph) Merge(other *PyPIGraph) {
+	clean := newEcosystemGraph(p.Ecosystem())
+	clean.addGraph(p)
+	clean.addGraph(other)
+	info := p.info
+	*p = *clean
+	p.info = info
+}
+
+// Merges graphs into a new graph, whose names are normalized like those of the first graph
+func MergeGraphs(graphs ...*PyPIGraph) *PyPIGraph {
+	merged := newPyPIGraph()
+	if len(graphs) > 0 {
+		merged = newEcosystemGraph(graphs[0].Ecosystem())
+	}
+	for _, graph := range graphs {
+		merged.addGraph(graph)
+	}
+	return merged
+}
+
+// Adds the packages and edges of other to p, normalizing names and skipping edges p already has
+func (p *PyPIGraph) addGraph(other *PyPIGraph) {
+	for _, name := range other.display {
+		p.normalize(name) // keep the display names of other
+	}
+	for _, pkg := range other.sortedPkgs() {
+		normPkg := p.NormalizedName(pkg)
+		if _, in := other.Req[pkg]; in {
+			p.addPkg(normPkg)
+		}
+		for _, dep := range other.Req[pkg] {
+			if other.optional[Edge{pkg, dep}] {
+				continue
+			}
+			normDep := p.NormalizedName(dep)
+			if !containsString(p.Req[normPkg], normDep) || p.optional[Edge{normPkg, normDep}] {
+				p.addEdge(normPkg, normDep)
+			}
+		}
+		for extra, deps := range other.Extras[pkg] {
+			for _, dep := range deps {
+				p.addExtraEdge(normPkg, p.NormalizedName(dep), extra)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cheerio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Writes the graph as Cypher statements that create it in Neo4j, e.g., with cypher-shell: a (:Package {name, displayName, extras}) node per
+// package, unique by name, and a [:REQUIRES] relationship per dependency edge, with an extra property for dependencies required only by an extra
+// (one relationship per extra). Statements are grouped in cypher-shell :begin/:commit transactions of
+// cypherBatchSize relationships.
+func (p *PyPIGraph) WriteCypher(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "CREATE CONSTRAINT package_name IF NOT EXISTS FOR (p:Package) REQUIRE p.name IS UNIQUE;")
+	fmt.Fprintln(bw, ":begin")
+	for _, pkg := range p.sortedPkgs() {
+		fmt.Fprintf(bw, "CREATE (:Package {name: %s, displayName: %s, extras: [%s]});\n", cypherString(pkg), cypherString(p.DisplayName(pkg)),
+			cypherList(p.ExtrasOf(pkg)))
+	}
+	fmt.Fprintln(bw, ":commit")
+
+	n := 0
+	p.visitExtraEdges(func(pkg, dep, extra string) {
+		if n%cypherBatchSize == 0 {
+			if n > 0 {
+				fmt.Fprintln(bw, ":commit")
+			}
+			fmt.Fprintln(bw, ":begin")
+		}
+		n++
+		props := ""
+		if extra != "" {
+			props = fmt.Sprintf(" {extra: %s}", cypherString(extra))
+		}
+		fmt.Fprintf(bw, "MATCH (a:Package {name: %s}), (b:Package {name: %s}) CREATE (a)-[:REQUIRES%s]->(b);\n", cypherString(pkg), cypherString(dep), props)
+	})
+	if n > 0 {
+		fmt.Fprintln(bw, ":commit")
+	}
+	return bw.Flush()
+}
+
+// Number of relationships created per transaction by WriteCypher
+const cypherBatchSize = 10000
+
+// Returns s as a single-quoted Cypher string literal
+func cypherString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func cypherList(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = cypherString(s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Writes the graph to dir in the CSV layout of Neo4j's bulk importer, which is much faster than Cypher for whole crawls:
+//
+//	neo4j-admin database import full --nodes=Package=dir/packages.csv --relationships=REQUIRES=dir/requires.csv
+//
+// Nodes and relationships have the same properties as with WriteCypher; extras are ";"-separated arrays (the importer's default delimiter).
+func (p *PyPIGraph) WriteNeo4jCSV(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	err := writeCSVFile(filepath.Join(dir, "packages.csv"), func(cw *csv.Writer) {
+		cw.Write([]string{"name:ID(Package)", "displayName", "extras:string[]"})
+		for _, pkg := range p.sortedPkgs() {
+			cw.Write([]string{pkg, p.DisplayName(pkg), strings.Join(p.ExtrasOf(pkg), ";")})
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return writeCSVFile(filepath.Join(dir, "requires.csv"), func(cw *csv.Writer) {
+		cw.Write([]string{":START_ID(Package)", ":END_ID(Package)", "extra"})
+		p.visitExtraEdges(func(pkg, dep, extra string) {
+			cw.Write([]string{pkg, dep, extra})
+		})
+	})
+}
+
+// Creates file and writes CSV records to it with write
+func writeCSVFile(file string, write func(cw *csv.Writer)) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	write(cw)
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
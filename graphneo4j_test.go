@@ -0,0 +1,61 @@
+package cheerio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteCypher(t *testing.T) {
+	g := testGraph("Flask:werkzeug", "Flask:python-dotenv:dotenv", "o'pkg:flask")
+
+	var buf bytes.Buffer
+	if err := g.WriteCypher(&buf); err != nil {
+		t.Fatal(err)
+	}
+	exp := `CREATE CONSTRAINT package_name IF NOT EXISTS FOR (p:Package) REQUIRE p.name IS UNIQUE;
+:begin
+CREATE (:Package {name: 'flask', displayName: 'Flask', extras: ['dotenv']});
+CREATE (:Package {name: 'o\'pkg', displayName: 'o\'pkg', extras: []});
+CREATE (:Package {name: 'python-dotenv', displayName: 'python-dotenv', extras: []});
+CREATE (:Package {name: 'werkzeug', displayName: 'werkzeug', extras: []});
+:commit
+:begin
+MATCH (a:Package {name: 'flask'}), (b:Package {name: 'werkzeug'}) CREATE (a)-[:REQUIRES]->(b);
+MATCH (a:Package {name: 'flask'}), (b:Package {name: 'python-dotenv'}) CREATE (a)-[:REQUIRES {extra: 'dotenv'}]->(b);
+MATCH (a:Package {name: 'o\'pkg'}), (b:Package {name: 'flask'}) CREATE (a)-[:REQUIRES]->(b);
+:commit
+`
+	if buf.String() != exp {
+		t.Errorf("WriteCypher: expected\n%s\ngot\n%s", exp, buf.String())
+	}
+}
+
+func TestWriteNeo4jCSV(t *testing.T) {
+	g := testGraph("Flask:werkzeug", "Flask:python-dotenv:dotenv")
+
+	dir, err := ioutil.TempDir("", "cheerio-neo4j")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := g.WriteNeo4jCSV(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"packages.csv": "name:ID(Package),displayName,extras:string[]\nflask,Flask,dotenv\npython-dotenv,python-dotenv,\nwerkzeug,werkzeug,\n",
+		"requires.csv": ":START_ID(Package),:END_ID(Package),extra\nflask,werkzeug,\nflask,python-dotenv,dotenv\n",
+	}
+	for file, exp := range tests {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != exp {
+			t.Errorf("%s: expected\n%s\ngot\n%s", file, exp, data)
+		}
+	}
+}
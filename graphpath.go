@@ -0,0 +1,75 @@
+package cheerio
+
+// Returns a shortest dependency chain explaining why package from (transitively) requires package to. The chain starts with from and ends with to. If
+// to is not reachable from from, returns nil.
+func (p *PyPIGraph) Path(from, to string) []string {
+	from, to = p.NormalizedName(from), p.NormalizedName(to)
+	if from == to {
+		return []string{from}
+	}
+
+	// Breadth-first search, remembering the package through which each package was first reached
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, dep := range p.Req[pkg] {
+			if _, seen := prev[dep]; seen {
+				continue
+			}
+			prev[dep] = pkg
+			if dep == to {
+				return tracePath(prev, from, to)
+			}
+			queue = append(queue, dep)
+		}
+	}
+	return nil
+}
+
+// Returns up to max distinct acyclic dependency chains from package from to package to, shortest chains first. If max <= 0, returns all chains (this
+// can be very large for popular packages).
+func (p *PyPIGraph) Paths(from, to string, max int) [][]string {
+	from, to = p.NormalizedName(from), p.NormalizedName(to)
+	if from == to {
+		return [][]string{{from}}
+	}
+
+	// Breadth-first over partial paths yields chains in order of increasing length
+	paths := make([][]string, 0)
+	queue := [][]string{{from}}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		for _, dep := range p.Req[path[len(path)-1]] {
+			if containsString(path, dep) {
+				continue
+			}
+			next := make([]string, len(path)+1)
+			copy(next, path)
+			next[len(path)] = dep
+			if dep == to {
+				paths = append(paths, next)
+				if max > 0 && len(paths) >= max {
+					return paths
+				}
+				continue
+			}
+			queue = append(queue, next)
+		}
+	}
+	return paths
+}
+
+func tracePath(prev map[string]string, from, to string) []string {
+	var path []string
+	for pkg := to; pkg != from; pkg = prev[pkg] {
+		path = append(path, pkg)
+	}
+	path = append(path, from)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
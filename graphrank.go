@@ -0,0 +1,91 @@
+package cheerio
+
+import (
+	"math"
+	"sort"
+)
+
+// A package together with a ranking score
+type PkgScore struct {
+	Pkg   string
+	Score float64
+}
+
+// Returns the n packages with the most direct reverse dependencies, highest first. If n <= 0, returns every package.
+func (p *PyPIGraph) MostRequired(n int) []PkgScore {
+	scores := make([]PkgScore, 0, len(p.ReqBy))
+	for pkg, reqBy := range p.ReqBy {
+		scores = append(scores, PkgScore{Pkg: pkg, Score: float64(len(reqBy))})
+	}
+	return topScores(scores, n)
+}
+
+// Returns the n packages with the highest PageRank, highest first. If n <= 0, returns every package.
+func (p *PyPIGraph) TopPageRank(n int) []PkgScore {
+	ranks := p.PageRank(0.85, 1e-9, 100)
+	scores := make([]PkgScore, 0, len(ranks))
+	for pkg, rank := range ranks {
+		scores = append(scores, PkgScore{Pkg: pkg, Score: rank})
+	}
+	return topScores(scores, n)
+}
+
+// Computes the PageRank of every package, where each package passes its rank on to the packages it requires. Packages that require nothing spread
+// their rank evenly over the whole graph. Iterates until the total change in rank falls below tolerance or after maxIter iterations. The ranks sum
+// to 1.
+func (p *PyPIGraph) PageRank(damping, tolerance float64, maxIter int) map[string]float64 {
+	pkgs := p.sortedPkgs()
+	n := float64(len(pkgs))
+	if n == 0 {
+		return map[string]float64{}
+	}
+
+	rank := make(map[string]float64, len(pkgs))
+	for _, pkg := range pkgs {
+		rank[pkg] = 1 / n
+	}
+	for iter := 0; iter < maxIter; iter++ {
+		dangling := 0.0
+		for _, pkg := range pkgs {
+			if len(p.Req[pkg]) == 0 {
+				dangling += rank[pkg]
+			}
+		}
+
+		next := make(map[string]float64, len(pkgs))
+		base := (1-damping)/n + damping*dangling/n
+		for _, pkg := range pkgs {
+			next[pkg] += base
+			if deps := p.Req[pkg]; len(deps) > 0 {
+				share := damping * rank[pkg] / float64(len(deps))
+				for _, dep := range deps {
+					next[dep] += share
+				}
+			}
+		}
+
+		delta := 0.0
+		for _, pkg := range pkgs {
+			delta += math.Abs(next[pkg] - rank[pkg])
+		}
+		rank = next
+		if delta < tolerance {
+			break
+		}
+	}
+	return rank
+}
+
+// Sorts scores by decreasing score (then by name) and truncates to n if n > 0
+func topScores(scores []PkgScore, n int) []PkgScore {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Pkg < scores[j].Pkg
+	})
+	if n > 0 && n < len(scores) {
+		scores = scores[:n]
+	}
+	return scores
+}
@@ -0,0 +1,86 @@
+package cheerio
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sorted names of the packages of a graph, built on the first search and dropped when the graph changes
+type searchIndex struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// Drops the names, e.g., after a package was added
+func (s *searchIndex) reset() {
+	if s != nil {
+		s.mu.Lock()
+		s.names = nil
+		s.mu.Unlock()
+	}
+}
+
+// Returns the sorted names of the packages in the graph, including packages that are only known as dependencies
+func (p *PyPIGraph) searchNames() []string {
+	if p.search == nil {
+		return p.sortedPkgs()
+	}
+	p.search.mu.Lock()
+	defer p.search.mu.Unlock()
+	if p.search.names == nil {
+		p.search.names = p.sortedPkgs()
+	}
+	return p.search.names
+}
+
+// Returns the names of the packages in the graph matching pattern, for autocompletion and discovery. Patterns with "*" or "?" wildcards are
+// globs, and patterns enclosed in slashes are regular expressions (see PackageFilter), whose matches are returned sorted. Other patterns are
+// matched as substrings: packages whose name starts with pattern come first, followed by the other packages whose name contains it, each
+// sorted. Names and patterns are normalized (see NormalizedName), and at most limit names are returned if limit is positive.
+func (p *PyPIGraph) Search(pattern string, limit int) ([]string, error) {
+	names := p.searchNames()
+	full := func(matches []string) bool { return limit > 0 && len(matches) >= limit }
+	matches := make([]string, 0)
+
+	if strings.ContainsAny(pattern, "*?") || (len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/")) {
+		regexps, err := (&PackageFilter{ecosystem: p.Ecosystem()}).compilePatterns([]string{pattern})
+		if err != nil || len(regexps) == 0 {
+			return nil, err
+		}
+		// only scan the names starting with the literal prefix of a glob
+		if !strings.HasPrefix(pattern, "/") {
+			prefix := p.NormalizedName(pattern[:strings.IndexAny(pattern, "*?")])
+			names = names[sort.SearchStrings(names, prefix):]
+			for i, name := range names {
+				if !strings.HasPrefix(name, prefix) {
+					names = names[:i]
+					break
+				}
+			}
+		}
+		for _, name := range names {
+			if full(matches) {
+				break
+			}
+			if regexps[0].MatchString(name) {
+				matches = append(matches, name)
+			}
+		}
+		return matches, nil
+	}
+
+	query := p.NormalizedName(strings.TrimSpace(pattern))
+	for i := sort.SearchStrings(names, query); i < len(names) && strings.HasPrefix(names[i], query) && !full(matches); i++ {
+		matches = append(matches, names[i])
+	}
+	for _, name := range names {
+		if full(matches) {
+			break
+		}
+		if strings.Contains(name, query) && !strings.HasPrefix(name, query) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, nil
+}
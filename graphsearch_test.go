@@ -0,0 +1,43 @@
+package cheerio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	g := testGraph("app:flask", "flask:werkzeug", "flask-login:flask", "pytest-flask:flask", "django:sqlparse", "django-rest-framework:django")
+	g.addPkg("Flask_SQLAlchemy")
+
+	tests := []struct {
+		pattern string
+		limit   int
+		exp     []string
+	}{
+		{"flask", 0, []string{"flask", "flask-login", "flask-sqlalchemy", "pytest-flask"}},
+		{"Flask_", 0, []string{"flask-login", "flask-sqlalchemy"}},
+		{"flask", 2, []string{"flask", "flask-login"}},
+		{"django-*", 0, []string{"django-rest-framework"}},
+		{"*-flask", 0, []string{"pytest-flask"}},
+		{"/^(app|sqlparse)$/", 0, []string{"app", "sqlparse"}},
+		{"nothing", 0, []string{}},
+	}
+	for _, test := range tests {
+		matches, err := g.Search(test.pattern, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(matches, test.exp) {
+			t.Errorf("Search(%q, %d): expected %v, got %v", test.pattern, test.limit, test.exp, matches)
+		}
+	}
+
+	// the index is rebuilt when the graph changes
+	g.addEdge("flask-cors", "flask")
+	if matches, _ := g.Search("flask-c", 0); !reflect.DeepEqual(matches, []string{"flask-cors"}) {
+		t.Errorf("expected the new package to be found, got %v", matches)
+	}
+	if _, err := g.Search("/(/", 0); err == nil {
+		t.Errorf("expected an error for an invalid regexp")
+	}
+}
@@ -0,0 +1,65 @@
+package cheerio
+
+// Summary statistics of a PyPIGraph
+type GraphStats struct {
+	Packages int // number of packages
+	Edges    int // number of dependency edges
+
+	// Degree distributions: maps a degree to the number of packages with that degree
+	OutDegrees map[int]int // number of packages required
+	InDegrees  map[int]int // number of packages required by
+
+	Orphans  []string // packages that neither require nor are required by any other package, sorted
+	MaxDepth int      // length of the longest dependency chain, with each cycle collapsed into a single package
+	SCCs     int      // number of strongly connected components
+	Cycles   int      // number of strongly connected components with more than one package
+}
+
+// Computes summary statistics of the graph
+func (p *PyPIGraph) Stats() *GraphStats {
+	stats := &GraphStats{
+		OutDegrees: make(map[int]int),
+		InDegrees:  make(map[int]int),
+		Orphans:    make([]string, 0),
+	}
+
+	pkgs := p.sortedPkgs()
+	stats.Packages = len(pkgs)
+	for _, pkg := range pkgs {
+		out, in := len(p.Req[pkg]), len(p.ReqBy[pkg])
+		stats.Edges += out
+		stats.OutDegrees[out]++
+		stats.InDegrees[in]++
+		if out == 0 && in == 0 {
+			stats.Orphans = append(stats.Orphans, pkg)
+		}
+	}
+
+	// Components come out dependencies-first, so the depth of every dependency is known by the time a component is reached
+	sccs := p.StronglyConnectedComponents()
+	component := make(map[string]int)
+	for c, scc := range sccs {
+		for _, pkg := range scc {
+			component[pkg] = c
+		}
+	}
+	depth := make([]int, len(sccs))
+	for c, scc := range sccs {
+		for _, pkg := range scc {
+			for _, dep := range p.Req[pkg] {
+				if d := component[dep]; d != c && depth[d]+1 > depth[c] {
+					depth[c] = depth[d] + 1
+				}
+			}
+		}
+		if depth[c] > stats.MaxDepth {
+			stats.MaxDepth = depth[c]
+		}
+		if len(scc) > 1 {
+			stats.Cycles++
+		}
+	}
+	stats.SCCs = len(sccs)
+
+	return stats
+}
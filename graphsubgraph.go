@@ -0,0 +1,49 @@
+package cheerio
+
+// Returns the subgraph induced by the given seed packages and the packages they transitively require, up to depth levels of dependencies away from
+// the seeds (if depth <= 0, the full closure is included). Only edges between included packages are kept. This is useful for shipping small
+// per-project graph files instead of the whole index.
+func (p *PyPIGraph) SubgraphOf(pkgs []string, depth int) *PyPIGraph {
+	included := make(map[string]bool)
+	frontier := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		pkg = p.NormalizedName(pkg)
+		if !included[pkg] {
+			included[pkg] = true
+			frontier = append(frontier, pkg)
+		}
+	}
+	for level := 0; len(frontier) > 0 && (depth <= 0 || level < depth); level++ {
+		var next []string
+		for _, pkg := range frontier {
+			for _, dep := range p.Req[pkg] {
+				if !included[dep] {
+					included[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	sub := newEcosystemGraph(p.Ecosystem())
+	for pkg := range included {
+		if name, in := p.display[pkg]; in {
+			sub.normalize(name)
+		}
+		sub.addPkg(pkg)
+		for _, dep := range p.Req[pkg] {
+			if included[dep] && !p.optional[Edge{pkg, dep}] {
+				sub.addEdge(pkg, dep)
+			}
+		}
+		for extra, deps := range p.Extras[pkg] {
+			for _, dep := range deps {
+				if included[dep] {
+					sub.addExtraEdge(pkg, dep, extra)
+				}
+			}
+		}
+	}
+	return sub
+}
@@ -0,0 +1,70 @@
+package cheerio
+
+import (
+	"sort"
+	"strings"
+)
+
+// Prefixes that are often left out of or added to package names, e.g., "python-dateutil" for "dateutil"
+var suggestPrefixes = []string{"python-", "py-"}
+
+// Returns up to n packages of the graph whose names are close to pkg, best first, as "did you mean" hints when pkg isn't in the graph (Requires
+// and RequiredBy return nil for unknown packages). Close names are spelling variants that only differ from pkg in separators or in a "python-"
+// prefix (e.g., "djangorestframework" for "django-rest-framework"), and names within a few edits of pkg (see FindTyposquats), allowing more edits
+// for longer names. Candidates are ranked by edit distance, then by number of dependents, then by name. pkg itself is never suggested.
+func (p *PyPIGraph) Suggest(pkg string, n int) []string {
+	name := p.NormalizedName(pkg)
+	key := suggestKey(name)
+	maxDistance := 1
+	if len(name) >= 9 {
+		maxDistance = 3
+	} else if len(name) >= 5 {
+		maxDistance = 2
+	}
+
+	type suggestion struct {
+		pkg        string
+		distance   int
+		dependents int
+	}
+	var suggestions []suggestion
+	for _, candidate := range p.searchNames() {
+		if candidate == name {
+			continue
+		}
+		distance := 0
+		if suggestKey(candidate) != key {
+			if d := len(candidate) - len(name); d > maxDistance || -d > maxDistance {
+				continue
+			}
+			if distance = editDistance(name, candidate, maxDistance); distance > maxDistance {
+				continue
+			}
+		}
+		suggestions = append(suggestions, suggestion{candidate, distance, len(p.ReqBy[candidate])})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if a.dependents != b.dependents {
+			return a.dependents > b.dependents
+		}
+		return a.pkg < b.pkg
+	})
+
+	names := make([]string, 0, n)
+	for i := 0; i < len(suggestions) && i < n; i++ {
+		names = append(names, suggestions[i].pkg)
+	}
+	return names
+}
+
+// Returns the name without separators and common prefixes, so spelling variants of a name have the same key
+func suggestKey(name string) string {
+	for _, prefix := range suggestPrefixes {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return withoutSeparators(name)
+}
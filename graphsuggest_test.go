@@ -0,0 +1,26 @@
+package cheerio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	g := testGraph("app:requests", "lib:requests", "app:requests-oauthlib", "app:django-rest-framework", "app:python-dateutil", "app:request")
+
+	tests := []struct {
+		pkg string
+		exp []string
+	}{
+		{"reqeusts", []string{"requests", "request"}},
+		{"djangorestframework", []string{"django-rest-framework"}},
+		{"dateutil", []string{"python-dateutil"}},
+		{"requests", []string{"request"}},
+		{"zzzzzzzz", []string{}},
+	}
+	for _, test := range tests {
+		if got := g.Suggest(test.pkg, 2); !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("Suggest(%s): expected %v, got %v", test.pkg, test.exp, got)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package cheerio
+
+import (
+	"sort"
+)
+
+// Returns an install order for the given packages and everything they transitively require: each package appears after all of its dependencies. If
+// no packages are given, orders the entire graph. Packages that form a dependency cycle cannot be strictly ordered; they are emitted together
+// (sorted by name) at the point where the whole cycle's external dependencies have been satisfied. The second return value lists those cycles.
+func (p *PyPIGraph) TopoSort(pkgs ...string) ([]string, [][]string) {
+	g := p
+	if len(pkgs) > 0 {
+		g = p.SubgraphOf(pkgs, 0)
+	}
+
+	order := make([]string, 0, len(g.Req))
+	cycles := make([][]string, 0)
+	for _, scc := range g.StronglyConnectedComponents() {
+		if len(scc) > 1 {
+			sort.Strings(scc)
+			cycles = append(cycles, scc)
+		}
+		order = append(order, scc...)
+	}
+	return order, cycles
+}
@@ -0,0 +1,88 @@
+package cheerio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A package registry whose packages and requirements can be crawled into a dependency graph. PackageIndex (PyPI) implements it, as do the
+// registries of other ecosystems (see Ecosystems). Requirements from other ecosystems keep their version ranges in Requirement.Range, and
+// development-only dependencies have Extra "dev".
+type Index interface {
+	// Returns the names of all packages in the registry
+	AllPackages() ([]string, error)
+
+	// Returns the requirements of the latest release of a package
+	FetchPackageRequirements(pkg string) ([]*Requirement, error)
+
+	// Returns the source repository URL of a package
+	FetchSourceRepoURL(pkg string) (string, error)
+}
+
+// Default registries of the supported ecosystems, keyed by ecosystem name
+var Ecosystems = map[string]Index{
+	"pypi":      DefaultPyPI,
+	"npm":       DefaultNPM,
+	"rubygems":  DefaultRubyGems,
+	"crates":    DefaultCrates,
+	"maven":     DefaultMaven,
+	"packagist": DefaultPackagist,
+	"go":        DefaultGoProxy,
+	"nuget":     DefaultNuGet,
+	"conda":     DefaultConda,
+}
+
+// Returns the default registry of an ecosystem, e.g., "npm"
+func EcosystemIndex(ecosystem string) (Index, error) {
+	if idx, in := Ecosystems[strings.ToLower(ecosystem)]; in {
+		return idx, nil
+	}
+	names := make([]string, 0, len(Ecosystems))
+	for name := range Ecosystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("Unknown ecosystem '%s' (expected one of %s)", ecosystem, strings.Join(names, ", "))
+}
+
+// Returns the name of the ecosystem of an index (see Ecosystems), which decides how its package names are normalized (see
+// NormalizedEcosystemPkgName). Indexes of unknown types, and caches wrapping them, are taken to be PyPI indexes.
+func IndexEcosystem(idx Index) string {
+	switch idx := idx.(type) {
+	case *NPMRegistry:
+		return "npm"
+	case *RubyGems:
+		return "rubygems"
+	case *Crates:
+		return "crates"
+	case *MavenRepository:
+		return "maven"
+	case *Packagist:
+		return "packagist"
+	case *GoModuleProxy:
+		return "go"
+	case *NuGetFeed:
+		return "nuget"
+	case *CondaChannels:
+		return "conda"
+	case *CachedIndex:
+		return IndexEcosystem(idx.Index)
+	}
+	return "pypi"
+}
+
+// Returns requirements for a map from dependency name to native version range, sorted by name, with Extra set to extra. Dependencies already in
+// seen are skipped, and the others are added to it.
+func rangeRequirements(deps map[string]string, extra string, seen map[string]bool) []*Requirement {
+	names := sortedStringKeys(deps)
+	reqs := make([]*Requirement, 0, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		reqs = append(reqs, &Requirement{Name: name, Range: strings.TrimSpace(deps[name]), Extra: extra})
+	}
+	return reqs
+}
@@ -0,0 +1,71 @@
+package cheerio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// Default number of results sent to Kafka per request
+const DefaultKafkaBatchSize = 100
+
+// Produces results to a Kafka topic through a Confluent-compatible REST proxy (API v2), keyed by package name so that the results of a package
+// always land in the same partition. Results are sent in batches of BatchSize; Close sends the last, partial batch. A batch that fails to be sent
+// is kept, and sent again with the next one.
+type KafkaRESTSink struct {
+	TopicURL  string // e.g., "http://localhost:8082/topics/cheerio"
+	BatchSize int
+
+	batch []kafkaRecord
+}
+
+type kafkaRecord struct {
+	Key   string        `json:"key"`
+	Value PackageResult `json:"value"`
+}
+
+var kafkaTopicURLRegexp = regexp.MustCompile(`^https?://[^/]+(/.*)?/topics/[^/]+$`)
+
+// Returns a sink producing to the topic at topicURL, e.g., "http://localhost:8082/topics/cheerio"
+func NewKafkaRESTSink(topicURL string) (*KafkaRESTSink, error) {
+	if !kafkaTopicURLRegexp.MatchString(topicURL) {
+		return nil, fmt.Errorf("Not a Kafka REST proxy topic URL: %s", topicURL)
+	}
+	return &KafkaRESTSink{TopicURL: topicURL, BatchSize: DefaultKafkaBatchSize}, nil
+}
+
+func (s *KafkaRESTSink) Write(res PackageResult) error {
+	s.batch = append(s.batch, kafkaRecord{Key: res.Pkg, Value: res})
+	if len(s.batch) >= s.BatchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *KafkaRESTSink) Close() error {
+	return s.flush()
+}
+
+// Sends the buffered results to the REST proxy
+func (s *KafkaRESTSink) flush() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": s.batch})
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(s.TopicURL, "application/vnd.kafka.json.v2+json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Kafka REST proxy returned %s for %s", resp.Status, s.TopicURL)
+	}
+	s.batch = s.batch[:0]
+	return nil
+}
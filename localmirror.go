@@ -0,0 +1,92 @@
+package cheerio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Returns an index over a local mirror of PyPI, so graphs can be built without network access, e.g., in air-gapped environments. dir is the path or
+// file:// URI of a directory containing a simple/ index with a <pkg>/index.html page per package, as written by pip2pi or dir2pi, or of a
+// bandersnatch mirror, whose index is in web/simple/. Release files are read in place. The index's Client reads the files of the mirror, and
+// only those; it must be kept if the URI is copied to another index.
+func NewLocalMirror(dir string) (*PackageIndex, error) {
+	if strings.HasPrefix(dir, "file://") {
+		u, err := url.Parse(dir)
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.FromSlash(u.Path)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filepath.Join(dir, "web", "simple")); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, "web")
+	} else if info, err := os.Stat(filepath.Join(dir, "simple")); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a mirror: it has neither a simple/ nor a web/simple/ directory", dir)
+	}
+
+	// the index pages of the mirror are read through the index's client, like those of remote indexes
+	index := NewPackageIndex((&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String())
+	index.Client = &http.Client{Transport: &mirrorTransport{dir: dir, files: http.NewFileTransport(http.Dir(dir))}}
+	return index, nil
+}
+
+// Serves the file:// requests of a local mirror index from the mirror directory, refusing requests for files outside of it
+type mirrorTransport struct {
+	dir   string
+	files http.RoundTripper // rooted at dir
+}
+
+func (t *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "file" {
+		return nil, fmt.Errorf("%s is not a file of the local mirror %s", req.URL, t.dir)
+	}
+	rel, err := filepath.Rel(t.dir, filepath.FromSlash(req.URL.Path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is outside of the local mirror %s", req.URL, t.dir)
+	}
+	files := req.Clone(req.Context())
+	files.URL.Path = "/" + filepath.ToSlash(rel)
+	if strings.HasSuffix(req.URL.Path, "/") && rel != "." {
+		files.URL.Path += "/" // index pages are served for directories
+	}
+	resp, err := t.files.RoundTrip(files)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req // links of index pages are relative to the mirror URL
+	return resp, nil
+}
+
+// Returns the directory of a local mirror from the URI of its index, and whether the URI is that of a local mirror
+func mirrorDir(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+// Returns the names of the packages that have a page in a local mirror's simple/ index, sorted
+func mirrorPackages(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "simple"))
+	if err != nil {
+		return nil, err
+	}
+	pkgs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			pkgs = append(pkgs, entry.Name())
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
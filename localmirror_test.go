@@ -0,0 +1,74 @@
+package cheerio
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLocalMirror(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio-mirror-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a bandersnatch mirror: index pages in web/simple/, files in web/packages/
+	write := func(name string, contents []byte) {
+		path := filepath.Join(dir, "web", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("simple/flask/index.html", []byte(`<a href="../../packages/ab/cd/Flask-1.0.tar.gz#sha256=0123abcd">Flask-1.0.tar.gz</a><br/>`))
+	write("simple/six/index.html", []byte(`<a href="../../packages/ef/01/six-1.0.tar.gz#sha256=0123abcd">six-1.0.tar.gz</a><br/>`))
+
+	var sdist bytes.Buffer
+	gz := gzip.NewWriter(&sdist)
+	tw := tar.NewWriter(gz)
+	requires := []byte("six>=1.0\n")
+	tw.WriteHeader(&tar.Header{Name: "Flask-1.0/Flask.egg-info/requires.txt", Mode: 0644, Size: int64(len(requires))})
+	tw.Write(requires)
+	tw.Close()
+	gz.Close()
+	write("packages/ab/cd/Flask-1.0.tar.gz", sdist.Bytes())
+
+	if _, err := NewLocalMirror(filepath.Join(dir, "web", "packages")); err == nil {
+		t.Errorf("NewLocalMirror: expected an error for a directory without index")
+	}
+	mirror, err := NewLocalMirror("file://" + filepath.ToSlash(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirror.Logger = NopLogger{}
+
+	if pkgs, err := mirror.AllPackages(); err != nil || !reflect.DeepEqual(pkgs, []string{"flask", "six"}) {
+		t.Errorf("AllPackages: expected [flask six], got %v, %v", pkgs, err)
+	}
+	reqs, err := mirror.FetchPackageRequirements("flask")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || reqs[0].Name != "six" {
+		t.Errorf("FetchPackageRequirements: expected six, got %v", reqs)
+	}
+
+	// files outside of the mirror aren't served, and http.DefaultTransport isn't changed
+	if resp, err := mirror.Client.Get("file://" + filepath.ToSlash(filepath.Join(dir, "web", "..", "secret"))); err == nil {
+		resp.Body.Close()
+		t.Errorf("expected a file outside of the mirror to be refused")
+	}
+	if resp, err := http.Get("file://" + filepath.ToSlash(filepath.Join(dir, "web", "simple", "six", "index.html"))); err == nil {
+		resp.Body.Close()
+		t.Errorf("expected http.DefaultClient not to read local files")
+	}
+}
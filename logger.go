@@ -0,0 +1,50 @@
+package cheerio
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Receives the diagnostics of cheerio, e.g., requirements that couldn't be parsed. tag categorizes the message, e.g., "req" for requirement
+// parsing or "depsdev" for the deps.dev API. Implementations must be safe for concurrent use.
+type Logger interface {
+	Logf(tag, format string, args ...interface{})
+}
+
+// Writes "[tag] message" lines to stderr
+type StderrLogger struct{}
+
+func (StderrLogger) Logf(tag, format string, args ...interface{}) {
+	os.Stderr.WriteString(fmt.Sprintf("[%s] %s\n", tag, fmt.Sprintf(format, args...)))
+}
+
+// Discards all diagnostics
+type NopLogger struct{}
+
+func (NopLogger) Logf(tag, format string, args ...interface{}) {}
+
+// Logs diagnostics as warnings of a structured logger, with the tag as the "tag" attribute
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{Logger: l}
+}
+
+func (s *SlogLogger) Logf(tag, format string, args ...interface{}) {
+	s.Logger.Warn(fmt.Sprintf(format, args...), slog.String("tag", tag))
+}
+
+// The logger of package-level functions, e.g., ParseRequirements, and of indexes whose Logger is nil. Not safe to change concurrently with
+// their use.
+var DefaultLogger Logger = StderrLogger{}
+
+// Returns l, or DefaultLogger if l is nil
+func loggerOr(l Logger) Logger {
+	if l == nil {
+		return DefaultLogger
+	}
+	return l
+}
@@ -0,0 +1,43 @@
+package cheerio
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Records the diagnostics it receives
+type recordingLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (r *recordingLogger) Logf(tag, format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, fmt.Sprintf("[%s] %s", tag, fmt.Sprintf(format, args...)))
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	reqs, err := parseRequirements("flask>=1.0\n?? not a requirement\n", ParseLenient, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 {
+		t.Errorf("expected 1 requirement, got %d", len(reqs))
+	}
+	exp := "[req] Could not parse requirement: Unable to parse requirement from string on line 2: '?? not a requirement'"
+	if len(logger.logs) != 1 || logger.logs[0] != exp {
+		t.Errorf("expected log %q, got %q", exp, logger.logs)
+	}
+
+	var buf bytes.Buffer
+	NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil))).Logf("depsdev", "deps.dev reported an error for %s", "flask")
+	if out := buf.String(); !strings.Contains(out, `level=WARN msg="deps.dev reported an error for flask" tag=depsdev`) {
+		t.Errorf("unexpected slog output %q", out)
+	}
+}
@@ -0,0 +1,88 @@
+package cheerio
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// A bounded in-process cache of parsed lookups, e.g., set as PackageIndex.Cache so that hot packages aren't fetched and parsed again on every
+// call. Once it holds Size entries, adding one evicts the least recently used; entries older than TTL are dropped when looked up. Values are
+// shared between callers, so they must not be modified. It is safe for concurrent use.
+type LRUCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List // of *lruEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// Returns a cache of at most size entries, which expire after ttl (never if ttl is 0)
+func NewLRUCache(size int, ttl time.Duration) *LRUCache {
+	return &LRUCache{size: size, ttl: ttl, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Returns the value cached under key, and whether there is one that hasn't expired
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, in := c.entries[key]
+	if !in {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Caches value under key, replacing any previous value
+func (c *LRUCache) Add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry{key: key, value: value, expires: time.Now().Add(c.ttl)}
+	if elem, in := c.entries[key]; in {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Returns the number of cached entries, including expired ones that haven't been dropped yet
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Returns the value cached under key in c, or calls fetch and caches its value if it succeeds. A nil cache caches nothing.
+func (c *LRUCache) memoize(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return fetch()
+	}
+	if value, in := c.Get(key); in {
+		return value, nil
+	}
+	value, err := fetch()
+	if err == nil {
+		c.Add(key, value)
+	}
+	return value, err
+}
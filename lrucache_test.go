@@ -0,0 +1,64 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a") // b is now the least recently used
+	c.Add("c", 3)
+	if _, in := c.Get("b"); in {
+		t.Errorf("expected b to be evicted")
+	}
+	if v, in := c.Get("a"); !in || v != 1 {
+		t.Errorf("expected a to be cached, got %v", v)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+
+	c = NewLRUCache(10, time.Millisecond)
+	c.Add("a", 1)
+	time.Sleep(5 * time.Millisecond)
+	if _, in := c.Get("a"); in {
+		t.Errorf("expected a to expire")
+	}
+}
+
+func TestPackageIndexCache(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch strings.ToLower(r.URL.Path) {
+		case "/pypi/flask/json":
+			fmt.Fprint(w, `{"info": {"requires_dist": ["Werkzeug (>=2.0)"]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL, Sources: []RequirementsSource{SourceJSON}, Cache: NewLRUCache(100, time.Hour), Logger: NopLogger{}}
+	for _, name := range []string{"flask", "Flask", "FLASK"} {
+		reqs, err := index.FetchPackageRequirements(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reqs) != 1 || reqs[0].Name != "Werkzeug" {
+			t.Errorf("unexpected requirements %+v", reqs)
+		}
+		index.FetchPackageRequirements("missing")
+	}
+	if n := atomic.LoadInt32(&requests); n != 4 {
+		t.Errorf("expected 1 request for the cached package, whatever its spelling, and 3 for the missing one, got %d", n)
+	}
+}
@@ -0,0 +1,361 @@
+package cheerio
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The values of PEP 508 environment marker variables (e.g., "python_version" or "sys_platform") for a target environment
+type MarkerEnv map[string]string
+
+// Platform-dependent marker values, keyed by sys_platform
+var markerPlatforms = map[string]MarkerEnv{
+	"linux":  {"os_name": "posix", "platform_system": "Linux", "platform_machine": "x86_64"},
+	"darwin": {"os_name": "posix", "platform_system": "Darwin", "platform_machine": "x86_64"},
+	"win32":  {"os_name": "nt", "platform_system": "Windows", "platform_machine": "AMD64"},
+	"cygwin": {"os_name": "posix", "platform_system": "CYGWIN_NT-10.0", "platform_machine": "x86_64"},
+}
+
+// Returns the marker environment of CPython at pythonVersion (e.g., "3.11" or "2.7.18") on platform, a sys_platform value such as "linux",
+// "darwin", or "win32". Variables that can't be derived from these, such as platform_release, are left empty; set them on the returned map if
+// needed.
+func NewMarkerEnv(pythonVersion, platform string) (MarkerEnv, error) {
+	platformEnv, in := markerPlatforms[platform]
+	if !in {
+		return nil, fmt.Errorf("Unknown platform '%s' (expected one of linux, darwin, win32, or cygwin)", platform)
+	}
+	v, err := parseVersion(pythonVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	fullVersion := fmt.Sprintf("%d.%d.%d", v.releaseN(3)[0], v.releaseN(3)[1], v.releaseN(3)[2])
+	env := MarkerEnv{
+		"python_version":                 fmt.Sprintf("%d.%d", v.releaseN(2)[0], v.releaseN(2)[1]),
+		"python_full_version":            fullVersion,
+		"implementation_name":            "cpython",
+		"implementation_version":         fullVersion,
+		"platform_python_implementation": "CPython",
+		"sys_platform":                   platform,
+		"platform_release":               "",
+		"platform_version":               "",
+		"extra":                          "",
+	}
+	for name, value := range platformEnv {
+		env[name] = value
+	}
+	return env, nil
+}
+
+// Variables whose values are compared as versions when both sides of a comparison are valid versions
+var markerVersionVars = map[string]bool{"python_version": true, "python_full_version": true, "implementation_version": true, "platform_release": true}
+
+// Legacy (PEP 345) spellings of marker variables
+var legacyMarkerVars = map[string]string{
+	"os.name":                        "os_name",
+	"sys.platform":                   "sys_platform",
+	"platform.version":               "platform_version",
+	"platform.machine":               "platform_machine",
+	"platform.python_implementation": "platform_python_implementation",
+	"python_implementation":          "platform_python_implementation",
+	"platform.python_version":        "python_full_version",
+}
+
+// Evaluates a PEP 508 environment marker, e.g., "python_version < '3.8' and sys_platform != 'win32'", against env
+func EvalMarker(marker string, env MarkerEnv) (bool, error) {
+	tokens, err := markerTokens(marker)
+	if err != nil {
+		return false, err
+	}
+	p := &markerParser{tokens: tokens, env: env}
+	result, err := p.or()
+	if err != nil {
+		return false, err
+	}
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("[marker] Unexpected '%s' in marker: %s", p.tokens[p.pos], marker)
+	}
+	return result, nil
+}
+
+// Returns whether a requirement applies in env: its marker (if any) must evaluate to true, and a requirement that is only needed for an extra
+// applies only if env's "extra" is that extra
+func (r *Requirement) AppliesTo(env MarkerEnv) (bool, error) {
+	if r.Extra != "" && normalizeSeparators(r.Extra) != normalizeSeparators(env["extra"]) {
+		return false, nil
+	}
+	if r.Marker == "" {
+		return true, nil
+	}
+	return EvalMarker(r.Marker, env)
+}
+
+var extraMarkerRegexp = regexp.MustCompile(`^extra\s*==\s*["']([^"']*)["']$`)
+
+// Splits an "extra == ..." clause off a marker, returning the (normalized) extra and the rest of the marker, e.g., "test" and
+// `python_version >= "3.8"` for `extra == "test" and python_version >= "3.8"`. Markers without such a clause, or combining it with others by
+// "or", are returned whole.
+func splitExtraMarker(marker string) (extra, rest string) {
+	clauses := markerAndClauses(marker)
+	var others []string
+	for _, clause := range clauses {
+		if match := extraMarkerRegexp.FindStringSubmatch(unparenthesize(clause)); match != nil && extra == "" {
+			extra = NormalizedPkgName(match[1]) // PEP 685
+		} else {
+			others = append(others, clause)
+		}
+	}
+	if extra == "" {
+		return "", marker
+	}
+	if len(others) == 1 {
+		return extra, unparenthesize(others[0])
+	}
+	return extra, strings.Join(others, " and ")
+}
+
+// Returns the clauses of a marker joined by "and" outside of parentheses, e.g., ["(a or b)", "c"] for "(a or b) and c", or nil if the marker
+// joins clauses by "or" outside of parentheses
+func markerAndClauses(marker string) []string {
+	var clauses []string
+	depth, quote, start := 0, byte(0), 0
+	for i := 0; i < len(marker); i++ {
+		switch c := marker[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(marker[i:], " or "):
+			return nil
+		case depth == 0 && strings.HasPrefix(marker[i:], " and "):
+			clauses = append(clauses, strings.TrimSpace(marker[start:i]))
+			start = i + len(" and ")
+			i += len(" and ") - 1
+		}
+	}
+	return append(clauses, strings.TrimSpace(marker[start:]))
+}
+
+// Removes the parentheses around a whole marker, e.g., "(a or b)" becomes "a or b", but "(a) and (b)" is returned as is
+func unparenthesize(marker string) string {
+	marker = strings.TrimSpace(marker)
+	for strings.HasPrefix(marker, "(") && strings.HasSuffix(marker, ")") {
+		depth := 0
+		for i := 0; i < len(marker)-1; i++ {
+			if marker[i] == '(' {
+				depth++
+			} else if marker[i] == ')' {
+				depth--
+			}
+			if depth == 0 {
+				return marker
+			}
+		}
+		marker = strings.TrimSpace(marker[1 : len(marker)-1])
+	}
+	return marker
+}
+
+// Returns the requirements that apply in env (see AppliesTo)
+func FilterRequirements(reqs []*Requirement, env MarkerEnv) ([]*Requirement, error) {
+	filtered := make([]*Requirement, 0, len(reqs))
+	for _, req := range reqs {
+		applies, err := req.AppliesTo(env)
+		if err != nil {
+			return nil, fmt.Errorf("Requirement %s: %s", req.Name, err)
+		}
+		if applies {
+			filtered = append(filtered, req)
+		}
+	}
+	return filtered, nil
+}
+
+// Splits a marker into tokens: parentheses, operators, quoted strings (kept with their quotes), and words
+func markerTokens(marker string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(marker); {
+		c := marker[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(marker[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("[marker] Unterminated string in marker: %s", marker)
+			}
+			tokens = append(tokens, marker[i:i+end+2])
+			i += end + 2
+		case strings.ContainsRune("<>=!~", rune(c)):
+			j := i
+			for j < len(marker) && strings.ContainsRune("<>=!~", rune(marker[j])) {
+				j++
+			}
+			tokens = append(tokens, marker[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(marker) && !strings.ContainsRune(" \t()\"'<>=!~", rune(marker[j])) {
+				j++
+			}
+			tokens = append(tokens, marker[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// A recursive descent parser and evaluator for the marker grammar of PEP 508
+type markerParser struct {
+	tokens []string
+	pos    int
+	env    MarkerEnv
+}
+
+func (p *markerParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *markerParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *markerParser) or() (bool, error) {
+	result, err := p.and()
+	if err != nil {
+		return false, err
+	}
+	for p.peek() == "or" {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return false, err
+		}
+		result = result || right
+	}
+	return result, nil
+}
+
+func (p *markerParser) and() (bool, error) {
+	result, err := p.atom()
+	if err != nil {
+		return false, err
+	}
+	for p.peek() == "and" {
+		p.pos++
+		right, err := p.atom()
+		if err != nil {
+			return false, err
+		}
+		result = result && right
+	}
+	return result, nil
+}
+
+func (p *markerParser) atom() (bool, error) {
+	if p.peek() == "(" {
+		p.pos++
+		result, err := p.or()
+		if err != nil {
+			return false, err
+		}
+		if p.next() != ")" {
+			return false, fmt.Errorf("[marker] Expected ')'")
+		}
+		return result, nil
+	}
+
+	left, leftVar, err := p.value()
+	if err != nil {
+		return false, err
+	}
+	op := p.next()
+	if op == "not" {
+		if p.next() != "in" {
+			return false, fmt.Errorf("[marker] Expected 'in' after 'not'")
+		}
+		op = "not in"
+	}
+	right, rightVar, err := p.value()
+	if err != nil {
+		return false, err
+	}
+	return compareMarkerValues(left, op, right, markerVersionVars[leftVar] || markerVersionVars[rightVar], leftVar == "extra" || rightVar == "extra")
+}
+
+// Returns the value of a quoted string or variable token, and the variable's name if it is a variable
+func (p *markerParser) value() (string, string, error) {
+	tok := p.next()
+	if tok == "" {
+		return "", "", fmt.Errorf("[marker] Unexpected end of marker")
+	}
+	if tok[0] == '"' || tok[0] == '\'' {
+		return tok[1 : len(tok)-1], "", nil
+	}
+	name := tok
+	if modern, in := legacyMarkerVars[name]; in {
+		name = modern
+	}
+	value, in := p.env[name]
+	if !in {
+		return "", "", fmt.Errorf("[marker] Unknown marker variable '%s'", tok)
+	}
+	return value, name, nil
+}
+
+// Applies a marker operator. Comparisons involving version variables use PEP 440 semantics when both sides are valid versions; all others compare
+// strings. Extra names are compared normalized.
+func compareMarkerValues(left, op, right string, versions, extras bool) (bool, error) {
+	if extras {
+		left, right = normalizeSeparators(left), normalizeSeparators(right)
+	}
+
+	switch op {
+	case "in":
+		return strings.Contains(right, left), nil
+	case "not in":
+		return !strings.Contains(right, left), nil
+	case "==", "!=", "<", "<=", ">", ">=", "~=", "===":
+	default:
+		return false, fmt.Errorf("[marker] Unknown marker operator '%s'", op)
+	}
+
+	if versions && op != "===" {
+		if _, err := parseVersion(left); err == nil {
+			if _, err := parseVersion(right); err == nil || strings.HasSuffix(right, ".*") {
+				return versionMatches(left, op, right)
+			}
+		}
+	}
+
+	switch op {
+	case "==", "===":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	case "<":
+		return left < right, nil
+	case "<=":
+		return left <= right, nil
+	case ">":
+		return left > right, nil
+	case ">=":
+		return left >= right, nil
+	}
+	return false, fmt.Errorf("[marker] Operator '%s' requires version operands, got '%s' and '%s'", op, left, right)
+}
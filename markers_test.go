@@ -0,0 +1,100 @@
+package cheerio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvalMarker(t *testing.T) {
+	linux311, err := NewMarkerEnv("3.11", "linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	win27, err := NewMarkerEnv("2.7.18", "win32")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		marker          string
+		linux311, win27 bool
+	}{
+		{`python_version < "3"`, false, true},
+		{`python_version >= '3.6' and sys_platform != 'win32'`, true, false},
+		{`python_version<'3.8' or os_name=="nt"`, false, true},
+		{`(python_version == "2.7" or python_version >= "3.10") and platform_system == "Linux"`, true, false},
+		{`python_full_version >= "3.11.0"`, true, false},
+		{`python_version == "3.*"`, true, false},
+		{`"linux" in sys_platform`, true, false},
+		{`"win" not in sys_platform`, true, false},
+		{`platform_python_implementation == 'CPython' and implementation_name == "cpython"`, true, true},
+		{`sys.platform == "win32"`, false, true},
+		{`python_version > "3.9" and python_version < "3.12"`, true, false},
+	}
+	for _, test := range tests {
+		if result, err := EvalMarker(test.marker, linux311); err != nil {
+			t.Errorf("EvalMarker(%q): %s", test.marker, err)
+		} else if result != test.linux311 {
+			t.Errorf("EvalMarker(%q) on CPython 3.11/linux: expected %v, got %v", test.marker, test.linux311, result)
+		}
+		if result, err := EvalMarker(test.marker, win27); err != nil {
+			t.Errorf("EvalMarker(%q): %s", test.marker, err)
+		} else if result != test.win27 {
+			t.Errorf("EvalMarker(%q) on CPython 2.7/win32: expected %v, got %v", test.marker, test.win27, result)
+		}
+	}
+
+	for _, bad := range []string{`python_version <`, `foo == "1"`, `(python_version < "3"`, `python_version < "3" xor os_name == "nt"`, `os_name == 'nt`} {
+		if _, err := EvalMarker(bad, linux311); err == nil {
+			t.Errorf("EvalMarker(%q): expected error", bad)
+		}
+	}
+}
+
+func TestFilterRequirements(t *testing.T) {
+	reqs, err := ParseRequirements(`six
+
+[:sys_platform == "win32"]
+colorama
+
+[test]
+pytest
+
+[Docs:python_version >= "3"]
+sphinx
+sphinx-rtd-theme[dev, fonts]>=1.0; os_name == "nt"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := reqs[len(reqs)-1]; last.Name != "sphinx-rtd-theme" || last.Extra != "docs" || last.Marker != `(python_version >= "3") and (os_name == "nt")` {
+		t.Errorf("expected the section's extra and both markers, got %+v", last)
+	}
+	env, _ := NewMarkerEnv("3.11", "linux")
+
+	filtered, err := FilterRequirements(reqs, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := reqNames(filtered); !reflect.DeepEqual(names, []string{"six"}) {
+		t.Errorf("FilterRequirements: expected [six], got %v", names)
+	}
+
+	env["extra"] = "docs"
+	env["sys_platform"] = "win32"
+	filtered, err = FilterRequirements(reqs, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := reqNames(filtered); !reflect.DeepEqual(names, []string{"six", "colorama", "sphinx"}) {
+		t.Errorf("FilterRequirements: expected [six colorama sphinx], got %v", names)
+	}
+}
+
+func reqNames(reqs []*Requirement) []string {
+	names := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		names = append(names, req.Name)
+	}
+	return names
+}
@@ -0,0 +1,321 @@
+package cheerio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var DefaultMaven = &MavenRepository{URI: "https://repo1.maven.org/maven2"}
+
+// A Maven repository (e.g., Maven Central) of Java packages. Packages are named "groupId/artifactId", e.g., "com.google.guava/guava", since ":" is
+// the graph file separator. Requirements are read from POM files, with properties, parent POMs, and dependency management (including imported
+// BOMs) resolved.
+type MavenRepository struct {
+	URI string
+}
+
+// The parts of a POM file used here
+type mavenPOM struct {
+	GroupID    string       `xml:"groupId"`
+	ArtifactID string       `xml:"artifactId"`
+	Version    string       `xml:"version"`
+	URL        string       `xml:"url"`
+	Parent     *mavenParent `xml:"parent"`
+	Properties mavenProps   `xml:"properties"`
+	Managed    []*mavenDep  `xml:"dependencyManagement>dependencies>dependency"`
+	Deps       []*mavenDep  `xml:"dependencies>dependency"`
+	SCM        mavenSCM     `xml:"scm"`
+}
+
+type mavenParent struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type mavenProps struct {
+	Entries []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+type mavenDep struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+	Scope      string `xml:"scope"`
+	Optional   string `xml:"optional"`
+}
+
+type mavenSCM struct {
+	URL        string `xml:"url"`
+	Connection string `xml:"connection"`
+}
+
+// A POM with its inherited and interpolated data
+type effectivePOM struct {
+	pom     *mavenPOM
+	props   map[string]string
+	managed map[string]*mavenDep // "groupId/artifactId" -> managed dependency
+	repoURL []string             // candidate repository URLs, own first
+}
+
+// Maximum depth of parent POM chains and BOM imports
+const maxMavenPOMDepth = 10
+
+// Listing all artifacts isn't supported, since Maven repositories have no package list short of the full repository index
+func (m *MavenRepository) AllPackages() ([]string, error) {
+	return nil, fmt.Errorf("Listing all packages of a Maven repository isn't supported")
+}
+
+// Returns the requirements of the latest release of a package ("groupId/artifactId"). Test dependencies have Extra "dev", provided dependencies
+// Extra "provided", and optional dependencies Extra "optional".
+func (m *MavenRepository) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	version, err := m.LatestVersion(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return m.FetchPackageRequirementsAt(pkg, version)
+}
+
+// Returns the requirements of a version of a package (see FetchPackageRequirements)
+func (m *MavenRepository) FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error) {
+	group, artifact, err := splitMavenPkg(pkg)
+	if err != nil {
+		return nil, err
+	}
+	eff, err := m.effectivePOM(group, artifact, version, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := make([]*Requirement, 0, len(eff.pom.Deps))
+	for _, dep := range eff.pom.Deps {
+		group, artifact := eff.interpolate(dep.GroupID), eff.interpolate(dep.ArtifactID)
+		req := &Requirement{Name: group + "/" + artifact, Range: eff.interpolate(dep.Version)}
+		scope, optional := eff.interpolate(dep.Scope), eff.interpolate(dep.Optional)
+		if managed, in := eff.managed[req.Name]; in {
+			if req.Range == "" {
+				req.Range = managed.Version
+			}
+			if scope == "" {
+				scope = managed.Scope
+			}
+		}
+		switch {
+		case scope == "system" || scope == "import":
+			continue
+		case scope == "test":
+			req.Extra = "dev"
+		case scope == "provided":
+			req.Extra = "provided"
+		case optional == "true":
+			req.Extra = "optional"
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// Returns the source repository URL of the latest release of a package from the SCM or project URL of its POM (or its parents)
+func (m *MavenRepository) FetchSourceRepoURL(pkg string) (string, error) {
+	group, artifact, err := splitMavenPkg(pkg)
+	if err != nil {
+		return "", err
+	}
+	version, err := m.LatestVersion(pkg)
+	if err != nil {
+		return "", err
+	}
+	eff, err := m.effectivePOM(group, artifact, version, 0)
+	if err != nil {
+		return "", err
+	}
+	var candidates []repoCandidate
+	for _, u := range eff.repoURL {
+		if canonical, err := CanonicalRepoURL(scmURLPrefixRegexp.ReplaceAllString(u, "")); err == nil {
+			candidates = append(candidates, repoCandidate{canonical, RepoFromProjectURL})
+		}
+	}
+	if repoURL, _ := matchRepoURL(candidates); repoURL != "" {
+		return repoURL, nil
+	}
+	return "", fmt.Errorf("Could not parse repo URL from POM of %s", pkg)
+}
+
+var scmURLPrefixRegexp = regexp.MustCompile(`^scm:[a-z]+:`)
+
+// Returns the latest release of a package from its maven-metadata.xml
+func (m *MavenRepository) LatestVersion(pkg string) (string, error) {
+	group, artifact, err := splitMavenPkg(pkg)
+	if err != nil {
+		return "", err
+	}
+	var metadata struct {
+		Release  string   `xml:"versioning>release"`
+		Latest   string   `xml:"versioning>latest"`
+		Versions []string `xml:"versioning>versions>version"`
+	}
+	if err := m.getXML(&metadata, fmt.Sprintf("%s/%s/maven-metadata.xml", strings.Replace(group, ".", "/", -1), artifact)); err != nil {
+		return "", err
+	}
+	switch {
+	case metadata.Release != "":
+		return metadata.Release, nil
+	case metadata.Latest != "":
+		return metadata.Latest, nil
+	case len(metadata.Versions) > 0:
+		return metadata.Versions[len(metadata.Versions)-1], nil
+	}
+	return "", fmt.Errorf("[no-files] %w: no versions of %s listed", ErrNoReleases, pkg)
+}
+
+// Fetches a POM and resolves its parents, properties, and dependency management
+func (m *MavenRepository) effectivePOM(group, artifact, version string, depth int) (*effectivePOM, error) {
+	if depth > maxMavenPOMDepth {
+		return nil, fmt.Errorf("POM parents or imports of %s/%s nested too deeply", group, artifact)
+	}
+	var pom mavenPOM
+	path := fmt.Sprintf("%s/%s/%s/%s-%s.pom", strings.Replace(group, ".", "/", -1), artifact, version, artifact, version)
+	if err := m.getXML(&pom, path); err != nil {
+		return nil, err
+	}
+
+	eff := &effectivePOM{pom: &pom, props: make(map[string]string), managed: make(map[string]*mavenDep)}
+	var parent *effectivePOM
+	if pom.Parent != nil {
+		var err error
+		if parent, err = m.effectivePOM(pom.Parent.GroupID, pom.Parent.ArtifactID, pom.Parent.Version, depth+1); err != nil {
+			return nil, fmt.Errorf("Resolving parent POM: %s", err)
+		}
+		for k, v := range parent.props {
+			eff.props[k] = v
+		}
+		if pom.GroupID == "" {
+			pom.GroupID = pom.Parent.GroupID
+		}
+		if pom.Version == "" {
+			pom.Version = pom.Parent.Version
+		}
+		eff.props["project.parent.groupId"], eff.props["project.parent.version"] = pom.Parent.GroupID, pom.Parent.Version
+	}
+	for _, entry := range pom.Properties.Entries {
+		eff.props[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	eff.props["project.groupId"], eff.props["project.artifactId"], eff.props["project.version"] = pom.GroupID, pom.ArtifactID, pom.Version
+	eff.props["pom.groupId"], eff.props["pom.artifactId"], eff.props["pom.version"] = pom.GroupID, pom.ArtifactID, pom.Version
+
+	eff.repoURL = []string{pom.SCM.URL, pom.SCM.Connection, pom.URL}
+	if parent != nil {
+		for k, v := range parent.managed {
+			eff.managed[k] = v
+		}
+		eff.repoURL = append(eff.repoURL, parent.repoURL...)
+	}
+	for _, dep := range pom.Managed {
+		managed := &mavenDep{
+			GroupID:    eff.interpolate(dep.GroupID),
+			ArtifactID: eff.interpolate(dep.ArtifactID),
+			Version:    eff.interpolate(dep.Version),
+			Type:       eff.interpolate(dep.Type),
+			Scope:      eff.interpolate(dep.Scope),
+		}
+		if managed.Scope == "import" && managed.Type == "pom" {
+			bom, err := m.effectivePOM(managed.GroupID, managed.ArtifactID, managed.Version, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("Importing BOM: %s", err)
+			}
+			for k, v := range bom.managed {
+				if _, in := eff.managed[k]; !in {
+					eff.managed[k] = v
+				}
+			}
+			continue
+		}
+		eff.managed[managed.GroupID+"/"+managed.ArtifactID] = managed
+	}
+	return eff, nil
+}
+
+var mavenPropertyRegexp = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// Replaces "${property}" references with the values of the POM's properties, leaving unknown properties as they are
+func (eff *effectivePOM) interpolate(s string) string {
+	s = strings.TrimSpace(s)
+	for i := 0; i < maxMavenPOMDepth && strings.Contains(s, "${"); i++ {
+		replaced := mavenPropertyRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+			if value, in := eff.props[ref[2:len(ref)-1]]; in {
+				return value
+			}
+			return ref
+		})
+		if replaced == s {
+			break
+		}
+		s = replaced
+	}
+	return s
+}
+
+func (m *MavenRepository) getXML(v interface{}, path string) error {
+	resp, err := http.Get(m.URI + "/" + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return notFoundIf(resp.StatusCode, fmt.Errorf("Maven repository returned %s for %s", resp.Status, path))
+	}
+	decoder := xml.NewDecoder(resp.Body)
+	decoder.CharsetReader = xmlCharsetReader
+	return decoder.Decode(v)
+}
+
+// Windows-1252 characters of bytes 0x80-0x9F, which ISO-8859-1 leaves to control characters; 0 where undefined
+var windows1252Runes = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// Decodes XML declaring a non-UTF-8 encoding, e.g., the encoding="ISO-8859-1" of many POMs on Maven Central. Supports ISO-8859-1 (Latin-1),
+// Windows-1252 and ASCII.
+func xmlCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	windows1252 := false
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "latin-1", "l1", "us-ascii", "ascii":
+	case "windows-1252", "cp1252", "x-cp1252":
+		windows1252 = true
+	default:
+		return nil, fmt.Errorf("Unsupported XML encoding %s", label)
+	}
+	b, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	var decoded strings.Builder
+	decoded.Grow(len(b))
+	for _, c := range b {
+		if r := windows1252Runes[c&0x1F]; windows1252 && c >= 0x80 && c < 0xA0 && r != 0 {
+			decoded.WriteRune(r)
+		} else {
+			decoded.WriteRune(rune(c))
+		}
+	}
+	return strings.NewReader(decoded.String()), nil
+}
+
+// Splits a package name into its group and artifact IDs
+func splitMavenPkg(pkg string) (string, string, error) {
+	parts := strings.Split(pkg, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Expected a Maven package name of the form groupId/artifactId, got '%s'", pkg)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,74 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var testMavenFiles = map[string]string{
+	"/com/example/app/maven-metadata.xml": `<metadata><groupId>com.example</groupId><artifactId>app</artifactId>
+		<versioning><latest>2.0-SNAPSHOT</latest><release>1.1</release><versions><version>1.0</version><version>1.1</version></versions></versioning></metadata>`,
+	"/com/example/app/1.1/app-1.1.pom": `<?xml version="1.0" encoding="UTF-8"?>
+		<project xmlns="http://maven.apache.org/POM/4.0.0">
+		  <parent><groupId>com.example</groupId><artifactId>parent</artifactId><version>3</version></parent>
+		  <artifactId>app</artifactId>
+		  <version>1.1</version>
+		  <properties><guava.version>32.1.2-jre</guava.version></properties>
+		  <dependencies>
+		    <dependency><groupId>com.google.guava</groupId><artifactId>guava</artifactId><version>${guava.version}</version></dependency>
+		    <dependency><groupId>org.slf4j</groupId><artifactId>slf4j-api</artifactId></dependency>
+		    <dependency><groupId>${project.groupId}</groupId><artifactId>util</artifactId><version>${project.version}</version><optional>true</optional></dependency>
+		    <dependency><groupId>junit</groupId><artifactId>junit</artifactId></dependency>
+		  </dependencies>
+		</project>`,
+	"/com/example/parent/3/parent-3.pom": `<project>
+		  <groupId>com.example</groupId><artifactId>parent</artifactId><version>3</version>
+		  <properties><slf4j.version>2.0.9</slf4j.version></properties>
+		  <scm><connection>scm:git:git://github.com/example/app.git</connection></scm>
+		  <dependencyManagement><dependencies>
+		    <dependency><groupId>org.slf4j</groupId><artifactId>slf4j-api</artifactId><version>${slf4j.version}</version></dependency>
+		    <dependency><groupId>org.junit</groupId><artifactId>bom</artifactId><version>4</version><type>pom</type><scope>import</scope></dependency>
+		  </dependencies></dependencyManagement>
+		</project>`,
+	// in Latin-1, as many POMs on Maven Central are
+	"/org/junit/bom/4/bom-4.pom": "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<!-- \xa9 J\xfcnit -->\n" + `<project><groupId>org.junit</groupId><artifactId>bom</artifactId><version>4</version>
+		  <dependencyManagement><dependencies>
+		    <dependency><groupId>junit</groupId><artifactId>junit</artifactId><version>4.13.2</version><scope>test</scope></dependency>
+		  </dependencies></dependencyManagement>
+		</project>`,
+}
+
+func TestMavenRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if file, in := testMavenFiles[r.URL.Path]; in {
+			fmt.Fprint(w, file)
+		} else {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var idx Index = &MavenRepository{URI: server.URL}
+	reqs, err := idx.FetchPackageRequirements("com.example/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range reqs {
+		got = append(got, fmt.Sprintf("%s(%s):%s", req.Name, req.Specifier(), req.Extra))
+	}
+	exp := []string{"com.google.guava/guava(32.1.2-jre):", "org.slf4j/slf4j-api(2.0.9):", "com.example/util(1.1):optional", "junit/junit(4.13.2):dev"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("FetchPackageRequirements: expected %v, got %v", exp, got)
+	}
+
+	if repoURL, err := idx.FetchSourceRepoURL("com.example/app"); err != nil || repoURL != "https://github.com/example/app" {
+		t.Errorf("FetchSourceRepoURL: got %s, %v", repoURL, err)
+	}
+	if _, err := idx.FetchPackageRequirements("com.example:app"); err == nil {
+		t.Errorf("FetchPackageRequirements: expected an error for a groupId:artifactId name")
+	}
+}
@@ -0,0 +1,51 @@
+package cheerio
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/beyang/cheerio/metadata"
+)
+
+// Core metadata of a Python distribution, as found in PKG-INFO files (metadata versions 1.0 through 2.x). It is parsed by the metadata package,
+// which other Go projects can use without cheerio.
+type Metadata metadata.Metadata
+
+// Fetches and parses the PKG-INFO metadata of the latest release of a package
+func (p *PackageIndex) FetchMetadata(pkg string) (*Metadata, error) {
+	metadata, err := p.Cache.memoize("metadata:"+NormalizedPkgName(pkg), func() (interface{}, error) {
+		b, err := p.FetchRawMetadata(pkg, pkgInfoPattern, pkgInfoPattern, pkgInfoPattern)
+		if err != nil {
+			return nil, err
+		}
+		return ParseMetadata(string(b)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metadata.(*Metadata), nil
+}
+
+// Parses the headers of a PKG-INFO file (see metadata.ParsePKGINFO)
+func ParseMetadata(rawMetadata string) *Metadata {
+	return (*Metadata)(metadata.ParsePKGINFO(rawMetadata))
+}
+
+// Matches the parenthesized version specifiers of older metadata, e.g., "Werkzeug (>=2.0)"
+var parenSpecifierRegexp = regexp.MustCompile(`^([^\s(;]+)\s*\(([^)]*)\)`)
+
+// Returns the requirements declared by Requires-Dist fields. An "extra == ..." clause of a requirement's marker sets its Extra, and is removed
+// from its Marker (see ParseRequirementLine), e.g., `pytest; extra == "test" and python_version >= "3.8"` requires pytest for the extra test
+// where python_version >= "3.8".
+func (m *Metadata) Requirements() []*Requirement {
+	reqs := make([]*Requirement, 0, len(m.RequiresDist))
+	for _, reqStr := range m.RequiresDist {
+		req, err := ParseRequirementLine(parenSpecifierRegexp.ReplaceAllString(strings.TrimSpace(reqStr), "$1$2"))
+		if err != nil {
+			DefaultLogger.Logf("req", "Could not parse requirement: %s", err)
+			continue
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
@@ -0,0 +1,107 @@
+// Package metadata parses the metadata files of Python distributions: the PKG-INFO of sdists, the METADATA of wheels and the requires.txt of
+// *.egg-info directories. Its functions are pure; they neither fetch nor interpret requirements, which are returned as raw PEP 508 strings.
+package metadata
+
+import (
+	"strings"
+)
+
+// Core metadata of a Python distribution, as found in PKG-INFO and METADATA files (metadata versions 1.0 through 2.x)
+type Metadata struct {
+	MetadataVersion   string
+	Name              string
+	Version           string
+	Summary           string
+	HomePage          string
+	DownloadURL       string
+	Author            string
+	AuthorEmail       string
+	Maintainer        string
+	MaintainerEmail   string
+	License           string
+	LicenseExpression string // SPDX expression (metadata 2.4+), e.g., "MIT OR Apache-2.0"
+	Classifiers       []string
+	ProjectURLs       map[string]string // label -> URL, e.g., "Source" -> "https://github.com/mitsuhiko/flask"
+	RequiresDist      []string          // raw PEP 508 requirement strings
+	RequiresPython    string
+}
+
+// Parses the headers of a PKG-INFO file. Parsing stops at the first blank line (which separates the headers from the long description in metadata
+// 2.1+). If a single-valued field is repeated, the first value is kept. Fields whose value is "UNKNOWN", as written by old setuptools, are skipped.
+func ParsePKGINFO(raw string) *Metadata {
+	m := &Metadata{
+		Classifiers:  make([]string, 0),
+		ProjectURLs:  make(map[string]string),
+		RequiresDist: make([]string, 0),
+	}
+
+	single := map[string]*string{
+		"metadata-version":   &m.MetadataVersion,
+		"name":               &m.Name,
+		"version":            &m.Version,
+		"summary":            &m.Summary,
+		"home-page":          &m.HomePage,
+		"download-url":       &m.DownloadURL,
+		"author":             &m.Author,
+		"author-email":       &m.AuthorEmail,
+		"maintainer":         &m.Maintainer,
+		"maintainer-email":   &m.MaintainerEmail,
+		"license":            &m.License,
+		"license-expression": &m.LicenseExpression,
+		"requires-python":    &m.RequiresPython,
+	}
+
+	for _, header := range Headers(raw) {
+		key, value := strings.ToLower(header[0]), header[1]
+		if value == "UNKNOWN" {
+			continue
+		}
+		if field, in := single[key]; in {
+			if *field == "" {
+				*field = value
+			}
+			continue
+		}
+		switch key {
+		case "classifier":
+			m.Classifiers = append(m.Classifiers, value)
+		case "requires-dist":
+			m.RequiresDist = append(m.RequiresDist, value)
+		case "project-url":
+			if i := strings.Index(value, ","); i >= 0 {
+				label, url := strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:])
+				if _, in := m.ProjectURLs[label]; !in {
+					m.ProjectURLs[label] = url
+				}
+			}
+		}
+	}
+	return m
+}
+
+// Parses the METADATA file of a wheel, which has the format of a PKG-INFO file (see ParsePKGINFO)
+func ParseMETADATA(raw string) *Metadata {
+	return ParsePKGINFO(raw)
+}
+
+// Splits the RFC 822-style headers of a metadata file into (key, value) pairs, in order, joining continuation lines. Stops at the first blank line.
+func Headers(raw string) [][2]string {
+	var headers [][2]string
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(headers) > 0 {
+				last := &headers[len(headers)-1]
+				last[1] += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			headers = append(headers, [2]string{strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])})
+		}
+	}
+	return headers
+}
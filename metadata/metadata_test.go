@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePKGINFO(t *testing.T) {
+	raw := "Metadata-Version: 2.1\r\n" +
+		"Name: Flask\n" +
+		"Version: 1.0\n" +
+		"Version: 2.0\n" +
+		"Summary: A simple framework\n" +
+		"Home-page: UNKNOWN\n" +
+		"License: BSD-3-Clause\n" +
+		"\tand some more terms\n" +
+		"Classifier: Framework :: Flask\n" +
+		"Classifier: License :: OSI Approved :: BSD License\n" +
+		"Project-URL: Source, https://github.com/pallets/flask\n" +
+		"Project-URL: Source, https://example.com/flask\n" +
+		"Project-URL: no comma\n" +
+		"Requires-Dist: Werkzeug>=2.0\n" +
+		"Requires-Dist: asgiref>=3.2; extra == \"async\"\n" +
+		"Requires-Python: >=3.6\n" +
+		"\n" +
+		"Name: long description\n"
+
+	m := ParsePKGINFO(raw)
+	exp := &Metadata{
+		MetadataVersion: "2.1",
+		Name:            "Flask",
+		Version:         "1.0",
+		Summary:         "A simple framework",
+		License:         "BSD-3-Clause\nand some more terms",
+		Classifiers:     []string{"Framework :: Flask", "License :: OSI Approved :: BSD License"},
+		ProjectURLs:     map[string]string{"Source": "https://github.com/pallets/flask"},
+		RequiresDist:    []string{"Werkzeug>=2.0", `asgiref>=3.2; extra == "async"`},
+		RequiresPython:  ">=3.6",
+	}
+	if !reflect.DeepEqual(m, exp) {
+		t.Errorf("expected %+v, got %+v", exp, m)
+	}
+}
+
+func TestParsePKGINFOEmpty(t *testing.T) {
+	m := ParsePKGINFO("")
+	if m.Name != "" || m.Classifiers == nil || m.ProjectURLs == nil || m.RequiresDist == nil {
+		t.Errorf("expected empty metadata with non-nil collections, got %+v", m)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	raw := "  continuation without header\nName: foo\nDescription: first\n        second\nnot a header\n:no key\nVersion:1.0\n\nAuthor: bar\n"
+	exp := [][2]string{{"Name", "foo"}, {"Description", "first\nsecond"}, {"Version", "1.0"}}
+	if headers := Headers(raw); !reflect.DeepEqual(headers, exp) {
+		t.Errorf("expected %q, got %q", exp, headers)
+	}
+}
@@ -0,0 +1,35 @@
+package metadata
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A requirement line of a requires.txt file, with the section it is listed under
+type RequiresTxtLine struct {
+	Requirement string // the raw requirement, e.g., "pytest>=3.0"
+	Extra       string // the extra named by the section header, as written, e.g., "test" for "[test]"; empty outside of sections
+	Marker      string // the environment marker of the section header, e.g., `python_version < "3"` for `[:python_version < "3"]`
+	LineNo      int    // 1-based line number
+}
+
+var sectionHeaderRegexp = regexp.MustCompile(`^\[([A-Za-z0-9\._\-]*)(?::(.*))?\]$`)
+
+// Parses the requires.txt file of an *.egg-info directory into its requirement lines. Requirements that follow a "[extra]" or "[extra:marker]"
+// section header are only needed for that extra, and only where the marker holds. Blank lines are skipped; the requirements themselves are not
+// parsed.
+func ParseRequiresTxt(raw string) []RequiresTxtLine {
+	var lines []RequiresTxtLine
+	extra, marker := "", ""
+	for lineNo, line := range strings.Split(raw, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if header := sectionHeaderRegexp.FindStringSubmatch(line); header != nil {
+			extra, marker = header[1], strings.TrimSpace(header[2])
+			continue
+		}
+		lines = append(lines, RequiresTxtLine{Requirement: line, Extra: extra, Marker: marker, LineNo: lineNo + 1})
+	}
+	return lines
+}
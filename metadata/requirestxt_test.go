@@ -0,0 +1,35 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRequiresTxt(t *testing.T) {
+	raw := "Werkzeug>=2.0\r\n" +
+		"\n" +
+		"[async]\n" +
+		"asgiref>=3.2\n" +
+		"[:python_version < \"3.8\"]\n" +
+		"importlib-metadata\n" +
+		"[dotenv: sys_platform == \"win32\" ]\n" +
+		"  python-dotenv  \n" +
+		"[not a header\n"
+
+	exp := []RequiresTxtLine{
+		{Requirement: "Werkzeug>=2.0", LineNo: 1},
+		{Requirement: "asgiref>=3.2", Extra: "async", LineNo: 4},
+		{Requirement: "importlib-metadata", Marker: `python_version < "3.8"`, LineNo: 6},
+		{Requirement: "python-dotenv", Extra: "dotenv", Marker: `sys_platform == "win32"`, LineNo: 8},
+		{Requirement: "[not a header", Extra: "dotenv", Marker: `sys_platform == "win32"`, LineNo: 9},
+	}
+	if lines := ParseRequiresTxt(raw); !reflect.DeepEqual(lines, exp) {
+		t.Errorf("expected %+v, got %+v", exp, lines)
+	}
+}
+
+func TestParseRequiresTxtEmpty(t *testing.T) {
+	if lines := ParseRequiresTxt("\n  \n"); len(lines) != 0 {
+		t.Errorf("expected no lines, got %+v", lines)
+	}
+}
@@ -0,0 +1,52 @@
+package cheerio
+
+import (
+	"github.com/kr/pretty"
+	"reflect"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	m := ParseMetadata(`Metadata-Version: 2.1
+Name: Flask
+Version: 1.1.2
+Summary: A simple framework for building complex web applications.
+Home-page: https://palletsprojects.com/p/flask/
+Author: Armin Ronacher
+Author-email: armin.ronacher@active-4.com
+License: BSD-3-Clause
+Project-URL: Documentation, https://flask.palletsprojects.com/
+Project-URL: Code, https://github.com/pallets/flask
+Description: Flask
+        =====
+Platform: UNKNOWN
+Classifier: Development Status :: 5 - Production/Stable
+Classifier: License :: OSI Approved :: BSD License
+Requires-Python: >=2.7, !=3.0.*
+Requires-Dist: Werkzeug (>=0.15)
+Requires-Dist: python-dotenv ; extra == 'dotenv'
+
+Long description: not a header
+`)
+
+	exp := &Metadata{
+		MetadataVersion: "2.1",
+		Name:            "Flask",
+		Version:         "1.1.2",
+		Summary:         "A simple framework for building complex web applications.",
+		HomePage:        "https://palletsprojects.com/p/flask/",
+		Author:          "Armin Ronacher",
+		AuthorEmail:     "armin.ronacher@active-4.com",
+		License:         "BSD-3-Clause",
+		Classifiers:     []string{"Development Status :: 5 - Production/Stable", "License :: OSI Approved :: BSD License"},
+		ProjectURLs: map[string]string{
+			"Documentation": "https://flask.palletsprojects.com/",
+			"Code":          "https://github.com/pallets/flask",
+		},
+		RequiresDist:   []string{"Werkzeug (>=0.15)", "python-dotenv ; extra == 'dotenv'"},
+		RequiresPython: ">=2.7, !=3.0.*",
+	}
+	if !reflect.DeepEqual(m, exp) {
+		t.Errorf("Metadata does not match: %v", pretty.Diff(m, exp))
+	}
+}
@@ -0,0 +1,134 @@
+package cheerio
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Default lifetimes of the entries of a CachedIndex
+const (
+	DefaultCacheTTL         = 24 * time.Hour
+	DefaultNegativeCacheTTL = time.Hour
+)
+
+// A cache of metadata lookups that can be shared between processes, e.g., RedisCache
+type MetadataCache interface {
+	// Returns the value cached under key, and whether there is one
+	Get(key string) ([]byte, bool, error)
+
+	// Caches value under key for ttl
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// Caches the requirements and source repository URLs that an Index looks up, for services that look packages up at request time. Lookups that
+// fail because of the package itself, e.g., because it doesn't exist or has no requires.txt (see ErrorCategory), are cached too, for NegativeTTL,
+// and still satisfy errors.Is with the original error; other failures, e.g., network errors, aren't cached. If the cache fails, lookups fall
+// through to the index.
+type CachedIndex struct {
+	Index
+	Cache       MetadataCache
+	TTL         time.Duration
+	NegativeTTL time.Duration
+	Prefix      string // of cache keys, e.g., to share a cache between indexes of several ecosystems
+	Logger      Logger // receives cache errors; DefaultLogger if nil
+}
+
+// Returns a copy of the index whose lookups are canceled once ctx is done, if the wrapped index supports it, as PackageIndex does
+func (c *CachedIndex) withContext(ctx context.Context) Index {
+	if inner, ok := c.Index.(contextIndex); ok {
+		index := *c
+		index.Index = inner.withContext(ctx)
+		return &index
+	}
+	return c
+}
+
+// Returns index with lookups cached in cache for the default TTLs
+func NewCachedIndex(index Index, cache MetadataCache) *CachedIndex {
+	return &CachedIndex{Index: index, Cache: cache, TTL: DefaultCacheTTL, NegativeTTL: DefaultNegativeCacheTTL, Prefix: "cheerio:"}
+}
+
+// A cached lookup: its result, or the category (see ErrorCategory) and message of its error
+type cacheEntry struct {
+	Requirements []*Requirement `json:",omitempty"`
+	RepoURL      string         `json:",omitempty"`
+	ErrCategory  string         `json:",omitempty"`
+	Err          string         `json:",omitempty"`
+}
+
+// The errors of each category whose lookups are cached
+var cachedErrors = map[string]error{
+	"not_found":        ErrPackageNotFound,
+	"no_releases":      ErrNoReleases,
+	"no_sdist":         ErrNoSdist,
+	"no_wheel":         ErrNoWheel,
+	"no_requires_file": ErrNoRequiresFile,
+}
+
+// An error restored from the cache, which unwraps to the error of its category
+type cachedError struct {
+	msg      string
+	sentinel error
+}
+
+func (e *cachedError) Error() string { return e.msg }
+func (e *cachedError) Unwrap() error { return e.sentinel }
+
+func (c *CachedIndex) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	var entry cacheEntry
+	err := c.lookup("reqs:"+c.normalize(pkg), &entry, func() (err error) {
+		entry.Requirements, err = c.Index.FetchPackageRequirements(pkg)
+		return err
+	})
+	return entry.Requirements, err
+}
+
+func (c *CachedIndex) FetchSourceRepoURL(pkg string) (string, error) {
+	var entry cacheEntry
+	err := c.lookup("repo:"+c.normalize(pkg), &entry, func() (err error) {
+		entry.RepoURL, err = c.Index.FetchSourceRepoURL(pkg)
+		return err
+	})
+	return entry.RepoURL, err
+}
+
+// Returns the normalized name of pkg by the naming rules of the ecosystem of the index (see NormalizedEcosystemPkgName), so that the spellings of
+// a name share a cache entry
+func (c *CachedIndex) normalize(pkg string) string {
+	return NormalizedEcosystemPkgName(IndexEcosystem(c.Index), pkg)
+}
+
+// Fills entry from the cache, or by calling fetch and caching the entry or its error, and returns the error of the lookup
+func (c *CachedIndex) lookup(key string, entry *cacheEntry, fetch func() error) error {
+	key = c.Prefix + key
+	if b, found, err := c.Cache.Get(key); err != nil {
+		loggerOr(c.Logger).Logf("cache", "unable to read %s: %s", key, err)
+	} else if found && json.Unmarshal(b, entry) == nil {
+		if sentinel, in := cachedErrors[entry.ErrCategory]; in {
+			return &cachedError{msg: entry.Err, sentinel: sentinel}
+		}
+		return nil
+	}
+
+	err := fetch()
+	toCache, ttl := *entry, c.TTL
+	if err != nil {
+		category := ErrorCategory(err)
+		if _, in := cachedErrors[category]; !in {
+			return err
+		}
+		toCache, ttl = cacheEntry{ErrCategory: category, Err: err.Error()}, c.NegativeTTL
+	}
+	if ttl <= 0 {
+		return err
+	}
+	b, merr := json.Marshal(toCache)
+	if merr == nil {
+		merr = c.Cache.Set(key, b, ttl)
+	}
+	if merr != nil {
+		loggerOr(c.Logger).Logf("cache", "unable to write %s: %s", key, merr)
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package cheerio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Counts the requirements lookups of a fakeIndex, failing those of "gone" as not found and those of "flaky" as network errors
+type countingIndex struct {
+	fakeIndex
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingIndex) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	c.mu.Lock()
+	c.calls[pkg]++
+	c.mu.Unlock()
+	switch pkg {
+	case "gone":
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, pkg)
+	case "flaky":
+		return nil, errors.New("connection reset by peer")
+	}
+	return c.fakeIndex.FetchPackageRequirements(pkg)
+}
+
+// Serves GET and SET (with PX) of the Redis protocol from a map, recording the TTLs set
+func fakeRedis(t *testing.T) (addr string, ttl func(key string) time.Duration) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	var mu sync.Mutex
+	values, ttls := make(map[string]string), make(map[string]time.Duration)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					args := make([]string, n)
+					for i := range args {
+						line, _ = r.ReadString('\n')
+						size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+						b := make([]byte, size+2)
+						io.ReadFull(r, b)
+						args[i] = string(b[:size])
+					}
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						if v, in := values[args[1]]; in {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+						} else {
+							io.WriteString(conn, "$-1\r\n")
+						}
+					case "SET":
+						ms, _ := strconv.Atoi(args[4])
+						values[args[1]], ttls[args[1]] = args[2], time.Duration(ms)*time.Millisecond
+						io.WriteString(conn, "+OK\r\n")
+					default:
+						fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return l.Addr().String(), func(key string) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		return ttls[key]
+	}
+}
+
+func TestCachedIndex(t *testing.T) {
+	addr, ttl := fakeRedis(t)
+	cache, err := NewRedisCache("redis://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	idx := &countingIndex{fakeIndex: fakeIndex{"a": {{Name: "b", Constraint: ">=", Version: "1.0"}}}, calls: make(map[string]int)}
+	cached := NewCachedIndex(idx, cache)
+	cached.NegativeTTL = time.Minute
+	cached.Logger = NopLogger{}
+
+	for i := 0; i < 3; i++ {
+		reqs, err := cached.FetchPackageRequirements("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reqs) != 1 || reqs[0].Name != "b" || reqs[0].Version != "1.0" {
+			t.Errorf("unexpected requirements %+v", reqs)
+		}
+		if _, err := cached.FetchPackageRequirements("gone"); !errors.Is(err, ErrPackageNotFound) {
+			t.Errorf("expected a not found error, got %v", err)
+		}
+		if _, err := cached.FetchPackageRequirements("flaky"); err == nil {
+			t.Errorf("expected an error")
+		}
+	}
+	if _, err := cached.FetchPackageRequirements("A"); err != nil {
+		t.Fatal(err)
+	}
+	if idx.calls["a"] != 1 || idx.calls["gone"] != 1 {
+		t.Errorf("expected found and missing packages to be fetched once, whatever their spelling, got %v", idx.calls)
+	}
+	if idx.calls["flaky"] != 3 {
+		t.Errorf("expected network errors not to be cached, got %d fetches", idx.calls["flaky"])
+	}
+	if ttl("cheerio:reqs:a") != DefaultCacheTTL || ttl("cheerio:reqs:gone") != time.Minute {
+		t.Errorf("unexpected TTLs %s, %s", ttl("cheerio:reqs:a"), ttl("cheerio:reqs:gone"))
+	}
+
+	// concurrent lookups use connections of their own, which are kept for later lookups
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cached.FetchPackageRequirements("a"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if idle := len(cache.idle); idle < 1 || idle > DefaultRedisPoolSize {
+		t.Errorf("expected 1 to %d idle connections, got %d", DefaultRedisPoolSize, idle)
+	}
+
+	// lookups fall through to the index when the cache is down
+	cache.Addr = "127.0.0.1:1"
+	cache.Close()
+	if _, err := cached.FetchPackageRequirements("a"); err != nil || idx.calls["a"] != 2 {
+		t.Errorf("expected a fetch without cache, got %d fetches, error %v", idx.calls["a"], err)
+	}
+}
+
+func TestNewRedisCache(t *testing.T) {
+	c, err := NewRedisCache("redis://:secret@cache.internal/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Addr != "cache.internal:6379" || c.Password != "secret" || c.DB != 2 {
+		t.Errorf("unexpected cache %+v", c)
+	}
+	if _, err := NewRedisCache("http://cache.internal"); err == nil {
+		t.Errorf("expected an error for a non-Redis URL")
+	}
+}
@@ -0,0 +1,168 @@
+package cheerio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Returns the category of a crawl error, for metrics and reports: "not_found", "no_releases", "no_sdist", "no_wheel", "no_requires_file", "parse",
+// "timeout" (see ErrTimeout and PackageIndex.Timeout), or "other".
+func ErrorCategory(err error) string {
+	var parseErr *ParseError
+	switch {
+	case errors.Is(err, ErrPackageNotFound):
+		return "not_found"
+	case errors.Is(err, ErrNoReleases):
+		return "no_releases"
+	case errors.Is(err, ErrNoSdist):
+		return "no_sdist"
+	case errors.Is(err, ErrNoWheel):
+		return "no_wheel"
+	case errors.Is(err, ErrNoRequiresFile):
+		return "no_requires_file"
+	case errors.As(err, &parseErr):
+		return "parse"
+	case isTimeout(err):
+		return "timeout"
+	}
+	return "other"
+}
+
+// Counters of the progress of crawls, exposed in the Prometheus text format by ServeHTTP, e.g., on a /metrics endpoint. Pass it in
+// CrawlOptions.Metrics to count crawled packages, and wrap the HTTP transport of the indexes with Transport to count requests and bytes. The
+// zero value is ready to use; a CrawlMetrics is safe for concurrent use.
+type CrawlMetrics struct {
+	requests      int64
+	requestErrors int64
+	bytes         int64
+	packages      int64
+	fetchNanos    int64
+
+	mu      sync.Mutex
+	started time.Time
+	errors  map[string]int64 // by ErrorCategory
+}
+
+// Records the result of crawling a package
+func (m *CrawlMetrics) observe(res PackageResult) {
+	atomic.AddInt64(&m.packages, 1)
+	atomic.AddInt64(&m.fetchNanos, int64(res.Duration))
+	if res.Err != nil {
+		m.mu.Lock()
+		if m.errors == nil {
+			m.errors = make(map[string]int64)
+		}
+		m.errors[ErrorCategory(res.Err)]++
+		m.mu.Unlock()
+	}
+}
+
+// Records the start of a crawl; packages per second are measured from the first start
+func (m *CrawlMetrics) start() {
+	m.mu.Lock()
+	if m.started.IsZero() {
+		m.started = time.Now()
+	}
+	m.mu.Unlock()
+}
+
+// Returns a transport that counts the requests made through base (http.DefaultTransport if nil) and the bytes of their response bodies,
+// e.g., to instrument all indexes with http.DefaultClient.Transport = metrics.Transport(nil).
+func (m *CrawlMetrics) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &metricsTransport{base: base, metrics: m}
+}
+
+type metricsTransport struct {
+	base    http.RoundTripper
+	metrics *CrawlMetrics
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&t.metrics.requests, 1)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		atomic.AddInt64(&t.metrics.requestErrors, 1)
+		return nil, err
+	}
+	resp.Body = &countingReader{ReadCloser: resp.Body, n: &t.metrics.bytes}
+	return resp, nil
+}
+
+// Adds the number of bytes read to n
+type countingReader struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
+
+// Writes the metrics in the Prometheus text exposition format
+func (m *CrawlMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	categories := make([]string, 0, len(m.errors))
+	for category := range m.errors {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	errorCounts := make([]int64, len(categories))
+	for i, category := range categories {
+		errorCounts[i] = m.errors[category]
+	}
+	started := m.started
+	m.mu.Unlock()
+
+	packages := atomic.LoadInt64(&m.packages)
+	rate := 0.0
+	if !started.IsZero() {
+		if elapsed := time.Since(started).Seconds(); elapsed > 0 {
+			rate = float64(packages) / elapsed
+		}
+	}
+
+	metrics := []struct {
+		name, kind, help string
+		value            interface{}
+	}{
+		{"cheerio_http_requests_total", "counter", "HTTP requests made to package indexes.", atomic.LoadInt64(&m.requests)},
+		{"cheerio_http_request_errors_total", "counter", "HTTP requests that failed without a response.", atomic.LoadInt64(&m.requestErrors)},
+		{"cheerio_http_response_bytes_total", "counter", "Bytes of HTTP response bodies downloaded.", atomic.LoadInt64(&m.bytes)},
+		{"cheerio_crawl_packages_total", "counter", "Packages crawled, successfully or not.", packages},
+		{"cheerio_crawl_fetch_seconds_total", "counter", "Time spent fetching package requirements.", float64(atomic.LoadInt64(&m.fetchNanos)) / 1e9},
+		{"cheerio_crawl_packages_per_second", "gauge", "Packages crawled per second since the first crawl started.", rate},
+	}
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", metric.name, metric.help, metric.name, metric.kind, metric.name, metric.value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprint(w, "# HELP cheerio_crawl_errors_total Packages whose requirements couldn't be fetched, by error category.\n"+
+		"# TYPE cheerio_crawl_errors_total counter\n"); err != nil {
+		return err
+	}
+	for i, category := range categories {
+		if _, err := fmt.Fprintf(w, "cheerio_crawl_errors_total{category=%q} %d\n", category, errorCounts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serves the metrics in the Prometheus text exposition format
+func (m *CrawlMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
@@ -0,0 +1,68 @@
+package cheerio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorCategory(t *testing.T) {
+	tests := map[error]string{
+		fmt.Errorf("%w: flask", ErrPackageNotFound):                  "not_found",
+		fmt.Errorf("[no-files] %w for pkg flask", ErrNoReleases):     "no_releases",
+		fmt.Errorf("[tar/zip] %w for pkg flask", ErrNoSdist):         "no_sdist",
+		fmt.Errorf("%w: No file matched pattern", ErrNoRequiresFile): "no_requires_file",
+		&ParseError{Line: "??", Msg: "Unable to parse requirement"}:  "parse",
+		fmt.Errorf("connection reset"):                               "other",
+	}
+	for err, exp := range tests {
+		if category := ErrorCategory(err); category != exp {
+			t.Errorf("%v: expected category %s, got %s", err, exp, category)
+		}
+	}
+}
+
+func TestCrawlMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "0123456789")
+	}))
+	defer server.Close()
+
+	metrics := &CrawlMetrics{}
+	client := &http.Client{Transport: metrics.Transport(nil)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	idx := fakeIndex{"a": nil, "b": nil}
+	opts := &CrawlOptions{Packages: []string{"a", "b", "missing"}, Metrics: metrics}
+	if err := Crawl(context.Background(), idx, opts, func(PackageResult) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"cheerio_http_requests_total 2\n",
+		"cheerio_http_response_bytes_total 20\n",
+		"cheerio_crawl_packages_total 3\n",
+		"# TYPE cheerio_crawl_errors_total counter\n",
+		`cheerio_crawl_errors_total{category="other"} 1` + "\n",
+	} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Errorf("expected metrics to contain %q, got\n%s", exp, buf.String())
+		}
+	}
+}
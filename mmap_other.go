@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package cheerio
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// Reads a file into memory on platforms without mmap support, returning its contents and a no-op unmap function
+func mmapFile(f *os.File) ([]byte, func() error, error) {
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
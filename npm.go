@@ -0,0 +1,146 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var DefaultNPM = &NPMRegistry{URI: "https://registry.npmjs.org", ReplicateURI: "https://replicate.npmjs.com"}
+
+// The npm registry, read through its JSON API (https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md)
+type NPMRegistry struct {
+	URI string
+
+	// CouchDB replica of the registry, used to list all packages
+	ReplicateURI string
+}
+
+// A package document ("packument") from the registry, reduced to the fields used here
+type npmPackument struct {
+	Name       string                 `json:"name"`
+	DistTags   map[string]string      `json:"dist-tags"`
+	Versions   map[string]*npmVersion `json:"versions"`
+	Repository npmRepository          `json:"repository"`
+}
+
+// The package.json of a published version
+type npmVersion struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	Repository           npmRepository     `json:"repository"`
+}
+
+// The repository field of package.json, which is either an object with a URL or a shorthand string, e.g., "github:user/repo" or "user/repo"
+type npmRepository struct {
+	URL string
+}
+
+func (r *npmRepository) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		r.URL = s
+		return nil
+	}
+	var obj struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil // ignore malformed repository fields
+	}
+	r.URL = obj.URL
+	return nil
+}
+
+// Returns the names of all packages in the registry, from the replica's _all_docs view. This is a very large download.
+func (n *NPMRegistry) AllPackages() ([]string, error) {
+	resp, err := http.Get(n.ReplicateURI + "/_all_docs")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("npm replica returned %s", resp.Status)
+	}
+
+	var allDocs struct {
+		Rows []struct {
+			ID string `json:"id"`
+		} `json:"rows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&allDocs); err != nil {
+		return nil, err
+	}
+	pkgs := make([]string, 0, len(allDocs.Rows))
+	for _, row := range allDocs.Rows {
+		if !strings.HasPrefix(row.ID, "_design/") {
+			pkgs = append(pkgs, row.ID)
+		}
+	}
+	return pkgs, nil
+}
+
+// Returns the requirements of the latest version of a package (its "latest" dist-tag): dependencies and optional dependencies, then peer
+// dependencies (Extra "peer"), then development dependencies (Extra "dev")
+func (n *NPMRegistry) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	packument, err := n.fetchPackument(pkg)
+	if err != nil {
+		return nil, err
+	}
+	latest := packument.Versions[packument.DistTags["latest"]]
+	if latest == nil {
+		return nil, fmt.Errorf("[no-files] %w: no latest version found for npm pkg %s", ErrNoReleases, pkg)
+	}
+
+	seen := make(map[string]bool)
+	reqs := rangeRequirements(latest.Dependencies, "", seen)
+	reqs = append(reqs, rangeRequirements(latest.OptionalDependencies, "", seen)...)
+	reqs = append(reqs, rangeRequirements(latest.PeerDependencies, "peer", seen)...)
+	reqs = append(reqs, rangeRequirements(latest.DevDependencies, "dev", seen)...)
+	return reqs, nil
+}
+
+var npmRepoShorthandRegexp = regexp.MustCompile(`^(?:(github|gitlab|bitbucket):)?([A-Za-z0-9_\.\-]+/[A-Za-z0-9_\.\-]+)$`)
+
+var npmRepoHosts = map[string]string{"": "github.com", "github": "github.com", "gitlab": "gitlab.com", "bitbucket": "bitbucket.org"}
+
+// Returns the canonical source repository URL of a package, from the repository field of its latest version (or of the package)
+func (n *NPMRegistry) FetchSourceRepoURL(pkg string) (string, error) {
+	packument, err := n.fetchPackument(pkg)
+	if err != nil {
+		return "", err
+	}
+	repoURL := packument.Repository.URL
+	if latest := packument.Versions[packument.DistTags["latest"]]; latest != nil && latest.Repository.URL != "" {
+		repoURL = latest.Repository.URL
+	}
+	if repoURL == "" {
+		return "", fmt.Errorf("No repository found in metadata for npm pkg %s", pkg)
+	}
+	if match := npmRepoShorthandRegexp.FindStringSubmatch(repoURL); match != nil {
+		repoURL = fmt.Sprintf("https://%s/%s", npmRepoHosts[match[1]], match[2])
+	}
+	return CanonicalRepoURL(repoURL)
+}
+
+// Fetches a package document. Scoped package names ("@scope/name") are escaped as the registry expects.
+func (n *NPMRegistry) fetchPackument(pkg string) (*npmPackument, error) {
+	resp, err := http.Get(n.URI + "/" + url.PathEscape(pkg))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("npm registry returned %s for pkg %s", resp.Status, pkg))
+	}
+	var packument npmPackument
+	if err := json.NewDecoder(resp.Body).Decode(&packument); err != nil {
+		return nil, err
+	}
+	return &packument, nil
+}
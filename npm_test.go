@@ -0,0 +1,52 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNPMRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/_all_docs":
+			fmt.Fprint(w, `{"total_rows": 3, "rows": [{"id": "express"}, {"id": "@babel/core"}, {"id": "_design/app"}]}`)
+		case "/express":
+			fmt.Fprint(w, `{"name": "express", "dist-tags": {"latest": "4.18.2"}, "repository": {"type": "git", "url": "git+https://github.com/expressjs/express.git"},
+				"versions": {"4.18.2": {
+					"dependencies": {"body-parser": "1.20.1", "debug": "2.6.9"},
+					"optionalDependencies": {"debug": "2.6.9"},
+					"devDependencies": {"mocha": "^10.0.0"}}}}`)
+		case "/@babel%2Fcore":
+			fmt.Fprint(w, `{"name": "@babel/core", "dist-tags": {"latest": "7.0.0"}, "versions": {"7.0.0": {"repository": "babel/babel"}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var idx Index = &NPMRegistry{URI: server.URL, ReplicateURI: server.URL}
+	if pkgs, err := idx.AllPackages(); err != nil || !reflect.DeepEqual(pkgs, []string{"express", "@babel/core"}) {
+		t.Errorf("AllPackages: got %v, %v", pkgs, err)
+	}
+
+	reqs, err := idx.FetchPackageRequirements("express")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range reqs {
+		got = append(got, fmt.Sprintf("%s@%s:%s", req.Name, req.Specifier(), req.Extra))
+	}
+	if exp := []string{"body-parser@1.20.1:", "debug@2.6.9:", "mocha@^10.0.0:dev"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("FetchPackageRequirements: expected %v, got %v", exp, got)
+	}
+
+	for pkg, exp := range map[string]string{"express": "https://github.com/expressjs/express", "@babel/core": "https://github.com/babel/babel"} {
+		if repoURL, err := idx.FetchSourceRepoURL(pkg); err != nil || repoURL != exp {
+			t.Errorf("FetchSourceRepoURL(%s): expected %s, got %s, %v", pkg, exp, repoURL, err)
+		}
+	}
+}
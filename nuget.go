@@ -0,0 +1,228 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var DefaultNuGet = &NuGetFeed{URI: "https://api.nuget.org/v3/index.json"}
+
+// A NuGet v3 feed of .NET packages (https://learn.microsoft.com/en-us/nuget/api/overview). Requirements are read from the .nuspec files served by the
+// package content resource, and the package list from the catalog.
+type NuGetFeed struct {
+	URI string // service index URL
+
+	mu        sync.Mutex
+	resources map[string]string // resource type -> URL, from the service index
+}
+
+// The dependencies of a package that apply to one target framework (or to all, if TargetFramework is "")
+type NuGetDependencyGroup struct {
+	TargetFramework string // e.g., "net6.0" or ".NETStandard2.0"
+	Requirements    []*Requirement
+}
+
+type nuspec struct {
+	Metadata struct {
+		ID         string `xml:"id"`
+		Version    string `xml:"version"`
+		ProjectURL string `xml:"projectUrl"`
+		Repository struct {
+			URL string `xml:"url,attr"`
+		} `xml:"repository"`
+		Dependencies struct {
+			Groups []struct {
+				TargetFramework string             `xml:"targetFramework,attr"`
+				Deps            []nuspecDependency `xml:"dependency"`
+			} `xml:"group"`
+			Deps []nuspecDependency `xml:"dependency"` // legacy nuspecs list dependencies without groups
+		} `xml:"dependencies"`
+	} `xml:"metadata"`
+}
+
+type nuspecDependency struct {
+	ID      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+}
+
+// Returns the IDs of all packages, by walking the feed's catalog. This reads every catalog page, which takes a very long time on nuget.org.
+func (n *NuGetFeed) AllPackages() ([]string, error) {
+	catalogURL, err := n.resource("Catalog/3.0.0")
+	if err != nil {
+		return nil, err
+	}
+	var catalog struct {
+		Items []struct {
+			ID string `json:"@id"`
+		} `json:"items"`
+	}
+	if err := getJSON(&catalog, catalogURL); err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, pageRef := range catalog.Items {
+		var page struct {
+			Items []struct {
+				PackageID string `json:"nuget:id"`
+			} `json:"items"`
+		}
+		if err := getJSON(&page, pageRef.ID); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if id := strings.ToLower(item.PackageID); !seen[id] {
+				seen[id] = true
+				pkgs = append(pkgs, item.PackageID)
+			}
+		}
+	}
+	return pkgs, nil
+}
+
+// Returns the dependencies of the latest version of a package in any of its dependency groups, each listed once (with the version range of the
+// first group that has it). Use FetchDependencyGroups for the dependencies of each target framework.
+func (n *NuGetFeed) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	spec, err := n.fetchNuspec(pkg, "")
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]*Requirement, 0)
+	seen := make(map[string]bool)
+	for _, group := range nuspecDependencyGroups(spec) {
+		for _, req := range group.Requirements {
+			if id := strings.ToLower(req.Name); !seen[id] {
+				seen[id] = true
+				reqs = append(reqs, req)
+			}
+		}
+	}
+	return reqs, nil
+}
+
+// Returns the dependency groups in the .nuspec of a version of a package (the latest version if version is "")
+func (n *NuGetFeed) FetchDependencyGroups(pkg, version string) ([]*NuGetDependencyGroup, error) {
+	spec, err := n.fetchNuspec(pkg, version)
+	if err != nil {
+		return nil, err
+	}
+	return nuspecDependencyGroups(spec), nil
+}
+
+// Returns the source repository URL of a package from the repository element or project URL of its latest .nuspec
+func (n *NuGetFeed) FetchSourceRepoURL(pkg string) (string, error) {
+	spec, err := n.fetchNuspec(pkg, "")
+	if err != nil {
+		return "", err
+	}
+	var candidates []repoCandidate
+	for _, u := range []string{spec.Metadata.Repository.URL, spec.Metadata.ProjectURL} {
+		if canonical, err := CanonicalRepoURL(u); err == nil {
+			candidates = append(candidates, repoCandidate{canonical, RepoFromProjectURL})
+		}
+	}
+	if repoURL, _ := matchRepoURL(candidates); repoURL != "" {
+		return repoURL, nil
+	}
+	return "", fmt.Errorf("Could not parse repo URL from nuspec of package %s", pkg)
+}
+
+// Fetches the .nuspec of a version of a package from the package content resource. If version is "", uses the latest listed version.
+func (n *NuGetFeed) fetchNuspec(pkg, version string) (*nuspec, error) {
+	base, err := n.resource("PackageBaseAddress/3.0.0")
+	if err != nil {
+		return nil, err
+	}
+	base = strings.TrimSuffix(base, "/")
+	id := strings.ToLower(pkg)
+	if version == "" {
+		var versions struct {
+			Versions []string `json:"versions"`
+		}
+		if err := getJSON(&versions, fmt.Sprintf("%s/%s/index.json", base, id)); err != nil {
+			return nil, err
+		}
+		if len(versions.Versions) == 0 {
+			return nil, fmt.Errorf("[no-files] %w: no versions of package %s", ErrNoReleases, pkg)
+		}
+		version = versions.Versions[len(versions.Versions)-1]
+	}
+
+	nuspecURL := fmt.Sprintf("%s/%s/%s/%s.nuspec", base, id, strings.ToLower(version), id)
+	resp, err := http.Get(nuspecURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("NuGet feed returned %s for %s", resp.Status, nuspecURL))
+	}
+	var spec nuspec
+	if err := xml.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Returns the URL of a resource of the feed, e.g., "PackageBaseAddress/3.0.0", from the service index
+func (n *NuGetFeed) resource(resourceType string) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.resources == nil {
+		var index struct {
+			Resources []struct {
+				ID   string `json:"@id"`
+				Type string `json:"@type"`
+			} `json:"resources"`
+		}
+		if err := getJSON(&index, n.URI); err != nil {
+			return "", err
+		}
+		n.resources = make(map[string]string)
+		for _, r := range index.Resources {
+			if _, in := n.resources[r.Type]; !in {
+				n.resources[r.Type] = r.ID
+			}
+		}
+	}
+	if u, in := n.resources[resourceType]; in {
+		return u, nil
+	}
+	return "", fmt.Errorf("NuGet feed %s has no %s resource", n.URI, resourceType)
+}
+
+func nuspecDependencyGroups(spec *nuspec) []*NuGetDependencyGroup {
+	toReqs := func(deps []nuspecDependency) []*Requirement {
+		reqs := make([]*Requirement, 0, len(deps))
+		for _, dep := range deps {
+			reqs = append(reqs, &Requirement{Name: dep.ID, Range: dep.Version})
+		}
+		return reqs
+	}
+	var groups []*NuGetDependencyGroup
+	if deps := spec.Metadata.Dependencies.Deps; len(deps) > 0 {
+		groups = append(groups, &NuGetDependencyGroup{Requirements: toReqs(deps)})
+	}
+	for _, group := range spec.Metadata.Dependencies.Groups {
+		groups = append(groups, &NuGetDependencyGroup{TargetFramework: group.TargetFramework, Requirements: toReqs(group.Deps)})
+	}
+	return groups
+}
+
+// Fetches a URL and decodes its JSON response into v
+func getJSON(v interface{}, u string) error {
+	resp, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return notFoundIf(resp.StatusCode, fmt.Errorf("%s returned %s", u, resp.Status))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,77 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const testNuspec = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>Serilog.Sinks.File</id>
+    <version>5.0.0</version>
+    <repository type="git" url="https://github.com/serilog/serilog-sinks-file.git" />
+    <projectUrl>https://serilog.net/</projectUrl>
+    <dependencies>
+      <group targetFramework=".NETFramework4.5">
+        <dependency id="Serilog" version="2.10.0" exclude="Build,Analyzers" />
+      </group>
+      <group targetFramework="net5.0">
+        <dependency id="Serilog" version="2.10.0" />
+        <dependency id="System.Text.Json" version="[5.0.0, )" />
+      </group>
+    </dependencies>
+  </metadata>
+</package>`
+
+func TestNuGetFeed(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/index.json":
+			fmt.Fprintf(w, `{"version": "3.0.0", "resources": [
+				{"@id": "%s/v3-flatcontainer/", "@type": "PackageBaseAddress/3.0.0"},
+				{"@id": "%s/v3/catalog0/index.json", "@type": "Catalog/3.0.0"}]}`, server.URL, server.URL)
+		case "/v3/catalog0/index.json":
+			fmt.Fprintf(w, `{"items": [{"@id": "%s/v3/catalog0/page0.json"}]}`, server.URL)
+		case "/v3/catalog0/page0.json":
+			fmt.Fprint(w, `{"items": [{"nuget:id": "Serilog"}, {"nuget:id": "Serilog.Sinks.File"}, {"nuget:id": "serilog"}]}`)
+		case "/v3-flatcontainer/serilog.sinks.file/index.json":
+			fmt.Fprint(w, `{"versions": ["4.1.0", "5.0.0"]}`)
+		case "/v3-flatcontainer/serilog.sinks.file/5.0.0/serilog.sinks.file.nuspec":
+			fmt.Fprint(w, testNuspec)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	feed := &NuGetFeed{URI: server.URL + "/v3/index.json"}
+	var idx Index = feed
+	if pkgs, err := idx.AllPackages(); err != nil || !reflect.DeepEqual(pkgs, []string{"Serilog", "Serilog.Sinks.File"}) {
+		t.Errorf("AllPackages: got %v, %v", pkgs, err)
+	}
+
+	reqs, err := idx.FetchPackageRequirements("Serilog.Sinks.File")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range reqs {
+		got = append(got, fmt.Sprintf("%s(%s)", req.Name, req.Specifier()))
+	}
+	if exp := []string{"Serilog(2.10.0)", "System.Text.Json([5.0.0, ))"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("FetchPackageRequirements: expected %v, got %v", exp, got)
+	}
+
+	groups, err := feed.FetchDependencyGroups("Serilog.Sinks.File", "5.0.0")
+	if err != nil || len(groups) != 2 || groups[1].TargetFramework != "net5.0" || len(groups[1].Requirements) != 2 {
+		t.Errorf("FetchDependencyGroups: got %v, %v", groups, err)
+	}
+	if repoURL, err := idx.FetchSourceRepoURL("Serilog.Sinks.File"); err != nil || repoURL != "https://github.com/serilog/serilog-sinks-file" {
+		t.Errorf("FetchSourceRepoURL: got %s, %v", repoURL, err)
+	}
+}
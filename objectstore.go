@@ -0,0 +1,307 @@
+package cheerio
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Environment variables configuring DefaultObjectStore. The S3 ones are those of the AWS CLI; AWS_ENDPOINT_URL points s3:// URLs at an
+// S3-compatible service, e.g., MinIO, instead of AWS.
+const (
+	AWSAccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	AWSSecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	AWSSessionTokenEnv = "AWS_SESSION_TOKEN"
+	AWSRegionEnv       = "AWS_REGION"
+	AWSEndpointEnv     = "AWS_ENDPOINT_URL"
+	GCSTokenEnv        = "GOOGLE_OAUTH_ACCESS_TOKEN"
+)
+
+var DefaultObjectStore = &ObjectStore{
+	S3Endpoint:      os.Getenv(AWSEndpointEnv),
+	S3Region:        os.Getenv(AWSRegionEnv),
+	AccessKeyID:     os.Getenv(AWSAccessKeyEnv),
+	SecretAccessKey: os.Getenv(AWSSecretKeyEnv),
+	SessionToken:    os.Getenv(AWSSessionTokenEnv),
+	GCSEndpoint:     "https://storage.googleapis.com",
+	GCSToken:        os.Getenv(GCSTokenEnv),
+}
+
+// Reads and writes objects named by s3://<bucket>/<key> URLs, in S3 or an S3-compatible service, and gs://<bucket>/<object> URLs, in Google Cloud
+// Storage, e.g., to publish graph files that a fleet of servers loads (see NewPyPIGraph and PyPIGraph.WriteFile). Requests are unauthenticated
+// if no credentials are set, which suits public buckets.
+type ObjectStore struct {
+	S3Endpoint      string // S3-compatible service, addressed with path-style URLs; AWS if empty
+	S3Region        string // "us-east-1" if empty
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // for temporary credentials
+
+	GCSEndpoint string
+	GCSToken    string // OAuth 2.0 access token, e.g., from "gcloud auth print-access-token"
+}
+
+// Returns whether uri names an object in object storage (s3:// or gs://) rather than a local file
+func IsObjectURL(uri string) bool {
+	return strings.HasPrefix(uri, "s3://") || strings.HasPrefix(uri, "gs://")
+}
+
+// Returns the contents of an object. Objects that don't exist are reported with an error wrapping os.ErrNotExist.
+func (s *ObjectStore) Get(uri string) (io.ReadCloser, error) {
+	req, err := s.request("GET", uri, nil, 0, sha256Hex(nil))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", os.ErrNotExist, uri)
+		}
+		return nil, fmt.Errorf("Reading %s returned %s", uri, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Creates or replaces an object. Readers see either the previous or the new contents, never a partial object.
+func (s *ObjectStore) Put(uri string, contents []byte) error {
+	return s.put(uri, bytes.NewReader(contents), int64(len(contents)), sha256Hex(contents))
+}
+
+// Uploads an object of size bytes read from body, whose hex-encoded SHA-256 hash (which S3 signatures cover) is payloadHash
+func (s *ObjectStore) put(uri string, body io.Reader, size int64, payloadHash string) error {
+	req, err := s.request("PUT", uri, body, size, payloadHash)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Writing %s returned %s: %s", uri, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// Returns a writer spooling the contents of an object to a temporary file, which is uploaded (see Put) when the writer is closed, so large
+// objects, e.g., graph files, aren't held in memory
+func (s *ObjectStore) Create(uri string) io.WriteCloser {
+	return &objectWriter{store: s, uri: uri, hash: sha256.New()}
+}
+
+type objectWriter struct {
+	store *ObjectStore
+	uri   string
+	file  *os.File // created on the first write
+	hash  hash.Hash
+	size  int64
+	err   error // of creating or writing the file
+}
+
+func (w *objectWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.file == nil {
+		if w.file, w.err = ioutil.TempFile("", "cheerio-object-"); w.err != nil {
+			return 0, w.err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	w.err = err
+	return n, err
+}
+
+func (w *objectWriter) Close() error {
+	if w.file == nil {
+		if w.err != nil {
+			return w.err
+		}
+		return w.store.Put(w.uri, nil)
+	}
+	defer os.Remove(w.file.Name())
+	defer w.file.Close()
+	if w.err != nil {
+		return w.err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return w.store.put(w.uri, w.file, w.size, hex.EncodeToString(w.hash.Sum(nil)))
+}
+
+// Creates (or truncates) a local file, or an object in DefaultObjectStore if file is an s3:// or gs:// URL
+func createFile(file string) (io.WriteCloser, error) {
+	if IsObjectURL(file) {
+		return DefaultObjectStore.Create(file), nil
+	}
+	return os.Create(file)
+}
+
+// Returns the authenticated request for a GET or PUT of an object, whose body of size bytes has the hex-encoded SHA-256 hash payloadHash
+func (s *ObjectStore) request(method, uri string, body io.Reader, size int64, payloadHash string) (*http.Request, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("Not an object URL: %s (expected <scheme>://<bucket>/<key>)", uri)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		var target string
+		if s.S3Endpoint != "" {
+			target = fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.S3Endpoint, "/"), bucket, awsEscapePath(key))
+		} else {
+			target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, s.region(), awsEscapePath(key))
+		}
+		req, err := newObjectRequest(method, target, body, size)
+		if err != nil {
+			return nil, err
+		}
+		if s.AccessKeyID != "" {
+			s.signV4(req, payloadHash, time.Now())
+		}
+		return req, nil
+	case "gs":
+		target := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", s.GCSEndpoint, url.PathEscape(bucket), url.PathEscape(key))
+		if method == "PUT" {
+			target = fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", s.GCSEndpoint, url.PathEscape(bucket), url.QueryEscape(key))
+			method = "POST"
+		}
+		req, err := newObjectRequest(method, target, body, size)
+		if err != nil {
+			return nil, err
+		}
+		if s.GCSToken != "" {
+			req.Header.Set("Authorization", "Bearer "+s.GCSToken)
+		}
+		return req, nil
+	}
+	return nil, fmt.Errorf("Unsupported object storage scheme '%s' (expected s3 or gs)", u.Scheme)
+}
+
+// Returns a request with a body of size bytes, if body isn't nil
+func newObjectRequest(method, target string, body io.Reader, size int64) (*http.Request, error) {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	return req, nil
+}
+
+func (s *ObjectStore) region() string {
+	if s.S3Region == "" {
+		return "us-east-1"
+	}
+	return s.S3Region
+}
+
+// Signs an S3 request with AWS Signature Version 4, covering the host, every header already set on the request, and the payload, given by its
+// hex-encoded SHA-256 hash
+func (s *ObjectStore) signV4(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, req.URL.EscapedPath(), canonicalQuery(req.URL.Query()), canonicalHeaders.String(),
+		signedHeaders, payloadHash}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, s.region())
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	for _, part := range []string{s.region(), "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.AccessKeyID, scope,
+		signedHeaders, signature))
+}
+
+// Escapes an object key as S3 expects in request paths: every byte but unreserved characters and "/" is percent-encoded
+func awsEscapePath(key string) string {
+	return awsEscape(key, "-_.~/")
+}
+
+// Percent-encodes every byte of s but letters, digits and the given characters
+func awsEscape(s, unreserved string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || strings.IndexByte(unreserved, c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, awsEscape(key, "-_.~")+"="+awsEscape(value, "-_.~"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
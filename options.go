@@ -0,0 +1,99 @@
+package cheerio
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Configures a PackageIndex created by NewPackageIndex
+type PackageIndexOption func(*PackageIndex)
+
+// Returns the index served at uri, e.g., "https://pypi.python.org" or the file:// URL of a local mirror, configured by opts in order, e.g.,
+//
+//	NewPackageIndex("https://pypi.corp", WithBasicAuth("ci", token), WithRateLimit(5), WithCache(NewLRUCache(1000, time.Hour)))
+func NewPackageIndex(uri string, opts ...PackageIndexOption) *PackageIndex {
+	p := &PackageIndex{URI: strings.TrimSuffix(uri, "/")}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Sends the index's requests with client, e.g., one with a custom transport (see PackageIndex.Client)
+func WithClient(client *http.Client) PackageIndexOption {
+	return func(p *PackageIndex) { p.Client = client }
+}
+
+// Caches the index's parsed metadata and requirements in cache
+func WithCache(cache *LRUCache) PackageIndexOption {
+	return func(p *PackageIndex) { p.Cache = cache }
+}
+
+// Sends an extra header with the index's requests; may be given several times
+func WithHeader(key, value string) PackageIndexOption {
+	return func(p *PackageIndex) {
+		if p.Headers == nil {
+			p.Headers = make(http.Header)
+		}
+		p.Headers.Add(key, value)
+	}
+}
+
+// Authenticates the index's requests with HTTP basic auth, e.g., with a token of a private index; requests to other hosts, e.g., those
+// serving release files, are not authenticated
+func WithBasicAuth(user, password string) PackageIndexOption {
+	return func(p *PackageIndex) { p.BasicAuth = url.UserPassword(user, password) }
+}
+
+// Sends the index's requests at most rate times per second, backing off when the index is overloaded (see NewThrottle)
+func WithRateLimit(rate float64) PackageIndexOption {
+	return func(p *PackageIndex) { p.Throttle = NewThrottle(rate, 0) }
+}
+
+// Paces the index's requests with throttle, e.g., one shared by several indexes
+func WithThrottle(throttle *Throttle) PackageIndexOption {
+	return func(p *PackageIndex) { p.Throttle = throttle }
+}
+
+// Sends the index's diagnostics to logger
+func WithLogger(logger Logger) PackageIndexOption {
+	return func(p *PackageIndex) { p.Logger = logger }
+}
+
+// Reads requirements from sources, in order (see PackageIndex.Sources)
+func WithSources(sources ...RequirementsSource) PackageIndexOption {
+	return func(p *PackageIndex) { p.Sources = sources }
+}
+
+// Chooses release files with policy (see PackageIndex.Policy)
+func WithPolicy(policy ArtifactPolicy) PackageIndexOption {
+	return func(p *PackageIndex) { p.Policy = policy }
+}
+
+// Handles unparseable lines of requires.txt files as mode says, e.g., ParseStrict to fail on them
+func WithParseMode(mode ParseMode) PackageIndexOption {
+	return func(p *PackageIndex) { p.ParseMode = mode }
+}
+
+// Sends the index's requests through proxy, e.g., "socks5://localhost:1080"
+func WithProxy(proxy string) PackageIndexOption {
+	return func(p *PackageIndex) { p.Proxy = proxy }
+}
+
+// Uses config for the index's TLS connections, e.g., from NewTLSConfig
+func WithTLS(config *tls.Config) PackageIndexOption {
+	return func(p *PackageIndex) { p.TLS = config }
+}
+
+// Identifies the index's requests with userAgent instead of DefaultUserAgent
+func WithUserAgent(userAgent string) PackageIndexOption {
+	return func(p *PackageIndex) { p.UserAgent = userAgent }
+}
+
+// Gives up on each of the index's requests after timeout
+func WithTimeout(timeout time.Duration) PackageIndexOption {
+	return func(p *PackageIndex) { p.Timeout = timeout }
+}
@@ -0,0 +1,74 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewPackageIndex(t *testing.T) {
+	var mu sync.Mutex
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		mu.Unlock()
+		fmt.Fprint(w, `<a href="/files/foo-1.0.tar.gz">foo-1.0.tar.gz</a>`)
+	}))
+	defer server.Close()
+
+	var requests int
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	index := NewPackageIndex(server.URL+"/", WithClient(client), WithBasicAuth("ci", "secret"), WithRateLimit(100),
+		WithSources(SourceJSON), WithLogger(NopLogger{}), WithTimeout(time.Minute))
+	if index.URI != server.URL || len(index.Sources) != 1 || index.Throttle == nil || index.Timeout != time.Minute {
+		t.Errorf("unexpected index %+v", index)
+	}
+	if _, err := index.Versions("foo"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 || len(auths) != 1 || auths[0] != "Basic Y2k6c2VjcmV0" {
+		t.Errorf("expected an authenticated request through the client, got %d requests with %q", requests, auths)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestBasicAuthOtherHost(t *testing.T) {
+	var mu sync.Mutex
+	var auths []string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		mu.Unlock()
+		fmt.Fprint(w, `<a href="/files/foo-1.0.tar.gz">foo-1.0.tar.gz</a>`)
+	}))
+	defer mirror.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		mu.Unlock()
+		http.Redirect(w, r, mirror.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	index := NewPackageIndex(server.URL, WithBasicAuth("ci", "secret"), WithLogger(NopLogger{}))
+	if _, err := index.Versions("foo"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(auths) != 2 || auths[0] != "Basic Y2k6c2VjcmV0" || auths[1] != "" {
+		t.Errorf("expected only the request to the index to be authenticated, got %q", auths)
+	}
+}
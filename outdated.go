@@ -0,0 +1,61 @@
+package cheerio
+
+import (
+	"strings"
+)
+
+// Source of the latest versions of packages, e.g., a PackageIndex or a FakePyPI
+type LatestVersionSource interface {
+	LatestVersion(pkg string) (string, error)
+}
+
+// A requirement compared to the latest release of its package (see CheckOutdated)
+type OutdatedRequirement struct {
+	Req          *Requirement
+	Pinned       string // the version pinned by an "==" or "===" clause, e.g., "1.0" for "flask==1.0"; empty if the requirement isn't pinned
+	Latest       string // the latest version of the package; empty if it couldn't be fetched
+	Outdated     bool   // whether the pinned version is older than the latest version
+	AllowsLatest bool   // whether the requirement's specifier admits the latest version, i.e., upgrading doesn't take editing the requirement
+	Err          error  // why the latest version couldn't be fetched
+}
+
+// Compares requirements, e.g., those of a requirements file, to the latest versions of their packages according to src, in order. Direct URL
+// references are skipped, as they don't name versions, and so are requirements that don't pin a version unless unpinned is set. The latest
+// version of each package is fetched once.
+func CheckOutdated(src LatestVersionSource, reqs []*Requirement, unpinned bool) []*OutdatedRequirement {
+	type latestVersion struct {
+		version string
+		err     error
+	}
+	latest := make(map[string]latestVersion)
+
+	results := make([]*OutdatedRequirement, 0, len(reqs))
+	for _, req := range reqs {
+		if req.URL != "" {
+			continue
+		}
+		res := &OutdatedRequirement{Req: req}
+		for _, clause := range req.Clauses() {
+			if (clause[0] == "==" || clause[0] == "===") && !strings.Contains(clause[1], "*") {
+				res.Pinned = clause[1]
+				break
+			}
+		}
+		if res.Pinned == "" && !unpinned {
+			continue
+		}
+
+		pkg := NormalizedPkgName(req.Name)
+		l, in := latest[pkg]
+		if !in {
+			l.version, l.err = src.LatestVersion(req.Name)
+			latest[pkg] = l
+		}
+		if res.Latest, res.Err = l.version, l.err; res.Err == nil {
+			res.Outdated = res.Pinned != "" && CompareVersions(res.Pinned, res.Latest) < 0
+			res.AllowsLatest, _ = req.Matches(res.Latest)
+		}
+		results = append(results, res)
+	}
+	return results
+}
@@ -0,0 +1,66 @@
+package cheerio
+
+import (
+	"testing"
+)
+
+func TestCheckOutdated(t *testing.T) {
+	index := FakePyPI{
+		"Flask":    {Requirements: map[string][]*Requirement{"1.0": nil, "2.0": nil, "2.1rc1": nil}},
+		"requests": {Requirements: map[string][]*Requirement{"2.31.0": nil}},
+	}
+	reqs := []*Requirement{
+		{Name: "flask", Constraint: "==", Version: "1.0"},
+		{Name: "flask", Constraint: ">=", Version: "1.0"},
+		{Name: "requests", Constraint: "==", Version: "2.31.0"},
+		{Name: "celery", URL: "https://github.com/celery/celery/archive/master.zip"},
+		{Name: "django", Constraint: "==", Version: "4.2"},
+	}
+
+	results := CheckOutdated(index, reqs, true)
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results without the direct reference, got %d", len(results))
+	}
+	if res := results[0]; res.Pinned != "1.0" || res.Latest != "2.0" || !res.Outdated || res.AllowsLatest {
+		t.Errorf("expected an outdated pin that doesn't allow the latest version, got %+v", res)
+	}
+	if res := results[1]; res.Pinned != "" || res.Outdated || !res.AllowsLatest {
+		t.Errorf("expected an unpinned requirement that allows the latest version, got %+v", res)
+	}
+	if res := results[2]; res.Outdated || !res.AllowsLatest {
+		t.Errorf("expected an up-to-date pin, got %+v", res)
+	}
+	if res := results[3]; res.Err == nil {
+		t.Errorf("expected an error for an unknown package, got %+v", res)
+	}
+}
+
+// Counts the latest versions fetched from a source
+type countingLatestSource struct {
+	LatestVersionSource
+	fetched []string
+}
+
+func (s *countingLatestSource) LatestVersion(pkg string) (string, error) {
+	s.fetched = append(s.fetched, pkg)
+	return s.LatestVersionSource.LatestVersion(pkg)
+}
+
+func TestCheckOutdatedPinnedOnly(t *testing.T) {
+	src := &countingLatestSource{LatestVersionSource: FakePyPI{
+		"flask":    {Requirements: map[string][]*Requirement{"2.0": nil}},
+		"requests": {Requirements: map[string][]*Requirement{"2.31.0": nil}},
+	}}
+	reqs := []*Requirement{
+		{Name: "flask", Constraint: ">=", Version: "1.0"},
+		{Name: "requests", Constraint: "==", Version: "2.31.0"},
+	}
+
+	results := CheckOutdated(src, reqs, false)
+	if len(results) != 1 || results[0].Req.Name != "requests" {
+		t.Errorf("expected only the pinned requirement, got %+v", results)
+	}
+	if len(src.fetched) != 1 || src.fetched[0] != "requests" {
+		t.Errorf("expected only the latest version of the pinned package to be fetched, got %q", src.fetched)
+	}
+}
@@ -0,0 +1,112 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var DefaultPackagist = &Packagist{URI: "https://packagist.org", RepoURI: "https://repo.packagist.org"}
+
+// The Packagist registry of PHP (Composer) packages, named "vendor/package". Requirements are read from the Composer v2 metadata API
+// (https://packagist.org/apidoc).
+type Packagist struct {
+	URI     string // packagist.org, used to list packages
+	RepoURI string // repo.packagist.org, serving package metadata
+}
+
+// A release in Composer metadata, reduced to the fields used here
+type composerRelease struct {
+	Version    string            `json:"version"`
+	Require    map[string]string `json:"require"`
+	RequireDev map[string]string `json:"require-dev"`
+	Source     struct {
+		URL string `json:"url"`
+	} `json:"source"`
+	Homepage string `json:"homepage"`
+}
+
+// Returns the names of all packages on Packagist
+func (p *Packagist) AllPackages() ([]string, error) {
+	resp, err := http.Get(p.URI + "/packages/list.json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Packagist returned %s for the package list", resp.Status)
+	}
+	var list struct {
+		PackageNames []string `json:"packageNames"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.PackageNames, nil
+}
+
+// Returns the require (then require-dev, with Extra "dev") requirements of the latest release of a package. Platform requirements, such as "php"
+// or "ext-json", are omitted, since they aren't packages.
+func (p *Packagist) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	release, err := p.latestRelease(pkg)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	reqs := rangeRequirements(composerPackages(release.Require), "", seen)
+	return append(reqs, rangeRequirements(composerPackages(release.RequireDev), "dev", seen)...), nil
+}
+
+// Returns the source repository URL of the latest release of a package
+func (p *Packagist) FetchSourceRepoURL(pkg string) (string, error) {
+	release, err := p.latestRelease(pkg)
+	if err != nil {
+		return "", err
+	}
+	var candidates []repoCandidate
+	for _, u := range []string{release.Source.URL, release.Homepage} {
+		if canonical, err := CanonicalRepoURL(u); err == nil {
+			candidates = append(candidates, repoCandidate{canonical, RepoFromProjectURL})
+		}
+	}
+	if repoURL, _ := matchRepoURL(candidates); repoURL != "" {
+		return repoURL, nil
+	}
+	return "", fmt.Errorf("Could not parse repo URL from metadata of package %s", pkg)
+}
+
+// Fetches the newest tagged release of a package. In the (minified) v2 metadata format, releases are listed newest first, and only the first is
+// complete; later ones only list the fields that changed.
+func (p *Packagist) latestRelease(pkg string) (*composerRelease, error) {
+	pkg = strings.ToLower(pkg)
+	resp, err := http.Get(fmt.Sprintf("%s/p2/%s.json", p.RepoURI, pkg))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("Packagist returned %s for package %s", resp.Status, pkg))
+	}
+	var metadata struct {
+		Packages map[string][]*composerRelease `json:"packages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, err
+	}
+	if releases := metadata.Packages[pkg]; len(releases) > 0 {
+		return releases[0], nil
+	}
+	return nil, fmt.Errorf("[no-files] %w of package %s", ErrNoReleases, pkg)
+}
+
+// Returns the requirements on packages ("vendor/package" names), leaving out platform requirements
+func composerPackages(require map[string]string) map[string]string {
+	pkgs := make(map[string]string, len(require))
+	for name, constraint := range require {
+		if strings.Contains(name, "/") {
+			pkgs[name] = constraint
+		}
+	}
+	return pkgs
+}
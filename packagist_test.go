@@ -0,0 +1,47 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPackagist(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packages/list.json":
+			fmt.Fprint(w, `{"packageNames": ["laravel/framework", "monolog/monolog"]}`)
+		case "/p2/monolog/monolog.json":
+			fmt.Fprint(w, `{"minified": "composer/2.0", "packages": {"monolog/monolog": [
+				{"name": "monolog/monolog", "version": "3.5.0", "require": {"php": ">=8.1", "ext-json": "*", "psr/log": "^2.0 || ^3.0"},
+				 "require-dev": {"phpunit/phpunit": "^10.1", "psr/log": "^3.0"},
+				 "source": {"type": "git", "url": "https://github.com/Seldaek/monolog.git"}},
+				{"version": "3.4.0", "require": {"psr/log": "^2.0"}}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var idx Index = &Packagist{URI: server.URL, RepoURI: server.URL}
+	if pkgs, err := idx.AllPackages(); err != nil || len(pkgs) != 2 {
+		t.Errorf("AllPackages: got %v, %v", pkgs, err)
+	}
+
+	reqs, err := idx.FetchPackageRequirements("Monolog/Monolog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range reqs {
+		got = append(got, fmt.Sprintf("%s(%s):%s", req.Name, req.Specifier(), req.Extra))
+	}
+	if exp := []string{"psr/log(^2.0 || ^3.0):", "phpunit/phpunit(^10.1):dev"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("FetchPackageRequirements: expected %v, got %v", exp, got)
+	}
+	if repoURL, err := idx.FetchSourceRepoURL("monolog/monolog"); err != nil || repoURL != "https://github.com/Seldaek/monolog" {
+		t.Errorf("FetchSourceRepoURL: got %s, %v", repoURL, err)
+	}
+}
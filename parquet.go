@@ -0,0 +1,284 @@
+package cheerio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A column of a parquetTable
+type parquetColumn struct {
+	Name     string
+	Int64    bool // INT64 values instead of UTF-8 strings
+	Optional bool // empty values are written as nulls
+}
+
+// A minimal Parquet writer for tables of string and int64 columns, enough for DuckDB, Spark, pandas, etc. to read graph exports without a
+// dependency on a Parquet library: the table is written as a single row group with one uncompressed, PLAIN-encoded data page per column. Rows
+// are given as strings, parsed as integers for Int64 columns.
+type parquetTable struct {
+	columns []parquetColumn
+	rows    [][]string
+}
+
+func newParquetTable(columns ...parquetColumn) *parquetTable {
+	return &parquetTable{columns: columns}
+}
+
+func (t *parquetTable) add(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+const (
+	parquetMagic = "PAR1"
+
+	// Parquet physical types, repetitions, converted types, encodings and page types, from parquet.thrift
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+	parquetRequired      = 0
+	parquetOptional      = 1
+	parquetUTF8          = 0
+	parquetPlain         = 0
+	parquetRLE           = 3
+	parquetDataPage      = 0
+)
+
+// Writes the table in the Parquet file format
+func (t *parquetTable) write(w io.Writer) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(t.columns))
+	for i := range t.columns {
+		page, err := t.page(i)
+		if err != nil {
+			return err
+		}
+		var header thriftCompact
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structBegin(5) // DataPageHeader
+		header.i32(1, int32(len(t.rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.structEnd()
+		header.stop()
+
+		chunks[i].offset = int64(file.Len())
+		file.Write(header.Bytes())
+		file.Write(page)
+		chunks[i].size = int64(file.Len()) - chunks[i].offset
+	}
+
+	var meta thriftCompact
+	meta.i32(1, 1) // version
+	meta.listBegin(2, thriftStruct, len(t.columns)+1)
+	meta.elemBegin() // root
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(t.columns)))
+	meta.elemEnd()
+	for _, col := range t.columns {
+		meta.elemBegin()
+		if col.Int64 {
+			meta.i32(1, parquetTypeInt64)
+		} else {
+			meta.i32(1, parquetTypeByteArray)
+		}
+		if col.Optional {
+			meta.i32(3, parquetOptional)
+		} else {
+			meta.i32(3, parquetRequired)
+		}
+		meta.binary(4, col.Name)
+		if !col.Int64 {
+			meta.i32(6, parquetUTF8)
+		}
+		meta.elemEnd()
+	}
+	meta.i64(3, int64(len(t.rows)))
+	meta.listBegin(4, thriftStruct, 1)
+	meta.elemBegin() // RowGroup
+	meta.listBegin(1, thriftStruct, len(t.columns))
+	var totalSize int64
+	for i, col := range t.columns {
+		totalSize += chunks[i].size
+		meta.elemBegin() // ColumnChunk
+		meta.i64(2, chunks[i].offset)
+		meta.structBegin(3) // ColumnMetaData
+		if col.Int64 {
+			meta.i32(1, parquetTypeInt64)
+		} else {
+			meta.i32(1, parquetTypeByteArray)
+		}
+		meta.listBegin(2, thriftI32, 2)
+		meta.varint(zigzag(parquetPlain))
+		meta.varint(zigzag(parquetRLE))
+		meta.listBegin(3, thriftBinary, 1)
+		meta.varint(uint64(len(col.Name)))
+		meta.WriteString(col.Name)
+		meta.i32(4, 0) // uncompressed
+		meta.i64(5, int64(len(t.rows)))
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.structEnd()
+		meta.elemEnd()
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, int64(len(t.rows)))
+	meta.elemEnd()
+	meta.binary(6, "cheerio")
+	meta.stop()
+
+	file.Write(meta.Bytes())
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(meta.Len()))
+	file.Write(length[:])
+	file.WriteString(parquetMagic)
+	_, err := file.WriteTo(w)
+	return err
+}
+
+// Returns the data page of column i: definition levels if the column is optional, followed by the PLAIN-encoded non-null values
+func (t *parquetTable) page(i int) ([]byte, error) {
+	col := t.columns[i]
+	var page bytes.Buffer
+	if col.Optional {
+		// RLE-encoded runs of definition levels (1 for values, 0 for nulls) with a bit width of 1, prefixed by their length
+		var levels thriftCompact
+		for start := 0; start < len(t.rows); {
+			defined := t.rows[start][i] != ""
+			end := start + 1
+			for end < len(t.rows) && (t.rows[end][i] != "") == defined {
+				end++
+			}
+			levels.varint(uint64(end-start) << 1)
+			if defined {
+				levels.WriteByte(1)
+			} else {
+				levels.WriteByte(0)
+			}
+			start = end
+		}
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(levels.Len()))
+		page.Write(length[:])
+		page.Write(levels.Bytes())
+	}
+	for _, row := range t.rows {
+		value := row[i]
+		if value == "" && col.Optional {
+			continue
+		}
+		if col.Int64 {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid integer %q in column %s", value, col.Name)
+			}
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], uint64(n))
+			page.Write(b[:])
+		} else {
+			var length [4]byte
+			binary.LittleEndian.PutUint32(length[:], uint32(len(value)))
+			page.Write(length[:])
+			page.WriteString(value)
+		}
+	}
+	return page.Bytes(), nil
+}
+
+// Thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// A writer of structs in the Thrift compact protocol, which Parquet uses for its metadata
+type thriftCompact struct {
+	bytes.Buffer
+	last  int16   // id of the last field written in the current struct
+	stack []int16 // ids of the last fields of enclosing structs
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+func (t *thriftCompact) varint(n uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.Write(b[:binary.PutUvarint(b[:], n)])
+}
+
+func (t *thriftCompact) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.last = id
+}
+
+func (t *thriftCompact) i32(id int16, n int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(n)))
+}
+
+func (t *thriftCompact) i64(id int16, n int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(n))
+}
+
+func (t *thriftCompact) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.varint(uint64(len(s)))
+	t.WriteString(s)
+}
+
+// Writes the header of a list field, whose n elements are written next
+func (t *thriftCompact) listBegin(id int16, elemType byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		t.WriteByte(0xf0 | elemType)
+		t.varint(uint64(n))
+	}
+}
+
+func (t *thriftCompact) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+func (t *thriftCompact) structEnd() {
+	t.elemEnd()
+}
+
+// Begins a struct element of a list
+func (t *thriftCompact) elemBegin() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftCompact) elemEnd() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// Ends the top-level struct
+func (t *thriftCompact) stop() {
+	t.WriteByte(0)
+}
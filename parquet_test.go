@@ -0,0 +1,171 @@
+package cheerio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// Reads a Thrift compact struct into a map from field id to value: int64 for integers, string for binaries, []interface{} for lists, and
+// map[int16]interface{} for structs
+func readThriftStruct(t *testing.T, r *bytes.Reader) map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b == 0 {
+			return fields
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			n, _ := binary.ReadUvarint(r)
+			id = int16(n>>1) ^ -int16(n&1)
+		}
+		last = id
+		fields[id] = readThriftValue(t, r, b&0x0f)
+	}
+}
+
+func readThriftValue(t *testing.T, r *bytes.Reader, typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		n, _ := binary.ReadUvarint(r)
+		return int64(n>>1) ^ -int64(n&1)
+	case thriftBinary:
+		n, _ := binary.ReadUvarint(r)
+		b := make([]byte, n)
+		r.Read(b)
+		return string(b)
+	case thriftList:
+		h, _ := r.ReadByte()
+		n := uint64(h >> 4)
+		if n == 15 {
+			n, _ = binary.ReadUvarint(r)
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = readThriftValue(t, r, h&0x0f)
+		}
+		return list
+	case thriftStruct:
+		return readThriftStruct(t, r)
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+// Reads the columns of a Parquet file written by parquetTable, with nil for nulls
+func readParquet(t *testing.T, data []byte) (names []string, columns [][]interface{}) {
+	if string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatal("missing Parquet magic")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := readThriftStruct(t, bytes.NewReader(data[len(data)-8-metaLen:len(data)-8]))
+	numRows := meta[3].(int64)
+
+	schema := meta[2].([]interface{})
+	chunks := meta[4].([]interface{})[0].(map[int16]interface{})[1].([]interface{})
+	for i, elem := range schema[1:] {
+		col := elem.(map[int16]interface{})
+		names = append(names, col[4].(string))
+		optional := col[3].(int64) == parquetOptional
+
+		colMeta := chunks[i].(map[int16]interface{})[3].(map[int16]interface{})
+		if colMeta[1] != col[1] || colMeta[5].(int64) != numRows {
+			t.Fatalf("column %s: inconsistent metadata %v", names[i], colMeta)
+		}
+		r := bytes.NewReader(data[colMeta[9].(int64):])
+		header := readThriftStruct(t, r)
+		page := make([]byte, header[3].(int64))
+		r.Read(page)
+
+		defined := make([]bool, 0, numRows)
+		if optional {
+			levelsLen := binary.LittleEndian.Uint32(page)
+			levels := bytes.NewReader(page[4 : 4+levelsLen])
+			for levels.Len() > 0 {
+				run, _ := binary.ReadUvarint(levels)
+				value, _ := levels.ReadByte()
+				for j := uint64(0); j < run>>1; j++ {
+					defined = append(defined, value == 1)
+				}
+			}
+			page = page[4+levelsLen:]
+		} else {
+			for j := int64(0); j < numRows; j++ {
+				defined = append(defined, true)
+			}
+		}
+
+		values := make([]interface{}, 0, numRows)
+		for _, d := range defined {
+			switch {
+			case !d:
+				values = append(values, nil)
+			case col[1].(int64) == parquetTypeInt64:
+				values = append(values, int64(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			default:
+				n := binary.LittleEndian.Uint32(page)
+				values = append(values, string(page[4:4+n]))
+				page = page[4+n:]
+			}
+		}
+		columns = append(columns, values)
+	}
+	return names, columns
+}
+
+func TestWriteEdgeParquet(t *testing.T) {
+	g := testGraph("flask:werkzeug", "flask:jinja2", "flask:python-dotenv:dotenv", "jinja2:markupsafe")
+
+	var buf bytes.Buffer
+	if err := g.WriteEdgeParquet(&buf); err != nil {
+		t.Fatal(err)
+	}
+	names, columns := readParquet(t, buf.Bytes())
+	if exp := []string{"source", "target", "constraint", "extra"}; !reflect.DeepEqual(names, exp) {
+		t.Errorf("expected columns %v, got %v", exp, names)
+	}
+	exp := [][]interface{}{
+		{"flask", "flask", "flask", "jinja2"},
+		{"jinja2", "werkzeug", "python-dotenv", "markupsafe"},
+		{nil, nil, nil, nil},
+		{nil, nil, "dotenv", nil},
+	}
+	if !reflect.DeepEqual(columns, exp) {
+		t.Errorf("expected %v, got %v", exp, columns)
+	}
+}
+
+func TestWriteNodeParquet(t *testing.T) {
+	g := testGraph("Flask:werkzeug", "Flask:python-dotenv:dotenv")
+	info := PackageInfoIndex{"flask": {Pkg: "flask", Version: "2.0.0", Downloads: &DownloadCounts{LastMonth: 1000}}}
+
+	var buf bytes.Buffer
+	if err := g.WriteNodeParquet(&buf, info); err != nil {
+		t.Fatal(err)
+	}
+	names, columns := readParquet(t, buf.Bytes())
+	if exp := nodeColumns(true); !reflect.DeepEqual(names, exp) {
+		t.Errorf("expected columns %v, got %v", exp, names)
+	}
+	exp := [][]interface{}{
+		{"flask", "python-dotenv", "werkzeug"},
+		{"Flask", "python-dotenv", "werkzeug"},
+		{int64(2), nil, nil},
+		{int64(0), int64(1), int64(1)},
+		{"dotenv", nil, nil},
+		{"2.0.0", nil, nil},
+		{nil, nil, nil},
+		{nil, nil, nil},
+		{int64(1000), nil, nil},
+	}
+	if !reflect.DeepEqual(columns, exp) {
+		t.Errorf("expected %v, got %v", exp, columns)
+	}
+}
@@ -0,0 +1,223 @@
+package cheerio
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A version parsed according to PEP 440, e.g., "1!2.0.1rc1.post2.dev3+local"
+type pep440Version struct {
+	epoch   int
+	release []int
+	pre     *[2]int // (phase, number), where phase is 0 for "a", 1 for "b", and 2 for "rc"
+	post    int     // -1 if not a post-release
+	dev     int     // -1 if not a development release
+	local   string
+}
+
+var pep440Regexp = regexp.MustCompile(`^v?(?:([0-9]+)!)?([0-9]+(?:\.[0-9]+)*)` +
+	`(?:[\-_\.]?(a|alpha|b|beta|rc|c|pre|preview)[\-_\.]?([0-9]*))?` +
+	`(?:-([0-9]+)|[\-_\.]?(post|rev|r)[\-_\.]?([0-9]*))?` +
+	`(?:[\-_\.]?(dev)[\-_\.]?([0-9]*))?` +
+	`(?:\+([a-z0-9]+(?:[\-_\.][a-z0-9]+)*))?$`)
+
+var prePhases = map[string]int{"a": 0, "alpha": 0, "b": 1, "beta": 1, "rc": 2, "c": 2, "pre": 2, "preview": 2}
+
+// Parses a PEP 440 version. Returns an error for legacy versions that don't follow the scheme.
+func parseVersion(s string) (*pep440Version, error) {
+	match := pep440Regexp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if match == nil {
+		return nil, fmt.Errorf("Invalid PEP 440 version: '%s'", s)
+	}
+
+	v := &pep440Version{post: -1, dev: -1, local: match[10]}
+	v.epoch, _ = strconv.Atoi(match[1])
+	for _, part := range strings.Split(match[2], ".") {
+		n, _ := strconv.Atoi(part)
+		v.release = append(v.release, n)
+	}
+	if match[3] != "" {
+		n, _ := strconv.Atoi(match[4])
+		v.pre = &[2]int{prePhases[match[3]], n}
+	}
+	if match[5] != "" {
+		v.post, _ = strconv.Atoi(match[5])
+	} else if match[6] != "" {
+		v.post, _ = strconv.Atoi(match[7])
+	}
+	if match[8] != "" {
+		v.dev, _ = strconv.Atoi(match[9])
+	}
+	return v, nil
+}
+
+// Returns whether v is a pre-release or development release
+func (v *pep440Version) isPrerelease() bool {
+	return v.pre != nil || v.dev >= 0
+}
+
+// Returns whether v and w have the same epoch and release segment, ignoring pre-, post-, and development release parts
+func (v *pep440Version) sameRelease(w *pep440Version) bool {
+	n := len(v.release)
+	if len(w.release) > n {
+		n = len(w.release)
+	}
+	return v.epoch == w.epoch && intsEqual(v.releaseN(n), w.releaseN(n))
+}
+
+// Returns the release segment padded with zeros (or truncated) to n components
+func (v *pep440Version) releaseN(n int) []int {
+	release := make([]int, n)
+	copy(release, v.release)
+	return release
+}
+
+// Compares two versions, ignoring local version labels unless withLocal is set. Returns -1, 0, or 1.
+func (v *pep440Version) compare(w *pep440Version, withLocal bool) int {
+	if c := compareInts(v.epoch, w.epoch); c != 0 {
+		return c
+	}
+	n := len(v.release)
+	if len(w.release) > n {
+		n = len(w.release)
+	}
+	vr, wr := v.releaseN(n), w.releaseN(n)
+	for i := range vr {
+		if c := compareInts(vr[i], wr[i]); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(v.preKey(), w.preKey()); c != 0 {
+		return c
+	}
+	if v.pre != nil && w.pre != nil {
+		if c := compareInts(v.pre[1], w.pre[1]); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(v.post, w.post); c != 0 {
+		return c
+	}
+	if c := compareInts(devKey(v.dev), devKey(w.dev)); c != 0 {
+		return c
+	}
+	if withLocal {
+		return strings.Compare(v.local, w.local)
+	}
+	return 0
+}
+
+// Orders the pre-release phase: development releases of a final version sort before its pre-releases, and final versions after them
+func (v *pep440Version) preKey() int {
+	if v.pre != nil {
+		return v.pre[0]
+	} else if v.dev >= 0 && v.post < 0 {
+		return -1
+	}
+	return 3
+}
+
+// Orders development releases before the release they precede
+func devKey(dev int) int {
+	if dev < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return dev
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// Compares two version strings according to PEP 440, returning -1, 0, or 1. Versions that don't follow PEP 440 are compared as strings, and sort
+// before those that do.
+func CompareVersions(a, b string) int {
+	va, errA := parseVersion(a)
+	vb, errB := parseVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.compare(vb, true)
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Returns whether version ver satisfies the version clause "<op><spec>", e.g., op ">=" and spec "1.0", as defined by PEP 440. Supports the
+// operators "==", "!=", "<", "<=", ">", ">=", "~=", and "===", as well as wildcards ("==1.*") with "==" and "!=".
+func versionMatches(ver, op, spec string) (bool, error) {
+	if op == "===" {
+		return strings.EqualFold(strings.TrimSpace(ver), strings.TrimSpace(spec)), nil
+	}
+
+	v, err := parseVersion(ver)
+	if err != nil {
+		return false, err
+	}
+
+	if op == "==" || op == "!=" {
+		var equal bool
+		if prefix := strings.TrimSuffix(spec, ".*"); prefix != spec {
+			p, err := parseVersion(prefix)
+			if err != nil {
+				return false, err
+			}
+			equal = v.epoch == p.epoch && intsEqual(v.releaseN(len(p.release)), p.release)
+		} else {
+			s, err := parseVersion(spec)
+			if err != nil {
+				return false, err
+			}
+			// a local label is only compared if the specifier has one
+			equal = v.compare(s, s.local != "") == 0
+		}
+		return equal == (op == "=="), nil
+	}
+
+	s, err := parseVersion(spec)
+	if err != nil {
+		return false, err
+	}
+	c := v.compare(s, false)
+	switch op {
+	case "<":
+		// "<1.0" does not match pre-releases of 1.0 unless the specifier is itself a pre-release
+		return c < 0 && (s.isPrerelease() || !v.isPrerelease() || !v.sameRelease(s)), nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		// ">1.0" does not match post-releases of 1.0 unless the specifier is itself a post-release
+		return c > 0 && (s.post >= 0 || v.post < 0 || !v.sameRelease(s)), nil
+	case ">=":
+		return c >= 0, nil
+	case "~=":
+		if len(s.release) < 2 {
+			return false, fmt.Errorf("Invalid compatible release clause: '~=%s'", spec)
+		}
+		prefix := s.release[:len(s.release)-1]
+		return c >= 0 && v.epoch == s.epoch && intsEqual(v.releaseN(len(prefix)), prefix), nil
+	}
+	return false, fmt.Errorf("Unknown version operator: '%s'", op)
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
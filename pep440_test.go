@@ -0,0 +1,61 @@
+package cheerio
+
+import (
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{"1.0.dev1", "1.0a1.dev1", "1.0a1", "1.0b2", "1.0rc1", "1.0", "1.0+local", "1.0.post1.dev1", "1.0.post1", "1.0.1", "1.1", "1!0.1"}
+	for i := range ordered {
+		for j := range ordered {
+			if c, exp := CompareVersions(ordered[i], ordered[j]), compareInts(i, j); c != exp {
+				t.Errorf("CompareVersions(%q, %q): expected %d, got %d", ordered[i], ordered[j], exp, c)
+			}
+		}
+	}
+
+	if c := CompareVersions("1.0", "1.0.0"); c != 0 {
+		t.Errorf("CompareVersions: expected 1.0 == 1.0.0, got %d", c)
+	}
+	if c := CompareVersions("v1.0-RC1", "1.0rc1"); c != 0 {
+		t.Errorf("CompareVersions: expected v1.0-RC1 == 1.0rc1, got %d", c)
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	tests := []struct {
+		ver, op, spec string
+		exp           bool
+	}{
+		{"1.0", "==", "1.0.0", true},
+		{"1.0+local", "==", "1.0", true},
+		{"1.0+local", "==", "1.0+other", false},
+		{"1.4.2", "==", "1.4.*", true},
+		{"1.5", "==", "1.4.*", false},
+		{"1.5", "!=", "1.4.*", true},
+		{"2.2.5", "~=", "2.2", true},
+		{"3.0", "~=", "2.2", false},
+		{"2.2.5", "~=", "2.2.0", true},
+		{"2.3", "~=", "2.2.0", false},
+		{"1.0rc1", "<", "1.0", false},
+		{"0.9rc1", "<", "1.0", true},
+		{"1.0rc1", "<", "1.0rc2", true},
+		{"1.0.post1", ">", "1.0", false},
+		{"1.0.1", ">", "1.0", true},
+		{"1.0.post2", ">", "1.0.post1", true},
+		{"1.0", ">=", "1.0rc1", true},
+		{"1.0", "<=", "1.0", true},
+		{"1.0-foo", "===", "1.0-FOO", true},
+	}
+	for _, test := range tests {
+		if match, err := versionMatches(test.ver, test.op, test.spec); err != nil {
+			t.Errorf("versionMatches(%q, %q, %q): %s", test.ver, test.op, test.spec, err)
+		} else if match != test.exp {
+			t.Errorf("versionMatches(%q, %q, %q): expected %v, got %v", test.ver, test.op, test.spec, test.exp, match)
+		}
+	}
+
+	if _, err := versionMatches("1.0", "~=", "1"); err == nil {
+		t.Errorf("versionMatches: expected error for single-component compatible release clause")
+	}
+}
@@ -0,0 +1,133 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parse the requirements declared in a Pipfile (used by pipenv). Returns the [packages] and [dev-packages] requirements separately, each sorted by
+// name.
+func ParsePipfile(contents string) (packages, devPackages []*Requirement, err error) {
+	doc, err := parseTOML(contents)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not parse Pipfile: %s", err)
+	}
+
+	if packages, err = pipfileSection(doc["packages"]); err != nil {
+		return nil, nil, err
+	}
+	if devPackages, err = pipfileSection(doc["dev-packages"]); err != nil {
+		return nil, nil, err
+	}
+	return packages, devPackages, nil
+}
+
+func pipfileSection(section interface{}) ([]*Requirement, error) {
+	reqs := make([]*Requirement, 0)
+	if section == nil {
+		return reqs, nil
+	}
+	table, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Pipfile section is not a table")
+	}
+
+	for _, name := range sortedKeys(table) {
+		switch spec := table[name].(type) {
+		case string:
+			reqs = append(reqs, requirementFromSpec(name, spec))
+		case map[string]interface{}:
+			version, _ := spec["version"].(string)
+			req := requirementFromSpec(name, version)
+			req.Marker, _ = spec["markers"].(string)
+			req.Editable, _ = spec["editable"].(bool)
+			for _, vcs := range []string{"git", "hg", "svn", "bzr"} {
+				if url, ok := spec[vcs].(string); ok {
+					req.URL = vcs + "+" + url
+					if ref, ok := spec["ref"].(string); ok {
+						req.URL += "@" + ref
+					}
+				}
+			}
+			for _, key := range []string{"path", "file"} {
+				if url, ok := spec[key].(string); ok {
+					req.URL = url
+				}
+			}
+			reqs = append(reqs, req)
+		default:
+			return nil, fmt.Errorf("Unrecognized Pipfile requirement for %s: %v", name, spec)
+		}
+	}
+	return reqs, nil
+}
+
+// Parse the pinned requirements in a Pipfile.lock. Returns the "default" and "develop" requirements separately, each sorted by name.
+func ParsePipfileLock(contents string) (packages, devPackages []*Requirement, err error) {
+	type lockedPkg struct {
+		Version  string   `json:"version"`
+		Hashes   []string `json:"hashes"`
+		Markers  string   `json:"markers"`
+		Git      string   `json:"git"`
+		Ref      string   `json:"ref"`
+		Path     string   `json:"path"`
+		Editable bool     `json:"editable"`
+	}
+	var lock struct {
+		Default map[string]lockedPkg `json:"default"`
+		Develop map[string]lockedPkg `json:"develop"`
+	}
+	if err := json.Unmarshal([]byte(contents), &lock); err != nil {
+		return nil, nil, fmt.Errorf("Could not parse Pipfile.lock: %s", err)
+	}
+
+	convert := func(locked map[string]lockedPkg) []*Requirement {
+		names := make([]string, 0, len(locked))
+		for name := range locked {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		reqs := make([]*Requirement, 0, len(names))
+		for _, name := range names {
+			pkg := locked[name]
+			req := requirementFromSpec(name, pkg.Version)
+			req.Marker = pkg.Markers
+			req.Hashes = pkg.Hashes
+			req.Editable = pkg.Editable
+			if pkg.Git != "" {
+				req.URL = "git+" + pkg.Git
+				if pkg.Ref != "" {
+					req.URL += "@" + pkg.Ref
+				}
+			} else if pkg.Path != "" {
+				req.URL = pkg.Path
+			}
+			reqs = append(reqs, req)
+		}
+		return reqs
+	}
+	return convert(lock.Default), convert(lock.Develop), nil
+}
+
+// Builds a requirement from a package name and a version specifier such as ">=1.0" or "*". Specifiers that cannot be parsed are dropped.
+func requirementFromSpec(name, spec string) *Requirement {
+	spec = strings.TrimSpace(spec)
+	if spec != "" && spec != "*" {
+		if req, err := ParseRequirement(name + spec); err == nil {
+			return req
+		}
+	}
+	return &Requirement{Name: name}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
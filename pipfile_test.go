@@ -0,0 +1,76 @@
+package cheerio
+
+import (
+	"github.com/kr/pretty"
+	"reflect"
+	"testing"
+)
+
+func TestParsePipfile(t *testing.T) {
+	packages, devPackages, err := ParsePipfile(`[[source]]
+url = "https://pypi.python.org/simple"
+verify_ssl = true
+name = "pypi"
+
+[packages]
+flask = "*"
+requests = ">=2.3.0"
+"zope.interface" = {version = "==4.1", markers = "python_version < '3'"}
+werkzeug = {git = "https://github.com/mitsuhiko/werkzeug.git", ref = "0.9", editable = true}
+
+[dev-packages]
+nose = "==1.3.0"  # test runner
+
+[requires]
+python_version = "2.7"
+`)
+	if err != nil {
+		t.Fatalf("Error parsing Pipfile: %s", err)
+	}
+
+	expPackages := []*Requirement{
+		{Name: "flask"},
+		{Name: "requests", Constraint: ">=", Version: "2.3.0"},
+		{Name: "werkzeug", URL: "git+https://github.com/mitsuhiko/werkzeug.git@0.9", Editable: true},
+		{Name: "zope.interface", Constraint: "==", Version: "4.1", Marker: "python_version < '3'"},
+	}
+	expDevPackages := []*Requirement{
+		{Name: "nose", Constraint: "==", Version: "1.3.0"},
+	}
+	if !reflect.DeepEqual(packages, expPackages) {
+		t.Errorf("Packages do not match: %v", pretty.Diff(packages, expPackages))
+	}
+	if !reflect.DeepEqual(devPackages, expDevPackages) {
+		t.Errorf("Dev packages do not match: %v", pretty.Diff(devPackages, expDevPackages))
+	}
+}
+
+func TestParsePipfileLock(t *testing.T) {
+	packages, devPackages, err := ParsePipfileLock(`{
+    "_meta": {"hash": {"sha256": "abcd"}},
+    "default": {
+        "flask": {"hashes": ["sha256:aaaa", "sha256:bbbb"], "version": "==0.10.1"},
+        "pywin32": {"version": "==219", "markers": "sys_platform == 'win32'"}
+    },
+    "develop": {
+        "nose": {"version": "==1.3.0"}
+    }
+}`)
+	if err != nil {
+		t.Fatalf("Error parsing Pipfile.lock: %s", err)
+	}
+
+	expPackages := []*Requirement{
+		{Name: "flask", Constraint: "==", Version: "0.10.1", Hashes: []string{"sha256:aaaa", "sha256:bbbb"}},
+		{Name: "pywin32", Constraint: "==", Version: "219", Marker: "sys_platform == 'win32'"},
+	}
+	expDevPackages := []*Requirement{
+		{Name: "nose", Constraint: "==", Version: "1.3.0"},
+	}
+	if !reflect.DeepEqual(packages, expPackages) {
+		t.Errorf("Packages do not match: %v", pretty.Diff(packages, expPackages))
+	}
+	if !reflect.DeepEqual(devPackages, expDevPackages) {
+		t.Errorf("Dev packages do not match: %v", pretty.Diff(devPackages, expDevPackages))
+	}
+}
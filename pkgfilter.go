@@ -0,0 +1,136 @@
+package cheerio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Selects packages by name. A package matches if it matches any include pattern (or there are none) and no exclude pattern. Patterns are
+// globs matched against the normalized package name, e.g., "django-*" ("*" matches any characters and "?" one), or regular expressions if
+// enclosed in slashes, e.g., "/^(django|flask)-/".
+type PackageFilter struct {
+	ecosystem string // whose naming rules normalize names and patterns
+	include   []*regexp.Regexp
+	exclude   []*regexp.Regexp
+}
+
+// Returns a filter of PyPI packages from include and exclude patterns (see PackageFilter)
+func NewPackageFilter(include, exclude []string) (*PackageFilter, error) {
+	return NewEcosystemPackageFilter("pypi", include, exclude)
+}
+
+// Like NewPackageFilter, for the packages of an ecosystem (see Ecosystems), whose names and patterns are normalized by its naming rules (see
+// NormalizedEcosystemPkgName)
+func NewEcosystemPackageFilter(ecosystem string, include, exclude []string) (*PackageFilter, error) {
+	f := &PackageFilter{ecosystem: ecosystem}
+	var err error
+	if f.include, err = f.compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = f.compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *PackageFilter) compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		expr := ""
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr = pattern[1 : len(pattern)-1]
+		} else {
+			expr = "^" + globToRegexp(NormalizedEcosystemPkgName(f.ecosystem, pattern)) + "$"
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid package pattern %q: %s", pattern, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+// Translates a glob with "*" and "?" wildcards to a regular expression
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return expr.String()
+}
+
+// Returns whether the filter selects pkg. A nil filter selects all packages.
+func (f *PackageFilter) Match(pkg string) bool {
+	if f == nil {
+		return true
+	}
+	name := NormalizedEcosystemPkgName(f.ecosystem, pkg)
+	if len(f.include) > 0 && !anyRegexpMatches(f.include, name) {
+		return false
+	}
+	return !anyRegexpMatches(f.exclude, name)
+}
+
+func anyRegexpMatches(regexps []*regexp.Regexp, s string) bool {
+	for _, re := range regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the packages of pkgs the filter selects
+func (f *PackageFilter) Apply(pkgs []string) []string {
+	if f == nil {
+		return pkgs
+	}
+	selected := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if f.Match(pkg) {
+			selected = append(selected, pkg)
+		}
+	}
+	return selected
+}
+
+// Reads package names, one per line; blank lines and comments starting with "#" are ignored
+func ReadPackageList(r io.Reader) ([]string, error) {
+	pkgs := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if pkg := strings.TrimSpace(line); pkg != "" {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs, scanner.Err()
+}
+
+// Reads the package names listed in file (see ReadPackageList)
+func LoadPackageList(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPackageList(f)
+}
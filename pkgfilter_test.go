@@ -0,0 +1,78 @@
+package cheerio
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPackageFilter(t *testing.T) {
+	pkgs := []string{"Django", "django-rest-framework", "django_extensions", "flask", "Flask-Login", "requests"}
+	tests := []struct {
+		include, exclude []string
+		exp              []string
+	}{
+		{nil, nil, pkgs},
+		{nil, []string{"django-*"}, []string{"Django", "flask", "Flask-Login", "requests"}},
+		{[]string{"flask*", "/^req/"}, nil, []string{"flask", "Flask-Login", "requests"}},
+		{[]string{"django*"}, []string{"Django-Rest-Framework"}, []string{"Django", "django_extensions"}},
+		{[]string{"fl?sk"}, []string{""}, []string{"flask"}},
+	}
+	for _, test := range tests {
+		filter, err := NewPackageFilter(test.include, test.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if selected := filter.Apply(pkgs); !reflect.DeepEqual(selected, test.exp) {
+			t.Errorf("include %v, exclude %v: expected %v, got %v", test.include, test.exclude, test.exp, selected)
+		}
+	}
+
+	if _, err := NewPackageFilter([]string{"/(/"}, nil); err == nil {
+		t.Errorf("expected error for invalid regexp")
+	}
+	npmFilter, err := NewEcosystemPackageFilter("npm", []string{"socket.io*"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"socket.io", "Socket.IO-client"}
+	if selected := npmFilter.Apply([]string{"socket.io", "Socket.IO-client", "socket-io"}); !reflect.DeepEqual(selected, exp) {
+		t.Errorf("expected npm names to keep their dots, got %v", selected)
+	}
+
+	var nilFilter *PackageFilter
+	if !nilFilter.Match("anything") {
+		t.Errorf("nil filter should match all packages")
+	}
+}
+
+func TestCrawlFilter(t *testing.T) {
+	idx := fakeIndex{"app": {{Name: "broken"}, {Name: "lib"}}, "lib": nil, "broken": nil}
+	filter, _ := NewPackageFilter(nil, []string{"broken"})
+
+	var visited []string
+	opts := &CrawlOptions{Seeds: []string{"app"}, Depth: -1, Filter: filter}
+	if err := Crawl(context.Background(), idx, opts, func(res PackageResult) error {
+		visited = append(visited, res.Pkg)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(visited)
+	if fmt.Sprint(visited) != "[app lib]" {
+		t.Errorf("expected excluded requirement to be skipped, got %v", visited)
+	}
+}
+
+func TestReadPackageList(t *testing.T) {
+	pkgs, err := ReadPackageList(strings.NewReader("# packages to crawl\nflask\n\n  requests  # HTTP\n#django\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pkgs, []string{"flask", "requests"}) {
+		t.Errorf("unexpected packages %v", pkgs)
+	}
+}
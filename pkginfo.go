@@ -0,0 +1,230 @@
+package cheerio
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Per-package facts gathered from PKG-INFO metadata during a crawl (see FetchPackageInfo)
+type PackageInfo struct {
+	Pkg            string
+	Version        string   `json:",omitempty"`
+	RequiresPython string   `json:",omitempty"` // Requires-Python specifier, e.g., ">=3.7"
+	PythonVersions []string `json:",omitempty"` // Python versions named by trove classifiers, e.g., ["2.7", "3", "3.6"]
+
+	License            string   `json:",omitempty"` // License field, or License-Expression if given
+	LicenseClassifiers []string `json:",omitempty"` // license trove classifiers without the "License ::" prefix, e.g., ["OSI Approved :: MIT License"]
+
+	Downloads *DownloadCounts `json:",omitempty"` // recent download counts from pypistats.org, if fetched
+}
+
+var pythonClassifierRegexp = regexp.MustCompile(`^Programming Language :: Python :: ([0-9]+(?:\.[0-9]+)?)(?: :: Only)?$`)
+
+// Extracts the facts recorded in a PackageInfo from parsed metadata
+func PackageInfoFromMetadata(m *Metadata) *PackageInfo {
+	info := &PackageInfo{
+		Pkg:            NormalizedPkgName(m.Name),
+		Version:        m.Version,
+		RequiresPython: strings.TrimSpace(m.RequiresPython),
+	}
+	if info.License = strings.TrimSpace(m.LicenseExpression); info.License == "" {
+		info.License = strings.TrimSpace(m.License)
+	}
+	for _, classifier := range m.Classifiers {
+		classifier = strings.TrimSpace(classifier)
+		if match := pythonClassifierRegexp.FindStringSubmatch(classifier); match != nil && !containsString(info.PythonVersions, match[1]) {
+			info.PythonVersions = append(info.PythonVersions, match[1])
+		} else if strings.HasPrefix(classifier, "License :: ") {
+			info.LicenseClassifiers = append(info.LicenseClassifiers, strings.TrimPrefix(classifier, "License :: "))
+		}
+	}
+	sort.Slice(info.PythonVersions, func(i, j int) bool { return CompareVersions(info.PythonVersions[i], info.PythonVersions[j]) < 0 })
+	return info
+}
+
+// Fetches the metadata of the latest release of a package and extracts its PackageInfo
+func (p *PackageIndex) FetchPackageInfo(pkg string) (*PackageInfo, error) {
+	metadata, err := p.FetchMetadata(pkg)
+	if err != nil {
+		return nil, err
+	}
+	info := PackageInfoFromMetadata(metadata)
+	info.Pkg = NormalizedPkgName(pkg)
+	return info, nil
+}
+
+// Returns whether the package supports a Python version (e.g., "3.12"), and whether that is known. Requires-Python takes precedence; otherwise, the
+// trove classifiers are consulted. A package that lists classifiers for some minor versions of a major version is taken to support only those.
+func (info *PackageInfo) SupportsPython(pyVersion string) (supported bool, known bool) {
+	py, err := parseVersion(pyVersion)
+	if err != nil {
+		return false, false
+	}
+
+	if info.RequiresPython != "" {
+		if req, err := ParseRequirement("python" + info.RequiresPython); err == nil {
+			ok, err := req.Matches(pyVersion)
+			return ok, err == nil
+		}
+	}
+
+	if len(info.PythonVersions) == 0 {
+		return false, false
+	}
+	var minors, majors []string
+	for _, ver := range info.PythonVersions {
+		if strings.Contains(ver, ".") {
+			minors = append(minors, ver)
+		} else {
+			majors = append(majors, ver)
+		}
+	}
+	major := releaseString(py.releaseN(1))
+	majorMinor := releaseString(py.releaseN(2))
+	for _, ver := range minors {
+		if strings.HasPrefix(ver, major+".") {
+			return containsString(minors, majorMinor), true
+		}
+	}
+	return containsString(majors, major), true
+}
+
+// Formats a release segment, e.g., "3.12"
+func releaseString(release []int) string {
+	parts := make([]string, len(release))
+	for i, n := range release {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// PackageInfo of many packages, keyed by normalized package name
+type PackageInfoIndex map[string]*PackageInfo
+
+// Reads a PackageInfoIndex from JSON lines, one PackageInfo per line (the output of "cheerio info-generate"). Lines that can't be parsed, such as
+// error reports, are skipped.
+func ReadPackageInfoIndex(r io.Reader) (PackageInfoIndex, error) {
+	idx := make(PackageInfoIndex)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var info PackageInfo
+		if err := json.Unmarshal(scanner.Bytes(), &info); err == nil && info.Pkg != "" {
+			idx[NormalizedPkgName(info.Pkg)] = &info
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Reads a PackageInfoIndex from a file (see ReadPackageInfoIndex)
+func LoadPackageInfoIndex(file string) (PackageInfoIndex, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPackageInfoIndex(f)
+}
+
+// Returns whether a package supports a Python version, and whether that is known (see PackageInfo.SupportsPython)
+func (idx PackageInfoIndex) SupportedOn(pkg, pyVersion string) (supported bool, known bool) {
+	info, in := idx[NormalizedPkgName(pkg)]
+	if !in {
+		return false, false
+	}
+	return info.SupportsPython(pyVersion)
+}
+
+// Returns whether a package's latest release supports a Python version (e.g., "3.12"). Packages whose metadata doesn't say are assumed to support it.
+func (p *PackageIndex) SupportedOn(pkg, pyVersion string) (bool, error) {
+	info, err := p.FetchPackageInfo(pkg)
+	if err != nil {
+		return false, err
+	}
+	if supported, known := info.SupportsPython(pyVersion); known {
+		return supported, nil
+	}
+	return true, nil
+}
+
+// Returns the packages in the closure of pkg (including pkg itself) that are known not to support a Python version according to idx, sorted.
+func (p *PyPIGraph) UnsupportedOn(pkg, pyVersion string, idx PackageInfoIndex) []string {
+	pkgs := p.closureWithSelf(pkg)
+
+	unsupported := make([]string, 0)
+	for _, dep := range pkgs {
+		if supported, known := idx.SupportedOn(dep, pyVersion); known && !supported {
+			unsupported = append(unsupported, dep)
+		}
+	}
+	sort.Strings(unsupported)
+	return unsupported
+}
+
+// Returns the names of the package's licenses: the license classifiers (without the "OSI Approved ::" qualifier) if there are any, otherwise the
+// License field. License fields holding a full license text are reduced to their first line. Returns nil if the package declares no license.
+func (info *PackageInfo) Licenses() []string {
+	var licenses []string
+	for _, classifier := range info.LicenseClassifiers {
+		name := classifier
+		if i := strings.LastIndex(classifier, " :: "); i >= 0 {
+			name = classifier[i+len(" :: "):]
+		}
+		if !containsString(licenses, name) {
+			licenses = append(licenses, name)
+		}
+	}
+	if len(licenses) == 0 && info.License != "" {
+		license := strings.TrimSpace(strings.SplitN(info.License, "\n", 2)[0])
+		if len(license) > 80 {
+			license = license[:80] + "..."
+		}
+		licenses = append(licenses, license)
+	}
+	return licenses
+}
+
+// Label for packages without license information in license reports
+const UnknownLicense = "UNKNOWN"
+
+// Returns the licenses of the packages in the closure of pkg (including pkg itself) according to idx, as a map from license name to the packages
+// under that license, each sorted. Packages without license information (or missing from idx) are listed under UnknownLicense.
+func (p *PyPIGraph) ClosureLicenses(pkg string, idx PackageInfoIndex) map[string][]string {
+	pkgs := p.closureWithSelf(pkg)
+
+	licenses := make(map[string][]string)
+	for _, dep := range pkgs {
+		var depLicenses []string
+		if info, in := idx[dep]; in {
+			depLicenses = info.Licenses()
+		}
+		if len(depLicenses) == 0 {
+			depLicenses = []string{UnknownLicense}
+		}
+		for _, license := range depLicenses {
+			licenses[license] = append(licenses[license], dep)
+		}
+	}
+	for license := range licenses {
+		sort.Strings(licenses[license])
+	}
+	return licenses
+}
+
+// Returns pkg followed by the packages in its closure
+func (p *PyPIGraph) closureWithSelf(pkg string) []string {
+	pkgs := []string{NormalizedPkgName(pkg)}
+	for _, level := range p.Closure(pkg) {
+		pkgs = append(pkgs, level...)
+	}
+	return pkgs
+}
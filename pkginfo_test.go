@@ -0,0 +1,89 @@
+package cheerio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPackageInfoSupportsPython(t *testing.T) {
+	info := PackageInfoFromMetadata(ParseMetadata(`Metadata-Version: 2.1
+Name: Flask
+Version: 2.0.1
+Classifier: Programming Language :: Python :: 3.6
+Classifier: Programming Language :: Python
+Classifier: Programming Language :: Python :: 3
+Classifier: Programming Language :: Python :: 3.10
+Classifier: Programming Language :: Python :: 2 :: Only
+`))
+	if exp := []string{"2", "3", "3.6", "3.10"}; !reflect.DeepEqual(info.PythonVersions, exp) {
+		t.Errorf("PackageInfoFromMetadata: expected Python versions %v, got %v", exp, info.PythonVersions)
+	}
+
+	tests := []struct {
+		info             PackageInfo
+		py               string
+		supported, known bool
+	}{
+		{info: *info, py: "3.10", supported: true, known: true},
+		{info: *info, py: "3.12", supported: false, known: true},
+		{info: *info, py: "2.7", supported: true, known: true},
+		{info: PackageInfo{PythonVersions: []string{"2.7"}}, py: "3.12", supported: false, known: true},
+		{info: PackageInfo{RequiresPython: ">=3.7", PythonVersions: []string{"2.7"}}, py: "3.12", supported: true, known: true},
+		{info: PackageInfo{RequiresPython: ">=2.7, !=3.0.*, !=3.1.*"}, py: "3.1", supported: false, known: true},
+		{info: PackageInfo{RequiresPython: "<3.12"}, py: "3.12", supported: false, known: true},
+		{info: PackageInfo{}, py: "3.12", supported: false, known: false},
+	}
+	for _, test := range tests {
+		if supported, known := test.info.SupportsPython(test.py); supported != test.supported || known != test.known {
+			t.Errorf("SupportsPython(%+v, %s): expected (%v, %v), got (%v, %v)", test.info, test.py, test.supported, test.known, supported, known)
+		}
+	}
+}
+
+func TestUnsupportedOn(t *testing.T) {
+	idx, err := ReadPackageInfoIndex(strings.NewReader(`{"Pkg": "app", "RequiresPython": ">=3.8"}
+{"Pkg": "web", "PythonVersions": ["2.7", "3.6"]}
+{"Pkg": "util", "RequiresPython": ">=2.7"}
+{"Pkg": "other", "RequiresPython": "<3"}
+not json
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := testGraph("app:web", "web:util", "web:legacy", "other:util")
+
+	if unsupported := g.UnsupportedOn("app", "3.12", idx); !reflect.DeepEqual(unsupported, []string{"web"}) {
+		t.Errorf("UnsupportedOn: expected [web], got %v", unsupported)
+	}
+	if unsupported := g.UnsupportedOn("app", "2.7", idx); !reflect.DeepEqual(unsupported, []string{"app"}) {
+		t.Errorf("UnsupportedOn: expected [app], got %v", unsupported)
+	}
+}
+
+func TestClosureLicenses(t *testing.T) {
+	mit := PackageInfoFromMetadata(ParseMetadata(`Metadata-Version: 1.1
+Name: web
+License: MIT
+Classifier: License :: OSI Approved :: MIT License
+Classifier: Programming Language :: Python
+`))
+	if exp := []string{"OSI Approved :: MIT License"}; !reflect.DeepEqual(mit.LicenseClassifiers, exp) {
+		t.Errorf("PackageInfoFromMetadata: expected license classifiers %v, got %v", exp, mit.LicenseClassifiers)
+	}
+
+	expr := PackageInfoFromMetadata(ParseMetadata("Metadata-Version: 2.4\nName: app\nLicense-Expression: MIT OR Apache-2.0\n"))
+	fullText := &PackageInfo{Pkg: "util", License: "Copyright (c) 2010 Someone\n\nPermission is hereby granted..."}
+	idx := PackageInfoIndex{"app": expr, "web": mit, "util": fullText}
+	g := testGraph("app:web", "web:util", "web:legacy")
+
+	exp := map[string][]string{
+		"MIT OR Apache-2.0":          {"app"},
+		"MIT License":                {"web"},
+		"Copyright (c) 2010 Someone": {"util"},
+		UnknownLicense:               {"legacy"},
+	}
+	if licenses := g.ClosureLicenses("app", idx); !reflect.DeepEqual(licenses, exp) {
+		t.Errorf("ClosureLicenses: expected %v, got %v", exp, licenses)
+	}
+}
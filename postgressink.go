@@ -0,0 +1,63 @@
+package cheerio
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Stores results in a PostgreSQL table with one row per package, replacing the row of a package when it is crawled again. The table has the
+// columns pkg (primary key), requirements (jsonb, null if the crawl failed), error, started and duration_ms.
+type PostgresSink struct {
+	db     *sql.DB
+	insert *sql.Stmt
+}
+
+var sqlIdentRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Returns a sink writing to table (created if it doesn't exist) of db. The caller opens db with a PostgreSQL driver of its choice, e.g.,
+// sql.Open("postgres", ...) after importing github.com/lib/pq, and closes it after closing the sink.
+func NewPostgresSink(db *sql.DB, table string) (*PostgresSink, error) {
+	if !sqlIdentRegexp.MatchString(table) {
+		return nil, fmt.Errorf("Invalid table name %q", table)
+	}
+	_, err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		pkg text PRIMARY KEY,
+		requirements jsonb,
+		error text,
+		started timestamptz NOT NULL,
+		duration_ms bigint NOT NULL
+	)`, table))
+	if err != nil {
+		return nil, err
+	}
+	insert, err := db.Prepare(fmt.Sprintf(`INSERT INTO %s (pkg, requirements, error, started, duration_ms) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (pkg) DO UPDATE SET requirements = EXCLUDED.requirements, error = EXCLUDED.error, started = EXCLUDED.started,
+		duration_ms = EXCLUDED.duration_ms`, table))
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresSink{db: db, insert: insert}, nil
+}
+
+func (s *PostgresSink) Write(res PackageResult) error {
+	var reqs, errMsg interface{}
+	if res.Err != nil {
+		errMsg = res.Err.Error()
+	} else {
+		b, err := json.Marshal(res.Requirements)
+		if err != nil {
+			return err
+		}
+		reqs = string(b)
+	}
+	_, err := s.insert.Exec(res.Pkg, reqs, errMsg, res.Started, int64(res.Duration/time.Millisecond))
+	return err
+}
+
+// Closes the prepared statement of the sink; the database handle is left open
+func (s *PostgresSink) Close() error {
+	return s.insert.Close()
+}
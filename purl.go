@@ -0,0 +1,171 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// A package URL (https://github.com/package-url/purl-spec), e.g., "pkg:npm/%40babel/core@7.0.0", identifying a package across ecosystems
+type PURL struct {
+	Type       string // e.g., "pypi", "npm", or "maven"
+	Namespace  string // e.g., the npm scope or the Maven group ID, "" if none
+	Name       string
+	Version    string            // "" if unspecified
+	Qualifiers map[string]string // e.g., "repository_url" -> "...", nil if none
+	Subpath    string
+}
+
+// purl types of the ecosystems in Ecosystems
+var ecosystemPURLTypes = map[string]string{
+	"pypi":      "pypi",
+	"npm":       "npm",
+	"rubygems":  "gem",
+	"crates":    "cargo",
+	"maven":     "maven",
+	"packagist": "composer",
+	"go":        "golang",
+	"nuget":     "nuget",
+	"conda":     "conda",
+}
+
+// purl types whose names are case-insensitive, and so are lowercased
+var lowercasePURLTypes = map[string]bool{"pypi": true, "npm": true, "composer": true, "conda": true}
+
+// Returns the purl of a package of an ecosystem (see Ecosystems), given by its native name (e.g., "@babel/core" for npm or "org.slf4j/slf4j-api"
+// for Maven), with an optional version
+func PackagePURL(ecosystem, pkg, version string) (string, error) {
+	purlType, in := ecosystemPURLTypes[strings.ToLower(ecosystem)]
+	if !in {
+		return "", fmt.Errorf("Unknown ecosystem '%s'", ecosystem)
+	}
+	p := &PURL{Type: purlType, Name: pkg, Version: version}
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		p.Namespace, p.Name = pkg[:i], pkg[i+1:]
+	}
+	if purlType == "pypi" {
+		p.Name = strings.Replace(NormalizedPkgName(p.Name), "_", "-", -1)
+	}
+	if lowercasePURLTypes[purlType] {
+		p.Namespace, p.Name = strings.ToLower(p.Namespace), strings.ToLower(p.Name)
+	}
+	return p.String(), nil
+}
+
+// Returns the ecosystem (see Ecosystems) of the purl's type, and the package's native name in that ecosystem (the namespace and name joined by
+// "/"). Returns "" as the ecosystem for types without a supported ecosystem.
+func (p *PURL) Package() (ecosystem string, pkg string) {
+	for eco, purlType := range ecosystemPURLTypes {
+		if purlType == p.Type {
+			ecosystem = eco
+		}
+	}
+	if p.Namespace != "" {
+		return ecosystem, p.Namespace + "/" + p.Name
+	}
+	return ecosystem, p.Name
+}
+
+// Formats the purl in its canonical form, percent-encoding each component
+func (p *PURL) String() string {
+	var b strings.Builder
+	b.WriteString("pkg:" + strings.ToLower(p.Type) + "/")
+	if p.Namespace != "" {
+		for _, segment := range strings.Split(p.Namespace, "/") {
+			b.WriteString(purlEscape(segment) + "/")
+		}
+	}
+	b.WriteString(purlEscape(p.Name))
+	if p.Version != "" {
+		b.WriteString("@" + purlEscape(p.Version))
+	}
+	if len(p.Qualifiers) > 0 {
+		keys := make([]string, 0, len(p.Qualifiers))
+		for key := range p.Qualifiers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			sep := "&"
+			if i == 0 {
+				sep = "?"
+			}
+			b.WriteString(sep + strings.ToLower(key) + "=" + purlEscape(p.Qualifiers[key]))
+		}
+	}
+	if p.Subpath != "" {
+		segments := strings.Split(strings.Trim(p.Subpath, "/"), "/")
+		for i, segment := range segments {
+			segments[i] = purlEscape(segment)
+		}
+		b.WriteString("#" + strings.Join(segments, "/"))
+	}
+	return b.String()
+}
+
+// Parses a purl, e.g., "pkg:pypi/flask@0.10.1"
+func ParsePURL(s string) (*PURL, error) {
+	rest := strings.TrimSpace(s)
+	if !strings.HasPrefix(rest, "pkg:") {
+		return nil, fmt.Errorf("Not a purl (expected a pkg: prefix): '%s'", s)
+	}
+	rest = strings.TrimLeft(rest[len("pkg:"):], "/")
+	p := &PURL{}
+
+	if i := strings.Index(rest, "#"); i >= 0 {
+		subpath, err := url.PathUnescape(strings.Trim(rest[i+1:], "/"))
+		if err != nil {
+			return nil, err
+		}
+		p.Subpath, rest = subpath, rest[:i]
+	}
+	if i := strings.Index(rest, "?"); i >= 0 {
+		p.Qualifiers = make(map[string]string)
+		for _, pair := range strings.Split(rest[i+1:], "&") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 || kv[1] == "" {
+				continue
+			}
+			value, err := url.PathUnescape(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			p.Qualifiers[strings.ToLower(kv[0])] = value
+		}
+		rest = rest[:i]
+	}
+
+	rest = strings.Trim(rest, "/")
+	i := strings.Index(rest, "/")
+	if i <= 0 {
+		return nil, fmt.Errorf("Expected pkg:type/name in purl '%s'", s)
+	}
+	p.Type, rest = strings.ToLower(rest[:i]), rest[i+1:]
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		version, err := url.PathUnescape(rest[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		p.Version, rest = version, rest[:i]
+	}
+
+	segments := strings.Split(rest, "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = unescaped
+	}
+	p.Namespace, p.Name = strings.Join(segments[:len(segments)-1], "/"), segments[len(segments)-1]
+	if p.Name == "" {
+		return nil, fmt.Errorf("Missing name in purl '%s'", s)
+	}
+	return p, nil
+}
+
+// Percent-encodes a purl component. Unlike url.PathEscape, this also encodes "@", which separates the version.
+func purlEscape(s string) string {
+	return strings.Replace(url.PathEscape(s), "@", "%40", -1)
+}
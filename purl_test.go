@@ -0,0 +1,74 @@
+package cheerio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackagePURL(t *testing.T) {
+	tests := []struct {
+		ecosystem, pkg, version, exp string
+	}{
+		{"pypi", "Flask_SQLAlchemy", "3.0.0", "pkg:pypi/flask-sqlalchemy@3.0.0"},
+		{"npm", "@babel/core", "7.0.0", "pkg:npm/%40babel/core@7.0.0"},
+		{"npm", "express", "", "pkg:npm/express"},
+		{"maven", "org.slf4j/slf4j-api", "2.0.9", "pkg:maven/org.slf4j/slf4j-api@2.0.9"},
+		{"go", "github.com/pkg/errors", "v0.9.1", "pkg:golang/github.com/pkg/errors@v0.9.1"},
+		{"rubygems", "rails", "", "pkg:gem/rails"},
+		{"nuget", "Newtonsoft.Json", "13.0.1", "pkg:nuget/Newtonsoft.Json@13.0.1"},
+	}
+	for _, test := range tests {
+		purl, err := PackagePURL(test.ecosystem, test.pkg, test.version)
+		if err != nil || purl != test.exp {
+			t.Errorf("PackagePURL(%s, %s, %s): expected %s, got %s, %v", test.ecosystem, test.pkg, test.version, test.exp, purl, err)
+		}
+	}
+	if _, err := PackagePURL("cpan", "Moose", ""); err == nil {
+		t.Errorf("PackagePURL: expected an error for an unknown ecosystem")
+	}
+}
+
+func TestParsePURL(t *testing.T) {
+	p, err := ParsePURL("pkg:npm/%40babel/core@7.0.0?repository_url=https://registry.example.com#lib/index.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &PURL{Type: "npm", Namespace: "@babel", Name: "core", Version: "7.0.0", Qualifiers: map[string]string{"repository_url": "https://registry.example.com"},
+		Subpath: "lib/index.js"}
+	if !reflect.DeepEqual(p, exp) {
+		t.Errorf("ParsePURL: expected %+v, got %+v", exp, p)
+	}
+	if ecosystem, pkg := p.Package(); ecosystem != "npm" || pkg != "@babel/core" {
+		t.Errorf("Package: got %s, %s", ecosystem, pkg)
+	}
+	if s := p.String(); s != "pkg:npm/%40babel/core@7.0.0?repository_url=https:%2F%2Fregistry.example.com#lib/index.js" {
+		t.Errorf("String: got %s", s)
+	}
+
+	for _, s := range []string{"pkg:pypi/flask@0.10.1", "pkg:maven/org.slf4j/slf4j-api@2.0.9", "pkg:golang/github.com/pkg/errors"} {
+		if p, err := ParsePURL(s); err != nil || p.String() != s {
+			t.Errorf("ParsePURL(%s): round trip gave %v, %v", s, p, err)
+		}
+	}
+	for _, s := range []string{"npm/express", "pkg:express", "pkg:npm/"} {
+		if _, err := ParsePURL(s); err == nil {
+			t.Errorf("ParsePURL(%s): expected an error", s)
+		}
+	}
+}
+
+func TestSplitGraphLine(t *testing.T) {
+	tests := map[string][]string{
+		"flask:werkzeug":                    {"flask", "werkzeug"},
+		"flask:python-dotenv:dotenv":        {"flask", "python-dotenv", "dotenv"},
+		"pkg:npm/express:pkg:npm/debug":     {"pkg:npm/express", "pkg:npm/debug"},
+		"pkg:npm/express:pkg:npm/mocha:dev": {"pkg:npm/express", "pkg:npm/mocha", "dev"},
+		"pkg:pypi/flask":                    {"pkg:pypi/flask"},
+		"pkg:requests":                      {"pkg", "requests"},
+	}
+	for line, exp := range tests {
+		if fields := splitGraphLine(line); !reflect.DeepEqual(fields, exp) {
+			t.Errorf("splitGraphLine(%s): expected %v, got %v", line, exp, fields)
+		}
+	}
+}
@@ -1,28 +1,89 @@
 package cheerio
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"path/filepath"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/beyang/cheerio/fetch"
-	"github.com/beyang/go-version"
 )
 
-var DefaultPyPI = &PackageIndex{URI: "https://pypi.python.org"}
+var DefaultPyPI = NewPackageIndex("https://pypi.python.org")
 
 type PackageIndex struct {
-	URI string
+	URI    string
+	Logger Logger // receives diagnostics, e.g., unparseable requirements; DefaultLogger if nil
+
+	// Stop reading a source archive once the directory of the first matching file, e.g., the *.egg-info/ directory with the requires.txt, has been
+	// read, instead of downloading the rest of the archive to look for further matches. Speeds up crawls of packages with very large sdists.
+	EarlyExit bool
+
+	// Sources of requirements, tried in order until one has them (see FetchSourcedRequirements), e.g., AllRequirementsSources; only sdists if
+	// empty
+	Sources []RequirementsSource
+
+	// Chooses the release file that requirements and metadata are read from; DefaultArtifactPolicy if nil
+	Policy ArtifactPolicy
+
+	// Also select yanked files (PEP 592) when reading the latest release. Yanked files of a release that is requested by version are always
+	// used, as pip does for pinned versions.
+	IncludeYanked bool
+
+	// How unparseable lines of requires.txt files are handled; lenient if empty, so that a malformed line doesn't fail the whole package
+	ParseMode ParseMode
+
+	// Client of all requests, e.g., one with a custom transport; based on http.DefaultClient if nil. Proxy and TLS only apply without a Client.
+	Client *http.Client
+
+	// Proxy of all requests, e.g., "http://proxy.corp:3128" or "socks5://localhost:1080"; if empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables are honored, as by http.DefaultClient
+	Proxy string
+
+	// TLS settings of all requests, e.g., from NewTLSConfig to trust the internal CA of a private index or to present a client certificate;
+	// those of http.DefaultTransport if nil. Setting InsecureSkipVerify disables the verification of server certificates, so only do it for
+	// testing. The config must not be modified once used.
+	TLS *tls.Config
+
+	// User-Agent of all requests, DefaultUserAgent if empty. PyPI asks large crawlers to identify themselves, e.g., with a contact address.
+	UserAgent string
+
+	// Extra headers of the requests to the index's host, e.g., an Authorization header for a private index. They are not sent to other hosts
+	// that the index links or redirects to, e.g., those serving release files.
+	Headers http.Header
+
+	// HTTP basic auth credentials of the requests to the index's host, e.g., a token of a private index; none if nil. Like Headers, they are
+	// not sent to other hosts.
+	BasicAuth *url.Userinfo
+
+	// Paces all requests and backs off when the index is overloaded; none if nil. Indexes may share a throttle, e.g., to limit the total
+	// bandwidth of several crawls.
+	Throttle *Throttle
+
+	// Deadline of each request, including reading its response, e.g., so that a hung download doesn't stall a crawl; none if 0
+	Timeout time.Duration
+
+	// Caches parsed metadata and requirements, so that packages looked up repeatedly, e.g., by FetchSourceRepoURL, aren't fetched again; none
+	// if nil. Indexes may share a cache only if they have the same URI, Sources and Policy.
+	Cache *LRUCache
+
+	ctx context.Context // cancels all requests once done, e.g., those of a package that timed out in a crawl; none if nil (see withContext)
 }
 
-// Get names of all packages served by a PyPI server.
+// Get names of all packages served by a PyPI server, or of the package directories of a local mirror (see NewLocalMirror).
 func (p *PackageIndex) AllPackages() ([]string, error) {
+	if dir, ok := mirrorDir(p.URI); ok {
+		return mirrorPackages(dir)
+	}
 	pkgs := make([]string, 0)
 
-	resp, err := http.Get(fmt.Sprintf("%s/simple", p.URI))
+	resp, err := p.client().Get(fmt.Sprintf("%s/simple", p.URI))
 	if err != nil {
 		return nil, err
 	}
@@ -50,71 +111,173 @@ var requiresTxtEggPattern = regexp.MustCompile(`EGG\-INFO/requires\.txt`)
 var requiresTxtZipPattern = requiresTxtTarPattern
 
 // Fetches package requirements from PyPI by downloading the package archive and extracting the requires.txt file.  If no such file exists (sometimes
-// it doesn't), returns an error.
+// it doesn't), and no other metadata file declares requirements, returns an error wrapping ErrNoRequiresFile. Packages without release files, or
+// whose files are all yanked, return an error wrapping ErrNoReleases rather than no requirements, so a crawl doesn't record them as having no
+// dependencies. If the index has other Sources, they are tried in order instead (see FetchSourcedRequirements).
 func (p *PackageIndex) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
-	b, err := p.FetchRawMetadata(pkg, requiresTxtTarPattern, requiresTxtEggPattern, requiresTxtZipPattern)
+	return p.FetchPackageRequirementsAt(pkg, "")
+}
+
+// Fetches the requirements of a specific release of a package (see FetchPackageRequirements). If version is empty, uses the latest release.
+func (p *PackageIndex) FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error) {
+	reqs, _, err := p.FetchSourcedRequirements(pkg, version)
+	return reqs, err
+}
+
+// Reads the requirements of a release from its source archive or egg, or from a wheel if the index's Policy selects one (see
+// FetchPackageRequirements)
+func (p *PackageIndex) fetchSdistRequirements(pkg, version string) ([]*Requirement, error) {
+	artifact, err := p.selectArtifact(pkg, version, ArtifactSdist, ArtifactEgg, ArtifactWheel)
 	if err != nil {
-		if strings.Contains(err.Error(), "[no-files]") { // may not have a requires.txt
+		return nil, err
+	}
+	if artifact.Type == ArtifactWheel {
+		metadata, err := p.readWheelMetadata(artifact)
+		if err != nil {
+			return nil, err
+		}
+		return metadata.Requirements(), nil
+	}
+
+	b, err := p.readArchive(artifact, requiresTxtTarPattern, requiresTxtEggPattern, requiresTxtZipPattern)
+	if err != nil {
+		if errors.Is(err, fetch.ErrNoMatch) && artifact.Type == ArtifactEgg { // eggs only have a requires.txt if they have requirements
 			return nil, nil
+		} else if errors.Is(err, fetch.ErrNoMatch) { // sdist may declare requirements elsewhere
+			if reqs := p.fetchFallbackRequirements(artifact); len(reqs) > 0 {
+				return reqs, nil
+			}
+			return nil, fmt.Errorf("%w: %s", ErrNoRequiresFile, err)
 		} else {
 			return nil, err
 		}
 	}
-	return ParseRequirements(string(b))
-}
-
-func (p *PackageIndex) FetchRawMetadata(pkg string, tarPattern, eggPattern, zipPattern *regexp.Regexp) ([]byte, error) {
-	files, err := p.pkgFiles(pkg)
+	reqs, err := parseRequirements(string(b), p.ParseMode, loggerOr(p.Logger))
 	if err != nil {
-		return nil, err
-	} else if len(files) == 0 {
-		return nil, fmt.Errorf("[no-files] no files found for pkg %s", pkg)
+		return nil, fmt.Errorf("[parse] requires.txt of %s: %w", artifact.Filename, err)
 	}
+	return reqs, nil
+}
 
-	// Sort files in version order
-	version.Sort(files)
-
-	// Get the latest version
-	if path := lastTar(files); path != "" {
-		return fetch.RemoteDecompress(fmt.Sprintf("%s%s", p.URI, path), tarPattern, fetch.Tar)
-	} else if path := lastEgg(files); path != "" {
-		return fetch.RemoteDecompress(fmt.Sprintf("%s%s", p.URI, path), eggPattern, fetch.Zip)
-	} else if path := lastZip(files); path != "" {
-		return fetch.RemoteDecompress(fmt.Sprintf("%s%s", p.URI, path), zipPattern, fetch.Zip)
-	} else {
-		return nil, fmt.Errorf("[tar/zip] no tar or zip found in %+v for pkg %s", files, pkg)
-	}
+// Sources of requirements for sdists that have no requires.txt, in the order they are tried
+var fallbackRequirementsSources = []struct {
+	pattern *regexp.Regexp
+	parse   func(contents string) ([]*Requirement, error)
+}{
+	{pyprojectPattern, func(contents string) ([]*Requirement, error) {
+		reqs, _, err := ParsePyProject(contents)
+		return reqs, err
+	}},
+	{setupCfgPattern, ParseSetupCfg},
+	{setupPyPattern, ParseSetupPy},
 }
 
-var allPkgRegexp = regexp.MustCompile(`<a href='([A-Za-z0-9\._\-]+)'>([A-Za-z0-9\._\-]+)</a><br/>`)
-var pkgFilesRegexp = regexp.MustCompile(`<a href="([/A-Za-z0-9\._\-]+)#md5=[0-9a-z]+"[^>]*>([A-Za-z0-9\._\-]+)</a><br/>`)
-var requirementRegexp = regexp.MustCompile(`(?P<package>[A-Za-z0-9\._\-]+)(?:\[([A-Za-z0-9\._\-]+)\])?\s*(?:(?P<constraint>==|>=|>|<|<=)\s*(?P<version>[A-Za-z0-9\._\-]+)(?:\s*,\s*[<>=!]+\s*[a-z0-9\.]+)?)?`)
-var reqHeaderRegexp = regexp.MustCompile(`\[[A-Za-z0-9\._\-]+\]`)
+// Matches the members of an sdist of any of the fallbackRequirementsSources, so that they are read in one download
+var fallbackRequirementsPattern = func() *regexp.Regexp {
+	patterns := make([]string, len(fallbackRequirementsSources))
+	for i, source := range fallbackRequirementsSources {
+		patterns[i] = "(?:" + source.pattern.String() + ")"
+	}
+	return regexp.MustCompile(strings.Join(patterns, "|"))
+}()
 
-// Helpers
+func (p *PackageIndex) fetchFallbackRequirements(artifact *Artifact) []*Requirement {
+	members, err := p.readArchiveMembers(artifact, fallbackRequirementsPattern)
+	if err != nil {
+		return nil
+	}
+	for _, source := range fallbackRequirementsSources {
+		for name, b := range members {
+			if !source.pattern.MatchString(name) {
+				continue
+			}
+			if reqs, err := source.parse(string(b)); err == nil && len(reqs) > 0 {
+				return reqs
+			}
+		}
+	}
+	return nil
+}
 
-func (p *PackageIndex) pkgFiles(pkg string) ([]string, error) {
-	files := make([]string, 0)
+func (p *PackageIndex) FetchRawMetadata(pkg string, tarPattern, eggPattern, zipPattern *regexp.Regexp) ([]byte, error) {
+	return p.FetchRawMetadataAt(pkg, "", tarPattern, eggPattern, zipPattern)
+}
 
-	uriPath := fmt.Sprintf("/simple/%s", pkg)
-	uri := fmt.Sprintf("%s%s", p.URI, uriPath)
-	resp, err := http.Get(uri)
+// Like FetchRawMetadata, but reads the archive of a specific release. If ver is empty, reads the archive (tar, egg or zip) that the index's Policy
+// selects, by default one of the latest release.
+func (p *PackageIndex) FetchRawMetadataAt(pkg, ver string, tarPattern, eggPattern, zipPattern *regexp.Regexp) ([]byte, error) {
+	artifact, err := p.selectArtifact(pkg, ver, ArtifactSdist, ArtifactEgg)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	return p.readArchive(artifact, tarPattern, eggPattern, zipPattern)
+}
+
+// Returns the file of release ver of pkg (or of any release if ver is empty) that the index's Policy selects among those of the given types
+func (p *PackageIndex) selectArtifact(pkg, ver string, types ...ArtifactType) (*Artifact, error) {
+	// the legacy page URL, without a trailing slash, against which its relative links resolve
+	artifacts, err := p.pageArtifacts(pkg, fmt.Sprintf("%s/simple/%s", p.URI, pkg))
 	if err != nil {
 		return nil, err
 	}
-	matches := pkgFilesRegexp.FindAllStringSubmatch(string(body), -1)
-	for _, match := range matches {
-		if len(match) != 3 {
-			return nil, fmt.Errorf("Unexpected number of submatches: %d, %v", len(match), match)
-		} else {
-			files = append(files, filepath.Clean(filepath.Join(uriPath, match[1])))
+	var released []*Artifact
+	for _, artifact := range artifacts {
+		if ver == "" || artifact.Version == ver {
+			released = append(released, artifact)
 		}
 	}
+	if len(released) == 0 {
+		if ver != "" {
+			return nil, fmt.Errorf("[no-files] %w for pkg %s version %s", ErrNoReleases, pkg, ver)
+		}
+		return nil, fmt.Errorf("[no-files] %w for pkg %s", ErrNoReleases, pkg)
+	}
+	if ver == "" && !p.IncludeYanked {
+		if released = withoutYanked(released); len(released) == 0 {
+			return nil, fmt.Errorf("[no-files] %w: all files of pkg %s are yanked", ErrNoReleases, pkg)
+		}
+	}
+
+	var candidates []*Artifact
+	var files []string
+	for _, artifact := range released {
+		for _, t := range types {
+			if artifact.Type == t {
+				candidates = append(candidates, artifact)
+			}
+		}
+		files = append(files, artifact.Filename)
+	}
+	policy := p.Policy
+	if policy == nil {
+		policy = DefaultArtifactPolicy
+	}
+	if artifact := policy.Select(candidates, ver); artifact != nil {
+		return artifact, nil
+	}
+	return nil, fmt.Errorf("[tar/zip] %w (tar, egg or zip) found in %+v for pkg %s", ErrNoSdist, files, pkg)
+}
+
+// Returns the contents of the members of a tar, egg or zip archive that match the pattern for its kind
+func (p *PackageIndex) readArchive(artifact *Artifact, tarPattern, eggPattern, zipPattern *regexp.Regexp) ([]byte, error) {
+	switch {
+	case tarRegexp.MatchString(artifact.Filename):
+		return fetch.RemoteDecompressWith(artifact.URL, tarPattern, fetch.Tar, fetch.Options{StopAfterMatchDir: p.EarlyExit, Client: p.client()})
+	case artifact.Type == ArtifactEgg:
+		return fetch.RemoteDecompressWith(artifact.URL, eggPattern, fetch.Zip, fetch.Options{Client: p.client()})
+	default:
+		return fetch.RemoteDecompressWith(artifact.URL, zipPattern, fetch.Zip, fetch.Options{Client: p.client()})
+	}
+}
 
-	return files, nil
+// Like readArchive, but returns the contents of each matching member by name
+func (p *PackageIndex) readArchiveMembers(artifact *Artifact, pattern *regexp.Regexp) (map[string][]byte, error) {
+	compressType := fetch.Zip
+	if tarRegexp.MatchString(artifact.Filename) {
+		compressType = fetch.Tar
+	}
+	return fetch.RemoteDecompressMembers(artifact.URL, pattern, compressType, fetch.Options{Client: p.client()})
 }
+
+var allPkgRegexp = regexp.MustCompile(`<a href='([A-Za-z0-9\._\-]+)'>([A-Za-z0-9\._\-]+)</a><br/>`)
+var requirementRegexp = regexp.MustCompile(`(?P<package>[A-Za-z0-9\._\-]+)(?:\s*\[([A-Za-z0-9\._\-,\s]+)\])?\s*(?:(?P<constraint>===|==|!=|~=|>=|<=|>|<)\s*(?P<version>[A-Za-z0-9\._\-\*\+!]+)(?P<more>(?:\s*,\s*[<>=!~]+\s*[A-Za-z0-9\._\-\*\+!]+)*))?`)
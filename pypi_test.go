@@ -0,0 +1,166 @@
+package cheerio
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEarlyExit(t *testing.T) {
+	// an sdist whose egg-info comes first, followed by a large file; the archive is split after the header of the large file
+	var sdist bytes.Buffer
+	gz := gzip.NewWriter(&sdist)
+	tw := tar.NewWriter(gz)
+	addFile := func(name string, contents []byte) {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))})
+		tw.Write(contents)
+	}
+	addFile("pkg-1.0/pkg.egg-info/PKG-INFO", []byte("Metadata-Version: 1.0\nName: pkg\n"))
+	addFile("pkg-1.0/pkg.egg-info/requires.txt", []byte("flask>=1.0\n"))
+	addFile("pkg-1.0/pkg.egg-info/top_level.txt", []byte("pkg\n"))
+	noise := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(noise)
+	tw.WriteHeader(&tar.Header{Name: "pkg-1.0/data.bin", Mode: 0644, Size: int64(len(noise))})
+	tw.Flush()
+	gz.Flush()
+	split := sdist.Len()
+	tw.Write(noise)
+	tw.Close()
+	gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/pkg":
+			fmt.Fprint(w, `<a href="../../packages/pkg-1.0.tar.gz#md5=0123abcd">pkg-1.0.tar.gz</a><br/>`)
+		case "/packages/pkg-1.0.tar.gz":
+			// the tail of the archive is only sent after a delay, unless the client hangs up first
+			w.Write(sdist.Bytes()[:split])
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(2 * time.Second):
+			}
+			w.Write(sdist.Bytes()[split:])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, earlyExit := range []bool{true, false} {
+		index := &PackageIndex{URI: server.URL, EarlyExit: earlyExit}
+		started := time.Now()
+		reqs, err := index.FetchPackageRequirements("pkg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reqs) != 1 || reqs[0].Name != "flask" {
+			t.Errorf("EarlyExit %v: unexpected requirements %v", earlyExit, reqs)
+		}
+		if elapsed := time.Since(started); earlyExit && elapsed > time.Second {
+			t.Errorf("EarlyExit: expected the download to stop after the egg-info, took %s", elapsed)
+		} else if !earlyExit && elapsed < time.Second {
+			t.Errorf("expected the whole archive to be read without EarlyExit, took %s", elapsed)
+		}
+	}
+}
+
+func TestEggRequirements(t *testing.T) {
+	egg := func(files map[string]string) []byte {
+		var b bytes.Buffer
+		zw := zip.NewWriter(&b)
+		for name, contents := range files {
+			f, _ := zw.Create(name)
+			fmt.Fprint(f, contents)
+		}
+		zw.Close()
+		return b.Bytes()
+	}
+	eggs := map[string][]byte{
+		"/packages/legacy-2.0-py2.7.egg": egg(map[string]string{"EGG-INFO/PKG-INFO": "Name: legacy\n", "EGG-INFO/requires.txt": "six>=1.0\n"}),
+		"/packages/nodeps-1.0-py2.7.egg": egg(map[string]string{"EGG-INFO/PKG-INFO": "Name: nodeps\n"}),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/legacy":
+			// the tar of the older release must not be read instead of the egg of the latest one
+			fmt.Fprint(w, `<a href="../../packages/legacy-1.0.tar.gz#md5=0123abcd">legacy-1.0.tar.gz</a><br/>`)
+			fmt.Fprint(w, `<a href="../../packages/legacy-2.0-py2.7.egg#md5=0123abcd">legacy-2.0-py2.7.egg</a><br/>`)
+		case "/simple/nodeps":
+			fmt.Fprint(w, `<a href="../../packages/nodeps-1.0-py2.7.egg#md5=0123abcd">nodeps-1.0-py2.7.egg</a><br/>`)
+		default:
+			if b, in := eggs[r.URL.Path]; in {
+				w.Write(b)
+				return
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL, Logger: NopLogger{}}
+	reqs, err := index.FetchPackageRequirements("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || reqs[0].Name != "six" {
+		t.Errorf("FetchPackageRequirements(legacy): expected six from the egg, got %v", reqs)
+	}
+
+	reqs, err = index.FetchPackageRequirements("nodeps")
+	if err != nil || len(reqs) != 0 {
+		t.Errorf("FetchPackageRequirements(nodeps): expected no requirements for an egg without requires.txt, got %v, %v", reqs, err)
+	}
+}
+
+func TestFallbackRequirements(t *testing.T) {
+	var sdist bytes.Buffer
+	gz := gzip.NewWriter(&sdist)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct{ name, contents string }{
+		{"pkg-1.0/setup.py", "from setuptools import setup\nsetup(name='pkg', install_requires=['six'])\n"},
+		{"pkg-1.0/setup.cfg", "[options]\ninstall_requires =\n    flask>=1.0\n"},
+		{"pkg-1.0/pyproject.toml", "[build-system]\nrequires = [\"setuptools\"]\n"},
+	} {
+		tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.contents))})
+		tw.Write([]byte(file.contents))
+	}
+	tw.Close()
+	gz.Close()
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/pkg":
+			fmt.Fprint(w, `<a href="../../packages/pkg-1.0.tar.gz#md5=0123abcd">pkg-1.0.tar.gz</a><br/>`)
+		case "/packages/pkg-1.0.tar.gz":
+			downloads++
+			w.Write(sdist.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL, Logger: NopLogger{}}
+	reqs, err := index.FetchPackageRequirements("pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pyproject.toml declares no dependencies, so setup.cfg is used before setup.py
+	if len(reqs) != 1 || reqs[0].Name != "flask" {
+		t.Errorf("expected flask from setup.cfg, got %v", reqs)
+	}
+	if downloads != 2 {
+		t.Errorf("expected the sdist to be downloaded once for requires.txt and once for the fallback sources, got %d downloads", downloads)
+	}
+}
@@ -2,9 +2,15 @@ package cheerio
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -28,67 +34,426 @@ func init() {
 	}
 }
 
+// Package-level dependency data, as served by a PyPIGraph or fetched on demand (e.g., from deps.dev, see DepsDev). Package names are normalized.
+type DependencyGraph interface {
+	// Returns the packages that pkg requires
+	Requires(pkg string) []string
+
+	// Returns the packages that require pkg
+	RequiredBy(pkg string) []string
+
+	// Returns the packages that pkg requires, and whether they are known: Requires returns no dependencies both for packages without any and for
+	// packages it knows nothing about, which Lookup tells apart
+	Lookup(pkg string) (deps []string, found bool)
+}
+
 // Dependency graph over repositories in a given Python Package Index.
 type PyPIGraph struct {
 	Req   map[string][]string
 	ReqBy map[string][]string
+
+	// Dependencies required only with an extra (optional feature) of a package: package -> extra -> dependencies. These edges are also included in
+	// Req and ReqBy.
+	Extras map[string]map[string][]string
+
+	// Edges that are only required with some extra
+	optional map[Edge]bool
+
+	// Provenance from the header of the graph file (see Info)
+	info *GraphInfo
+
+	// Names packages were given when they were added, for those whose normalized name differs (see DisplayName)
+	display map[string]string
+
+	// Index of package names for Search
+	search *searchIndex
 }
 
-// Deserializes a PyPIGraph stored in a file
+// Deserializes a PyPIGraph stored in a file, which may be gzip-compressed, or in an object named by an s3:// or gs:// URL (see ObjectStore)
 func NewPyPIGraph(file string) (*PyPIGraph, error) {
-	var graph *PyPIGraph
-
-	f, err := os.Open(file)
+	var f io.ReadCloser
+	var err error
+	if IsObjectURL(file) {
+		f, err = DefaultObjectStore.Get(file)
+	} else {
+		f, err = os.Open(file)
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return ReadPyPIGraph(f)
+}
 
-	graph = &PyPIGraph{
-		Req:   make(map[string][]string),
-		ReqBy: make(map[string][]string),
+// Deserializes a PyPIGraph in the graph file format, the binary format (see WriteBinary), or the indexed format (see WriteMapped),
+// decompressing it if it is gzip-compressed
+func ReadPyPIGraph(r io.Reader) (*PyPIGraph, error) {
+	reader := bufio.NewReader(r)
+	if magic, _ := reader.Peek(2); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = bufio.NewReader(gz)
 	}
-	reader := bufio.NewReader(f)
+	if magic, _ := reader.Peek(len(binaryGraphMagic)); bytes.Equal(magic, binaryGraphMagic) {
+		return readBinaryPyPIGraph(reader)
+	} else if bytes.Equal(magic, mappedGraphMagic) {
+		mapped, err := ReadMappedGraph(reader)
+		if err != nil {
+			return nil, err
+		}
+		return mapped.Graph(), nil
+	}
+
+	graph := newPyPIGraph()
+	inHeader := true
 	for {
 		lineB, _, err := reader.ReadLine()
-		if err != nil {
+		if err == io.EOF {
 			break
+		} else if err != nil {
+			return nil, err
 		}
 		line := string(lineB)
-
-		if strings.Contains(line, ":") {
-			lineSplit := strings.Split(line, ":")
-			if len(lineSplit) == 2 {
-				pkg, dep := lineSplit[0], lineSplit[1]
-
-				if _, in := graph.Req[pkg]; !in {
-					graph.Req[pkg] = make([]string, 0)
+		if strings.HasPrefix(line, "#") { // header (see GraphInfo) or comment
+			if inHeader {
+				if graph.info == nil {
+					graph.info = &GraphInfo{}
 				}
-				graph.Req[pkg] = append(graph.Req[pkg], dep)
-
-				if _, in := graph.ReqBy[dep]; !in {
-					graph.ReqBy[dep] = make([]string, 0)
-				}
-				graph.ReqBy[dep] = append(graph.ReqBy[dep], pkg)
+				parseGraphHeaderLine(graph.info, line)
 			}
+			continue
+		}
+		inHeader = false
+
+		if lineSplit := splitGraphLine(line); len(lineSplit) == 2 {
+			graph.addEdge(lineSplit[0], lineSplit[1])
+		} else if len(lineSplit) == 3 {
+			graph.addExtraEdge(lineSplit[0], lineSplit[1], lineSplit[2])
 		} else if line != "" {
-			pkg := line
-			if _, in := graph.Req[pkg]; !in {
-				graph.Req[pkg] = make([]string, 0)
+			graph.addPkg(line)
+		}
+	}
+
+	return graph, nil
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Splits a graph file line into its package, dependency, and extra fields. Packages may be purls (see PackagePURL), e.g.,
+// "pkg:npm/express:pkg:npm/debug", whose "pkg:" scheme isn't taken as a separator.
+func splitGraphLine(line string) []string {
+	var fields []string
+	for _, part := range strings.Split(line, ":") {
+		if n := len(fields); n > 0 && fields[n-1] == "pkg" && strings.Contains(part, "/") {
+			fields[n-1] += ":" + part
+		} else {
+			fields = append(fields, part)
+		}
+	}
+	return fields
+}
+
+func newPyPIGraph() *PyPIGraph {
+	return &PyPIGraph{
+		Req:      make(map[string][]string),
+		ReqBy:    make(map[string][]string),
+		Extras:   make(map[string]map[string][]string),
+		optional: make(map[Edge]bool),
+		display:  make(map[string]string),
+		search:   &searchIndex{},
+	}
+}
+
+// Returns the normalized name of pkg, recording the name it was given if it differs (see DisplayName)
+func (p *PyPIGraph) normalize(pkg string) string {
+	norm := p.NormalizedName(pkg)
+	if norm != pkg {
+		if p.display == nil {
+			p.display = make(map[string]string)
+		}
+		if name, in := p.display[norm]; !in || pkg < name {
+			p.display[norm] = pkg
+		}
+	}
+	return norm
+}
+
+// Returns the name of pkg as it was given when building or loading the graph, e.g., "Django" or "zope.interface", while the graph is keyed and
+// queried by normalized names (see NormalizedName). Of several spellings of a name, the first in sort order is kept, so the display names
+// don't depend on the order packages were added in.
+func (p *PyPIGraph) DisplayName(pkg string) string {
+	norm := p.NormalizedName(pkg)
+	if name, in := p.display[norm]; in {
+		return name
+	}
+	return norm
+}
+
+func (p *PyPIGraph) addPkg(pkg string) {
+	pkg = p.normalize(pkg)
+	p.search.reset()
+	if _, in := p.Req[pkg]; !in {
+		p.Req[pkg] = make([]string, 0)
+	}
+	if _, in := p.ReqBy[pkg]; !in {
+		p.ReqBy[pkg] = make([]string, 0)
+	}
+}
+
+func (p *PyPIGraph) addEdge(pkg, dep string) {
+	pkg, dep = p.normalize(pkg), p.normalize(dep)
+	p.search.reset()
+	if p.optional[Edge{pkg, dep}] {
+		// already in the graph, but now required unconditionally
+		delete(p.optional, Edge{pkg, dep})
+		return
+	}
+
+	if _, in := p.Req[pkg]; !in {
+		p.Req[pkg] = make([]string, 0)
+	}
+	p.Req[pkg] = append(p.Req[pkg], dep)
+
+	if _, in := p.ReqBy[dep]; !in {
+		p.ReqBy[dep] = make([]string, 0)
+	}
+	p.ReqBy[dep] = append(p.ReqBy[dep], pkg)
+}
+
+// Adds pkg and its requirements
+func (p *PyPIGraph) addRequirements(pkg string, reqs []*Requirement) {
+	pkg = p.normalize(pkg)
+	p.addPkg(pkg)
+	for _, req := range reqs {
+		if req.Extra != "" {
+			p.addExtraEdge(pkg, req.Name, req.Extra)
+		} else {
+			p.addEdge(pkg, req.Name)
+		}
+	}
+}
+
+// Records that pkg requires dep only with the given extra
+func (p *PyPIGraph) addExtraEdge(pkg, dep, extra string) {
+	pkg, dep = p.normalize(pkg), p.normalize(dep)
+	if _, in := p.Extras[pkg]; !in {
+		p.Extras[pkg] = make(map[string][]string)
+	}
+	if containsString(p.Extras[pkg][extra], dep) {
+		return
+	}
+	p.Extras[pkg][extra] = append(p.Extras[pkg][extra], dep)
+
+	if !containsString(p.Req[pkg], dep) {
+		p.addEdge(pkg, dep)
+		p.optional[Edge{pkg, dep}] = true
+	}
+}
+
+// Serializes the graph in the format read by NewPyPIGraph: one line per package, followed by one "pkg:dep" line per distinct dependency, followed by
+// one "pkg:dep:extra" line per dependency that is only required with an extra, sorted by normalized name. Packages are written by their display
+// names (see DisplayName).
+func (p *PyPIGraph) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	edges := p.Edges()
+	for _, pkg := range p.sortedPkgs() {
+		if _, in := p.Req[pkg]; !in {
+			continue
+		}
+		name := p.DisplayName(pkg)
+		c, _ := fmt.Fprintln(bw, name)
+		n += int64(c)
+		for len(edges) > 0 && edges[0].Pkg == pkg {
+			if !p.optional[edges[0]] {
+				c, _ := fmt.Fprintf(bw, "%s:%s\n", name, p.DisplayName(edges[0].Dep))
+				n += int64(c)
 			}
-			if _, in := graph.ReqBy[pkg]; !in {
-				graph.ReqBy[pkg] = make([]string, 0)
+			edges = edges[1:]
+		}
+		extras := p.Extras[pkg]
+		for _, extra := range sortedExtras(extras) {
+			deps := append([]string(nil), extras[extra]...)
+			sort.Strings(deps)
+			for _, dep := range deps {
+				c, _ := fmt.Fprintf(bw, "%s:%s:%s\n", name, p.DisplayName(dep), extra)
+				n += int64(c)
 			}
 		}
 	}
+	return n, bw.Flush()
+}
 
-	return graph, nil
+// Like WriteTo, but first writes a header (see GraphInfo) with the provenance of the graph (see SetInfo), and a stamp recording the number of
+// packages and distinct edges of the graph and the SHA-256 digest of the rest of the output, e.g., "# sha256: 5e8f...". Apart from the
+// provenance, the output only depends on the contents of the graph, so graphs crawled from identical data produce identical files.
+func (p *PyPIGraph) WriteStamped(w io.Writer) error {
+	var body bytes.Buffer
+	if _, err := p.WriteTo(&body); err != nil {
+		return err
+	}
+	pkgs := 0
+	for _, pkg := range p.sortedPkgs() {
+		if _, in := p.Req[pkg]; in {
+			pkgs++
+		}
+	}
+	info := GraphInfo{}
+	if p.info != nil {
+		info = *p.info
+	}
+	info.Fields = make(map[string]string)
+	if p.info != nil {
+		for key, value := range p.info.Fields {
+			info.Fields[key] = value
+		}
+	}
+	info.Fields["packages"] = strconv.Itoa(pkgs)
+	info.Fields["edges"] = strconv.Itoa(len(p.Edges()))
+	info.Fields["sha256"] = fmt.Sprintf("%x", sha256.Sum256(body.Bytes()))
+	if err := WriteGraphHeader(w, &info); err != nil {
+		return err
+	}
+	_, err := body.WriteTo(w)
+	return err
+}
+
+func sortedExtras(extras map[string][]string) []string {
+	names := make([]string, 0, len(extras))
+	for extra := range extras {
+		names = append(names, extra)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Writes the graph to a file (see WriteTo), preceded by its header if it has provenance (see Info), in the binary format (see WriteBinary) if
+// the file name ends in ".bin", in the indexed format (see WriteMapped) if it ends in ".mmap", and gzip-compressed if it ends in ".gz", e.g.,
+// "pypi_graph.bin.gz". If file is an s3:// or gs:// URL, the graph is uploaded to object storage instead (see ObjectStore).
+func (p *PyPIGraph) WriteFile(file string) error {
+	f, err := createFile(file)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(file, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	if strings.HasSuffix(strings.TrimSuffix(file, ".gz"), ".bin") {
+		err = p.WriteBinary(w)
+	} else if strings.HasSuffix(file, ".mmap") {
+		err = p.WriteMapped(w)
+	} else if p.info != nil {
+		if err = WriteGraphHeader(w, p.info); err == nil {
+			_, err = p.WriteTo(w)
+		}
+	} else {
+		_, err = p.WriteTo(w)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
 }
 
 func (p *PyPIGraph) Requires(pkg string) []string {
-	return p.Req[NormalizedPkgName(pkg)]
+	return p.Req[p.NormalizedName(pkg)]
 }
 
 func (p *PyPIGraph) RequiredBy(pkg string) []string {
-	return p.ReqBy[NormalizedPkgName(pkg)]
+	return p.ReqBy[p.NormalizedName(pkg)]
+}
+
+// Returns the packages that pkg requires, and whether the graph lists the dependencies of pkg. found is false for packages that aren't in the
+// graph, and for packages that are only known as dependencies of others, e.g., because their own dependencies couldn't be crawled (see Contains).
+func (p *PyPIGraph) Lookup(pkg string) (deps []string, found bool) {
+	deps, found = p.Req[p.NormalizedName(pkg)]
+	return deps, found
+}
+
+// Returns whether pkg is in the graph, either with its dependencies or as a dependency of another package
+func (p *PyPIGraph) Contains(pkg string) bool {
+	pkg = p.NormalizedName(pkg)
+	_, inReq := p.Req[pkg]
+	_, inReqBy := p.ReqBy[pkg]
+	return inReq || inReqBy
+}
+
+// Returns the dependencies of pkg that are required unconditionally or by one of the given extras. With no extras, returns only the unconditional
+// dependencies.
+func (p *PyPIGraph) RequiresWithExtras(pkg string, extras ...string) []string {
+	pkg = p.NormalizedName(pkg)
+	deps := make([]string, 0)
+	for _, dep := range p.Req[pkg] {
+		if !p.optional[Edge{pkg, dep}] {
+			deps = append(deps, dep)
+		}
+	}
+	for _, extra := range extras {
+		for _, dep := range p.Extras[pkg][extra] {
+			if !containsString(deps, dep) {
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps
+}
+
+// Returns the extras that pkg declares, sorted
+func (p *PyPIGraph) ExtrasOf(pkg string) []string {
+	return sortedExtras(p.Extras[p.NormalizedName(pkg)])
+}
+
+// Returns all packages in the graph sorted by name, including packages that are only known as dependencies of other packages
+func (p *PyPIGraph) sortedPkgs() []string {
+	pkgs := make([]string, 0, len(p.Req))
+	for pkg := range p.Req {
+		pkgs = append(pkgs, pkg)
+	}
+	for pkg := range p.ReqBy {
+		if _, in := p.Req[pkg]; !in {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// A dependency edge: Pkg requires Dep
+type Edge struct {
+	Pkg string
+	Dep string
+}
+
+// Returns every distinct dependency edge in the graph, sorted by package and then dependency
+func (p *PyPIGraph) Edges() []Edge {
+	edges := make([]Edge, 0)
+	for _, pkg := range p.sortedPkgs() {
+		seen := make(map[string]bool)
+		for _, dep := range p.Req[pkg] {
+			if !seen[dep] {
+				seen[dep] = true
+				edges = append(edges, Edge{Pkg: pkg, Dep: dep})
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Pkg != edges[j].Pkg {
+			return edges[i].Pkg < edges[j].Pkg
+		}
+		return edges[i].Dep < edges[j].Dep
+	})
+	return edges
 }
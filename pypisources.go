@@ -0,0 +1,120 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Where the requirements of a PyPI package are read from (see PackageIndex.Sources)
+type RequirementsSource string
+
+const (
+	SourceJSON  RequirementsSource = "json"  // requires_dist of the release in PyPI's JSON API
+	SourceWheel RequirementsSource = "wheel" // Requires-Dist fields of the METADATA of a wheel (see FetchWheelMetadata)
+	SourceSdist RequirementsSource = "sdist" // requires.txt of a source archive, or its pyproject.toml, setup.cfg or setup.py
+)
+
+// Every source of requirements, from the cheapest to fetch to the most expensive
+var AllRequirementsSources = []RequirementsSource{SourceJSON, SourceWheel, SourceSdist}
+
+// Parses a comma-separated list of requirements sources, e.g., "json,wheel,sdist"
+func ParseRequirementsSources(list string) ([]RequirementsSource, error) {
+	var sources []RequirementsSource
+	for _, name := range strings.Split(list, ",") {
+		source := RequirementsSource(strings.ToLower(strings.TrimSpace(name)))
+		switch source {
+		case SourceJSON, SourceWheel, SourceSdist:
+			sources = append(sources, source)
+		case "":
+		default:
+			return nil, fmt.Errorf("Unknown requirements source '%s' (expected json, wheel or sdist)", name)
+		}
+	}
+	return sources, nil
+}
+
+// Fetches the requirements of a release of a package (the latest if version is empty) from the first of the index's Sources that has them, and
+// returns which source that was. A source that fails or doesn't know the requirements, e.g., a release without wheels, falls through to the
+// next; if all fail, the error of the last is returned. Packages that don't exist fail immediately.
+func (p *PackageIndex) FetchSourcedRequirements(pkg, version string) ([]*Requirement, RequirementsSource, error) {
+	type sourcedRequirements struct {
+		reqs   []*Requirement
+		source RequirementsSource
+	}
+	cached, err := p.Cache.memoize("reqs:"+NormalizedPkgName(pkg)+"@"+version, func() (interface{}, error) {
+		reqs, source, err := p.fetchSourcedRequirements(pkg, version)
+		return sourcedRequirements{reqs, source}, err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return cached.(sourcedRequirements).reqs, cached.(sourcedRequirements).source, nil
+}
+
+func (p *PackageIndex) fetchSourcedRequirements(pkg, version string) ([]*Requirement, RequirementsSource, error) {
+	sources := p.Sources
+	if len(sources) == 0 {
+		sources = []RequirementsSource{SourceSdist}
+	}
+	var err error
+	for i, source := range sources {
+		var reqs []*Requirement
+		switch source {
+		case SourceJSON:
+			reqs, err = p.fetchJSONRequirements(pkg, version)
+		case SourceWheel:
+			var metadata *Metadata
+			if metadata, err = p.FetchWheelMetadata(pkg, version); err == nil {
+				reqs = metadata.Requirements()
+			}
+		case SourceSdist:
+			reqs, err = p.fetchSdistRequirements(pkg, version)
+		default:
+			err = fmt.Errorf("Unknown requirements source '%s'", source)
+		}
+		if err == nil {
+			return reqs, source, nil
+		}
+		if errors.Is(err, ErrPackageNotFound) {
+			return nil, "", err
+		}
+		if i < len(sources)-1 {
+			loggerOr(p.Logger).Logf("source", "no requirements from %s for pkg %s, trying %s: %s", source, pkg, sources[i+1], err)
+		}
+	}
+	return nil, "", err
+}
+
+// Returns the requires_dist of a release in PyPI's JSON API. Releases uploaded without metadata have a null requires_dist, which is reported as
+// an error wrapping ErrNoRequiresFile rather than as no requirements.
+func (p *PackageIndex) fetchJSONRequirements(pkg, version string) ([]*Requirement, error) {
+	uri := fmt.Sprintf("%s/pypi/%s/json", p.URI, url.PathEscape(pkg))
+	if version != "" {
+		uri = fmt.Sprintf("%s/pypi/%s/%s/json", p.URI, url.PathEscape(pkg), url.PathEscape(version))
+	}
+	resp, err := p.client().Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("[json] JSON API returned %s for pkg %s", resp.Status, pkg))
+	}
+
+	var release struct {
+		Info struct {
+			RequiresDist *[]string `json:"requires_dist"`
+		} `json:"info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("[json] Unable to parse JSON API response for pkg %s: %s", pkg, err)
+	}
+	if release.Info.RequiresDist == nil {
+		return nil, fmt.Errorf("[json] %w: no requires_dist in JSON API for pkg %s", ErrNoRequiresFile, pkg)
+	}
+	return (&Metadata{RequiresDist: *release.Info.RequiresDist}).Requirements(), nil
+}
@@ -0,0 +1,90 @@
+package cheerio
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFetchSourcedRequirements(t *testing.T) {
+	var wheel bytes.Buffer
+	zw := zip.NewWriter(&wheel)
+	metadata, _ := zw.Create("wheelpkg-1.0.dist-info/METADATA")
+	fmt.Fprint(metadata, "Metadata-Version: 2.1\nName: wheelpkg\nVersion: 1.0\nRequires-Dist: six\n")
+	zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pypi/jsonpkg/json":
+			fmt.Fprint(w, `{"info": {"requires_dist": ["requests (>=2.0)", "pytest ; extra == 'test'"]}}`)
+		case "/pypi/wheelpkg/json":
+			fmt.Fprint(w, `{"info": {"requires_dist": null}}`)
+		case "/simple/wheelpkg/":
+			fmt.Fprint(w, `<a href="/files/wheelpkg-1.0-py3-none-any.whl">wheelpkg-1.0-py3-none-any.whl</a>`)
+		case "/files/wheelpkg-1.0-py3-none-any.whl":
+			http.ServeContent(w, r, "wheel.whl", time.Time{}, bytes.NewReader(wheel.Bytes()))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL, Sources: AllRequirementsSources, Logger: NopLogger{}}
+	tests := []struct {
+		pkg    string
+		reqs   []string
+		source RequirementsSource
+	}{
+		{"jsonpkg", []string{"requests", "pytest[test]"}, SourceJSON},
+		{"wheelpkg", []string{"six"}, SourceWheel},
+	}
+	for _, test := range tests {
+		reqs, source, err := index.FetchSourcedRequirements(test.pkg, "")
+		if err != nil {
+			t.Fatalf("FetchSourcedRequirements(%s): %s", test.pkg, err)
+		}
+		var names []string
+		for _, req := range reqs {
+			if req.Extra != "" {
+				names = append(names, fmt.Sprintf("%s[%s]", req.Name, req.Extra))
+			} else {
+				names = append(names, req.Name)
+			}
+		}
+		if !reflect.DeepEqual(names, test.reqs) || source != test.source {
+			t.Errorf("FetchSourcedRequirements(%s): expected %v from %s, got %v from %s", test.pkg, test.reqs, test.source, names, source)
+		}
+	}
+
+	if _, _, err := index.FetchSourcedRequirements("missing", ""); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("FetchSourcedRequirements: expected ErrPackageNotFound, got %v", err)
+	}
+
+	summary := &CrawlSummary{}
+	err := Crawl(context.Background(), index, &CrawlOptions{Packages: []string{"jsonpkg", "wheelpkg"}, Summary: summary}, func(res PackageResult) error {
+		if res.Source == "" {
+			t.Errorf("Crawl: expected the source of %s to be recorded", res.Pkg)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := map[RequirementsSource]int{SourceJSON: 1, SourceWheel: 1}; !reflect.DeepEqual(summary.Report().Sources, exp) {
+		t.Errorf("CrawlReport: expected sources %v, got %v", exp, summary.Report().Sources)
+	}
+
+	if sources, err := ParseRequirementsSources("wheel, sdist"); err != nil || !reflect.DeepEqual(sources, []RequirementsSource{SourceWheel, SourceSdist}) {
+		t.Errorf("ParseRequirementsSources: unexpected %v, %v", sources, err)
+	}
+	if _, err := ParseRequirementsSources("json,egg"); err == nil {
+		t.Error("ParseRequirementsSources: expected an error for an unknown source")
+	}
+}
@@ -0,0 +1,66 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+var DefaultPyPIStats = &PyPIStats{URI: "https://pypistats.org"}
+
+// Client of the pypistats.org API, which serves download counts of PyPI packages (https://pypistats.org/api/)
+type PyPIStats struct {
+	URI string
+}
+
+// Recent download counts of a package, excluding mirrors
+type DownloadCounts struct {
+	LastDay   int64 `json:"last_day"`
+	LastWeek  int64 `json:"last_week"`
+	LastMonth int64 `json:"last_month"`
+}
+
+// Fetches the recent download counts of a package
+func (s *PyPIStats) RecentDownloads(pkg string) (*DownloadCounts, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/packages/%s/recent", s.URI, url.PathEscape(NormalizedPkgName(pkg))))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pypistats returned %s for pkg %s", resp.Status, pkg)
+	}
+
+	var recent struct {
+		Data DownloadCounts `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&recent); err != nil {
+		return nil, err
+	}
+	return &recent.Data, nil
+}
+
+// Returns the n packages with the most downloads in the last month, highest first. If n <= 0, returns every package with download counts.
+func (idx PackageInfoIndex) MostDownloaded(n int) []PkgScore {
+	scores := make([]PkgScore, 0, len(idx))
+	for pkg, info := range idx {
+		if info.Downloads != nil {
+			scores = append(scores, PkgScore{Pkg: pkg, Score: float64(info.Downloads.LastMonth)})
+		}
+	}
+	return topScores(scores, n)
+}
+
+// Sorts packages in place by downloads in the last month, most popular first, e.g., to crawl popular packages before the rest. Packages without
+// download counts go last, in their original order.
+func (idx PackageInfoIndex) SortByDownloads(pkgs []string) {
+	downloads := func(pkg string) int64 {
+		if info, in := idx[NormalizedPkgName(pkg)]; in && info.Downloads != nil {
+			return info.Downloads.LastMonth
+		}
+		return -1
+	}
+	sort.SliceStable(pkgs, func(i, j int) bool { return downloads(pkgs[i]) > downloads(pkgs[j]) })
+}
@@ -0,0 +1,45 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRecentDownloads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/packages/flask/recent" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"last_day": 10, "last_week": 70, "last_month": 300}, "package": "flask", "type": "recent_downloads"}`)
+	}))
+	defer server.Close()
+
+	stats := &PyPIStats{URI: server.URL}
+	counts, err := stats.RecentDownloads("Flask")
+	if err != nil || *counts != (DownloadCounts{10, 70, 300}) {
+		t.Errorf("RecentDownloads: got %+v, %v", counts, err)
+	}
+	if _, err := stats.RecentDownloads("missing"); err == nil {
+		t.Errorf("RecentDownloads: expected an error for an unknown package")
+	}
+}
+
+func TestSortByDownloads(t *testing.T) {
+	idx := PackageInfoIndex{
+		"flask":    {Pkg: "flask", Downloads: &DownloadCounts{LastMonth: 300}},
+		"requests": {Pkg: "requests", Downloads: &DownloadCounts{LastMonth: 9000}},
+		"obscure":  {Pkg: "obscure"},
+	}
+	pkgs := []string{"unknown", "flask", "obscure", "Requests"}
+	idx.SortByDownloads(pkgs)
+	if exp := []string{"Requests", "flask", "unknown", "obscure"}; !reflect.DeepEqual(pkgs, exp) {
+		t.Errorf("SortByDownloads: expected %v, got %v", exp, pkgs)
+	}
+	if top := idx.MostDownloaded(1); len(top) != 1 || top[0].Pkg != "requests" {
+		t.Errorf("MostDownloaded: got %v", top)
+	}
+}
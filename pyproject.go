@@ -0,0 +1,168 @@
+package cheerio
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var pyprojectPattern = regexp.MustCompile(`^[^/]+/pyproject\.toml$`)
+
+// Parse the requirements declared in a pyproject.toml file. Both PEP 621 ([project] dependencies and optional-dependencies) and Poetry
+// ([tool.poetry] dependencies, dev-dependencies, and dependency groups) layouts are supported. Returns the runtime requirements and the
+// development/optional requirements separately.
+func ParsePyProject(contents string) (deps, devDeps []*Requirement, err error) {
+	doc, err := parseTOML(contents)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not parse pyproject.toml: %s", err)
+	}
+	deps, devDeps = make([]*Requirement, 0), make([]*Requirement, 0)
+
+	// PEP 621
+	if project, ok := doc["project"].(map[string]interface{}); ok {
+		deps = append(deps, pep508Requirements(project["dependencies"])...)
+		if optional, ok := project["optional-dependencies"].(map[string]interface{}); ok {
+			for _, extra := range sortedKeys(optional) {
+				for _, req := range pep508Requirements(optional[extra]) {
+					req.Extra = extra
+					devDeps = append(devDeps, req)
+				}
+			}
+		}
+	}
+
+	// Poetry
+	if tool, ok := doc["tool"].(map[string]interface{}); ok {
+		if poetry, ok := tool["poetry"].(map[string]interface{}); ok {
+			deps = append(deps, poetryRequirements(poetry["dependencies"])...)
+			devDeps = append(devDeps, poetryRequirements(poetry["dev-dependencies"])...)
+			if groups, ok := poetry["group"].(map[string]interface{}); ok {
+				for _, name := range sortedKeys(groups) {
+					if group, ok := groups[name].(map[string]interface{}); ok {
+						devDeps = append(devDeps, poetryRequirements(group["dependencies"])...)
+					}
+				}
+			}
+		}
+	}
+
+	return deps, devDeps, nil
+}
+
+// Parses a TOML array of PEP 508 requirement strings, e.g., ["requests[security]>=2.8.1", "pywin32; sys_platform == 'win32'"]
+func pep508Requirements(value interface{}) []*Requirement {
+	reqs := make([]*Requirement, 0)
+	array, _ := value.([]interface{})
+	for _, item := range array {
+		reqStr, ok := item.(string)
+		if !ok {
+			continue
+		}
+		if req, err := ParseRequirementLine(reqStr); err == nil {
+			reqs = append(reqs, req)
+		} else {
+			DefaultLogger.Logf("req", "Could not parse requirement: %s", err)
+		}
+	}
+	return reqs
+}
+
+// Parses a Poetry dependency table. The "python" entry, which constrains the interpreter rather than naming a package, is skipped. Version
+// constraints are converted to pip specifiers (see poetrySpec).
+func poetryRequirements(value interface{}) []*Requirement {
+	reqs := make([]*Requirement, 0)
+	table, _ := value.(map[string]interface{})
+	for _, name := range sortedKeys(table) {
+		if strings.ToLower(name) == "python" {
+			continue
+		}
+		switch spec := table[name].(type) {
+		case string:
+			reqs = append(reqs, requirementFromSpec(name, poetrySpec(spec)))
+		case map[string]interface{}:
+			version, _ := spec["version"].(string)
+			req := requirementFromSpec(name, poetrySpec(version))
+			req.Marker, _ = spec["markers"].(string)
+			req.Editable, _ = spec["develop"].(bool)
+			if url, ok := spec["git"].(string); ok {
+				req.URL = "git+" + url
+			} else if url, ok := spec["url"].(string); ok {
+				req.URL = url
+			} else if path, ok := spec["path"].(string); ok {
+				req.URL = path
+			}
+			reqs = append(reqs, req)
+		case []interface{}:
+			// multiple constraints dependency; use the first constraint
+			if len(spec) > 0 {
+				if first, ok := spec[0].(map[string]interface{}); ok {
+					version, _ := first["version"].(string)
+					reqs = append(reqs, requirementFromSpec(name, poetrySpec(version)))
+				}
+			}
+		}
+	}
+	return reqs
+}
+
+var bareVersionRegexp = regexp.MustCompile(`^[0-9][A-Za-z0-9\._\-]*$`)
+var releaseNumbersRegexp = regexp.MustCompile(`^[0-9]+(?:\.[0-9]+)*`)
+
+// Converts a Poetry version constraint into a pip specifier. A bare version is an exact constraint, and caret and tilde constraints get both their
+// bounds, e.g., "^1.2" is ">=1.2,<2.0" and "~1.2" is ">=1.2,<1.3".
+func poetrySpec(spec string) string {
+	clauses := strings.Split(spec, ",")
+	for i, clause := range clauses {
+		clause = strings.TrimSpace(clause)
+		switch {
+		case strings.HasPrefix(clause, "^"):
+			clause = poetryRange(strings.TrimSpace(clause[1:]), true)
+		case strings.HasPrefix(clause, "~") && !strings.HasPrefix(clause, "~="):
+			clause = poetryRange(strings.TrimSpace(clause[1:]), false)
+		case bareVersionRegexp.MatchString(clause):
+			clause = "==" + clause
+		}
+		clauses[i] = clause
+	}
+	return strings.Join(clauses, ",")
+}
+
+// Returns the pip specifier of a Poetry caret ("^") or tilde ("~") constraint on version ver. A caret allows changes that don't modify the left-most
+// non-zero release number, and a tilde allows changes of the last release number, or of the minor version if ver has more than two.
+func poetryRange(ver string, caret bool) string {
+	release := releaseNumbersRegexp.FindString(ver)
+	if release == "" {
+		return ">=" + ver
+	}
+	var nums []int
+	for _, part := range strings.Split(release, ".") {
+		n, _ := strconv.Atoi(part)
+		nums = append(nums, n)
+	}
+
+	// the index of the release number to increment
+	bump := 0
+	if caret {
+		for bump < len(nums)-1 && nums[bump] == 0 {
+			bump++
+		}
+	} else if len(nums) > 1 {
+		bump = 1
+	}
+	upper := make([]string, len(nums))
+	if len(upper) < 2 {
+		upper = make([]string, 2)
+	}
+	for i := range upper {
+		switch {
+		case i < bump:
+			upper[i] = strconv.Itoa(nums[i])
+		case i == bump:
+			upper[i] = strconv.Itoa(nums[i] + 1)
+		default:
+			upper[i] = "0"
+		}
+	}
+	return ">=" + ver + ",<" + strings.Join(upper, ".")
+}
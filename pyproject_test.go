@@ -0,0 +1,99 @@
+package cheerio
+
+import (
+	"github.com/kr/pretty"
+	"reflect"
+	"testing"
+)
+
+func TestParsePyProject(t *testing.T) {
+	tests := []struct {
+		contents    string
+		wantDeps    []*Requirement
+		wantDevDeps []*Requirement
+	}{
+		{
+			contents: `[project]
+name = "example"
+dependencies = [
+    "requests[security]>=2.8.1",
+    "pywin32 ; sys_platform == 'win32'",  # windows only
+]
+
+[project.optional-dependencies]
+test = ["nose==1.3.0"]
+`,
+			wantDeps: []*Requirement{
+				{Name: "requests", Extras: []string{"security"}, Constraint: ">=", Version: "2.8.1"},
+				{Name: "pywin32", Marker: "sys_platform == 'win32'"},
+			},
+			wantDevDeps: []*Requirement{
+				{Name: "nose", Constraint: "==", Version: "1.3.0", Extra: "test"},
+			},
+		},
+		{
+			contents: `[tool.poetry]
+name = "example"
+
+[tool.poetry.dependencies]
+python = "^3.7"
+flask = "^1.1"
+requests = "~2.25.1"
+celery = {version = ">=4.0", markers = "sys_platform != 'win32'"}
+werkzeug = {git = "https://github.com/pallets/werkzeug.git"}
+
+[tool.poetry.dev-dependencies]
+pytest = "6.2.1"
+
+[tool.poetry.group.docs.dependencies]
+sphinx = "*"
+`,
+			wantDeps: []*Requirement{
+				{Name: "celery", Constraint: ">=", Version: "4.0", Marker: "sys_platform != 'win32'"},
+				{Name: "flask", Constraint: ">=", Version: "1.1", More: []string{"<2.0"}},
+				{Name: "requests", Constraint: ">=", Version: "2.25.1", More: []string{"<2.26.0"}},
+				{Name: "werkzeug", URL: "git+https://github.com/pallets/werkzeug.git"},
+			},
+			wantDevDeps: []*Requirement{
+				{Name: "pytest", Constraint: "==", Version: "6.2.1"},
+				{Name: "sphinx"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		deps, devDeps, err := ParsePyProject(test.contents)
+		if err != nil {
+			t.Errorf("Error parsing pyproject.toml: %s", err)
+			continue
+		}
+		if !reflect.DeepEqual(deps, test.wantDeps) {
+			t.Errorf("Dependencies do not match: %v", pretty.Diff(deps, test.wantDeps))
+		}
+		if !reflect.DeepEqual(devDeps, test.wantDevDeps) {
+			t.Errorf("Dev dependencies do not match: %v", pretty.Diff(devDeps, test.wantDevDeps))
+		}
+	}
+}
+
+func TestPoetrySpec(t *testing.T) {
+	for spec, want := range map[string]string{
+		"2.13.0":        "==2.13.0",
+		"^1.2":          ">=1.2,<2.0",
+		"^1.2.3":        ">=1.2.3,<2.0.0",
+		"^0.2.3":        ">=0.2.3,<0.3.0",
+		"^0.0.3":        ">=0.0.3,<0.0.4",
+		"^1":            ">=1,<2.0",
+		"~1.2":          ">=1.2,<1.3",
+		"~1.2.3":        ">=1.2.3,<1.3.0",
+		"~1":            ">=1,<2.0",
+		"^1.2, !=1.2.5": ">=1.2,<2.0,!=1.2.5",
+		"~=1.2":         "~=1.2",
+		">=4.0":         ">=4.0",
+		"*":             "*",
+	} {
+		if got := poetrySpec(spec); got != want {
+			t.Errorf("poetrySpec(%q): want %q, got %q", spec, want, got)
+		}
+	}
+}
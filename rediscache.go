@@ -0,0 +1,218 @@
+package cheerio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default timeout of the requests of a RedisCache
+const DefaultRedisTimeout = 2 * time.Second
+
+// Default number of idle connections a RedisCache keeps open
+const DefaultRedisPoolSize = 8
+
+// A MetadataCache in Redis, or a server speaking its protocol, e.g., Valkey or KeyDB, so that the replicas of a service share their lookups
+// (see CachedIndex). Entries expire on the server. Concurrent requests use connections of their own, which are kept open in a small pool for
+// the following requests, and dropped after an error; it is safe for concurrent use.
+type RedisCache struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+	Timeout  time.Duration // of each request, including connecting
+	PoolSize int           // maximum number of idle connections kept open; DefaultRedisPoolSize if 0
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// A connection to a Redis server
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// Returns a cache in the Redis server at uri, e.g., "redis://:password@localhost:6379/0"; the password and database number are optional
+func NewRedisCache(uri string) (*RedisCache, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("Not a Redis URL: %s (expected redis://[:password@]host[:port][/db])", uri)
+	}
+	c := &RedisCache{Addr: u.Host, Timeout: DefaultRedisTimeout}
+	if u.Port() == "" {
+		c.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if c.DB, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("Invalid Redis database number '%s' in %s", db, uri)
+		}
+	}
+	return c, nil
+}
+
+func (c *RedisCache) Get(key string) ([]byte, bool, error) {
+	reply, err := c.do("GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	b, ok := reply.([]byte)
+	return b, ok, nil
+}
+
+func (c *RedisCache) Set(key string, value []byte, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	_, err := c.do("SET", key, string(value), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+// Closes the idle connections to the server. The cache reconnects if it's used again.
+func (c *RedisCache) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+	c.mu.Unlock()
+	var err error
+	for _, conn := range idle {
+		if closeErr := conn.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Errors replied by the server, e.g., to a command with the wrong arguments, after which the connection can still be used
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// Sends a command on an idle connection, or a new one if there is none, and returns its reply: nil, a string, an int64 or a []byte
+func (c *RedisCache) do(args ...string) (interface{}, error) {
+	conn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.roundTrip(args, c.Timeout)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close() // the connection may be out of sync with the server
+	} else {
+		c.put(conn)
+	}
+	return reply, err
+}
+
+// Takes an idle connection from the pool, or opens one
+func (c *RedisCache) get() (*redisConn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+	return c.connect()
+}
+
+// Returns a connection to the pool, or closes it if the pool is full
+func (c *RedisCache) put(conn *redisConn) {
+	size := c.PoolSize
+	if size <= 0 {
+		size = DefaultRedisPoolSize
+	}
+	c.mu.Lock()
+	if len(c.idle) < size {
+		c.idle = append(c.idle, conn)
+		conn = nil
+	}
+	c.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// Opens a connection, authenticating and selecting the database
+func (c *RedisCache) connect() (*redisConn, error) {
+	netConn, err := net.DialTimeout("tcp", c.Addr, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, r: bufio.NewReader(netConn)}
+	if c.Password != "" {
+		if _, err := conn.roundTrip([]string{"AUTH", c.Password}, c.Timeout); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.DB != 0 {
+		if _, err := conn.roundTrip([]string{"SELECT", strconv.Itoa(c.DB)}, c.Timeout); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (conn *redisConn) roundTrip(args []string, timeout time.Duration) (interface{}, error) {
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(conn, cmd.String()); err != nil {
+		return nil, err
+	}
+	return readRedisReply(conn.r)
+}
+
+// Reads a reply in the Redis serialization protocol (RESP2). Arrays aren't supported, as none of the commands sent returns one.
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("Malformed Redis reply: empty line")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Malformed Redis reply: %q", line)
+		} else if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+	return nil, fmt.Errorf("Unsupported Redis reply: %q", line)
+}
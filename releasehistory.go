@@ -0,0 +1,101 @@
+package cheerio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// A release of a package and when it was uploaded (see ReleaseHistory)
+type ReleaseInfo struct {
+	Version       string
+	Uploaded      time.Time      // when its first file was uploaded; zero if it has no files
+	ArtifactTypes []ArtifactType `json:",omitempty"` // the types of its files, e.g., [sdist wheel]
+	Yanked        bool           `json:",omitempty"` // whether all of its files were yanked (PEP 592)
+}
+
+// The parts of a package's page in PyPI's JSON API used by LatestVersion and ReleaseHistory
+type jsonPackage struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Releases map[string][]struct {
+		Filename   string    `json:"filename"`
+		UploadTime time.Time `json:"upload_time_iso_8601"`
+		Yanked     bool      `json:"yanked"`
+	} `json:"releases"`
+}
+
+// Fetches a package's page in PyPI's JSON API, which local mirrors don't serve
+func (p *PackageIndex) fetchJSONPackage(pkg string) (*jsonPackage, error) {
+	resp, err := p.client().Get(fmt.Sprintf("%s/pypi/%s/json", p.URI, url.PathEscape(pkg)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("[json] JSON API returned %s for pkg %s", resp.Status, pkg))
+	}
+
+	var jsonPkg jsonPackage
+	if err := json.NewDecoder(resp.Body).Decode(&jsonPkg); err != nil {
+		return nil, fmt.Errorf("[json] Unable to parse JSON API response for pkg %s: %s", pkg, err)
+	}
+	return &jsonPkg, nil
+}
+
+// Returns the latest version of a package according to PyPI's JSON API, which is that of its latest release that isn't a pre-release, unless
+// it only has pre-releases
+func (p *PackageIndex) LatestVersion(pkg string) (string, error) {
+	jsonPkg, err := p.fetchJSONPackage(pkg)
+	if err != nil {
+		return "", err
+	}
+	if jsonPkg.Info.Version == "" {
+		return "", fmt.Errorf("[json] %w for pkg %s", ErrNoReleases, pkg)
+	}
+	return jsonPkg.Info.Version, nil
+}
+
+// Returns the releases of a package listed by PyPI's JSON API, oldest first (by PEP 440 ordering), with their upload times and file types, e.g.,
+// to tell how far behind a pinned version is
+func (p *PackageIndex) ReleaseHistory(pkg string) ([]*ReleaseInfo, error) {
+	jsonPkg, err := p.fetchJSONPackage(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*ReleaseInfo, 0, len(jsonPkg.Releases))
+	for version, files := range jsonPkg.Releases {
+		release := &ReleaseInfo{Version: version, Yanked: len(files) > 0}
+		for _, file := range files {
+			if release.Uploaded.IsZero() || file.UploadTime.Before(release.Uploaded) {
+				release.Uploaded = file.UploadTime
+			}
+			if artifactType, _ := artifactTypeAndVersion(pkg, file.Filename); !containsArtifactType(release.ArtifactTypes, artifactType) {
+				release.ArtifactTypes = append(release.ArtifactTypes, artifactType)
+			}
+			release.Yanked = release.Yanked && file.Yanked
+		}
+		history = append(history, release)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if c := CompareVersions(history[i].Version, history[j].Version); c != 0 {
+			return c < 0
+		}
+		return history[i].Version < history[j].Version
+	})
+	return history, nil
+}
+
+func containsArtifactType(types []ArtifactType, t ArtifactType) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package cheerio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReleaseHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pypi/flask/json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"info": {"version": "2.0.1"}, "releases": {
+"2.0.1": [{"filename": "Flask-2.0.1-py3-none-any.whl", "upload_time_iso_8601": "2021-05-21T20:16:21.123456Z", "yanked": false},
+          {"filename": "Flask-2.0.1.tar.gz", "upload_time_iso_8601": "2021-05-21T20:16:19.000000Z", "yanked": false}],
+"0.10": [{"filename": "Flask-0.10.tar.gz", "upload_time_iso_8601": "2013-06-13T11:34:51.000000Z", "yanked": true}],
+"2.1.0rc1": [{"filename": "Flask-2.1.0rc1-py3-none-any.whl", "upload_time_iso_8601": "2022-03-01T10:00:00Z", "yanked": false}],
+"0.9": []
+}}`)
+	}))
+	defer server.Close()
+	index := &PackageIndex{URI: server.URL}
+
+	if latest, err := index.LatestVersion("flask"); err != nil || latest != "2.0.1" {
+		t.Errorf("expected latest version 2.0.1, got %q, %v", latest, err)
+	}
+	if _, err := index.LatestVersion("django"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected ErrPackageNotFound, got %v", err)
+	}
+
+	history, err := index.ReleaseHistory("flask")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []*ReleaseInfo{
+		{Version: "0.9"},
+		{Version: "0.10", Uploaded: time.Date(2013, 6, 13, 11, 34, 51, 0, time.UTC), ArtifactTypes: []ArtifactType{ArtifactSdist}, Yanked: true},
+		{Version: "2.0.1", Uploaded: time.Date(2021, 5, 21, 20, 16, 19, 0, time.UTC), ArtifactTypes: []ArtifactType{ArtifactWheel, ArtifactSdist}},
+		{Version: "2.1.0rc1", Uploaded: time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC), ArtifactTypes: []ArtifactType{ArtifactWheel}},
+	}
+	if !reflect.DeepEqual(history, exp) {
+		t.Errorf("unexpected history")
+		for _, release := range history {
+			t.Logf("%+v", release)
+		}
+	}
+}
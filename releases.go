@@ -0,0 +1,163 @@
+package cheerio
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The kind of a distribution file
+type ArtifactType string
+
+const (
+	ArtifactSdist ArtifactType = "sdist" // source archive (.tar.gz, .zip, ...)
+	ArtifactWheel ArtifactType = "wheel"
+	ArtifactEgg   ArtifactType = "egg"
+	ArtifactOther ArtifactType = "other" // e.g., Windows installers
+)
+
+// A distribution file of a release, as listed on a package's simple index page
+type Artifact struct {
+	Filename string
+	URL      string // absolute download URL, without the digest fragment
+	Type     ArtifactType
+	Version  string
+	Digests  map[string]string `json:",omitempty"` // hash name -> hex digest, from the URL fragment, e.g., "sha256" -> "4a5f..."
+
+	// Whether the file was yanked (PEP 592), and why, if the index says so. Yanked files are skipped when selecting the file of the latest
+	// release, unless PackageIndex.IncludeYanked is set.
+	Yanked       bool   `json:",omitempty"`
+	YankedReason string `json:",omitempty"`
+}
+
+// A release of a package and its distribution files
+type Release struct {
+	Version   string
+	Artifacts []*Artifact
+}
+
+// Returns the releases of a package listed on its simple index page, oldest first (by PEP 440 ordering). Files whose version can't be determined
+// from their name are omitted.
+func (p *PackageIndex) Versions(pkg string) ([]*Release, error) {
+	artifacts, err := p.pkgArtifacts(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []*Release
+	byVersion := make(map[string]*Release)
+	for _, artifact := range artifacts {
+		if artifact.Version == "" {
+			continue
+		}
+		release, in := byVersion[artifact.Version]
+		if !in {
+			release = &Release{Version: artifact.Version}
+			byVersion[artifact.Version] = release
+			releases = append(releases, release)
+		}
+		release.Artifacts = append(release.Artifacts, artifact)
+	}
+	sort.SliceStable(releases, func(i, j int) bool { return CompareVersions(releases[i].Version, releases[j].Version) < 0 })
+	return releases, nil
+}
+
+var simpleLinkRegexp = regexp.MustCompile(`(?is)<a\s([^>]*)>([^<]*)</a>`)
+var hrefRegexp = regexp.MustCompile(`(?i)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+var digestFragmentRegexp = regexp.MustCompile(`^([a-z0-9]+)=([0-9a-fA-F]+)$`)
+var yankedRegexp = regexp.MustCompile(`(?i)(?:^|\s)data-yanked(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?(?:\s|$)`)
+
+// Returns the distribution files listed on a package's simple index page, in either the legacy page format (relative links with "#md5="
+// fragments) or the PEP 503 format (absolute links with any hash fragment).
+func (p *PackageIndex) pkgArtifacts(pkg string) ([]*Artifact, error) {
+	return p.pageArtifacts(pkg, fmt.Sprintf("%s/simple/%s/", p.URI, pkg))
+}
+
+// Returns the distribution files listed on the simple index page at pageURL
+func (p *PackageIndex) pageArtifacts(pkg, pageURL string) ([]*Artifact, error) {
+	resp, err := p.client().Get(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("[no-files] simple index returned %s for pkg %s", resp.Status, pkg))
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// links are relative to the page's final URL, e.g., after a redirect from /simple/<pkg> to /simple/<pkg>/
+	return parseSimpleIndexPage(pkg, resp.Request.URL.String(), string(body))
+}
+
+// Parses the links of a simple index page, resolving them against pageURL
+func parseSimpleIndexPage(pkg, pageURL, page string) ([]*Artifact, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]*Artifact, 0)
+	for _, link := range simpleLinkRegexp.FindAllStringSubmatch(page, -1) {
+		href := hrefRegexp.FindStringSubmatch(link[1])
+		if href == nil {
+			continue
+		}
+		ref, err := url.Parse(html.UnescapeString(href[1] + href[2]))
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref)
+
+		artifact := &Artifact{Filename: strings.TrimSpace(html.UnescapeString(link[2]))}
+		if artifact.Filename == "" {
+			artifact.Filename = path.Base(u.Path)
+		}
+		if match := digestFragmentRegexp.FindStringSubmatch(u.Fragment); match != nil {
+			artifact.Digests = map[string]string{strings.ToLower(match[1]): strings.ToLower(match[2])}
+		}
+		if match := yankedRegexp.FindStringSubmatch(link[1]); match != nil {
+			artifact.Yanked, artifact.YankedReason = true, html.UnescapeString(match[1]+match[2]+match[3])
+		}
+		u.Fragment = ""
+		artifact.URL = u.String()
+		artifact.Type, artifact.Version = artifactTypeAndVersion(pkg, artifact.Filename)
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+// Returns the artifacts that aren't yanked
+func withoutYanked(artifacts []*Artifact) []*Artifact {
+	var unyanked []*Artifact
+	for _, artifact := range artifacts {
+		if !artifact.Yanked {
+			unyanked = append(unyanked, artifact)
+		}
+	}
+	return unyanked
+}
+
+// Returns the type and version of a distribution file from its name
+func artifactTypeAndVersion(pkg, filename string) (ArtifactType, string) {
+	switch {
+	case strings.HasSuffix(filename, ".whl"):
+		// {name}-{version}(-{build})?-{python}-{abi}-{platform}.whl
+		if parts := strings.Split(strings.TrimSuffix(filename, ".whl"), "-"); len(parts) >= 5 {
+			return ArtifactWheel, parts[1]
+		}
+		return ArtifactWheel, ""
+	case strings.HasSuffix(filename, ".egg"):
+		return ArtifactEgg, fileVersion(pkg, filename)
+	case archiveExtRegexp.MatchString(filename):
+		return ArtifactSdist, fileVersion(pkg, filename)
+	}
+	return ArtifactOther, ""
+}
@@ -0,0 +1,99 @@
+package cheerio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simple/flask/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1>Links for Flask</h1>
+<a href="../../packages/source/F/Flask/Flask-0.10.tar.gz#md5=4c83829a4d2a8e8a6f3ee4a5bf8e4a7b" rel="internal">Flask-0.10.tar.gz</a><br/>
+<a href="../../packages/source/F/Flask/Flask-0.9.tar.gz#md5=4a89ef2b3ab0f151f781182bd0cc8933">Flask-0.9.tar.gz</a><br/>
+<a href="https://files.example.com/packages/Flask-2.0.0rc1-py3-none-any.whl#sha256=ABCDEF01" data-requires-python="&gt;=3.6">Flask-2.0.0rc1-py3-none-any.whl</a><br />
+<a href="https://files.example.com/packages/Flask-0.10-py2.7.egg">Flask-0.10-py2.7.egg</a><br />
+<a href="https://files.example.com/packages/Flask-0.10.win32.exe">Flask-0.10.win32.exe</a><br />
+</body></html>`)
+	}))
+	defer server.Close()
+
+	releases, err := (&PackageIndex{URI: server.URL}).Versions("flask")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []string
+	for _, release := range releases {
+		versions = append(versions, release.Version)
+	}
+	if exp := []string{"0.9", "0.10", "2.0.0rc1"}; !reflect.DeepEqual(versions, exp) {
+		t.Fatalf("Versions: expected %v, got %v", exp, versions)
+	}
+
+	exp := []*Artifact{
+		{
+			Filename: "Flask-0.10.tar.gz",
+			URL:      server.URL + "/packages/source/F/Flask/Flask-0.10.tar.gz",
+			Type:     ArtifactSdist,
+			Version:  "0.10",
+			Digests:  map[string]string{"md5": "4c83829a4d2a8e8a6f3ee4a5bf8e4a7b"},
+		},
+		{
+			Filename: "Flask-0.10-py2.7.egg",
+			URL:      "https://files.example.com/packages/Flask-0.10-py2.7.egg",
+			Type:     ArtifactEgg,
+			Version:  "0.10",
+		},
+	}
+	if !reflect.DeepEqual(releases[1].Artifacts, exp) {
+		t.Errorf("Versions: expected artifacts %+v, got %+v", exp, releases[1].Artifacts)
+	}
+	if wheel := releases[2].Artifacts[0]; wheel.Type != ArtifactWheel || wheel.Digests["sha256"] != "abcdef01" {
+		t.Errorf("Versions: unexpected wheel artifact %+v", wheel)
+	}
+}
+
+func TestYanked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/pkg":
+			fmt.Fprint(w, `<a href="../../packages/pkg-1.0.tar.gz#md5=0123abcd">pkg-1.0.tar.gz</a><br/>
+<a href="../../packages/pkg-1.1.tar.gz#md5=0123abcd" data-yanked="broken &amp; insecure">pkg-1.1.tar.gz</a><br/>`)
+		case "/simple/gone":
+			fmt.Fprint(w, `<a data-yanked href="../../packages/gone-1.0.tar.gz#md5=0123abcd">gone-1.0.tar.gz</a><br/>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL}
+	artifact, err := index.selectArtifact("pkg", "", ArtifactSdist)
+	if err != nil || artifact.Filename != "pkg-1.0.tar.gz" {
+		t.Errorf("selectArtifact: expected the unyanked pkg-1.0.tar.gz, got %+v, %v", artifact, err)
+	}
+	artifact, err = index.selectArtifact("pkg", "1.1", ArtifactSdist)
+	if err != nil || !artifact.Yanked || artifact.YankedReason != "broken & insecure" {
+		t.Errorf("selectArtifact: expected the yanked file of a requested release, got %+v, %v", artifact, err)
+	}
+	if _, err := index.selectArtifact("gone", "", ArtifactSdist); !errors.Is(err, ErrNoReleases) {
+		t.Errorf("selectArtifact: expected ErrNoReleases if all files are yanked, got %v", err)
+	}
+	if reqs, err := index.FetchPackageRequirements("gone"); !errors.Is(err, ErrNoReleases) {
+		t.Errorf("FetchPackageRequirements: expected ErrNoReleases rather than no requirements if all files are yanked, got %v, %v", reqs, err)
+	}
+
+	index.IncludeYanked = true
+	artifact, err = index.selectArtifact("pkg", "", ArtifactSdist)
+	if err != nil || artifact.Filename != "pkg-1.1.tar.gz" {
+		t.Errorf("selectArtifact: expected pkg-1.1.tar.gz with IncludeYanked, got %+v, %v", artifact, err)
+	}
+}
@@ -0,0 +1,101 @@
+package cheerio
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Reverse index from source repositories to the PyPI packages published from them. A repository may publish several packages (e.g., a monorepo).
+type RepoIndex struct {
+	pkgs map[string][]string // repo key -> sorted package names
+	urls map[string]string   // repo key -> canonical repo URL
+}
+
+// Builds a RepoIndex from a map of package names to repository URLs. URLs that cannot be canonicalized are skipped. If packages spell the same
+// repository URL with different case, the spelling used by the first package (in name order) is kept.
+func NewRepoIndex(pkgRepos map[string]string) *RepoIndex {
+	idx := &RepoIndex{pkgs: make(map[string][]string), urls: make(map[string]string)}
+	pkgs := make([]string, 0, len(pkgRepos))
+	for pkg := range pkgRepos {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		idx.add(pkg, pkgRepos[pkg])
+	}
+	for key := range idx.pkgs {
+		sort.Strings(idx.pkgs[key])
+	}
+	return idx
+}
+
+// Reads a RepoIndex from the TSV output of "cheerio repos" (package, repository URL, and error columns). Lines without a repository URL are
+// skipped.
+func ReadRepoIndex(r io.Reader) (*RepoIndex, error) {
+	pkgRepos := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) >= 2 && fields[1] != "" {
+			pkgRepos[fields[0]] = fields[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewRepoIndex(pkgRepos), nil
+}
+
+// Reads a RepoIndex from a file (see ReadRepoIndex)
+func LoadRepoIndex(file string) (*RepoIndex, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRepoIndex(f)
+}
+
+func (idx *RepoIndex) add(pkg, repoURL string) {
+	canonical, err := CanonicalRepoURL(repoURL)
+	if err != nil {
+		return
+	}
+	key := repoKey(canonical)
+	if _, in := idx.urls[key]; !in {
+		idx.urls[key] = canonical
+	}
+	pkg = NormalizedPkgName(pkg)
+	if !containsString(idx.pkgs[key], pkg) {
+		idx.pkgs[key] = append(idx.pkgs[key], pkg)
+	}
+}
+
+// Repository hosts treat owner and repository names case-insensitively, so repositories are keyed by their lowercased canonical URL
+func repoKey(canonicalURL string) string {
+	return strings.ToLower(canonicalURL)
+}
+
+// Returns the packages published from a repository, sorted by name. Any spelling of the repository URL accepted by CanonicalRepoURL may be used.
+func (idx *RepoIndex) Packages(repoURL string) []string {
+	canonical, err := CanonicalRepoURL(repoURL)
+	if err != nil {
+		return nil
+	}
+	return idx.pkgs[repoKey(canonical)]
+}
+
+// Returns the canonical URLs of the repositories that publish more than one package, sorted
+func (idx *RepoIndex) MultiPackageRepos() []string {
+	repos := make([]string, 0)
+	for key, pkgs := range idx.pkgs {
+		if len(pkgs) > 1 {
+			repos = append(repos, idx.urls[key])
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
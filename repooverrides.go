@@ -0,0 +1,64 @@
+package cheerio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variable naming a repo overrides file that is loaded at startup (see LoadRepoOverrides)
+const RepoOverridesEnv = "CHEERIO_REPO_OVERRIDES"
+
+// User-supplied source repository URLs, keyed by normalized package name. These take precedence over both PyPI metadata and the hard-coded
+// pypiRepos map.
+var repoOverrides = make(map[string]string)
+
+func init() {
+	if file := os.Getenv(RepoOverridesEnv); file != "" {
+		if err := LoadRepoOverrides(file); err != nil {
+			DefaultLogger.Logf("repo", "Could not load repo overrides from %s: %s", file, err)
+		}
+	}
+}
+
+// Loads source repository URL corrections and additions from a file. Files ending in ".json" must contain a JSON object mapping package names to
+// repository URLs. Other files are read as TSV: one "<package>\t<url>" pair per line, with blank lines and lines starting with "#" ignored. Later
+// loads override earlier ones. Not safe to call concurrently with source repository lookups.
+func LoadRepoOverrides(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	overrides := make(map[string]string)
+	if filepath.Ext(file) == ".json" {
+		if err := json.NewDecoder(f).Decode(&overrides); err != nil {
+			return err
+		}
+	} else {
+		scanner := bufio.NewScanner(f)
+		for lineNum := 1; scanner.Scan(); lineNum++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			if len(fields) != 2 {
+				return fmt.Errorf("%s:%d: expected <package>\\t<url>, got %q", file, lineNum, line)
+			}
+			overrides[strings.TrimSpace(fields[0])] = strings.TrimSpace(fields[1])
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	for pkg, repoURL := range overrides {
+		repoOverrides[NormalizedPkgName(pkg)] = repoURL
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package cheerio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRepoOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { repoOverrides = make(map[string]string) }()
+
+	tsvFile, jsonFile := filepath.Join(dir, "overrides.tsv"), filepath.Join(dir, "overrides.json")
+	ioutil.WriteFile(tsvFile, []byte("# corrections\nFlask\thttps://github.com/pallets/flask\nmypkg\thttps://gitlab.com/me/mypkg\n"), 0644)
+	ioutil.WriteFile(jsonFile, []byte(`{"mypkg": "https://codeberg.org/me/mypkg"}`), 0644)
+
+	if err := LoadRepoOverrides(tsvFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadRepoOverrides(jsonFile); err != nil {
+		t.Fatal(err)
+	}
+
+	for pkg, want := range map[string]string{"flask": "https://github.com/pallets/flask", "mypkg": "https://codeberg.org/me/mypkg"} {
+		if repoURL, err := DefaultPyPI.FetchSourceRepoURL(pkg); err != nil {
+			t.Errorf("%s: FetchSourceRepoURL error: %s", pkg, err)
+		} else if repoURL != want {
+			t.Errorf("%s: want repoURL == %q, got %q", pkg, want, repoURL)
+		}
+	}
+
+	ioutil.WriteFile(tsvFile, []byte("missing-url\n"), 0644)
+	if err := LoadRepoOverrides(tsvFile); err == nil {
+		t.Errorf("Expected error loading malformed overrides file")
+	}
+}
@@ -0,0 +1,109 @@
+package cheerio
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// How confident we are that a resolved repository URL points to an existing repository
+type RepoConfidence string
+
+const (
+	RepoUnverified  RepoConfidence = "unverified"  // the URL was not checked
+	RepoVerified    RepoConfidence = "verified"    // the URL responded successfully
+	RepoUnreachable RepoConfidence = "unreachable" // the URL could not be reached, or the host reported that it does not exist
+)
+
+// A source repository resolved for a package
+type RepoResult struct {
+	Pkg        string
+	URL        string // canonical repository URL
+	Source     RepoSource
+	Score      float64 // between 0 and 1; how likely URL is to be the package's source repository
+	Confidence RepoConfidence
+}
+
+// Resolves the source repository of a package (see FetchSourceRepoURL) and canonicalizes its URL. The result is scored by where the URL was found.
+// If verify is true, the repository URL is checked with a HEAD request, and the score is raised or lowered accordingly.
+func (p *PackageIndex) ResolveSourceRepo(pkg string, verify bool) (*RepoResult, error) {
+	rawURL, source, err := p.detectSourceRepo(pkg)
+	if err != nil {
+		return nil, err
+	}
+	repoURL, err := CanonicalRepoURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RepoResult{
+		Pkg:        NormalizedPkgName(pkg),
+		URL:        repoURL,
+		Source:     source,
+		Score:      repoSourceScores[source],
+		Confidence: RepoUnverified,
+	}
+	if verify {
+		result.Confidence = verifyRepoURL(p.client(), repoURL)
+		if result.Confidence == RepoUnreachable {
+			result.Score /= 2
+		} else if result.Score < 1 {
+			result.Score += (1 - result.Score) / 2
+		}
+	}
+	return result, nil
+}
+
+var hostColonPathRegexp = regexp.MustCompile(`^([A-Za-z\+]+://[^/:]+):([^0-9/][^/]*/.*)$`)
+var scpLikeRepoRegexp = regexp.MustCompile(`^(?:[A-Za-z0-9_\-]+@)?([A-Za-z0-9\.\-]+\.[A-Za-z]+):([^/].*)$`)
+
+// The ports of repository URL schemes that CanonicalRepoURL drops
+var defaultRepoPorts = map[string]string{"http": "80", "https": "443", "ssh": "22", "git": "9418"}
+
+// Canonicalizes a repository URL so that different spellings of the same repository compare equal: "git://", "ssh://", "git+https://", and scp-like
+// ("git@github.com:user/repo") URLs are rewritten as "https://" URLs, "http" is upgraded to "https", and the host is lowercased with any "www."
+// prefix removed. Ports are kept unless they are the default of the URL's scheme. Trailing slashes and the ".git" suffix are stripped, as are
+// query strings and fragments.
+func CanonicalRepoURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if match := scpLikeRepoRegexp.FindStringSubmatch(rawURL); match != nil && !strings.Contains(rawURL, "://") {
+		rawURL = fmt.Sprintf("https://%s/%s", match[1], match[2])
+	}
+	rawURL = strings.TrimPrefix(rawURL, "git+")
+	rawURL = hostColonPathRegexp.ReplaceAllString(rawURL, "$1/$2") // e.g., "git://github.com:user/repo"
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("Not an absolute repository URL: %s", rawURL)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != defaultRepoPorts[strings.ToLower(u.Scheme)] {
+		host += ":" + port // e.g., a self-hosted server on another port is another repository
+	}
+	path := strings.TrimSuffix(strings.TrimRight(u.Path, "/"), ".git")
+
+	return fmt.Sprintf("https://%s%s", host, path), nil
+}
+
+// Issues a HEAD request to a repository URL to check that it still exists
+func VerifyRepoURL(repoURL string) RepoConfidence {
+	return verifyRepoURL(http.DefaultClient, repoURL)
+}
+
+func verifyRepoURL(client *http.Client, repoURL string) RepoConfidence {
+	resp, err := client.Head(repoURL)
+	if err != nil {
+		return RepoUnreachable
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+		return RepoVerified
+	}
+	return RepoUnreachable
+}
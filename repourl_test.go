@@ -0,0 +1,60 @@
+package cheerio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalRepoURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/mitsuhiko/flask", "https://github.com/mitsuhiko/flask"},
+		{"git://github.com/mitsuhiko/flask", "https://github.com/mitsuhiko/flask"},
+		{"http://www.GitHub.com/mitsuhiko/flask.git/", "https://github.com/mitsuhiko/flask"},
+		{"git+https://github.com/mitsuhiko/flask.git#egg=flask", "https://github.com/mitsuhiko/flask"},
+		{"git@github.com:mitsuhiko/flask.git", "https://github.com/mitsuhiko/flask"},
+		{"ssh://git@bitbucket.org/ned/coveragepy", "https://bitbucket.org/ned/coveragepy"},
+		{"git://github.com:gittip/postgres.py", "https://github.com/gittip/postgres.py"},
+		{"https://git.example.com:8443/org/repo", "https://git.example.com:8443/org/repo"},
+		{"https://git.example.com:443/org/repo", "https://git.example.com/org/repo"},
+		{"ssh://git@git.example.com:22/org/repo.git", "https://git.example.com/org/repo"},
+	}
+
+	for _, test := range tests {
+		got, err := CanonicalRepoURL(test.url)
+		if err != nil {
+			t.Errorf("%s: CanonicalRepoURL error: %s", test.url, err)
+		} else if got != test.want {
+			t.Errorf("%s: want %q, got %q", test.url, test.want, got)
+		}
+	}
+
+	if _, err := CanonicalRepoURL("not a url"); err == nil {
+		t.Errorf("Expected error canonicalizing relative URL")
+	}
+}
+
+func TestRepoIndex(t *testing.T) {
+	idx, err := ReadRepoIndex(strings.NewReader(`flask	git://github.com/mitsuhiko/flask	
+zope.interface	https://github.com/zopefoundation/zope.interface	
+azure-storage	https://github.com/Azure/azure-sdk-for-python	
+azure-keyvault	https://github.com/azure/azure-sdk-for-python.git	
+nothing		No homepage found in metadata for pkg nothing
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pkgs := idx.Packages("https://github.com/mitsuhiko/flask"); !reflect.DeepEqual(pkgs, []string{"flask"}) {
+		t.Errorf("Packages: expected [flask], got %v", pkgs)
+	}
+	if pkgs, exp := idx.Packages("github.com:azure/azure-sdk-for-python"), []string{"azure-keyvault", "azure-storage"}; !reflect.DeepEqual(pkgs, exp) {
+		t.Errorf("Packages: expected %v, got %v", exp, pkgs)
+	}
+	if repos, exp := idx.MultiPackageRepos(), []string{"https://github.com/azure/azure-sdk-for-python"}; !reflect.DeepEqual(repos, exp) {
+		t.Errorf("MultiPackageRepos: expected %v, got %v", exp, repos)
+	}
+}
@@ -0,0 +1,182 @@
+package cheerio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Version specifiers of a package, from several requirement sets, that no version can satisfy together
+type RequirementConflict struct {
+	Pkg        string
+	Specifiers map[string]string // requirement set name -> specifier, e.g., "requirements.txt" -> ">=2.0"
+}
+
+func (c *RequirementConflict) String() string {
+	var sets []string
+	for _, set := range sortedStringKeys(c.Specifiers) {
+		sets = append(sets, fmt.Sprintf("%s (%s)", set, c.Specifiers[set]))
+	}
+	return fmt.Sprintf("%s: %s", c.Pkg, strings.Join(sets, ", "))
+}
+
+// Merges named requirement sets (e.g., the requirements files of several projects) into one requirement per package whose version clauses are the
+// union of those in all sets (in set name order), sorted by package name. Also returns the packages whose combined clauses can't be satisfied by any version. This
+// check is done on the specifiers alone, without consulting the index, so it ignores whether a satisfying release actually exists.
+func MergeRequirementSets(sets map[string][]*Requirement) ([]*Requirement, []*RequirementConflict) {
+	merged := make(map[string]*Requirement)
+	specifiers := make(map[string]map[string][]string) // pkg -> set name -> clauses
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, req := range sets[name] {
+			pkg := NormalizedPkgName(req.Name)
+			if _, in := merged[pkg]; !in {
+				merged[pkg] = &Requirement{Name: pkg}
+				specifiers[pkg] = make(map[string][]string)
+			}
+			for _, clause := range req.Clauses() {
+				merged[pkg].addClause(clause)
+				specifiers[pkg][name] = append(specifiers[pkg][name], clause[0]+clause[1])
+			}
+		}
+	}
+
+	pkgs := make([]string, 0, len(merged))
+	for pkg := range merged {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	reqs := make([]*Requirement, 0, len(pkgs))
+	var conflicts []*RequirementConflict
+	for _, pkg := range pkgs {
+		reqs = append(reqs, merged[pkg])
+		if !clausesSatisfiable(merged[pkg].Clauses()) {
+			conflict := &RequirementConflict{Pkg: pkg, Specifiers: make(map[string]string)}
+			for name, clauses := range specifiers[pkg] {
+				conflict.Specifiers[name] = strings.Join(clauses, ",")
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return reqs, conflicts
+}
+
+// Adds a version clause to the requirement unless it already has it
+func (r *Requirement) addClause(clause [2]string) {
+	for _, existing := range r.Clauses() {
+		if existing == clause {
+			return
+		}
+	}
+	if r.Constraint == "" {
+		r.Constraint, r.Version = clause[0], clause[1]
+	} else {
+		r.More = append(r.More, clause[0]+clause[1])
+	}
+}
+
+// A lower or upper bound on versions
+type versionBound struct {
+	v         *pep440Version
+	inclusive bool
+}
+
+// Returns whether some version could satisfy all of the given (operator, version) clauses. Clauses on versions that don't follow PEP 440 are
+// assumed to be satisfiable.
+func clausesSatisfiable(clauses [][2]string) bool {
+	var lower, upper *versionBound
+	var exact []string
+	for _, clause := range clauses {
+		op, spec := clause[0], clause[1]
+		if op == "===" || op == "!=" {
+			if op == "===" {
+				exact = append(exact, spec)
+			}
+			continue
+		}
+		prefix := strings.TrimSuffix(spec, ".*")
+		v, err := parseVersion(prefix)
+		if err != nil {
+			continue
+		}
+		switch {
+		case op == "==" && prefix != spec:
+			lower = tighterLower(lower, &pep440Version{epoch: v.epoch, release: v.release, post: -1, dev: 0}, true)
+			upper = tighterUpper(upper, bumpRelease(v, len(v.release)), false)
+		case op == "==":
+			exact = append(exact, spec)
+			lower = tighterLower(lower, v, true)
+			upper = tighterUpper(upper, v, true)
+		case op == ">=":
+			lower = tighterLower(lower, v, true)
+		case op == ">":
+			lower = tighterLower(lower, v, false)
+		case op == "<=":
+			upper = tighterUpper(upper, v, true)
+		case op == "<":
+			upper = tighterUpper(upper, v, false)
+		case op == "~=" && len(v.release) >= 2:
+			lower = tighterLower(lower, v, true)
+			upper = tighterUpper(upper, bumpRelease(v, len(v.release)-1), false)
+		}
+	}
+
+	if lower != nil && upper != nil {
+		c := lower.v.compare(upper.v, false)
+		if c > 0 || c == 0 && !(lower.inclusive && upper.inclusive) {
+			return false
+		}
+	}
+
+	// Exact versions must satisfy every clause, including exclusions
+	for _, ver := range exact {
+		for _, clause := range clauses {
+			if match, err := versionMatches(ver, clause[0], clause[1]); err == nil && !match {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returns the higher of a lower bound and the bound at v, or the more exclusive one if they are at the same version
+func tighterLower(cur *versionBound, v *pep440Version, inclusive bool) *versionBound {
+	if cur == nil {
+		return &versionBound{v, inclusive}
+	}
+	switch c := v.compare(cur.v, false); {
+	case c > 0:
+		return &versionBound{v, inclusive}
+	case c == 0:
+		return &versionBound{v, cur.inclusive && inclusive}
+	}
+	return cur
+}
+
+// Returns the lower of an upper bound and the bound at v, or the more exclusive one if they are at the same version
+func tighterUpper(cur *versionBound, v *pep440Version, inclusive bool) *versionBound {
+	if cur == nil {
+		return &versionBound{v, inclusive}
+	}
+	switch c := v.compare(cur.v, false); {
+	case c < 0:
+		return &versionBound{v, inclusive}
+	case c == 0:
+		return &versionBound{v, cur.inclusive && inclusive}
+	}
+	return cur
+}
+
+// Returns the first release (a ".dev0" release, which sorts before all others) after all versions starting with the first n release components of v,
+// e.g., 1.5.dev0 for v 1.4.2 and n 2
+func bumpRelease(v *pep440Version, n int) *pep440Version {
+	release := v.releaseN(n)
+	release[n-1]++
+	return &pep440Version{epoch: v.epoch, release: release, post: -1, dev: 0}
+}
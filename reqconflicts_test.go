@@ -0,0 +1,67 @@
+package cheerio
+
+import (
+	"testing"
+)
+
+func TestMergeRequirementSets(t *testing.T) {
+	sets := map[string][]*Requirement{
+		"api/requirements.txt": mustParseRequirements(t, "flask>=2.0\nrequests~=2.28\nsix==1.16.0\nclick"),
+		"web/requirements.txt": mustParseRequirements(t, "Flask<2.0\nrequests>=2.31,<3\nsix!=1.16.0\nclick>=8"),
+	}
+	merged, conflicts := MergeRequirementSets(sets)
+
+	var specs []string
+	for _, req := range merged {
+		specs = append(specs, req.Name+req.Specifier())
+	}
+	exp := []string{"click>=8", "flask>=2.0,<2.0", "requests~=2.28,>=2.31,<3", "six==1.16.0,!=1.16.0"}
+	if len(specs) != len(exp) {
+		t.Fatalf("MergeRequirementSets: expected %v, got %v", exp, specs)
+	}
+	for i := range exp {
+		if specs[i] != exp[i] {
+			t.Errorf("MergeRequirementSets: expected %v, got %v", exp, specs)
+			break
+		}
+	}
+
+	if len(conflicts) != 2 {
+		t.Fatalf("MergeRequirementSets: expected 2 conflicts, got %v", conflicts)
+	}
+	if s := conflicts[0].String(); s != "flask: api/requirements.txt (>=2.0), web/requirements.txt (<2.0)" {
+		t.Errorf("MergeRequirementSets: unexpected conflict %s", s)
+	}
+	if conflicts[1].Pkg != "six" {
+		t.Errorf("MergeRequirementSets: expected conflict on six, got %s", conflicts[1])
+	}
+}
+
+func TestClausesSatisfiable(t *testing.T) {
+	tests := []struct {
+		spec string
+		exp  bool
+	}{
+		{">=1.0,<2.0", true},
+		{">=2.0,<2.0", false},
+		{">=2.0,<=2.0", true},
+		{">2.0,<=2.0", false},
+		{"~=1.4.2,>=1.5", false},
+		{"~=1.4,>=1.5", true},
+		{"==1.4.*,>=1.5", false},
+		{"==1.4.*,<1.4.1", true},
+		{"==1.4.2,<1.4.1", false},
+		{"==1.0,==1.0.0", true},
+		{"==1.0,==1.1", false},
+		{"!=1.0,<2", true},
+	}
+	for _, test := range tests {
+		req, err := ParseRequirement("pkg" + test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok := clausesSatisfiable(req.Clauses()); ok != test.exp {
+			t.Errorf("clausesSatisfiable(%s): expected %v, got %v", test.spec, test.exp, ok)
+		}
+	}
+}
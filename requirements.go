@@ -3,54 +3,214 @@ package cheerio
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/beyang/cheerio/metadata"
 )
 
 type Requirement struct {
 	Name       string
 	Constraint string
 	Version    string
+	More       []string `json:",omitempty"` // further version clauses, e.g., ["<2.0", "!=1.5"] for "flask>=1.0,<2.0,!=1.5"
+	Extras     []string `json:",omitempty"` // extras of the required package that are also required, e.g., ["socks"] for "requests[socks]"
+	Extra      string   `json:",omitempty"` // the extra (optional feature) that pulls in this requirement, from requires.txt section headers
+	Marker     string   `json:",omitempty"` // PEP 508 environment marker, e.g., "python_version < '3'" (see AppliesTo)
+
+	// Version range in the native syntax of a non-PyPI ecosystem, e.g., "^1.2.0" for npm, which isn't interpreted (see Index). Constraint and
+	// Version are left empty.
+	Range string `json:",omitempty"`
+
+	// Fields below are only set when parsing pip requirements files (see ParseRequirementsFile)
+	URL      string   `json:",omitempty"` // direct reference or editable install location
+	Editable bool     `json:",omitempty"`
+	Hashes   []string `json:",omitempty"` // "--hash" values, e.g., "sha256:..."
 }
 
-// Parse requirements from a raw string in the requirements format expected by pip (e.g., in requirements.txt)
+// How unparseable lines of requirements are handled
+type ParseMode string
+
+const (
+	ParseLenient ParseMode = "lenient" // skip them, keeping just the package name of those that start with one, and report them as warnings
+	ParseStrict  ParseMode = "strict"  // fail with a *ParseError for the first of them
+)
+
+// Parse requirements from a raw string in the requirements format expected by pip (e.g., in requirements.txt). Requirements listed under a section
+// header, as in the requires.txt files in sdists, have their Extra and Marker set from the header: "[test]" sets the (normalized) Extra "test",
+// and `[:python_version < "3"]` the Marker; a requirement's own marker, e.g., `colorama; os_name == "nt"`, is combined with that of its section.
+// Unparseable lines are handled leniently and logged to DefaultLogger.
 func ParseRequirements(rawReqs string) ([]*Requirement, error) {
-	rawReqs = strings.TrimSpace(rawReqs)
+	return parseRequirements(rawReqs, ParseLenient, DefaultLogger)
+}
 
-	reqStrs := strings.Split(rawReqs, "\n")
+// Like ParseRequirements, but handles unparseable lines as mode says (lenient if empty) and returns a *ParseError for each of them as a warning
+// instead of logging it. In strict mode, the first of them is returned as the error.
+func ParseRequirementsMode(rawReqs string, mode ParseMode) ([]*Requirement, []*ParseError, error) {
 	reqs := make([]*Requirement, 0)
-	for _, reqStr := range reqStrs {
-		if reqStr == "" {
-			continue
-		}
-
-		if req, err := ParseRequirement(reqStr); err == nil {
+	var warnings []*ParseError
+	for _, line := range metadata.ParseRequiresTxt(rawReqs) {
+		extra := NormalizedPkgName(line.Extra)
+		if req, err := ParseRequirementLine(line.Requirement); err == nil {
+			if extra != "" {
+				req.Extra = extra
+			}
+			req.Marker = joinMarkers(line.Marker, req.Marker)
 			reqs = append(reqs, req)
-		} else if reqHeaderRegexp.MatchString(reqStr) {
-			// do nothing
 		} else {
-			os.Stderr.WriteString(fmt.Sprintf("[req] Could not parse requirement: %s\n", err))
+			parseErr := err.(*ParseError)
+			parseErr.LineNo = line.LineNo
+			if mode == ParseStrict {
+				return nil, nil, parseErr
+			}
+			warnings = append(warnings, parseErr)
+			if match := reqNamePrefixRegexp.FindStringSubmatch(line.Requirement); match != nil {
+				reqs = append(reqs, &Requirement{Name: match[1], Extra: extra, Marker: line.Marker})
+			}
 		}
 	}
-	return reqs, nil
+	return reqs, warnings, nil
+}
+
+// Returns a marker that holds where both markers hold, e.g., that of a requires.txt section and that of a requirement in it
+func joinMarkers(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return "(" + a + ") and (" + b + ")"
 }
 
-// Parse a single raw requirement, e.g., from "flask=1.0.1"
+// Matches the package name at the start of a malformed requirement, e.g., "flask" in "flask >= 1.0 beta", which lenient parsing keeps
+var reqNamePrefixRegexp = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9\._\-]*)(?:\s|[<>=!~\[(;]|$)`)
+
+// Like ParseRequirementsMode, but reports warnings to logger
+func parseRequirements(rawReqs string, mode ParseMode, logger Logger) ([]*Requirement, error) {
+	reqs, warnings, err := ParseRequirementsMode(rawReqs, mode)
+	for _, warning := range warnings {
+		logger.Logf("req", "Could not parse requirement: %s", warning)
+	}
+	return reqs, err
+}
+
+// Returns the parse mode named s, e.g., from a command-line flag: "lenient" or "strict"
+func ParseParseMode(s string) (ParseMode, error) {
+	switch mode := ParseMode(s); mode {
+	case ParseLenient, ParseStrict:
+		return mode, nil
+	}
+	return "", fmt.Errorf("Unknown parse mode '%s' (expected lenient or strict)", s)
+}
+
+// Parse a single raw requirement, e.g., from "flask=1.0.1". Returns a *ParseError if reqStr isn't a requirement.
 func ParseRequirement(reqStr string) (*Requirement, error) {
 	reqStr = strings.TrimSpace(reqStr)
 	match := requirementRegexp.FindStringSubmatch(reqStr)
-	if len(match) != 5 {
-		return nil, fmt.Errorf("Expected match of length 5, but got %+v from '%s'", match, reqStr)
+	if len(match) != 6 {
+		return nil, &ParseError{Line: reqStr, Msg: fmt.Sprintf("Expected match of length 6, but got %+v", match)}
 	} else if match[0] != reqStr {
-		return nil, fmt.Errorf("Unable to parse requirement from string: '%s'", reqStr)
+		return nil, &ParseError{Line: reqStr, Msg: "Unable to parse requirement from string"}
 	}
-	return &Requirement{
+	req := &Requirement{
 		Name:       match[1],
+		Extras:     splitExtras(match[2]),
 		Constraint: match[3],
 		Version:    match[4],
-	}, nil
+	}
+	for _, clause := range strings.Split(match[5], ",") {
+		if clause = strings.Replace(clause, " ", "", -1); clause != "" {
+			req.More = append(req.More, clause)
+		}
+	}
+	return req, nil
+}
+
+// Splits a comma-separated list of extras, e.g., "security, socks" from "requests[security, socks]"
+func splitExtras(list string) []string {
+	var extras []string
+	for _, extra := range strings.Split(list, ",") {
+		if extra = strings.TrimSpace(extra); extra != "" {
+			extras = append(extras, extra)
+		}
+	}
+	return extras
+}
+
+// Formats the requirement as a line of a pip requirements file, e.g., `requests[socks]>=2.0,<3; python_version >= "3.8"`, which is PEP 508
+// but for editable installs ("-e <url>") and hashes ("--hash=..."). Parsing the line with ParseRequirementLine yields the requirement again,
+// unless it only has a native Range (see Index), which is written in place of a specifier.
+func (r *Requirement) String() string {
+	if r.Editable {
+		return "-e " + r.URL
+	}
+	var b strings.Builder
+	b.WriteString(r.Name)
+	if len(r.Extras) > 0 {
+		b.WriteString("[" + strings.Join(r.Extras, ",") + "]")
+	}
+	if r.URL != "" {
+		b.WriteString(" @ " + r.URL + " ") // a space keeps the marker's ";" from being read as part of the URL
+	} else if r.Constraint != "" {
+		b.WriteString(strings.Join(append([]string{r.Constraint + r.Version}, r.More...), ","))
+	} else {
+		b.WriteString(r.Range)
+	}
+	marker := r.Marker
+	if r.Extra != "" {
+		extraClause := `extra == "` + r.Extra + `"`
+		if marker == "" {
+			marker = extraClause
+		} else if markerAndClauses(marker) == nil { // joined by "or", which binds less tightly than "and"
+			marker = "(" + marker + ") and " + extraClause
+		} else {
+			marker += " and " + extraClause
+		}
+	}
+	if marker != "" {
+		b.WriteString("; " + marker)
+	}
+	for _, hash := range r.Hashes {
+		b.WriteString(" --hash=" + hash)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var versionClauseRegexp = regexp.MustCompile(`^(===|==|!=|~=|>=|<=|>|<)\s*(\S+)$`)
+
+// Returns the version clauses of the requirement as (operator, version) pairs, e.g., [[">=", "1.0"], ["<", "2.0"]] for "flask>=1.0,<2.0"
+func (r *Requirement) Clauses() [][2]string {
+	var clauses [][2]string
+	if r.Constraint != "" {
+		clauses = append(clauses, [2]string{r.Constraint, r.Version})
+	}
+	for _, clause := range r.More {
+		if match := versionClauseRegexp.FindStringSubmatch(strings.TrimSpace(clause)); match != nil {
+			clauses = append(clauses, [2]string{match[1], match[2]})
+		}
+	}
+	return clauses
+}
+
+// Returns whether version ver satisfies all of the requirement's version clauses, as defined by PEP 440
+func (r *Requirement) Matches(ver string) (bool, error) {
+	for _, clause := range r.Clauses() {
+		if match, err := versionMatches(ver, clause[0], clause[1]); err != nil || !match {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Returns the version specifier of the requirement, e.g., ">=1.0,<2.0", or its native Range
+func (r *Requirement) Specifier() string {
+	if r.Range != "" && r.Constraint == "" {
+		return r.Range
+	}
+	var clauses []string
+	for _, clause := range r.Clauses() {
+		clauses = append(clauses, clause[0]+clause[1])
+	}
+	return strings.Join(clauses, ",")
 }
 
 // Return requirements for python PyPI package in directory
@@ -68,15 +228,38 @@ func RequirementsForDir(dir string) ([]*Requirement, error) {
 	// If repo contains requirements.txt, parse requirements from that (these should be more specific than those contained in a PyPIGraph, because
 	// they will often include version info).
 	reqFile := filepath.Join(dir, "requirements.txt")
-	if reqFileContents, err := ioutil.ReadFile(reqFile); err == nil {
-		if rawReqs, err := ParseRequirements(string(reqFileContents)); err == nil {
-			// Note: this currently doesn't handle pip+git-URL requirements
+	if rawReqs, err := ParseRequirementsFile(reqFile); err == nil {
+		for _, rawReq := range rawReqs {
+			reqs[NormalizedPkgName(rawReq.Name)] = rawReq
+		}
+	}
+
+	// If repo uses pipenv, parse requirements from the lock file if there is one, else from the Pipfile
+	if lockContents, err := ioutil.ReadFile(filepath.Join(dir, "Pipfile.lock")); err == nil {
+		if rawReqs, _, err := ParsePipfileLock(string(lockContents)); err == nil {
+			for _, rawReq := range rawReqs {
+				reqs[NormalizedPkgName(rawReq.Name)] = rawReq
+			}
+		}
+	} else if pipfileContents, err := ioutil.ReadFile(filepath.Join(dir, "Pipfile")); err == nil {
+		if rawReqs, _, err := ParsePipfile(string(pipfileContents)); err == nil {
 			for _, rawReq := range rawReqs {
 				reqs[NormalizedPkgName(rawReq.Name)] = rawReq
 			}
 		}
 	}
 
+	// If repo has a pyproject.toml, parse requirements from that
+	if pyprojectContents, err := ioutil.ReadFile(filepath.Join(dir, "pyproject.toml")); err == nil {
+		if rawReqs, _, err := ParsePyProject(string(pyprojectContents)); err == nil {
+			for _, rawReq := range rawReqs {
+				if _, in := reqs[NormalizedPkgName(rawReq.Name)]; !in {
+					reqs[NormalizedPkgName(rawReq.Name)] = rawReq
+				}
+			}
+		}
+	}
+
 	// if len(requirements) == 0 {
 	// 	// TODO: use depdump.py to best-effort get requirements
 	// }
@@ -2,6 +2,9 @@ package cheerio
 
 import (
 	"github.com/kr/pretty"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -42,31 +45,39 @@ func TestParseRequirements(t *testing.T) {
 			Name:       "dep7",
 			Constraint: "==",
 			Version:    "10",
+			Extra:      "this-is-a-heading",
 		},
 		{
 			Name:       "dep8.subdep",
 			Constraint: "==",
 			Version:    "1.2.3",
+			Extra:      "this-is-a-heading",
 		},
 		{
 			Name:       "dep9",
 			Constraint: ">",
 			Version:    "1",
+			Extra:      "this-is-a-heading",
 		},
 		{
 			Name:       "dep9",
 			Constraint: ">",
 			Version:    "1",
+			Extra:      "this-is-a-heading",
 		},
 		{
 			Name:       "dep10",
+			Extras:     []string{"extradep"},
 			Constraint: "==",
 			Version:    "1",
+			Extra:      "this-is-a-heading",
 		},
 		{
 			Name:       "dep10",
+			Extras:     []string{"extradep"},
 			Constraint: "",
 			Version:    "",
+			Extra:      "this-is-a-heading",
 		},
 	}
 	reqs, err := ParseRequirements(`dep1==2.3.2
@@ -92,3 +103,129 @@ dep10[extradep]
 		t.Errorf("Requirements do not match: %v", pretty.Diff(reqs, expReqs))
 	}
 }
+
+func TestParseRequirementsMode(t *testing.T) {
+	rawReqs := "flask>=1.0\n[test]\npytest >= 3.0 beta\n?? not a requirement\n"
+
+	reqs, warnings, err := ParseRequirementsMode(rawReqs, ParseLenient)
+	expReqs := []*Requirement{{Name: "flask", Constraint: ">=", Version: "1.0"}, {Name: "pytest", Extra: "test"}}
+	if err != nil || !reflect.DeepEqual(reqs, expReqs) {
+		t.Errorf("Requirements do not match: %v, %v", pretty.Diff(reqs, expReqs), err)
+	}
+	if len(warnings) != 2 || warnings[0].LineNo != 3 || warnings[1].LineNo != 4 {
+		t.Errorf("expected warnings for lines 3 and 4, got %v", warnings)
+	}
+
+	if _, _, err := ParseRequirementsMode(rawReqs, ParseStrict); err == nil || err.(*ParseError).LineNo != 3 {
+		t.Errorf("expected a parse error for line 3, got %v", err)
+	}
+}
+
+func TestParseRequirementsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"requirements.txt": `# application requirements
+--index-url https://pypi.python.org/simple
+-r base.txt
+-c constraints.txt
+flask>=0.10 # web framework
+pywin32==219 ; sys_platform == "win32"
+requests==2.3.0 \
+    --hash=sha256:aaaa \
+    --hash=sha256:bbbb
+-e git+https://github.com/mitsuhiko/werkzeug.git#egg=Werkzeug
+celery @ https://github.com/celery/celery/archive/master.zip
+`,
+		"base.txt": `httplib2==0.8
+`,
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expReqs := []*Requirement{
+		{Name: "httplib2", Constraint: "==", Version: "0.8"},
+		{Name: "flask", Constraint: ">=", Version: "0.10"},
+		{Name: "pywin32", Constraint: "==", Version: "219", Marker: `sys_platform == "win32"`},
+		{Name: "requests", Constraint: "==", Version: "2.3.0", Hashes: []string{"sha256:aaaa", "sha256:bbbb"}},
+		{Name: "Werkzeug", URL: "git+https://github.com/mitsuhiko/werkzeug.git#egg=Werkzeug", Editable: true},
+		{Name: "celery", URL: "https://github.com/celery/celery/archive/master.zip"},
+	}
+	reqs, err := ParseRequirementsFile(filepath.Join(dir, "requirements.txt"))
+	if err != nil {
+		t.Errorf("Error parsing requirements file: %s", err)
+	} else if !reflect.DeepEqual(reqs, expReqs) {
+		t.Errorf("Requirements do not match: %v", pretty.Diff(reqs, expReqs))
+	}
+
+	// a file included twice is no cycle, but a file including itself is
+	files = map[string]string{
+		"diamond.txt": "-r a.txt\n-r b.txt\n",
+		"a.txt":       "-r common.txt\nflask\n",
+		"b.txt":       "-r common.txt\n",
+		"common.txt":  "six\n",
+		"cycle.txt":   "six\n-r cycle.txt\n",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reqs, err := ParseRequirementsFile(filepath.Join(dir, "diamond.txt")); err != nil {
+		t.Errorf("Error parsing requirements file with diamond includes: %s", err)
+	} else if len(reqs) != 3 {
+		t.Errorf("expected 3 requirements from diamond includes, got %v", reqs)
+	}
+	if _, err := ParseRequirementsFile(filepath.Join(dir, "cycle.txt")); err == nil {
+		t.Errorf("expected an error for a circular include")
+	}
+}
+
+func TestRequirementString(t *testing.T) {
+	for _, test := range []struct{ line, formatted string }{
+		{"flask", "flask"},
+		{"flask >= 1.0, <2.0,!=1.5", "flask>=1.0,<2.0,!=1.5"},
+		{"requests[security, socks]==2.31.0", "requests[security,socks]==2.31.0"},
+		{`pywin32==219 ; sys_platform == "win32"`, `pywin32==219; sys_platform == "win32"`},
+		{`pytest; extra == "Test" and python_version >= "3.8"`, `pytest; python_version >= "3.8" and extra == "test"`},
+		{`colorama; (os_name == "nt" or sys_platform == "cygwin") and extra == "cli"`, `colorama; (os_name == "nt" or sys_platform == "cygwin") and extra == "cli"`},
+		{"celery[redis] @ https://example.com/celery.zip", "celery[redis] @ https://example.com/celery.zip"},
+		{`celery @ https://example.com/celery.zip ; os_name == "posix"`, `celery @ https://example.com/celery.zip ; os_name == "posix"`},
+		{"requests==2.3.0 --hash=sha256:aaaa --hash=sha256:bbbb", "requests==2.3.0 --hash=sha256:aaaa --hash=sha256:bbbb"},
+		{"-e git+https://github.com/mitsuhiko/werkzeug.git#egg=Werkzeug", "-e git+https://github.com/mitsuhiko/werkzeug.git#egg=Werkzeug"},
+	} {
+		req, err := ParseRequirementLine(test.line)
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+		if formatted := req.String(); formatted != test.formatted {
+			t.Errorf("%q: expected %q, got %q", test.line, test.formatted, formatted)
+		}
+		if reparsed, err := ParseRequirementLine(req.String()); err != nil || !reflect.DeepEqual(reparsed, req) {
+			t.Errorf("%q: round trip changed the requirement: %v, %v", test.line, pretty.Diff(reparsed, req), err)
+		}
+	}
+}
+
+func TestRequirementClauses(t *testing.T) {
+	req, err := ParseRequirement("flask >=1.0, <2.0,!=1.5.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec := req.Specifier(); spec != ">=1.0,<2.0,!=1.5.*" {
+		t.Errorf("Specifier: expected >=1.0,<2.0,!=1.5.*, got %s", spec)
+	}
+	for ver, exp := range map[string]bool{"1.0": true, "1.4.9": true, "1.5.2": false, "2.0": false, "0.9": false} {
+		if match, err := req.Matches(ver); err != nil || match != exp {
+			t.Errorf("Matches(%q): expected %v, got %v (error %v)", ver, exp, match, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package cheerio
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// A pip requirements file being edited, e.g., by a dependency update bot. Edits rewrite only the lines of the requirements they change, so
+// comments, blank lines, options such as "-r" includes (which aren't followed) and the order of requirements are preserved.
+type RequirementsFile struct {
+	lines []string
+}
+
+// Returns the requirements file with the given contents
+func NewRequirementsFile(contents string) *RequirementsFile {
+	return &RequirementsFile{lines: strings.Split(contents, "\n")}
+}
+
+// Reads a requirements file for editing
+func LoadRequirementsFile(file string) (*RequirementsFile, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewRequirementsFile(string(contents)), nil
+}
+
+// Returns the edited contents of the file
+func (f *RequirementsFile) String() string {
+	return strings.Join(f.lines, "\n")
+}
+
+// Writes the edited contents to file, keeping its permissions if it exists
+func (f *RequirementsFile) WriteFile(file string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return ioutil.WriteFile(file, []byte(f.String()), mode)
+}
+
+// Pins the requirements of pkg to version, e.g., "flask>=1.0,<2.0" becomes "flask==1.1.4", keeping their extras and markers. Their hashes are
+// dropped, as they are those of other files. Direct URL references are left as they are. Returns whether a requirement changed.
+func (f *RequirementsFile) Pin(pkg, version string) bool {
+	return f.edit(pkg, func(req *Requirement) *Requirement {
+		if req.URL != "" {
+			return req
+		}
+		pinned := *req
+		pinned.Constraint, pinned.Version, pinned.More, pinned.Hashes = "==", version, nil, nil
+		return &pinned
+	})
+}
+
+// Raises the requirements of pkg to version, keeping their operators: the version of their first "==", "===", "~=" or ">=" clause is set to
+// version, and further clauses that version doesn't satisfy are dropped, e.g., "flask>=1.0,<2.0" becomes "flask>=2.1" when bumped to 2.1.
+// Requirements without such a clause, or whose clause is already at version or higher, are left as they are. Returns whether a requirement
+// changed.
+func (f *RequirementsFile) Bump(pkg, version string) bool {
+	return f.edit(pkg, func(req *Requirement) *Requirement {
+		clauses := req.Clauses()
+		bumped := -1
+		for i, clause := range clauses {
+			if clause[0] == "==" || clause[0] == "===" || clause[0] == "~=" || clause[0] == ">=" {
+				bumped = i
+				break
+			}
+		}
+		if bumped < 0 || CompareVersions(clauses[bumped][1], version) >= 0 {
+			return req
+		}
+
+		bumpedReq := *req
+		bumpedReq.Constraint, bumpedReq.Version, bumpedReq.More, bumpedReq.Hashes = "", "", nil, nil
+		for i, clause := range clauses {
+			if i == bumped {
+				clause[1] = version
+			} else if match, err := versionMatches(version, clause[0], clause[1]); err != nil || !match {
+				continue
+			}
+			if bumpedReq.Constraint == "" {
+				bumpedReq.Constraint, bumpedReq.Version = clause[0], clause[1]
+			} else {
+				bumpedReq.More = append(bumpedReq.More, clause[0]+clause[1])
+			}
+		}
+		return &bumpedReq
+	})
+}
+
+// Removes the requirements of pkg, along with their comments. Returns whether there were any.
+func (f *RequirementsFile) Remove(pkg string) bool {
+	return f.edit(pkg, func(req *Requirement) *Requirement { return nil })
+}
+
+// Replaces each requirement of pkg with the one edit returns for it, or removes it if edit returns nil. Lines of requirements that edit leaves
+// unchanged are kept as they are. Returns whether a requirement changed.
+func (f *RequirementsFile) edit(pkg string, edit func(req *Requirement) *Requirement) bool {
+	changed := false
+	lines := make([]string, 0, len(f.lines))
+	for start := 0; start < len(f.lines); {
+		// a requirement may span several lines joined by continuations
+		end, logical, comment := start, "", ""
+		for ; end < len(f.lines); end++ {
+			code, lineComment := splitRequirementsComment(strings.TrimRight(f.lines[end], "\r"))
+			if lineComment != "" {
+				comment = lineComment
+			}
+			if !strings.HasSuffix(code, "\\") || end == len(f.lines)-1 {
+				logical += strings.TrimSuffix(code, "\\")
+				break
+			}
+			logical += strings.TrimSuffix(code, "\\") + " "
+		}
+		next := end + 1
+
+		logical = strings.TrimSpace(logical)
+		req, err := ParseRequirementLine(logical)
+		if logical == "" || (strings.HasPrefix(logical, "-") && !editableRegexp.MatchString(logical)) || err != nil ||
+			NormalizedPkgName(req.Name) != NormalizedPkgName(pkg) {
+			lines = append(lines, f.lines[start:next]...)
+			start = next
+			continue
+		}
+
+		edited := edit(req)
+		if edited == nil {
+			changed = true
+		} else if edited.String() == req.String() {
+			lines = append(lines, f.lines[start:next]...)
+		} else {
+			line := edited.String() + comment
+			if strings.HasSuffix(f.lines[end], "\r") {
+				line += "\r"
+			}
+			lines = append(lines, line)
+			changed = true
+		}
+		start = next
+	}
+	f.lines = lines
+	return changed
+}
@@ -0,0 +1,39 @@
+package cheerio
+
+import (
+	"testing"
+)
+
+func TestRequirementsFile(t *testing.T) {
+	f := NewRequirementsFile(`# application requirements
+--index-url https://pypi.python.org/simple
+-r base.txt
+Flask>=1.0,<2.0 # web framework
+requests==2.3.0 \
+    --hash=sha256:aaaa
+pywin32 ; sys_platform == "win32"
+celery @ https://github.com/celery/celery/archive/master.zip
+`)
+
+	if !f.Bump("flask", "2.1") || f.Bump("flask", "1.5") || !f.Pin("requests", "2.31.0") || !f.Remove("pywin32") || f.Remove("django") {
+		t.Errorf("unexpected results of edits")
+	}
+	if f.Pin("celery", "5.0") {
+		t.Errorf("expected direct references not to be pinned")
+	}
+	exp := `# application requirements
+--index-url https://pypi.python.org/simple
+-r base.txt
+Flask>=2.1 # web framework
+requests==2.31.0
+celery @ https://github.com/celery/celery/archive/master.zip
+`
+	if f.String() != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, f.String())
+	}
+
+	f = NewRequirementsFile("django~=3.2.0\r\nsix\r\n")
+	if !f.Bump("django", "4.2.1") || f.Bump("six", "1.16") || f.String() != "django~=4.2.1\r\nsix\r\n" {
+		t.Errorf("unexpected contents %q", f.String())
+	}
+}
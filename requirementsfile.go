@@ -0,0 +1,146 @@
+package cheerio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Parse a pip requirements file (e.g., requirements.txt), following "-r" includes relative to the including file. Handles comments, line
+// continuations, editable installs ("-e"), direct URL references, "--hash" options, and environment markers. Global options such as "--index-url"
+// and constraint files ("-c") are ignored.
+func ParseRequirementsFile(file string) ([]*Requirement, error) {
+	return parseRequirementsFile(file, make(map[string]bool))
+}
+
+// including holds the files being parsed, from the top-level file down to file's includer: a file included by several others (e.g., a
+// common.txt included by both base.txt and dev.txt) is parsed for each of them, but one that includes itself is an error.
+func parseRequirementsFile(file string, including map[string]bool) ([]*Requirement, error) {
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	if including[absFile] {
+		return nil, fmt.Errorf("Circular requirements file include: %s", file)
+	}
+	including[absFile] = true
+	defer delete(including, absFile)
+
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := make([]*Requirement, 0)
+	for _, line := range requirementsFileLines(string(contents)) {
+		if include := requirementsIncludeRegexp.FindStringSubmatch(line); include != nil {
+			includeFile := include[1]
+			if !filepath.IsAbs(includeFile) {
+				includeFile = filepath.Join(filepath.Dir(file), includeFile)
+			}
+			includeReqs, err := parseRequirementsFile(includeFile, including)
+			if err != nil {
+				return nil, err
+			}
+			reqs = append(reqs, includeReqs...)
+			continue
+		} else if strings.HasPrefix(line, "-") && !editableRegexp.MatchString(line) {
+			continue // global option or constraints file
+		}
+
+		if req, err := ParseRequirementLine(line); err == nil {
+			reqs = append(reqs, req)
+		} else {
+			DefaultLogger.Logf("req", "Could not parse requirement in %s: %s", file, err)
+		}
+	}
+	return reqs, nil
+}
+
+var requirementsIncludeRegexp = regexp.MustCompile(`^(?:-r|--requirement)(?:\s+|=)(\S+)$`)
+var editableRegexp = regexp.MustCompile(`^(?:-e|--editable)(?:\s+|=)(\S+)$`)
+var hashOptionRegexp = regexp.MustCompile(`\s+--hash[=\s](\S+)`)
+var eggFragmentRegexp = regexp.MustCompile(`#(?:.*&)?egg=([A-Za-z0-9\._\-]+)`)
+var directURLRegexp = regexp.MustCompile(`^([A-Za-z0-9\._\-]+)\s*(?:\[([A-Za-z0-9\._\-,\s]+)\])?\s*@\s*(\S+)$`)
+
+// Parse a single line of a pip requirements file (after comments and line continuations have been removed), e.g.,
+// "flask>=1.0 ; python_version >= '3.6' --hash=sha256:abcd" or "-e git+https://github.com/mitsuhiko/flask#egg=flask". An "extra == ..." clause
+// of the marker sets the requirement's Extra, and is removed from its Marker, as in Requires-Dist metadata fields.
+func ParseRequirementLine(line string) (*Requirement, error) {
+	line = strings.TrimSpace(line)
+
+	if editable := editableRegexp.FindStringSubmatch(line); editable != nil {
+		url := editable[1]
+		match := eggFragmentRegexp.FindStringSubmatch(url)
+		if match == nil {
+			return nil, &ParseError{Line: line, Msg: "Editable requirement has no #egg= name"}
+		}
+		return &Requirement{Name: match[1], URL: url, Editable: true}, nil
+	}
+
+	var hashes []string
+	for _, match := range hashOptionRegexp.FindAllStringSubmatch(line, -1) {
+		hashes = append(hashes, match[1])
+	}
+	line = strings.TrimSpace(hashOptionRegexp.ReplaceAllString(line, ""))
+
+	var marker string
+	if i := strings.Index(line, ";"); i >= 0 {
+		line, marker = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+	}
+
+	var req *Requirement
+	if match := directURLRegexp.FindStringSubmatch(line); match != nil {
+		req = &Requirement{Name: match[1], Extras: splitExtras(match[2]), URL: match[3]}
+	} else if strings.Contains(line, "://") {
+		match := eggFragmentRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return nil, &ParseError{Line: line, Msg: "URL requirement has no #egg= name"}
+		}
+		req = &Requirement{Name: match[1], URL: line}
+	} else {
+		var err error
+		if req, err = ParseRequirement(line); err != nil {
+			return nil, err
+		}
+	}
+	req.Extra, req.Marker = splitExtraMarker(marker)
+	req.Hashes = hashes
+	return req, nil
+}
+
+// Splits the contents of a requirements file into logical lines, joining continuation lines and dropping comments and blank lines
+func requirementsFileLines(contents string) []string {
+	var lines []string
+	var cur string
+	for _, line := range strings.Split(contents, "\n") {
+		line, _ = splitRequirementsComment(strings.TrimRight(line, "\r"))
+		if strings.HasSuffix(line, "\\") {
+			cur += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		if line = strings.TrimSpace(cur + line); line != "" {
+			lines = append(lines, line)
+		}
+		cur = ""
+	}
+	if cur = strings.TrimSpace(cur); cur != "" {
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+// Splits a line of a requirements file into its code and its comment, e.g., "flask>=0.10" and " # web framework". Lines starting with "#" are
+// all comment.
+func splitRequirementsComment(line string) (code, comment string) {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return "", line
+	} else if i := strings.Index(line, " #"); i >= 0 {
+		return line[:i], line[i:]
+	} else if i := strings.Index(line, "\t#"); i >= 0 {
+		return line[:i], line[i:]
+	}
+	return line, ""
+}
@@ -0,0 +1,305 @@
+package cheerio
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Release data needed to resolve requirements. PackageIndex implements it.
+type ReleaseSource interface {
+	// Returns the release versions of a package, oldest first
+	ReleaseVersions(pkg string) ([]string, error)
+
+	// Returns the requirements of a release of a package
+	FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error)
+}
+
+// Picks a concrete version of every package (transitively) required by a set of requirements, such that all version clauses are satisfied.
+type Resolver struct {
+	Source ReleaseSource
+
+	// If set, requirements whose environment markers don't hold in Env are ignored (see AppliesTo). Otherwise, markers are ignored, and only
+	// requirements of extras are dropped.
+	Env MarkerEnv
+
+	// Whether pre-releases are considered even if a final release also satisfies the requirements
+	Prereleases bool
+
+	// Maximum number of candidate versions to try before giving up (0 for the default of 10000)
+	MaxAttempts int
+}
+
+// The result of resolving a set of requirements
+type Resolution struct {
+	Pins      map[string]string // normalized package name -> version
+	Conflicts []*Conflict       // why resolution failed, if it did
+}
+
+// Requirements on a package that no release satisfies together
+type Conflict struct {
+	Pkg         string
+	Constraints []string // e.g., "flask>=2.0 (from app==1.0)"
+}
+
+func (c *Conflict) String() string {
+	return fmt.Sprintf("%s: %s", c.Pkg, strings.Join(c.Constraints, ", "))
+}
+
+// A failed pin caused by conflicting requirements, after which the resolver backtracks to the next candidate version
+type conflictError struct {
+	msg string
+}
+
+func (e *conflictError) Error() string {
+	return "[conflict] " + e.msg
+}
+
+// Returned (wrapped) when the resolver gives up after trying Resolver.MaxAttempts candidate versions. It doesn't backtrack.
+var errTooManyAttempts = errors.New("[conflict] Gave up")
+
+// A requirement and the pinned release that required it ("" for top-level requirements)
+type resolverConstraint struct {
+	req  *Requirement
+	from string
+}
+
+func (c resolverConstraint) String() string {
+	s := NormalizedPkgName(c.req.Name) + c.req.Specifier()
+	if c.from != "" {
+		s += fmt.Sprintf(" (from %s)", c.from)
+	}
+	return s
+}
+
+type resolverState struct {
+	pins        map[string]string
+	constraints map[string][]resolverConstraint
+	pending     []string
+}
+
+// Resolves requirements to pinned versions. Packages are pinned in breadth-first order, newest satisfying release first, backtracking when a pin
+// conflicts with the requirements of a later one. Requirements that reference a URL instead of the index are skipped. If resolution fails, the
+// returned Resolution lists the conflicts found along with the error.
+func (r *Resolver) Resolve(reqs []*Requirement) (*Resolution, error) {
+	state := &resolverState{pins: make(map[string]string), constraints: make(map[string][]resolverConstraint)}
+	roots, err := r.applicable(reqs)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range roots {
+		state.addConstraint(req, "")
+	}
+
+	rr := &resolveRun{Resolver: r, versions: make(map[string][]string), reqs: make(map[string][]*Requirement), conflicts: make(map[string]*Conflict)}
+	final, err := rr.resolve(state)
+	if err != nil {
+		return &Resolution{Pins: map[string]string{}, Conflicts: rr.sortedConflicts()}, err
+	}
+	return &Resolution{Pins: final.pins}, nil
+}
+
+func (s *resolverState) addConstraint(req *Requirement, from string) {
+	pkg := NormalizedPkgName(req.Name)
+	if _, in := s.constraints[pkg]; !in {
+		s.pending = append(s.pending, pkg)
+	}
+	s.constraints[pkg] = append(s.constraints[pkg], resolverConstraint{req, from})
+}
+
+func (s *resolverState) copy() *resolverState {
+	c := &resolverState{
+		pins:        make(map[string]string, len(s.pins)),
+		constraints: make(map[string][]resolverConstraint, len(s.constraints)),
+		pending:     append([]string(nil), s.pending...),
+	}
+	for pkg, ver := range s.pins {
+		c.pins[pkg] = ver
+	}
+	for pkg, cs := range s.constraints {
+		c.constraints[pkg] = append([]resolverConstraint(nil), cs...)
+	}
+	return c
+}
+
+// The caches and bookkeeping of a single Resolve call
+type resolveRun struct {
+	*Resolver
+	versions  map[string][]string
+	reqs      map[string][]*Requirement // keyed by "pkg==version"
+	conflicts map[string]*Conflict
+	attempts  int
+}
+
+func (rr *resolveRun) resolve(state *resolverState) (*resolverState, error) {
+	if len(state.pending) == 0 {
+		return state, nil
+	}
+	pkg := state.pending[0]
+	state.pending = state.pending[1:]
+
+	candidates, err := rr.candidates(pkg, state.constraints[pkg])
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		rr.addConflict(pkg, state.constraints[pkg])
+		return nil, &conflictError{fmt.Sprintf("No release of %s satisfies %s", pkg, constraintsString(state.constraints[pkg]))}
+	}
+
+	maxAttempts := rr.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 10000
+	}
+	var lastErr error
+	for _, ver := range candidates {
+		if rr.attempts++; rr.attempts > maxAttempts {
+			return nil, fmt.Errorf("%w after trying %d candidate versions", errTooManyAttempts, maxAttempts)
+		}
+
+		deps, err := rr.requirements(pkg, ver)
+		if err != nil {
+			return nil, err
+		}
+
+		next := state.copy()
+		next.pins[pkg] = ver
+		from := pkg + "==" + ver
+		consistent := true
+		for _, dep := range deps {
+			next.addConstraint(dep, from)
+			depPkg := NormalizedPkgName(dep.Name)
+			if pinned, in := next.pins[depPkg]; in {
+				if ok, _ := dep.Matches(pinned); !ok {
+					rr.addConflict(depPkg, next.constraints[depPkg])
+					lastErr = &conflictError{fmt.Sprintf("%s==%s does not satisfy %s", depPkg, pinned, constraintsString(next.constraints[depPkg]))}
+					consistent = false
+					break
+				}
+			}
+		}
+		if !consistent {
+			continue
+		}
+
+		final, err := rr.resolve(next)
+		var conflict *conflictError
+		if err == nil {
+			return final, nil
+		} else if !errors.As(err, &conflict) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Returns the releases of pkg that satisfy all constraints, newest first
+func (rr *resolveRun) candidates(pkg string, constraints []resolverConstraint) ([]string, error) {
+	versions, in := rr.versions[pkg]
+	if !in {
+		released, err := rr.Source.ReleaseVersions(pkg)
+		if err != nil {
+			return nil, err
+		}
+		versions = append([]string(nil), released...) // sorted below, so don't reorder the source's slice
+		sort.SliceStable(versions, func(i, j int) bool { return CompareVersions(versions[i], versions[j]) < 0 })
+		rr.versions[pkg] = versions
+	}
+
+	var finals, prereleases []string
+	for i := len(versions) - 1; i >= 0; i-- {
+		ver := versions[i]
+		matches := true
+		for _, c := range constraints {
+			if ok, _ := c.req.Matches(ver); !ok {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+		if v, err := parseVersion(ver); err == nil && v.isPrerelease() {
+			prereleases = append(prereleases, ver)
+		} else {
+			finals = append(finals, ver)
+		}
+	}
+	// Like pip, only fall back to pre-releases if no final release satisfies the constraints
+	if rr.Prereleases || len(finals) == 0 {
+		return append(finals, prereleases...), nil
+	}
+	return finals, nil
+}
+
+// Returns the applicable requirements of a release of pkg
+func (rr *resolveRun) requirements(pkg, ver string) ([]*Requirement, error) {
+	key := pkg + "==" + ver
+	if reqs, in := rr.reqs[key]; in {
+		return reqs, nil
+	}
+	reqs, err := rr.Source.FetchPackageRequirementsAt(pkg, ver)
+	if err != nil {
+		if !errors.Is(err, ErrNoRequiresFile) {
+			return nil, err
+		}
+		reqs = nil // release without requirements metadata
+	}
+	if reqs, err = rr.applicable(reqs); err != nil {
+		return nil, err
+	}
+	rr.reqs[key] = reqs
+	return reqs, nil
+}
+
+// Drops requirements that don't apply in the resolver's environment or reference a URL
+func (r *Resolver) applicable(reqs []*Requirement) ([]*Requirement, error) {
+	if r.Env != nil {
+		var err error
+		if reqs, err = FilterRequirements(reqs, r.Env); err != nil {
+			return nil, err
+		}
+	}
+	applicable := make([]*Requirement, 0, len(reqs))
+	for _, req := range reqs {
+		if req.URL == "" && (r.Env != nil || req.Extra == "") {
+			applicable = append(applicable, req)
+		}
+	}
+	return applicable, nil
+}
+
+func (rr *resolveRun) addConflict(pkg string, constraints []resolverConstraint) {
+	conflict := &Conflict{Pkg: pkg}
+	for _, c := range constraints {
+		conflict.Constraints = append(conflict.Constraints, c.String())
+	}
+	rr.conflicts[conflict.String()] = conflict
+}
+
+func (rr *resolveRun) sortedConflicts() []*Conflict {
+	conflicts := make([]*Conflict, 0, len(rr.conflicts))
+	for _, key := range sortedConflictKeys(rr.conflicts) {
+		conflicts = append(conflicts, rr.conflicts[key])
+	}
+	return conflicts
+}
+
+func sortedConflictKeys(conflicts map[string]*Conflict) []string {
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func constraintsString(constraints []resolverConstraint) string {
+	strs := make([]string, 0, len(constraints))
+	for _, c := range constraints {
+		strs = append(strs, c.String())
+	}
+	return strings.Join(strs, ", ")
+}
@@ -0,0 +1,145 @@
+package cheerio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A ReleaseSource backed by a map from "pkg==version" to requires.txt contents
+type fakeReleases map[string]string
+
+func (f fakeReleases) ReleaseVersions(pkg string) ([]string, error) {
+	var versions []string
+	for key := range f {
+		if split := strings.SplitN(key, "==", 2); split[0] == pkg {
+			versions = append(versions, split[1])
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("No releases of %s", pkg)
+	}
+	return versions, nil
+}
+
+func (f fakeReleases) FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error) {
+	return ParseRequirements(f[pkg+"=="+version])
+}
+
+func TestResolve(t *testing.T) {
+	source := fakeReleases{
+		"app==1.0":       "web>=1.0\nutil",
+		"web==1.0":       "util<2",
+		"web==1.1":       "util<2\nasync-lib",
+		"web==2.0":       "util>=3",
+		"util==1.5":      "",
+		"util==2.0":      "",
+		"util==3.0b1":    "",
+		"async-lib==0.1": "util!=1.5",
+		"tool==1.0":      "",
+		"tool==2.0rc1":   "",
+	}
+	r := &Resolver{Source: source}
+
+	// web 1.1 pulls in async-lib, which excludes util 1.5, the only release of util below 2, so the resolver backtracks to web 1.0
+	res, err := r.Resolve(mustParseRequirements(t, "app\nweb<2\ntool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := map[string]string{"app": "1.0", "web": "1.0", "util": "1.5", "tool": "1.0"}; !reflect.DeepEqual(res.Pins, exp) {
+		t.Errorf("Resolve: expected %v, got %v", exp, res.Pins)
+	}
+
+	res, err = r.Resolve(mustParseRequirements(t, "tool>1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := map[string]string{"tool": "2.0rc1"}; !reflect.DeepEqual(res.Pins, exp) {
+		t.Errorf("Resolve: expected pre-release fallback %v, got %v", exp, res.Pins)
+	}
+
+	res, err = r.Resolve(mustParseRequirements(t, "web==1.0\nutil>=2"))
+	if err == nil || !strings.HasPrefix(err.Error(), "[conflict]") {
+		t.Fatalf("Resolve: expected conflict, got %v", err)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].String() != "util: util>=2, util<2 (from web==1.0)" {
+		t.Errorf("Resolve: unexpected conflicts %v", res.Conflicts)
+	}
+}
+
+// A ReleaseSource whose release lists are shared with the test
+type sharedReleases map[string][]string
+
+func (s sharedReleases) ReleaseVersions(pkg string) ([]string, error) {
+	return s[pkg], nil
+}
+
+func (s sharedReleases) FetchPackageRequirementsAt(pkg, version string) ([]*Requirement, error) {
+	return nil, nil
+}
+
+func TestResolveLimits(t *testing.T) {
+	source := fakeReleases{
+		"app==1.0":       "web",
+		"web==1.0":       "util<2",
+		"web==1.1":       "util<2\nasync-lib",
+		"util==1.5":      "",
+		"async-lib==0.1": "util!=1.5",
+	}
+	_, err := (&Resolver{Source: source, MaxAttempts: 2}).Resolve(mustParseRequirements(t, "app"))
+	var conflict *conflictError
+	if !errors.Is(err, errTooManyAttempts) || errors.As(err, &conflict) {
+		t.Errorf("Resolve: expected to give up without backtracking, got %v", err)
+	}
+
+	shared := sharedReleases{"util": {"2.0", "1.0", "1.5"}}
+	if _, err := (&Resolver{Source: shared}).Resolve(mustParseRequirements(t, "util")); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"2.0", "1.0", "1.5"}; !reflect.DeepEqual(shared["util"], exp) {
+		t.Errorf("Resolve: expected the source's releases to stay %v, got %v", exp, shared["util"])
+	}
+}
+
+func TestResolvePackageIndex(t *testing.T) {
+	pages := map[string]string{
+		"/simple/web/": `<a href="https://files.pythonhosted.org/packages/01/web-1.0-py3-none-any.whl#sha256=0123456789abcdef">web-1.0-py3-none-any.whl</a><br />
+<a href="https://files.pythonhosted.org/packages/02/web-1.1-py3-none-any.whl#sha256=fedcba9876543210">web-1.1-py3-none-any.whl</a><br />`,
+		"/simple/util/": `<a href="https://files.pythonhosted.org/packages/03/util-1.5.tar.gz#sha256=0123456789abcdef">util-1.5.tar.gz</a><br />
+<a href="https://files.pythonhosted.org/packages/04/util-2.0-py3-none-any.whl#sha256=fedcba9876543210">util-2.0-py3-none-any.whl</a><br />`,
+		"/pypi/web/1.0/json":  `{"info": {"requires_dist": []}}`,
+		"/pypi/web/1.1/json":  `{"info": {"requires_dist": ["util<2"]}}`,
+		"/pypi/util/1.5/json": `{"info": {"requires_dist": []}}`,
+		"/pypi/util/2.0/json": `{"info": {"requires_dist": []}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, in := pages[r.URL.Path]
+		if !in {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	r := &Resolver{Source: &PackageIndex{URI: server.URL, Sources: []RequirementsSource{SourceJSON}}}
+	res, err := r.Resolve(mustParseRequirements(t, "web\nutil"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := map[string]string{"web": "1.1", "util": "1.5"}; !reflect.DeepEqual(res.Pins, exp) {
+		t.Errorf("Resolve: expected %v, got %v", exp, res.Pins)
+	}
+}
+
+func mustParseRequirements(t *testing.T, rawReqs string) []*Requirement {
+	reqs, err := ParseRequirements(rawReqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reqs
+}
@@ -0,0 +1,190 @@
+package cheerio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/beyang/cheerio/fetch"
+)
+
+var DefaultRubyGems = &RubyGems{URI: "https://rubygems.org"}
+
+// A RubyGems server, read through the gems API (https://guides.rubygems.org/rubygems-org-api/) and the gemspecs inside .gem archives
+type RubyGems struct {
+	URI string
+}
+
+// The gem info returned by /api/v1/gems/<name>.json, reduced to the fields used here
+type rubyGemInfo struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Platform      string `json:"platform"`
+	HomepageURI   string `json:"homepage_uri"`
+	SourceCodeURI string `json:"source_code_uri"`
+}
+
+var gemMetadataPattern = regexp.MustCompile(`^metadata\.gz$`)
+
+// Returns the names of all gems, from the compact index's /names list
+func (g *RubyGems) AllPackages() ([]string, error) {
+	resp, err := http.Get(g.URI + "/names")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("RubyGems returned %s for the gem list", resp.Status)
+	}
+
+	// The list starts with a "---" separator line
+	pkgs := make([]string, 0)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" && name != "---" {
+			pkgs = append(pkgs, name)
+		}
+	}
+	return pkgs, scanner.Err()
+}
+
+// Returns the requirements of the latest version of a gem, read from the gemspec in its .gem archive. Development dependencies have Extra "dev".
+func (g *RubyGems) FetchPackageRequirements(pkg string) ([]*Requirement, error) {
+	info, err := g.fetchGemInfo(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return g.FetchPackageRequirementsAt(info.Name, info.Version, info.Platform)
+}
+
+// Returns the requirements of a version of a gem for a platform ("ruby" or "" for pure-Ruby gems), read from the gemspec in its .gem archive
+func (g *RubyGems) FetchPackageRequirementsAt(pkg, version, platform string) ([]*Requirement, error) {
+	file := pkg + "-" + version
+	if platform != "" && platform != "ruby" {
+		file += "-" + platform
+	}
+	metadata, err := fetch.RemoteDecompress(fmt.Sprintf("%s/downloads/%s.gem", g.URI, url.PathEscape(file)), gemMetadataPattern, fetch.PlainTar)
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(metadata))
+	if err != nil {
+		return nil, err
+	}
+	gemspec, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseGemspecDependencies(string(gemspec)), nil
+}
+
+// Returns the source repository URL of a gem from its source_code_uri or homepage_uri
+func (g *RubyGems) FetchSourceRepoURL(pkg string) (string, error) {
+	info, err := g.fetchGemInfo(pkg)
+	if err != nil {
+		return "", err
+	}
+	repoURL, _ := matchRepoURL([]repoCandidate{{info.SourceCodeURI, RepoFromProjectURL}, {info.HomepageURI, RepoFromHomePage}})
+	if repoURL == "" {
+		return "", fmt.Errorf("Could not parse repo URL from metadata of gem %s", pkg)
+	}
+	return repoURL, nil
+}
+
+func (g *RubyGems) fetchGemInfo(pkg string) (*rubyGemInfo, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/gems/%s.json", g.URI, url.PathEscape(pkg)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, notFoundIf(resp.StatusCode, fmt.Errorf("RubyGems returned %s for gem %s", resp.Status, pkg))
+	}
+	var info rubyGemInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+var gemspecKeyRegexp = regexp.MustCompile(`^(\s*)(?:- )?([a-z_]+):\s*(.*)$`)
+var gemspecOperatorRegexp = regexp.MustCompile(`^\s*- - "?([<>=!~]+)"?\s*$`)
+
+// Parses the dependencies of a gemspec serialized as YAML (the metadata.gz file in .gem archives), e.g.:
+//
+//	dependencies:
+//	- !ruby/object:Gem::Dependency
+//	  name: rack
+//	  requirement: !ruby/object:Gem::Requirement
+//	    requirements:
+//	    - - "~>"
+//	      - !ruby/object:Gem::Version
+//	        version: '2.0'
+//	  type: :runtime
+//
+// Version requirements are joined into Range, e.g., "~> 2.0, >= 2.0.1"; the catch-all ">= 0" is dropped.
+func parseGemspecDependencies(gemspec string) []*Requirement {
+	reqs := make([]*Requirement, 0)
+	var req *Requirement
+	var clauses []string
+	inDeps, inRequirement, op := false, false, ""
+	finish := func() {
+		if req != nil && req.Name != "" {
+			req.Range = strings.Join(clauses, ", ")
+			reqs = append(reqs, req)
+		}
+		req, clauses = nil, nil
+	}
+
+	for _, line := range strings.Split(gemspec, "\n") {
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "- ") {
+			inDeps = strings.TrimSpace(line) == "dependencies:"
+			continue
+		}
+		if !inDeps {
+			continue
+		}
+		if strings.HasPrefix(line, "- ") {
+			finish()
+			req = &Requirement{}
+			continue
+		}
+		if req == nil {
+			continue
+		}
+
+		if match := gemspecOperatorRegexp.FindStringSubmatch(line); match != nil && inRequirement {
+			op = match[1]
+			continue
+		}
+		match := gemspecKeyRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		indent, key, value := len(match[1]), match[2], strings.Trim(strings.TrimSpace(match[3]), `'"`)
+		switch {
+		case indent == 2 && key == "name":
+			req.Name = value
+		case indent == 2 && key == "type":
+			if value == ":development" {
+				req.Extra = "dev"
+			}
+		case indent == 2:
+			inRequirement = key == "requirement"
+		case key == "version" && inRequirement && op != "":
+			if clause := op + " " + value; clause != ">= 0" {
+				clauses = append(clauses, clause)
+			}
+			op = ""
+		}
+	}
+	finish()
+	return reqs
+}
@@ -0,0 +1,111 @@
+package cheerio
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const testGemspec = `--- !ruby/object:Gem::Specification
+name: sinatra
+version: !ruby/object:Gem::Version
+  version: 3.0.5
+dependencies:
+- !ruby/object:Gem::Dependency
+  name: rack
+  requirement: !ruby/object:Gem::Requirement
+    requirements:
+    - - "~>"
+      - !ruby/object:Gem::Version
+        version: '2.2'
+    - - ">="
+      - !ruby/object:Gem::Version
+        version: 2.2.4
+  type: :runtime
+  prerelease: false
+  version_requirements: !ruby/object:Gem::Requirement
+    requirements:
+    - - "~>"
+      - !ruby/object:Gem::Version
+        version: '2.2'
+- !ruby/object:Gem::Dependency
+  name: tilt
+  requirement: !ruby/object:Gem::Requirement
+    requirements:
+    - - ">="
+      - !ruby/object:Gem::Version
+        version: '0'
+  type: :runtime
+- !ruby/object:Gem::Dependency
+  name: rspec
+  requirement: !ruby/object:Gem::Requirement
+    requirements:
+    - - "="
+      - !ruby/object:Gem::Version
+        version: 3.12.0
+  type: :development
+description: Classy web-development dressed in a DSL
+files:
+- lib/sinatra.rb
+`
+
+// Returns a .gem archive whose metadata.gz holds gemspec
+func testGem(t *testing.T, gemspec string) []byte {
+	var metadata bytes.Buffer
+	gz := gzip.NewWriter(&metadata)
+	gz.Write([]byte(gemspec))
+	gz.Close()
+
+	var gem bytes.Buffer
+	tw := tar.NewWriter(&gem)
+	if err := tw.WriteHeader(&tar.Header{Name: "metadata.gz", Mode: 0644, Size: int64(metadata.Len())}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(metadata.Bytes())
+	tw.Close()
+	return gem.Bytes()
+}
+
+func TestRubyGems(t *testing.T) {
+	gem := testGem(t, testGemspec)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/names":
+			fmt.Fprint(w, "---\nrails\nsinatra\n")
+		case "/api/v1/gems/sinatra.json":
+			fmt.Fprint(w, `{"name": "sinatra", "version": "3.0.5", "platform": "ruby", "homepage_uri": "http://sinatrarb.com/",
+				"source_code_uri": "https://github.com/sinatra/sinatra/tree/v3.0.5"}`)
+		case "/downloads/sinatra-3.0.5.gem":
+			w.Write(gem)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var idx Index = &RubyGems{URI: server.URL}
+	if pkgs, err := idx.AllPackages(); err != nil || !reflect.DeepEqual(pkgs, []string{"rails", "sinatra"}) {
+		t.Errorf("AllPackages: got %v, %v", pkgs, err)
+	}
+
+	reqs, err := idx.FetchPackageRequirements("sinatra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range reqs {
+		got = append(got, fmt.Sprintf("%s(%s):%s", req.Name, req.Specifier(), req.Extra))
+	}
+	if exp := []string{"rack(~> 2.2, >= 2.2.4):", "tilt():", "rspec(= 3.12.0):dev"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("FetchPackageRequirements: expected %v, got %v", exp, got)
+	}
+
+	if repoURL, err := idx.FetchSourceRepoURL("sinatra"); err != nil || repoURL != "https://github.com/sinatra/sinatra" {
+		t.Errorf("FetchSourceRepoURL: got %s, %v", repoURL, err)
+	}
+}
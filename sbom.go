@@ -0,0 +1,162 @@
+package cheerio
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A software bill of materials: the packages in the dependency closure of one or more root packages, with the release of each that is used
+type SBOM struct {
+	Name     string
+	Roots    []string // normalized names of the root packages
+	Packages []*SBOMPackage
+	Created  time.Time
+}
+
+// A package in an SBOM
+type SBOMPackage struct {
+	Name        string // normalized package name
+	Version     string // "" if unknown
+	DownloadURL string // URL of the release's sdist (or other file), "" if unknown
+	Checksums   map[string]string
+	License     string   // SPDX license expression, "" if unknown (see spdxLicense)
+	Requires    []string // normalized names of the packages in the SBOM that this package requires, sorted
+}
+
+// Builds SBOMs from the dependency graph and release data of a package index
+type SBOMBuilder struct {
+	Index PyPIIndex
+	Graph *PyPIGraph
+
+	// Package info used for license data (optional)
+	Info PackageInfoIndex
+
+	// Versions to use for packages, keyed by normalized name (optional). Other packages are listed at their latest release.
+	Pins map[string]string
+}
+
+// Builds an SBOM of the roots and every package they transitively require (without extras). Packages whose release data can't be fetched are
+// included without a version or download location.
+func (b *SBOMBuilder) Build(name string, roots ...string) (*SBOM, error) {
+	if b.Graph == nil {
+		return nil, fmt.Errorf("SBOMBuilder has no dependency graph")
+	}
+	sbom := &SBOM{Name: name, Created: time.Now().UTC()}
+	for _, root := range roots {
+		sbom.Roots = append(sbom.Roots, NormalizedPkgName(root))
+	}
+
+	// Walk the unconditional dependencies of the roots
+	included := make(map[string]bool)
+	queue := append([]string(nil), sbom.Roots...)
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		if included[pkg] {
+			continue
+		}
+		included[pkg] = true
+		queue = append(queue, b.Graph.RequiresWithExtras(pkg)...)
+	}
+
+	pkgs := make([]string, 0, len(included))
+	for pkg := range included {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	for _, pkg := range pkgs {
+		sbomPkg := &SBOMPackage{Name: pkg, Requires: make([]string, 0)}
+		for _, dep := range b.Graph.RequiresWithExtras(pkg) {
+			if included[dep] && !containsString(sbomPkg.Requires, dep) {
+				sbomPkg.Requires = append(sbomPkg.Requires, dep)
+			}
+		}
+		sort.Strings(sbomPkg.Requires)
+		if info, in := b.Info[pkg]; in {
+			sbomPkg.License = spdxLicense(info)
+		}
+		if err := b.addRelease(sbomPkg); err != nil {
+			DefaultLogger.Logf("sbom", "unable to fetch releases of pkg %s due to error: %s", pkg, err)
+		}
+		sbom.Packages = append(sbom.Packages, sbomPkg)
+	}
+	return sbom, nil
+}
+
+// Fills in the version, download URL, and checksums of a package from the index
+func (b *SBOMBuilder) addRelease(sbomPkg *SBOMPackage) error {
+	sbomPkg.Version = b.Pins[sbomPkg.Name]
+	if b.Index == nil {
+		return nil
+	}
+	releases, err := b.Index.Versions(sbomPkg.Name)
+	if err != nil {
+		return err
+	}
+
+	var release *Release
+	for _, r := range releases {
+		if sbomPkg.Version == "" || CompareVersions(r.Version, sbomPkg.Version) == 0 {
+			release = r
+		}
+	}
+	if release == nil {
+		return nil
+	}
+	sbomPkg.Version = release.Version
+
+	artifact := release.Artifacts[0]
+	for _, a := range release.Artifacts {
+		if a.Type == ArtifactSdist {
+			artifact = a
+			break
+		}
+	}
+	sbomPkg.DownloadURL, sbomPkg.Checksums = artifact.URL, artifact.Digests
+	return nil
+}
+
+// SPDX identifiers of common license classifiers
+var spdxLicenseClassifiers = map[string]string{
+	"MIT License":                                   "MIT",
+	"ISC License (ISCL)":                            "ISC",
+	"Apache Software License":                       "Apache-2.0",
+	"Mozilla Public License 2.0 (MPL 2.0)":          "MPL-2.0",
+	"GNU General Public License v2 (GPLv2)":         "GPL-2.0-only",
+	"GNU General Public License v3 (GPLv3)":         "GPL-3.0-only",
+	"GNU Lesser General Public License v3 (LGPLv3)": "LGPL-3.0-only",
+	"GNU Affero General Public License v3":          "AGPL-3.0-only",
+	"Python Software Foundation License":            "PSF-2.0",
+	"The Unlicense (Unlicense)":                     "Unlicense",
+	"Zope Public License":                           "ZPL-2.1",
+}
+
+var spdxExpressionRegexp = regexp.MustCompile(`^\(?[A-Za-z0-9\.\-\+]+(?:\)? (?:AND|OR|WITH) \(?[A-Za-z0-9\.\-\+]+\)?)*$`)
+
+// Returns the declared license of a package as an SPDX license expression, or "" if it can't be determined. A License field that is an SPDX
+// expression of listed licenses (e.g., from License-Expression) is used as is; otherwise, license classifiers with an unambiguous SPDX identifier
+// are mapped.
+func spdxLicense(info *PackageInfo) string {
+	license := strings.TrimSpace(info.License)
+	if isSPDXExpression(license) {
+		return license
+	}
+
+	var ids []string
+	for _, name := range info.Licenses() {
+		if id, in := spdxLicenseClassifiers[name]; in && !containsString(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, " OR ")
+}
+
+// Returns the package URL (purl) of the package's release, e.g., "pkg:pypi/flask@0.10.1"
+func (pkg *SBOMPackage) PURL() string {
+	purl, _ := PackagePURL("pypi", pkg.Name, pkg.Version)
+	return purl
+}
@@ -0,0 +1,158 @@
+package cheerio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Serves simple index pages listing an sdist for each of the given "pkg==version" releases
+func testSimpleIndex(releases ...string) *httptest.Server {
+	pages := make(map[string]string)
+	for _, release := range releases {
+		split := strings.SplitN(release, "==", 2)
+		pkg, ver := split[0], split[1]
+		pages["/simple/"+pkg+"/"] += fmt.Sprintf(`<a href="/packages/%s-%s.tar.gz#sha256=%064x">%s-%s.tar.gz</a><br/>`+"\n", pkg, ver, len(ver), pkg, ver)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if page, in := pages[r.URL.Path]; in {
+			fmt.Fprint(w, page)
+		} else {
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func testSBOM(t *testing.T) (*SBOM, *httptest.Server) {
+	server := testSimpleIndex("app==1.0", "web==1.0", "web==1.1", "util==2.0")
+	builder := &SBOMBuilder{
+		Index: &PackageIndex{URI: server.URL},
+		Graph: testGraph("app:web", "web:util", "web:docs:docs", "other:web"),
+		Info:  PackageInfoIndex{"web": {Pkg: "web", LicenseClassifiers: []string{"OSI Approved :: MIT License"}}, "util": {Pkg: "util", License: "BSD"}},
+		Pins:  map[string]string{"web": "1.0"},
+	}
+	sbom, err := builder.Build("app-sbom", "App")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sbom.Created = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	return sbom, server
+}
+
+func TestSBOMBuilder(t *testing.T) {
+	sbom, server := testSBOM(t)
+	defer server.Close()
+
+	exp := []*SBOMPackage{
+		{Name: "app", Version: "1.0", DownloadURL: server.URL + "/packages/app-1.0.tar.gz", Checksums: map[string]string{"sha256": fmt.Sprintf("%064x", 3)}, Requires: []string{"web"}},
+		{Name: "util", Version: "2.0", DownloadURL: server.URL + "/packages/util-2.0.tar.gz", Checksums: map[string]string{"sha256": fmt.Sprintf("%064x", 3)}, Requires: []string{}},
+		{Name: "web", Version: "1.0", DownloadURL: server.URL + "/packages/web-1.0.tar.gz", Checksums: map[string]string{"sha256": fmt.Sprintf("%064x", 3)}, License: "MIT", Requires: []string{"util"}},
+	}
+	if !reflect.DeepEqual(sbom.Packages, exp) {
+		t.Errorf("Build: expected %+v, got %+v", exp, sbom.Packages)
+	}
+}
+
+func TestWriteSPDX(t *testing.T) {
+	sbom, server := testSBOM(t)
+	defer server.Close()
+
+	var buf bytes.Buffer
+	if err := sbom.WriteSPDX(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Created != "2020-01-02T03:04:05Z" || len(doc.Packages) != 3 {
+		t.Errorf("WriteSPDX: unexpected document %+v", doc)
+	}
+	web := doc.Packages[2]
+	if web.SPDXID != "SPDXRef-Package-web" || web.LicenseDeclared != "MIT" || web.Checksums[0].Algorithm != "SHA256" || web.ExternalRefs[0].ReferenceLocator != "pkg:pypi/web@1.0" {
+		t.Errorf("WriteSPDX: unexpected package %+v", web)
+	}
+	if util := doc.Packages[1]; util.LicenseDeclared != "NOASSERTION" {
+		t.Errorf("WriteSPDX: expected NOASSERTION license for ambiguous BSD license, got %s", util.LicenseDeclared)
+	}
+	expRels := []spdxRelationship{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Package-app"},
+		{"SPDXRef-Package-app", "DEPENDS_ON", "SPDXRef-Package-web"},
+		{"SPDXRef-Package-web", "DEPENDS_ON", "SPDXRef-Package-util"},
+	}
+	if !reflect.DeepEqual(doc.Relationships, expRels) {
+		t.Errorf("WriteSPDX: expected relationships %v, got %v", expRels, doc.Relationships)
+	}
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	sbom, server := testSBOM(t)
+	defer server.Close()
+
+	var buf bytes.Buffer
+	if err := sbom.WriteCycloneDX(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var bom cdxBOM
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatal(err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" || !strings.HasPrefix(bom.SerialNumber, "urn:uuid:") || len(bom.Components) != 3 {
+		t.Errorf("WriteCycloneDX: unexpected BOM %+v", bom)
+	}
+	web := bom.Components[2]
+	if web.PURL != "pkg:pypi/web@1.0" || web.BOMRef != web.PURL || web.Hashes[0].Alg != "SHA-256" || web.Licenses[0].Expression != "MIT" {
+		t.Errorf("WriteCycloneDX: unexpected component %+v", web)
+	}
+	expDeps := []cdxDependency{
+		{Ref: "sbom:app-sbom", DependsOn: []string{"pkg:pypi/app@1.0"}},
+		{Ref: "pkg:pypi/app@1.0", DependsOn: []string{"pkg:pypi/web@1.0"}},
+		{Ref: "pkg:pypi/util@2.0"},
+		{Ref: "pkg:pypi/web@1.0", DependsOn: []string{"pkg:pypi/util@2.0"}},
+	}
+	if !reflect.DeepEqual(bom.Dependencies, expDeps) {
+		t.Errorf("WriteCycloneDX: expected dependencies %v, got %v", expDeps, bom.Dependencies)
+	}
+
+	buf.Reset()
+	if err := sbom.WriteCycloneDX(&buf, "xml"); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		`<bom xmlns="http://cyclonedx.org/schema/bom/1.5" serialNumber="` + bom.SerialNumber + `" version="1">`,
+		`<hash alg="SHA-256">`,
+		`<licenses>`,
+		`<dependency ref="pkg:pypi/web@1.0">`,
+	} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Errorf("WriteCycloneDX: expected XML to contain %s, got:\n%s", exp, buf.String())
+		}
+	}
+}
+
+func TestSPDXLicense(t *testing.T) {
+	for _, test := range []struct{ license, exp string }{
+		{"MIT", "MIT"},
+		{"Apache-2.0 OR MIT", "Apache-2.0 OR MIT"},
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", "(MIT OR Apache-2.0) AND BSD-3-Clause"},
+		{"GPL-2.0-or-later WITH Classpath-exception-2.0", "GPL-2.0-or-later WITH Classpath-exception-2.0"},
+		{"GPL-2.0+", "GPL-2.0+"},
+		{"LicenseRef-Proprietary", "LicenseRef-Proprietary"},
+		{"BSD-like", ""},
+		{"see LICENSE.txt", ""},
+		{"LICENSE.txt", ""},
+		{"MIT WITH BSD-like", ""},
+		{"BSD", ""},
+	} {
+		if license := spdxLicense(&PackageInfo{License: test.license}); license != test.exp {
+			t.Errorf("spdxLicense(%q): expected %q, got %q", test.license, test.exp, license)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cheerio
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var setupCfgPattern = regexp.MustCompile(`^[^/]+/setup\.cfg$`)
+var setupPyPattern = regexp.MustCompile(`^[^/]+/setup\.py$`)
+
+// Parse the install_requires option in the [options] section of a setup.cfg file
+func ParseSetupCfg(contents string) ([]*Requirement, error) {
+	section := ""
+	var value []string
+	inValue := false
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		// Indented lines continue the previous option's value
+		if inValue && trimmed != "" && (line[0] == ' ' || line[0] == '\t') {
+			value = append(value, trimmed)
+			continue
+		}
+		inValue = false
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+		} else if i := strings.IndexAny(trimmed, "=:"); i >= 0 && section == "options" {
+			if strings.TrimSpace(trimmed[:i]) == "install_requires" {
+				inValue = true
+				if first := strings.TrimSpace(trimmed[i+1:]); first != "" {
+					value = append(value, first)
+				}
+			}
+		}
+	}
+
+	// Like setuptools, treat a value given on a single line as a semicolon-separated list (so it cannot carry markers)
+	if len(value) == 1 {
+		value = strings.Split(value[0], ";")
+	}
+
+	reqs := make([]*Requirement, 0)
+	for _, reqStr := range value {
+		if reqStr = strings.TrimSpace(reqStr); reqStr == "" {
+			continue
+		}
+		if req, err := ParseRequirementLine(reqStr); err == nil {
+			reqs = append(reqs, req)
+		} else {
+			DefaultLogger.Logf("req", "Could not parse requirement: %s", err)
+		}
+	}
+	return reqs, nil
+}
+
+var installRequiresRegexp = regexp.MustCompile(`install_requires\s*=\s*(\[|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// Extract the install_requires list passed to setup() in a setup.py file. The file is not executed or parsed as Python; only a literal list of
+// strings (given directly, or assigned to a variable that is passed as install_requires) is recognized. Returns an error if no such list is found,
+// or if the list is only part of the value, e.g., install_requires=['flask'] + extra.
+func ParseSetupPy(contents string) ([]*Requirement, error) {
+	match := installRequiresRegexp.FindStringSubmatchIndex(contents)
+	if match == nil {
+		return nil, fmt.Errorf("No install_requires found in setup.py")
+	}
+
+	listStart := match[2]
+	if contents[listStart] != '[' {
+		// install_requires refers to a variable; find the variable's literal list assignment
+		variable := contents[match[2]:match[3]]
+		if !wholeExpression(contents[match[3]:], true) {
+			return nil, fmt.Errorf("install_requires is not a literal list of strings")
+		}
+		assignRegexp := regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(variable) + `\s*=\s*\[`)
+		assign := assignRegexp.FindStringIndex(contents)
+		if assign == nil {
+			return nil, fmt.Errorf("install_requires variable %s is not assigned a literal list", variable)
+		}
+		listStart = assign[1] - 1
+	}
+
+	strs, end, err := pythonStringList(contents[listStart:])
+	if err != nil {
+		return nil, err
+	}
+	if !wholeExpression(contents[listStart+end:], listStart == match[2]) {
+		// e.g., install_requires=['flask'] + extra, of which only part is known
+		return nil, fmt.Errorf("install_requires is not a literal list of strings")
+	}
+	reqs := make([]*Requirement, 0, len(strs))
+	for _, reqStr := range strs {
+		if req, err := ParseRequirementLine(reqStr); err == nil {
+			reqs = append(reqs, req)
+		} else {
+			DefaultLogger.Logf("req", "Could not parse requirement: %s", err)
+		}
+	}
+	return reqs, nil
+}
+
+// Returns the string literals in the Python list literal at the start of src, and the offset of the end of the list. Fails if the list contains
+// anything other than string literals, commas, whitespace, and comments (e.g., a function call), since its value cannot be known without running
+// Python.
+func pythonStringList(src string) ([]string, int, error) {
+	strs := make([]string, 0)
+	for i := 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == ']':
+			return strs, i + 1, nil
+		case c == ',' || c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, 0, fmt.Errorf("Unterminated string in install_requires")
+			}
+			strs = append(strs, src[i+1:i+1+end])
+			i += end + 1
+		default:
+			return nil, 0, fmt.Errorf("install_requires is not a literal list of strings")
+		}
+	}
+	return nil, 0, fmt.Errorf("Unterminated install_requires list")
+}
+
+// Returns whether the term at the start of src, e.g., a list literal or a variable, is a whole expression: whether it is followed by the comma or
+// parenthesis that ends a keyword argument (arg), or by the end of the line of an assignment, rather than by an operator such as "+"
+func wholeExpression(src string, arg bool) bool {
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '\n':
+			if !arg {
+				return true
+			}
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i-- // the newline ends the comment
+		case arg && (c == ',' || c == ')'):
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
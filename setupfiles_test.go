@@ -0,0 +1,102 @@
+package cheerio
+
+import (
+	"github.com/kr/pretty"
+	"reflect"
+	"testing"
+)
+
+func TestParseSetupCfg(t *testing.T) {
+	reqs, err := ParseSetupCfg(`[metadata]
+name = example
+install_requires = notme
+
+[options]
+packages = find:
+install_requires =
+    requests>=2.0
+    # a comment
+    six
+    pywin32; sys_platform == "win32"
+zip_safe = False
+`)
+	expReqs := []*Requirement{
+		{Name: "requests", Constraint: ">=", Version: "2.0"},
+		{Name: "six"},
+		{Name: "pywin32", Marker: `sys_platform == "win32"`},
+	}
+	if err != nil {
+		t.Errorf("Error parsing setup.cfg: %s", err)
+	} else if !reflect.DeepEqual(reqs, expReqs) {
+		t.Errorf("Requirements do not match: %v", pretty.Diff(reqs, expReqs))
+	}
+}
+
+func TestParseSetupCfgSingleLine(t *testing.T) {
+	reqs, err := ParseSetupCfg("[options]\ninstall_requires = requests>=2.0; six\n")
+	expReqs := []*Requirement{
+		{Name: "requests", Constraint: ">=", Version: "2.0"},
+		{Name: "six"},
+	}
+	if err != nil {
+		t.Errorf("Error parsing setup.cfg: %s", err)
+	} else if !reflect.DeepEqual(reqs, expReqs) {
+		t.Errorf("Requirements do not match: %v", pretty.Diff(reqs, expReqs))
+	}
+}
+
+func TestParseSetupPy(t *testing.T) {
+	tests := []struct {
+		contents string
+		wantReqs []*Requirement
+		wantErr  bool
+	}{
+		{
+			contents: `setup(
+    name='example',
+    install_requires=[
+        'flask>=0.10',  # web
+        "itsdangerous",
+    ],
+)`,
+			wantReqs: []*Requirement{{Name: "flask", Constraint: ">=", Version: "0.10"}, {Name: "itsdangerous"}},
+		},
+		{
+			contents: `requires = ['httplib2==0.8']
+
+setup(name='example', install_requires=requires)`,
+			wantReqs: []*Requirement{{Name: "httplib2", Constraint: "==", Version: "0.8"}},
+		},
+		{
+			contents: `setup(name='example', install_requires=open('requirements.txt').read().split())`,
+			wantErr:  true,
+		},
+		{
+			contents: `setup(name='example', install_requires=['a'] + extra)`,
+			wantErr:  true,
+		},
+		{
+			contents: `requires = ['a'] + extra
+setup(name='example', install_requires=requires)`,
+			wantErr: true,
+		},
+		{
+			contents: `requires = ['a']
+setup(name='example', install_requires=requires + extra)`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		reqs, err := ParseSetupPy(test.contents)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Expected error parsing %q, got %v", test.contents, reqs)
+			}
+		} else if err != nil {
+			t.Errorf("Error parsing setup.py: %s", err)
+		} else if !reflect.DeepEqual(reqs, test.wantReqs) {
+			t.Errorf("Requirements do not match: %v", pretty.Diff(reqs, test.wantReqs))
+		}
+	}
+}
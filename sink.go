@@ -0,0 +1,157 @@
+package cheerio
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// A destination for crawl results, e.g., a file, a database table, or a message queue. Sinks can be passed results by a Crawl visitor,
+// which is never called concurrently, so implementations needn't be safe for concurrent use.
+type Sink interface {
+	// Stores the result of crawling one package
+	Write(res PackageResult) error
+
+	// Flushes buffered results and releases the resources of the sink
+	Close() error
+}
+
+// Writes results as newline-delimited JSON records (see PackageResult.MarshalJSON)
+type NDJSONSink struct {
+	enc *json.Encoder
+}
+
+func NewNDJSONSink(w io.Writer) *NDJSONSink {
+	return &NDJSONSink{enc: json.NewEncoder(w)}
+}
+
+func (s *NDJSONSink) Write(res PackageResult) error {
+	return s.enc.Encode(res)
+}
+
+func (s *NDJSONSink) Close() error {
+	return nil
+}
+
+// Writes results in the graph file format read by NewPyPIGraph, naming packages as they were published (NewPyPIGraph normalizes them). Packages
+// whose requirements couldn't be fetched are skipped.
+type GraphSink struct {
+	w io.Writer
+}
+
+func NewGraphSink(w io.Writer) *GraphSink {
+	return &GraphSink{w: w}
+}
+
+func (s *GraphSink) Write(res PackageResult) error {
+	if res.Err != nil {
+		return nil
+	}
+	if _, err := fmt.Fprintln(s.w, res.Pkg); err != nil {
+		return err
+	}
+	for _, req := range res.Requirements {
+		line := res.Pkg + ":" + req.Name
+		if req.Extra != "" {
+			line += ":" + req.Extra
+		}
+		if _, err := fmt.Fprintln(s.w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GraphSink) Close() error {
+	return nil
+}
+
+// Collects results into a graph, and writes it sorted and stamped when closed (see PyPIGraph.WriteStamped), so that crawls of identical data
+// produce identical output regardless of the order in which packages were crawled. Packages whose requirements couldn't be fetched are
+// skipped.
+type SortedGraphSink struct {
+	w     io.Writer
+	graph *PyPIGraph
+
+	// Provenance written in the header of the graph
+	Info *GraphInfo
+}
+
+func NewSortedGraphSink(w io.Writer) *SortedGraphSink {
+	return &SortedGraphSink{w: w, graph: newPyPIGraph()}
+}
+
+func (s *SortedGraphSink) Write(res PackageResult) error {
+	if res.Err == nil {
+		s.graph.info = s.Info // its ecosystem normalizes the names
+		s.graph.addRequirements(res.Pkg, res.Requirements)
+	}
+	return nil
+}
+
+func (s *SortedGraphSink) Close() error {
+	s.graph.SetInfo(s.Info)
+	return s.graph.WriteStamped(s.w)
+}
+
+// Writes results to a local file, or to an object in object storage
+type FileSink struct {
+	Sink
+	file io.WriteCloser
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+}
+
+// Creates (or truncates) a file and returns a sink writing to it: results are written as NDJSON records if the file name ends in ".ndjson" or
+// ".jsonl", and in the graph file format otherwise. Files whose name ends in ".gz" are gzip-compressed, e.g., "pypi_graph.gz". If path is an
+// s3:// or gs:// URL, the results are uploaded to object storage when the sink is closed (see ObjectStore).
+func CreateFileSink(path string) (*FileSink, error) {
+	file, err := createFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileSink{file: file}
+	name := strings.ToLower(path)
+	if strings.HasSuffix(name, ".gz") {
+		name = strings.TrimSuffix(name, ".gz")
+		s.gz = gzip.NewWriter(file)
+		s.buf = bufio.NewWriter(s.gz)
+	} else {
+		s.buf = bufio.NewWriter(file)
+	}
+	buf := s.buf
+	switch filepath.Ext(name) {
+	case ".ndjson", ".jsonl":
+		s.Sink = NewNDJSONSink(buf)
+	default:
+		s.Sink = NewGraphSink(buf)
+	}
+	return s, nil
+}
+
+func (s *FileSink) Close() error {
+	if err := s.buf.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	if s.gz != nil {
+		if err := s.gz.Close(); err != nil {
+			s.file.Close()
+			return err
+		}
+	}
+	return s.file.Close()
+}
+
+// Opens the sink described by dest: a Kafka topic behind a REST proxy if dest is a URL like "kafka+http://localhost:8082/topics/cheerio"
+// (see KafkaRESTSink), and otherwise a local file or object (see CreateFileSink). PostgreSQL sinks need a database handle; see NewPostgresSink.
+func OpenSink(dest string) (Sink, error) {
+	if strings.HasPrefix(dest, "kafka+http://") || strings.HasPrefix(dest, "kafka+https://") {
+		return NewKafkaRESTSink(strings.TrimPrefix(dest, "kafka+"))
+	}
+	return CreateFileSink(dest)
+}
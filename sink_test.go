@@ -0,0 +1,270 @@
+package cheerio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sinkResults = []PackageResult{
+	{Pkg: "Flask", Requirements: []*Requirement{{Name: "Werkzeug"}, {Name: "python-dotenv", Extra: "dotenv"}}},
+	{Pkg: "broken", Err: fmt.Errorf("No file matched pattern")},
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio-sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		file string
+		exp  string
+	}{
+		{"graph.txt", "Flask\nFlask:Werkzeug\nFlask:python-dotenv:dotenv\n"},
+		{"results.ndjson", `{"Pkg":"Flask","Requirements":[{"Name":"Werkzeug","Constraint":"","Version":""},` +
+			`{"Name":"python-dotenv","Constraint":"","Version":"","Extra":"dotenv"}],"Started":"0001-01-01T00:00:00Z","DurationMs":0}` + "\n" +
+			`{"Pkg":"broken","Error":"No file matched pattern","Started":"0001-01-01T00:00:00Z","DurationMs":0}` + "\n"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, test.file)
+		sink, err := OpenSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range sinkResults {
+			if err := sink.Write(res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.exp {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.file, test.exp, b)
+		}
+	}
+}
+
+func TestKafkaRESTSink(t *testing.T) {
+	var batches [][]string
+	failures := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/topics/crawl" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Records []struct {
+				Key   string
+				Value struct{ Pkg string }
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var keys []string
+		for _, rec := range body.Records {
+			if rec.Key != rec.Value.Pkg {
+				http.Error(w, "key mismatch", http.StatusBadRequest)
+				return
+			}
+			keys = append(keys, rec.Key)
+		}
+		batches = append(batches, keys)
+		fmt.Fprint(w, `{"offsets":[]}`)
+	}))
+	defer server.Close()
+
+	if _, err := OpenSink("kafka+" + server.URL + "/crawl"); err == nil {
+		t.Errorf("expected error for URL without topic")
+	}
+	sink, err := OpenSink("kafka+" + server.URL + "/topics/crawl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.(*KafkaRESTSink).BatchSize = 2
+	for _, pkg := range []string{"a", "b", "c"} {
+		if err := sink.Write(PackageResult{Pkg: pkg, Started: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(batches) != "[[a b] [c]]" {
+		t.Errorf("expected batches [[a b] [c]], got %v", batches)
+	}
+
+	// a batch that fails to be sent is sent again with the next one
+	batches, failures = nil, 1
+	sink, _ = OpenSink("kafka+" + server.URL + "/topics/crawl")
+	sink.(*KafkaRESTSink).BatchSize = 2
+	for _, pkg := range []string{"a", "b"} {
+		sink.Write(PackageResult{Pkg: pkg, Started: time.Now()})
+	}
+	if err := sink.Write(PackageResult{Pkg: "c", Started: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(batches) != "[[a b c]]" {
+		t.Errorf("expected the failed batch to be resent, got batches %v", batches)
+	}
+}
+
+// A database/sql driver recording the statements executed through it
+type fakeSQLConnector struct {
+	execs []fakeSQLExec
+}
+
+type fakeSQLExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (c *fakeSQLConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeSQLConn{c}, nil
+}
+func (c *fakeSQLConnector) Driver() driver.Driver { return nil }
+
+type fakeSQLConn struct{ connector *fakeSQLConnector }
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{c.connector, query}, nil
+}
+func (c *fakeSQLConn) Close() error { return nil }
+func (c *fakeSQLConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+type fakeSQLStmt struct {
+	connector *fakeSQLConnector
+	query     string
+}
+
+func (s *fakeSQLStmt) Close() error  { return nil }
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.connector.execs = append(s.connector.execs, fakeSQLExec{query: s.query, args: args})
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("queries are not supported")
+}
+
+func TestPostgresSink(t *testing.T) {
+	connector := &fakeSQLConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	if _, err := NewPostgresSink(db, "results; DROP TABLE results"); err == nil {
+		t.Errorf("expected error for invalid table name")
+	}
+	sink, err := NewPostgresSink(db, "crawl.results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range sinkResults {
+		if err := sink.Write(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(connector.execs) != 3 || !strings.HasPrefix(connector.execs[0].query, "CREATE TABLE IF NOT EXISTS crawl.results (") {
+		t.Fatalf("expected a CREATE TABLE and two inserts, got %v", connector.execs)
+	}
+	expArgs := [][]driver.Value{
+		{"Flask", `[{"Name":"Werkzeug","Constraint":"","Version":""},{"Name":"python-dotenv","Constraint":"","Version":"","Extra":"dotenv"}]`, nil,
+			time.Time{}, int64(0)},
+		{"broken", nil, "No file matched pattern", time.Time{}, int64(0)},
+	}
+	for i, exp := range expArgs {
+		insert := connector.execs[i+1]
+		if !strings.HasPrefix(insert.query, "INSERT INTO crawl.results ") {
+			t.Errorf("expected an insert, got %s", insert.query)
+		}
+		if !reflect.DeepEqual(insert.args, exp) {
+			t.Errorf("insert of %s: expected args %v, got %v", exp[0], exp, insert.args)
+		}
+	}
+}
+
+func TestSortedGraphSink(t *testing.T) {
+	results := []PackageResult{
+		{Pkg: "Flask", Requirements: []*Requirement{{Name: "Werkzeug"}, {Name: "Jinja2"}, {Name: "python-dotenv", Extra: "dotenv"}}},
+		{Pkg: "broken", Err: fmt.Errorf("No file matched pattern")},
+		{Pkg: "app", Requirements: []*Requirement{{Name: "flask"}}},
+	}
+	write := func(order []int) string {
+		var buf bytes.Buffer
+		sink := NewSortedGraphSink(&buf)
+		for _, i := range order {
+			if err := sink.Write(results[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	out := write([]int{0, 1, 2})
+	if other := write([]int{2, 1, 0}); other != out {
+		t.Errorf("output depends on crawl order:\n%s\nvs\n%s", out, other)
+	}
+	body := "app\napp:Flask\nFlask\nFlask:Jinja2\nFlask:Werkzeug\nFlask:python-dotenv:dotenv\n"
+	exp := fmt.Sprintf("# cheerio-graph: 1\n# edges: 4\n# packages: 2\n# sha256: %x\n%s", sha256.Sum256([]byte(body)), body)
+	if out != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, out)
+	}
+
+	dir, err := ioutil.TempDir("", "cheerio-graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graph")
+	if err := ioutil.WriteFile(path, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	graph, err := NewPyPIGraph(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, in := graph.Req["# packages: 2"]; in || len(graph.Requires("flask")) != 3 {
+		t.Errorf("unexpected graph read from stamped output: %v", graph.Req)
+	}
+	if info := graph.Info(); info == nil || info.FormatVersion != GraphFormatVersion || info.Fields["packages"] != "2" {
+		t.Errorf("unexpected graph info %+v", info)
+	}
+}
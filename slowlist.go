@@ -0,0 +1,80 @@
+package cheerio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Returns whether err reports that a package or request exceeded its deadline
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// A file listing the packages that exceeded the deadlines of crawls (see CrawlOptions.SlowList), one per line in the format of
+// LoadPackageList, e.g., to skip them in later crawls and retry them separately with longer deadlines. Packages are appended as they time
+// out, so the list survives interrupted crawls. A SlowList is safe for concurrent use.
+type SlowList struct {
+	mu   sync.Mutex
+	file *os.File
+	pkgs map[string]bool
+}
+
+// Opens the slow list in file, creating it if it doesn't exist
+func OpenSlowList(file string) (*SlowList, error) {
+	l := &SlowList{pkgs: make(map[string]bool)}
+	if pkgs, err := LoadPackageList(file); err == nil {
+		for _, pkg := range pkgs {
+			l.pkgs[NormalizedPkgName(pkg)] = true
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l.file = f
+	return l, nil
+}
+
+// Adds pkg to the list, unless it's already listed
+func (l *SlowList) Add(pkg string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if name := NormalizedPkgName(pkg); !l.pkgs[name] {
+		l.pkgs[name] = true
+		if _, err := fmt.Fprintln(l.file, pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns whether pkg is listed
+func (l *SlowList) Contains(pkg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pkgs[NormalizedPkgName(pkg)]
+}
+
+// Returns the listed packages, normalized and sorted
+func (l *SlowList) Packages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pkgs := make([]string, 0, len(l.pkgs))
+	for pkg := range l.pkgs {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+func (l *SlowList) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,129 @@
+package cheerio
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SPDX checksum algorithm names, keyed by the hash names used in Artifact.Digests
+var spdxChecksumAlgorithms = map[string]string{
+	"md5":    "MD5",
+	"sha1":   "SHA1",
+	"sha224": "SHA224",
+	"sha256": "SHA256",
+	"sha384": "SHA384",
+	"sha512": "SHA512",
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+var spdxIDInvalidRegexp = regexp.MustCompile(`[^A-Za-z0-9\.\-]`)
+
+// Returns the SPDX element ID of a package
+func spdxPackageID(pkg string) string {
+	return "SPDXRef-Package-" + spdxIDInvalidRegexp.ReplaceAllString(pkg, "-")
+}
+
+// Writes the SBOM as an SPDX 2.3 JSON document. The document DESCRIBES the root packages, and each package DEPENDS_ON the packages it requires.
+func (s *SBOM) WriteSPDX(w io.Writer) error {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              s.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/cheerio-%s-%x", spdxIDInvalidRegexp.ReplaceAllString(s.Name, "-"), sha1.Sum([]byte(s.Name+s.Created.String()))),
+		CreationInfo: spdxCreationInfo{
+			Created:  s.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: cheerio"},
+		},
+		Packages:      make([]spdxPackage, 0, len(s.Packages)),
+		Relationships: make([]spdxRelationship, 0),
+	}
+
+	for _, root := range s.Roots {
+		doc.Relationships = append(doc.Relationships, spdxRelationship{"SPDXRef-DOCUMENT", "DESCRIBES", spdxPackageID(root)})
+	}
+	for _, pkg := range s.Packages {
+		spdxPkg := spdxPackage{
+			SPDXID:           spdxPackageID(pkg.Name),
+			Name:             pkg.Name,
+			VersionInfo:      pkg.Version,
+			DownloadLocation: spdxValue(pkg.DownloadURL),
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  spdxValue(pkg.License),
+			CopyrightText:    "NOASSERTION",
+			ExternalRefs:     []spdxExternalRef{{"PACKAGE-MANAGER", "purl", pkg.PURL()}},
+		}
+		for _, algorithm := range sortedStringKeys(pkg.Checksums) {
+			if spdxAlgorithm, in := spdxChecksumAlgorithms[algorithm]; in {
+				spdxPkg.Checksums = append(spdxPkg.Checksums, spdxChecksum{spdxAlgorithm, strings.ToLower(pkg.Checksums[algorithm])})
+			}
+		}
+		doc.Packages = append(doc.Packages, spdxPkg)
+
+		for _, dep := range pkg.Requires {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{spdxPkg.SPDXID, "DEPENDS_ON", spdxPackageID(dep)})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Returns value, or NOASSERTION if it is empty
+func spdxValue(value string) string {
+	if value == "" {
+		return "NOASSERTION"
+	}
+	return value
+}
@@ -0,0 +1,82 @@
+package cheerio
+
+import (
+	"strings"
+)
+
+// Identifiers of the SPDX License List (https://spdx.org/licenses/) found in package metadata, including deprecated ones such as "GPL-2.0".
+// Keys are lowercased, as SPDX identifiers are matched case-insensitively.
+var spdxLicenseIDs = lowercaseSet(
+	"0BSD", "AAL", "AFL-1.1", "AFL-1.2", "AFL-2.0", "AFL-2.1", "AFL-3.0", "AGPL-1.0", "AGPL-1.0-only", "AGPL-1.0-or-later", "AGPL-3.0",
+	"AGPL-3.0-only", "AGPL-3.0-or-later", "AML", "AMPAS", "ANTLR-PD", "APAFML", "APL-1.0", "APSL-1.0", "APSL-1.1", "APSL-1.2", "APSL-2.0",
+	"Apache-1.0", "Apache-1.1", "Apache-2.0", "Artistic-1.0", "Artistic-1.0-Perl", "Artistic-1.0-cl8", "Artistic-2.0", "BSD-1-Clause",
+	"BSD-2-Clause", "BSD-2-Clause-FreeBSD", "BSD-2-Clause-NetBSD", "BSD-2-Clause-Patent", "BSD-2-Clause-Views", "BSD-3-Clause",
+	"BSD-3-Clause-Attribution", "BSD-3-Clause-Clear", "BSD-3-Clause-LBNL", "BSD-3-Clause-Modification", "BSD-3-Clause-No-Nuclear-License",
+	"BSD-3-Clause-No-Nuclear-Warranty", "BSD-3-Clause-Open-MPI", "BSD-4-Clause", "BSD-4-Clause-UC", "BSD-Protection", "BSD-Source-Code",
+	"BSL-1.0", "BUSL-1.1", "Beerware", "BlueOak-1.0.0", "CAL-1.0", "CATOSL-1.1", "CC-BY-1.0", "CC-BY-2.0", "CC-BY-2.5", "CC-BY-3.0",
+	"CC-BY-4.0", "CC-BY-NC-4.0", "CC-BY-NC-ND-4.0", "CC-BY-NC-SA-4.0", "CC-BY-ND-4.0", "CC-BY-SA-3.0", "CC-BY-SA-4.0", "CC-PDDC", "CC0-1.0",
+	"CDDL-1.0", "CDDL-1.1", "CDLA-Permissive-1.0", "CDLA-Permissive-2.0", "CDLA-Sharing-1.0", "CECILL-1.0", "CECILL-1.1", "CECILL-2.0",
+	"CECILL-2.1", "CECILL-B", "CECILL-C", "CNRI-Jython", "CNRI-Python", "CNRI-Python-GPL-Compatible", "CPAL-1.0", "CPL-1.0", "CUA-OPL-1.0",
+	"ECL-1.0", "ECL-2.0", "EFL-1.0", "EFL-2.0", "EPL-1.0", "EPL-2.0", "EUDatagrid", "EUPL-1.0", "EUPL-1.1", "EUPL-1.2", "Entessa", "Fair",
+	"Frameworx-1.0", "FSFAP", "FSFUL", "FSFULLR", "FTL", "GFDL-1.1", "GFDL-1.1-only", "GFDL-1.1-or-later", "GFDL-1.2", "GFDL-1.2-only",
+	"GFDL-1.2-or-later", "GFDL-1.3", "GFDL-1.3-only", "GFDL-1.3-or-later", "GPL-1.0", "GPL-1.0+", "GPL-1.0-only", "GPL-1.0-or-later",
+	"GPL-2.0", "GPL-2.0+", "GPL-2.0-only", "GPL-2.0-or-later", "GPL-2.0-with-classpath-exception", "GPL-3.0", "GPL-3.0+", "GPL-3.0-only",
+	"GPL-3.0-or-later", "GPL-3.0-with-GCC-exception", "HPND", "ICU", "IJG", "IPA", "IPL-1.0", "ISC", "Imlib2", "Intel", "JSON", "LAL-1.2",
+	"LAL-1.3", "LGPL-2.0", "LGPL-2.0+", "LGPL-2.0-only", "LGPL-2.0-or-later", "LGPL-2.1", "LGPL-2.1+", "LGPL-2.1-only", "LGPL-2.1-or-later",
+	"LGPL-3.0", "LGPL-3.0+", "LGPL-3.0-only", "LGPL-3.0-or-later", "LGPLLR", "LPL-1.0", "LPL-1.02", "LPPL-1.3c", "LiLiQ-P-1.1",
+	"LiLiQ-R-1.1", "LiLiQ-Rplus-1.1", "Libpng", "MIT", "MIT-0", "MIT-CMU", "MIT-Modern-Variant", "MIT-advertising", "MIT-enna", "MIT-feh",
+	"MITNFA", "MPL-1.0", "MPL-1.1", "MPL-2.0", "MPL-2.0-no-copyleft-exception", "MS-PL", "MS-RL", "MirOS", "Motosoto", "MulanPSL-1.0",
+	"MulanPSL-2.0", "Multics", "NASA-1.3", "NCSA", "NGPL", "NLPL", "NPOSL-3.0", "NTP", "Naumen", "Nokia", "OCLC-2.0", "ODbL-1.0", "OFL-1.0",
+	"OFL-1.1", "OGTSL", "OLDAP-2.8", "OPL-1.0", "OSET-PL-2.1", "OSL-1.0", "OSL-1.1", "OSL-2.0", "OSL-2.1", "OSL-3.0", "OpenSSL", "PDDL-1.0",
+	"PHP-3.0", "PHP-3.01", "PSF-2.0", "PostgreSQL", "Python-2.0", "Python-2.0.1", "QPL-1.0", "RPL-1.1", "RPL-1.5", "RPSL-1.0", "RSCPL",
+	"Ruby", "SGI-B-2.0", "SISSL", "SMLNJ", "SPL-1.0", "SSPL-1.0", "Sleepycat", "TCL", "UCL-1.0", "UPL-1.0", "Unicode-3.0", "Unicode-DFS-2015",
+	"Unicode-DFS-2016", "Unlicense", "VSL-1.0", "Vim", "W3C", "W3C-20150513", "WTFPL", "Watcom-1.0", "X11", "XFree86-1.1", "Xnet", "YPL-1.1",
+	"ZPL-1.1", "ZPL-2.0", "ZPL-2.1", "Zend-2.0", "Zlib", "curl", "libpng-2.0", "libtiff", "wxWindows", "zlib-acknowledgement",
+)
+
+// Identifiers of the SPDX License Exceptions that may follow "WITH" in an SPDX license expression
+var spdxLicenseExceptions = lowercaseSet(
+	"389-exception", "Autoconf-exception-2.0", "Autoconf-exception-3.0", "Bison-exception-2.2", "Bootloader-exception", "CLISP-exception-2.0",
+	"Classpath-exception-2.0", "FLTK-exception", "Font-exception-2.0", "GCC-exception-2.0", "GCC-exception-3.1", "GPL-3.0-linking-exception",
+	"GPL-3.0-linking-source-exception", "GPL-CC-1.0", "LGPL-3.0-linking-exception", "LLVM-exception", "LZMA-exception", "Libtool-exception",
+	"Linux-syscall-note", "OCaml-LGPL-linking-exception", "OpenJDK-assembly-exception-1.0", "Qt-GPL-exception-1.0", "Qt-LGPL-exception-1.1",
+	"Swift-exception", "Universal-FOSS-exception-1.0", "WxWindows-exception-3.1", "eCos-exception-2.0", "freertos-exception-2.0",
+	"gnu-javamail-exception", "openvpn-openssl-exception", "u-boot-exception-2.0",
+)
+
+func lowercaseSet(ids ...string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[strings.ToLower(id)] = true
+	}
+	return set
+}
+
+// Returns whether expr is an SPDX license expression whose licenses and exceptions are all on the SPDX lists, e.g., "MIT OR Apache-2.0" or
+// "GPL-2.0-or-later WITH Classpath-exception-2.0", but not free text that merely looks like one, e.g., "BSD-like". License references
+// ("LicenseRef-...") are accepted.
+func isSPDXExpression(expr string) bool {
+	if !spdxExpressionRegexp.MatchString(expr) {
+		return false
+	}
+	exception := false
+	for _, token := range strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(expr)) {
+		switch {
+		case token == "AND" || token == "OR":
+		case token == "WITH":
+			exception = true
+		case exception:
+			if !spdxLicenseExceptions[strings.ToLower(token)] {
+				return false
+			}
+			exception = false
+		case strings.HasPrefix(token, "LicenseRef-"):
+		default:
+			id := strings.ToLower(token)
+			if !spdxLicenseIDs[id] && !spdxLicenseIDs[strings.TrimSuffix(id, "+")] {
+				return false
+			}
+		}
+	}
+	return true
+}
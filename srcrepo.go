@@ -3,49 +3,165 @@ package cheerio
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
-var homepageRegexp = regexp.MustCompile(`Home-page: (.+)\n`)
 var repoPatterns = []*regexp.Regexp{
-	regexp.MustCompile(`Home-page: (https?://github.com/(:?[^/\n\r]+)/(:?[^/\n\r]+))(:?/.*)?\s`),
-	regexp.MustCompile(`Home-page: (https?://bitbucket.org/(:?[^/\n\r]+)/(:?[^/\n\r]+))(:?/.*)?\s`),
-	regexp.MustCompile(`Home-page: (https?://code.google.com/p/(:?[^/\n\r]+))(:?/.*)?\s`),
+	regexp.MustCompile(`^(https?://github.com/(:?[^/\n\r]+)/(:?[^/\n\r]+))(:?/.*)?$`),
+	regexp.MustCompile(`^(https?://bitbucket.org/(:?[^/\n\r]+)/(:?[^/\n\r]+))(:?/.*)?$`),
+	regexp.MustCompile(`^(https?://code.google.com/p/(:?[^/\n\r]+))(:?/.*)?$`),
+
+	// GitLab (gitlab.com and self-hosted instances on gitlab.* hosts), whose projects may be nested in subgroups. Project pages end where GitLab's
+	// "/-/" route separator begins.
+	regexp.MustCompile(`^(https?://(?:www\.)?gitlab\.[A-Za-z0-9\.\-]+/[^/#?]+/[^#?]+?)(?:\.git)?/?(?:/-/.*)?(?:[#?].*)?$`),
+
+	// sourcehut
+	regexp.MustCompile(`^(https?://(?:git|hg)\.sr\.ht/~[^/#?]+/[^/#?]+)(?:[/#?].*)?$`),
+
+	// Codeberg and Gitea instances
+	regexp.MustCompile(`^(https?://(?:www\.)?(?:codeberg\.org|gitea\.com|gitea\.[A-Za-z0-9\.\-]+)/[^/#?]+/[^/#?]+)(?:[/#?].*)?$`),
 }
 
 var pkgInfoPattern = regexp.MustCompile(`(?:[^/]+/)*PKG\-INFO`)
 
+// Project-URL labels that may point to a source repository, most specific first
+var repoProjectURLLabels = []string{"source", "source code", "repository", "code"}
+
 // Returns the source repository URL for a given PyPI package. This information is not explicitly specified anywhere in PyPI metadata, so try to infer
-// it by doing the following: First, check if it is hardcoded below. If not, then fetch the metadata from the PyPI server and check if the website
-// (specified in the metdata) pattern matches a repository URL.
+// it by doing the following: First, check for a user-supplied override (see LoadRepoOverrides). If there is none, fetch the metadata from the PyPI
+// server and check if a source-like Project-URL (e.g., "Source" or "Repository"), the website, or a "Homepage" Project-URL (specified in the
+// metadata) pattern matches a repository URL. If not, check if it is hardcoded below. As a last resort, check whether any other Project-URL (e.g.,
+// an issue tracker) or the download URL points into a repository.
 func (p *PackageIndex) FetchSourceRepoURL(pkg string) (string, error) {
-	b, err := p.FetchRawMetadata(pkg, pkgInfoPattern, pkgInfoPattern, pkgInfoPattern)
+	repoURL, _, err := p.detectSourceRepo(pkg)
+	return repoURL, err
+}
+
+// Where a source repository URL was found
+type RepoSource string
+
+const (
+	RepoFromOverride   RepoSource = "override"    // user-supplied override file
+	RepoFromProjectURL RepoSource = "project-url" // a source-like Project-URL, e.g., "Source" or "Repository"
+	RepoFromHomePage   RepoSource = "home-page"   // the Home-page field or a "Homepage" Project-URL
+	RepoFromCurated    RepoSource = "curated"     // the hard-coded pypiRepos map
+	RepoFromHeuristic  RepoSource = "heuristic"   // another URL in the metadata that points into a repository
+)
+
+// Base scores (between 0 and 1) expressing how likely a URL from each source is to be the package's actual source repository
+var repoSourceScores = map[RepoSource]float64{
+	RepoFromOverride:   1.0,
+	RepoFromProjectURL: 0.9,
+	RepoFromHomePage:   0.8,
+	RepoFromCurated:    0.7,
+	RepoFromHeuristic:  0.4,
+}
+
+// Implements FetchSourceRepoURL, also returning where the URL was found
+func (p *PackageIndex) detectSourceRepo(pkg string) (string, RepoSource, error) {
+	if overrideURL, in := repoOverrides[NormalizedPkgName(pkg)]; in {
+		return overrideURL, RepoFromOverride, nil
+	}
+
+	metadata, err := p.FetchMetadata(pkg)
 	if err != nil {
 		// Try to fall back to hard-coded URLs
 		if hardURL, in := pypiRepos[NormalizedPkgName(pkg)]; in {
-			return hardURL, nil
+			return hardURL, RepoFromCurated, nil
 		} else {
-			return "", err
+			return "", "", err
 		}
 	}
-	rawMetadata := string(b)
 
 	// Check PyPI
-	for _, pattern := range repoPatterns {
-		if match := pattern.FindStringSubmatch(rawMetadata); len(match) >= 1 {
-			return match[1], nil
-		}
+	candidates := repoURLCandidates(metadata)
+	if repoURL, source := matchRepoURL(candidates); repoURL != "" && source != RepoFromHeuristic {
+		return repoURL, source, nil
 	}
 
 	// Try to fall back to hard-coded URLs
 	if hardURL, in := pypiRepos[NormalizedPkgName(pkg)]; in {
-		return hardURL, nil
+		return hardURL, RepoFromCurated, nil
+	}
+
+	if repoURL, source := matchRepoURL(candidates); repoURL != "" {
+		return repoURL, source, nil
 	}
 
 	// Return most informative error
-	if match := homepageRegexp.FindStringSubmatch(rawMetadata); len(match) >= 1 {
-		return "", fmt.Errorf("Could not parse repo URL from homepage: %s", match[1])
+	if metadata.HomePage != "" {
+		return "", "", fmt.Errorf("Could not parse repo URL from homepage: %s", metadata.HomePage)
+	}
+	return "", "", fmt.Errorf("No homepage found in metadata for pkg %s", pkg)
+}
+
+// A URL from package metadata that may be a source repository URL
+type repoCandidate struct {
+	url    string
+	source RepoSource
+}
+
+// Returns the repository URL matched by the first candidate URL that matches a repository pattern and where the candidate came from, or "" if none
+// match
+func matchRepoURL(candidates []repoCandidate) (string, RepoSource) {
+	for _, candidate := range candidates {
+		for _, pattern := range repoPatterns {
+			if match := pattern.FindStringSubmatch(strings.TrimSpace(candidate.url)); len(match) >= 1 {
+				return trimRepoURL(match[1]), candidate.source
+			}
+		}
+	}
+	return "", ""
+}
+
+// Strips URL fragments, query strings, trailing slashes, the ".git" suffix, and a leading "www." from a matched repository URL
+func trimRepoURL(repoURL string) string {
+	if i := strings.IndexAny(repoURL, "#?"); i >= 0 {
+		repoURL = repoURL[:i]
+	}
+	repoURL = strings.TrimSuffix(strings.TrimRight(repoURL, "/"), ".git")
+	return strings.Replace(repoURL, "://www.", "://", 1)
+}
+
+// Returns the URLs in metadata that may be source repository URLs, in the order they should be tried
+func repoURLCandidates(metadata *Metadata) []repoCandidate {
+	labeled := make(map[string]string)
+	for label, url := range metadata.ProjectURLs {
+		labeled[strings.ToLower(label)] = url
+	}
+
+	var candidates []repoCandidate
+	for _, label := range repoProjectURLLabels {
+		if url, in := labeled[label]; in {
+			candidates = append(candidates, repoCandidate{url, RepoFromProjectURL})
+			delete(labeled, label)
+		}
+	}
+	if metadata.HomePage != "" {
+		candidates = append(candidates, repoCandidate{metadata.HomePage, RepoFromHomePage})
+	}
+	if url, in := labeled["homepage"]; in {
+		candidates = append(candidates, repoCandidate{url, RepoFromHomePage})
+		delete(labeled, "homepage")
+	}
+
+	// Any remaining URL, e.g., "Bug Tracker, https://github.com/org/repo/issues", may point into the repository
+	for _, label := range sortedStringKeys(labeled) {
+		candidates = append(candidates, repoCandidate{labeled[label], RepoFromHeuristic})
+	}
+	if metadata.DownloadURL != "" {
+		candidates = append(candidates, repoCandidate{metadata.DownloadURL, RepoFromHeuristic})
+	}
+	return candidates
+}
+
+// Keys pypiRepos by normalized package names
+func init() {
+	normalized := make(map[string]string, len(pypiRepos))
+	for pkg, repoURL := range pypiRepos {
+		normalized[NormalizedPkgName(pkg)] = repoURL
 	}
-	return "", fmt.Errorf("No homepage found in metadata: %s", rawMetadata)
+	pypiRepos = normalized
 }
 
 var pypiRepos = map[string]string{
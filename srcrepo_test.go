@@ -24,3 +24,52 @@ func TestFetchSourceRepoURL(t *testing.T) {
 		}
 	}
 }
+
+func TestRepoURLCandidates(t *testing.T) {
+	metadata := ParseMetadata(`Metadata-Version: 2.1
+Name: example
+Home-page: https://example.readthedocs.io
+Project-URL: Homepage, https://example.org
+Project-URL: Source Code, https://github.com/example/example
+`)
+
+	repoURL, source := matchRepoURL(repoURLCandidates(metadata))
+	if want := "https://github.com/example/example"; repoURL != want || source != RepoFromProjectURL {
+		t.Errorf("want repoURL == %q from %s, got %q from %s", want, RepoFromProjectURL, repoURL, source)
+	}
+
+	metadata = ParseMetadata(`Metadata-Version: 2.1
+Name: example
+Home-page: https://example.readthedocs.io
+Project-URL: Bug Tracker, https://github.com/example/example/issues
+`)
+	repoURL, source = matchRepoURL(repoURLCandidates(metadata))
+	if want := "https://github.com/example/example"; repoURL != want || source != RepoFromHeuristic {
+		t.Errorf("want repoURL == %q from %s, got %q from %s", want, RepoFromHeuristic, repoURL, source)
+	}
+}
+
+func TestMatchRepoURL(t *testing.T) {
+	tests := []struct {
+		url         string
+		wantRepoURL string
+	}{
+		{"https://github.com/mitsuhiko/flask", "https://github.com/mitsuhiko/flask"},
+		{"https://github.com/mitsuhiko/flask.git", "https://github.com/mitsuhiko/flask"},
+		{"https://github.com/mitsuhiko/flask#readme", "https://github.com/mitsuhiko/flask"},
+		{"https://gitlab.com/pycqa/flake8", "https://gitlab.com/pycqa/flake8"},
+		{"https://gitlab.com/group/subgroup/project/-/tree/master", "https://gitlab.com/group/subgroup/project"},
+		{"https://www.gitlab.com/group/project.git/", "https://gitlab.com/group/project"},
+		{"https://gitlab.gnome.org/GNOME/pygobject", "https://gitlab.gnome.org/GNOME/pygobject"},
+		{"https://git.sr.ht/~sircmpwn/hut/tree", "https://git.sr.ht/~sircmpwn/hut"},
+		{"https://codeberg.org/forgejo/forgejo/issues", "https://codeberg.org/forgejo/forgejo"},
+		{"https://gitea.example.org/org/repo", "https://gitea.example.org/org/repo"},
+		{"https://example.readthedocs.io", ""},
+	}
+
+	for _, test := range tests {
+		if repoURL, _ := matchRepoURL([]repoCandidate{{test.url, RepoFromHomePage}}); repoURL != test.wantRepoURL {
+			t.Errorf("%s: want repoURL == %q, got %q", test.url, test.wantRepoURL, repoURL)
+		}
+	}
+}
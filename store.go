@@ -0,0 +1,243 @@
+package cheerio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The crawl result of a package kept in a ResultStore
+type StoredPackage struct {
+	Pkg          string
+	Requirements []*Requirement     `json:",omitempty"`
+	Source       RequirementsSource `json:",omitempty"` // where the requirements were read from, if known
+	Err          string             `json:",omitempty"` // why the requirements couldn't be fetched, if they couldn't
+	Info         *PackageInfo       `json:",omitempty"`
+	RepoURL      string             `json:",omitempty"`
+	Fetched      time.Time
+}
+
+// An embedded key-value store persisting the crawl results of packages in a local file, so crawls can be resumed or refreshed incrementally
+// (see Stale) and queried without re-parsing graph files (see Graph). Records are appended to the file as JSON lines as they are written, and
+// the last record of a package wins; Compact rewrites the file with only the latest records. A store is safe for concurrent use, and implements
+// Sink.
+type ResultStore struct {
+	mu        sync.Mutex
+	path      string
+	ecosystem string // whose naming rules normalize the keys of pkgs
+	file      *os.File
+	buf       *bufio.Writer
+	pkgs      map[string]*StoredPackage // keyed by normalized package name
+}
+
+// Opens the store of PyPI packages in file path, creating it if it doesn't exist. A truncated last record, e.g., from a crash, is ignored, and cut
+// off the file so that new records start on a line of their own.
+func OpenResultStore(path string) (*ResultStore, error) {
+	return OpenEcosystemResultStore(path, "pypi")
+}
+
+// Like OpenResultStore, for the packages of an ecosystem (see Ecosystems), whose names are normalized by its naming rules (see
+// NormalizedEcosystemPkgName)
+func OpenEcosystemResultStore(path, ecosystem string) (*ResultStore, error) {
+	s := &ResultStore{path: path, ecosystem: ecosystem, pkgs: make(map[string]*StoredPackage)}
+	size, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.file, s.buf = file, bufio.NewWriter(file)
+	return s, nil
+}
+
+// Reads the records of the store file, returning the size of its complete records. As every record ends with a newline, a last line without one
+// is a truncated record.
+func (s *ResultStore) load() (int64, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var size int64
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return size, nil
+		} else if err != nil {
+			return 0, err
+		}
+		var rec StoredPackage
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return 0, fmt.Errorf("Corrupt record on line %d of result store %s: %s", lineNo, s.path, err)
+		}
+		s.pkgs[NormalizedEcosystemPkgName(s.ecosystem, rec.Pkg)] = &rec
+		size += int64(len(line))
+	}
+}
+
+// Returns the stored result of a package
+func (s *ResultStore) Get(pkg string) (*StoredPackage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, in := s.pkgs[NormalizedEcosystemPkgName(s.ecosystem, pkg)]
+	return rec, in
+}
+
+// Stores the result of a package, replacing any previous one. The record is buffered until Flush or Close.
+func (s *ResultStore) Put(rec *StoredPackage) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.buf.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	s.pkgs[NormalizedEcosystemPkgName(s.ecosystem, rec.Pkg)] = rec
+	return nil
+}
+
+// Stores a crawl result, with its package info and repository URL if it has them (see CrawlOptions.Metadata); otherwise, those previously stored
+// for the package are kept
+func (s *ResultStore) Write(res PackageResult) error {
+	rec := &StoredPackage{Pkg: res.Pkg, Requirements: res.Requirements, Source: res.Source, Info: res.Info, RepoURL: res.RepoURL, Fetched: res.Started}
+	if res.Err != nil {
+		rec.Err = res.Err.Error()
+	}
+	if rec.Fetched.IsZero() {
+		rec.Fetched = time.Now()
+	}
+	if prev, in := s.Get(res.Pkg); in {
+		if rec.Info == nil {
+			rec.Info = prev.Info
+		}
+		if rec.RepoURL == "" {
+			rec.RepoURL = prev.RepoURL
+		}
+	}
+	return s.Put(rec)
+}
+
+// Returns the sorted names of the stored packages
+func (s *ResultStore) Packages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pkgs := make([]string, 0, len(s.pkgs))
+	for _, rec := range s.pkgs {
+		pkgs = append(pkgs, rec.Pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// Returns the packages of pkgs that aren't stored, or were fetched more than maxAge ago, i.e., those an incremental crawl has to fetch. Failed
+// fetches are retried regardless of their age if retryErrors is set.
+func (s *ResultStore) Stale(pkgs []string, maxAge time.Duration, retryErrors bool) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := time.Now().Add(-maxAge)
+	stale := make([]string, 0)
+	for _, pkg := range pkgs {
+		rec, in := s.pkgs[NormalizedEcosystemPkgName(s.ecosystem, pkg)]
+		if !in || rec.Fetched.Before(cutoff) || (retryErrors && rec.Err != "") {
+			stale = append(stale, pkg)
+		}
+	}
+	return stale
+}
+
+// Returns the dependency graph of the stored packages whose requirements were fetched
+func (s *ResultStore) Graph() *PyPIGraph {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	graph := newEcosystemGraph(s.ecosystem)
+	for _, rec := range s.pkgs {
+		if rec.Err == "" {
+			graph.addRequirements(rec.Pkg, rec.Requirements)
+		}
+	}
+	return graph
+}
+
+// Writes buffered records to the store file
+func (s *ResultStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Flush()
+}
+
+// Rewrites the store file with only the latest record of each package
+func (s *ResultStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+
+	tmp, err := os.Create(s.path + ".tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, name := range sortedPkgKeys(s.pkgs) {
+		if err := enc.Encode(s.pkgs[name]); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	s.file.Close()
+	if s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return err
+	}
+	s.buf.Reset(s.file)
+	return nil
+}
+
+// Flushes buffered records and closes the store file
+func (s *ResultStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.buf.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+func sortedPkgKeys(m map[string]*StoredPackage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
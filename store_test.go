@@ -0,0 +1,148 @@
+package cheerio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "results.store")
+
+	store, err := OpenResultStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := store.Put(&StoredPackage{Pkg: "Flask", RepoURL: "https://github.com/pallets/flask", Fetched: old}); err != nil {
+		t.Fatal(err)
+	}
+	results := []PackageResult{
+		{Pkg: "Flask", Requirements: []*Requirement{{Name: "Werkzeug"}, {Name: "python-dotenv", Extra: "dotenv"}}, Started: time.Now()},
+		{Pkg: "werkzeug", Started: time.Now()},
+		{Pkg: "broken", Err: fmt.Errorf("No file matched pattern"), Started: time.Now()},
+	}
+	for _, res := range results {
+		if err := store.Write(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopen, with a truncated record at the end
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"Pkg":"half`)
+	f.Close()
+	if store, err = OpenResultStore(path); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if pkgs := store.Packages(); !reflect.DeepEqual(pkgs, []string{"Flask", "broken", "werkzeug"}) {
+		t.Errorf("unexpected packages %v", pkgs)
+	}
+	flask, in := store.Get("flask")
+	if !in || len(flask.Requirements) != 2 || flask.RepoURL != "https://github.com/pallets/flask" || !flask.Fetched.After(old) {
+		t.Errorf("unexpected record for flask: %+v", flask)
+	}
+
+	if stale := store.Stale([]string{"flask", "broken", "new"}, time.Hour, true); !reflect.DeepEqual(stale, []string{"broken", "new"}) {
+		t.Errorf("expected stale [broken new], got %v", stale)
+	}
+	if stale := store.Stale([]string{"flask", "broken", "new"}, time.Hour, false); !reflect.DeepEqual(stale, []string{"new"}) {
+		t.Errorf("expected stale [new], got %v", stale)
+	}
+
+	graph := store.Graph()
+	if reqs := graph.Requires("flask"); !reflect.DeepEqual(reqs, []string{"werkzeug", "python-dotenv"}) {
+		t.Errorf("unexpected requirements of flask %v", reqs)
+	}
+	if reqs := graph.RequiresWithExtras("flask"); !reflect.DeepEqual(reqs, []string{"werkzeug"}) {
+		t.Errorf("unexpected base requirements of flask %v", reqs)
+	}
+	if _, in := graph.Req["broken"]; in {
+		t.Errorf("failed packages shouldn't be in the graph")
+	}
+
+	if err := store.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(&StoredPackage{Pkg: "click", Fetched: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(b), "\n"); lines != 4 {
+		t.Errorf("expected 4 records after compaction, got %d", lines)
+	}
+
+	// records written after a truncated one can be read back
+	store.Close()
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"Pkg":"half`)
+	f.Close()
+	if store, err = OpenResultStore(path); err != nil {
+		t.Fatal(err)
+	}
+	info := &PackageInfo{Pkg: "click", License: "BSD-3-Clause"}
+	if err := store.Write(PackageResult{Pkg: "click", Info: info, RepoURL: "https://github.com/pallets/click", Started: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	if store, err = OpenResultStore(path); err != nil {
+		t.Fatalf("reopening after appending to a truncated record: %s", err)
+	}
+	defer store.Close()
+	if click, in := store.Get("click"); !in || !reflect.DeepEqual(click.Info, info) || click.RepoURL != "https://github.com/pallets/click" {
+		t.Errorf("unexpected record for click: %+v", click)
+	}
+}
+
+func TestEcosystemResultStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := OpenEcosystemResultStore(filepath.Join(dir, "npm.store"), "npm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	store.Write(PackageResult{Pkg: "socket.io", Requirements: []*Requirement{{Name: "debug"}}, Started: time.Now()})
+	store.Write(PackageResult{Pkg: "socket-io", Started: time.Now()})
+
+	if rec, in := store.Get("Socket.IO"); !in || len(rec.Requirements) != 1 {
+		t.Errorf("expected socket.io to keep its requirements, got %+v", rec)
+	}
+	if stale := store.Stale([]string{"socket.io", "socket_io"}, time.Hour, false); !reflect.DeepEqual(stale, []string{"socket_io"}) {
+		t.Errorf("expected only socket_io to be stale, got %v", stale)
+	}
+	if graph := store.Graph(); graph.Ecosystem() != "npm" || len(graph.Requires("socket-io")) != 0 {
+		t.Errorf("expected an npm graph in which socket-io requires nothing, got %v", graph.Req)
+	}
+}
@@ -0,0 +1,187 @@
+package cheerio
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default limits of a Throttle
+const (
+	DefaultThrottleMaxDelay   = 5 * time.Minute
+	DefaultThrottleMaxRetries = 5
+)
+
+// Paces the requests of long crawls so that servers don't block them. Requests start at least MinInterval apart, and the bodies of responses are
+// read at most at BytesPerSecond, across all requests. When a server answers 429 Too Many Requests or 503 Service Unavailable, the request is
+// retried after the delay the server asks for in Retry-After, or an exponential backoff, and the interval between all requests grows by the same
+// delay; it shrinks again as requests succeed. Set it as PackageIndex.Throttle, or wrap the transport of other clients with Transport. The zero
+// value doesn't pace requests but still backs off; a Throttle is safe for concurrent use.
+type Throttle struct {
+	MinInterval    time.Duration
+	BytesPerSecond int64         // no bandwidth limit if 0
+	MaxDelay       time.Duration // of backoff; DefaultThrottleMaxDelay if 0
+	MaxRetries     int           // of a request answered 429 or 503; DefaultThrottleMaxRetries if 0, none if negative
+
+	mu         sync.Mutex
+	nextStart  time.Time     // earliest start of the next request
+	backoff    time.Duration // added to MinInterval while the server is overloaded
+	nextByteAt time.Time     // when the bandwidth limit allows reading more
+}
+
+// Returns a throttle spacing requests so that at most rate requests start per second (no limit if rate is 0), with at most bytesPerSecond of
+// response bodies (no limit if 0)
+func NewThrottle(rate float64, bytesPerSecond int64) *Throttle {
+	t := &Throttle{BytesPerSecond: bytesPerSecond}
+	if rate > 0 {
+		t.MinInterval = time.Duration(float64(time.Second) / rate)
+	}
+	return t
+}
+
+// Returns a transport that paces the requests made through base (http.DefaultTransport if nil)
+func (t *Throttle) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &throttleTransport{base: base, throttle: t}
+}
+
+type throttleTransport struct {
+	base     http.RoundTripper
+	throttle *Throttle
+}
+
+func (tt *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := tt.throttle
+	maxRetries := t.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultThrottleMaxRetries
+	}
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		if err := sleepCtx(req, t.reserveStart()); err != nil {
+			return nil, err
+		}
+		resp, err := tt.base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			t.recover()
+			if t.BytesPerSecond > 0 {
+				resp.Body = &throttledBody{ReadCloser: resp.Body, throttle: t}
+			}
+			return resp, nil
+		}
+
+		delay := t.slowDown(resp.Header.Get("Retry-After"))
+		if attempt >= maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if req.GetBody != nil {
+			// round trippers must not modify requests, so retries send a copy with a fresh body
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+		if err := sleepCtx(req, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Returns how long to wait before starting a request, reserving its slot
+func (t *Throttle) reserveStart() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	start := t.nextStart
+	if start.Before(now) {
+		start = now
+	}
+	t.nextStart = start.Add(t.MinInterval + t.backoff)
+	return start.Sub(now)
+}
+
+// Grows the backoff after a response asking to slow down, and returns how long to wait before retrying
+func (t *Throttle) slowDown(retryAfter string) time.Duration {
+	maxDelay := t.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = DefaultThrottleMaxDelay
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delay := 2 * t.backoff
+	if delay < time.Second {
+		delay = time.Second
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		delay = time.Until(date)
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay > t.backoff {
+		t.backoff = delay
+	}
+	return delay
+}
+
+// Shrinks the backoff after a successful response
+func (t *Throttle) recover() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.backoff /= 2; t.backoff < 10*time.Millisecond {
+		t.backoff = 0
+	}
+}
+
+// Returns how long to wait after reading n bytes to stay within the bandwidth limit
+func (t *Throttle) reserveBytes(n int) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if t.nextByteAt.Before(now) {
+		t.nextByteAt = now
+	}
+	t.nextByteAt = t.nextByteAt.Add(time.Duration(int64(n) * int64(time.Second) / t.BytesPerSecond))
+	return t.nextByteAt.Sub(now)
+}
+
+// A response body read within the bandwidth limit of a throttle
+type throttledBody struct {
+	io.ReadCloser
+	throttle *Throttle
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		time.Sleep(b.throttle.reserveBytes(n))
+	}
+	return n, err
+}
+
+// Sleeps for d, or until the request is canceled
+func sleepCtx(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
@@ -0,0 +1,107 @@
+package cheerio
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottleBackoff(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := atomic.AddInt32(&requests, 1); n <= 2 || r.URL.Path == "/down" {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: (&Throttle{MaxRetries: 3}).Transport(nil)}
+	resp, err := client.Get(server.URL + "/up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("expected success after 2 retries, got %s after %d requests", resp.Status, requests)
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	resp, err = client.Get(server.URL + "/down")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || atomic.LoadInt32(&requests) != 4 {
+		t.Errorf("expected the last response after 3 retries, got %s after %d requests", resp.Status, requests)
+	}
+
+	// retries resend the body without modifying the request
+	var bodies []string
+	poster := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if bodies = append(bodies, string(b)); len(bodies) < 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer poster.Close()
+	req, err := http.NewRequest("POST", poster.URL, strings.NewReader("call"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body
+	resp, err = (&Throttle{MaxRetries: 3}).Transport(nil).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(bodies) != 2 || bodies[1] != "call" {
+		t.Errorf("expected the body to be resent, got %s after bodies %q", resp.Status, bodies)
+	}
+	if req.Body != body {
+		t.Errorf("expected the request's body to be left alone")
+	}
+}
+
+func TestThrottlePacing(t *testing.T) {
+	body := strings.Repeat("x", 2000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewThrottle(50, 0).Transport(nil)} // 20ms apart
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected requests to be spread over 40ms, took %s", elapsed)
+	}
+
+	client = &http.Client{Transport: NewThrottle(0, 20000).Transport(nil)}
+	start = time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != body {
+		t.Errorf("unexpected body of %d bytes", len(b))
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected 2000 bytes at 20000 B/s to take 100ms, took %s", elapsed)
+	}
+}
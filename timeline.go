@@ -0,0 +1,104 @@
+package cheerio
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// The requirements of a release of a package and how they changed since its previous release: a record of the time series of a package's
+// dependencies (see VersionedPyPIGraph.Timeline). Requirements are formatted as by Requirement.String, e.g., `pytest>=6; extra == "test"`.
+type DependencyEvent struct {
+	Pkg          string
+	Version      string
+	Previous     string    `json:",omitempty"` // the previous recorded release; empty for the first one
+	Uploaded     time.Time // when the release was uploaded; zero if unknown
+	Requirements []string
+	Added        []string `json:",omitempty"` // requirements of packages (for an extra, where a marker holds) not required by the previous release
+	Removed      []string `json:",omitempty"` // requirements of the previous release that were dropped
+	Changed      []string `json:",omitempty"` // requirements whose version specifier changed, as they are in this release
+}
+
+// Returns the dependency events of the recorded releases of a package, oldest first, e.g., for research on how dependencies evolve. uploaded maps
+// versions to their upload times, e.g., from PackageIndex.ReleaseHistory; it may be nil. Releases are ordered by upload time if uploaded has the
+// times of all of them, so that, e.g., a backport follows the release it was made after, and by version otherwise. The first release has no
+// Added, Removed or Changed requirements. Requirements of the same package for the same extra and marker, e.g., "foo>=1" and "foo<2", are
+// compared as one, by their combined version specifiers.
+func (g *VersionedPyPIGraph) Timeline(pkg string, uploaded map[string]time.Time) []*DependencyEvent {
+	pkg = NormalizedPkgName(pkg)
+	events := make([]*DependencyEvent, 0)
+	var previous map[string][]*Requirement
+	prevVersion := ""
+	for _, ver := range timelineVersions(g.Versions(pkg), uploaded) {
+		event := &DependencyEvent{Pkg: pkg, Version: ver, Previous: prevVersion, Uploaded: uploaded[ver], Requirements: make([]string, 0)}
+		current := make(map[string][]*Requirement)
+		var keys []string
+		for _, req := range g.Req[pkg][ver] {
+			key := timelineKey(req)
+			if _, in := current[key]; !in {
+				keys = append(keys, key)
+			}
+			current[key] = append(current[key], req)
+			event.Requirements = append(event.Requirements, req.String())
+		}
+		for _, key := range keys {
+			if previous == nil {
+				break
+			}
+			if prevReqs, in := previous[key]; !in {
+				event.Added = append(event.Added, requirementStrings(current[key])...)
+			} else if combinedSpecifier(prevReqs) != combinedSpecifier(current[key]) {
+				event.Changed = append(event.Changed, requirementStrings(current[key])...)
+			}
+		}
+		for _, prevReq := range g.Req[pkg][prevVersion] {
+			if _, in := current[timelineKey(prevReq)]; !in && previous != nil {
+				event.Removed = append(event.Removed, prevReq.String())
+			}
+		}
+		events = append(events, event)
+		previous, prevVersion = current, ver
+	}
+	return events
+}
+
+// Returns versions, in version order, ordered by their upload times instead if all of them are known
+func timelineVersions(versions []string, uploaded map[string]time.Time) []string {
+	for _, ver := range versions {
+		if uploaded[ver].IsZero() {
+			return versions
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return uploaded[versions[i]].Before(uploaded[versions[j]]) })
+	return versions
+}
+
+// Identifies a requirement across releases by the package it requires, and the extra and marker it applies for
+func timelineKey(req *Requirement) string {
+	return NormalizedPkgName(req.Name) + "|" + req.Extra + "|" + req.Marker
+}
+
+// Returns the version clauses of requirements of the same package, sorted, so that the same constraints compare equal however they are split
+// across requirements
+func combinedSpecifier(reqs []*Requirement) string {
+	var clauses []string
+	for _, req := range reqs {
+		if req.Range != "" && req.Constraint == "" {
+			clauses = append(clauses, req.Range) // as by Requirement.Specifier
+			continue
+		}
+		for _, clause := range req.Clauses() {
+			clauses = append(clauses, clause[0]+clause[1])
+		}
+	}
+	sort.Strings(clauses)
+	return strings.Join(clauses, ",")
+}
+
+func requirementStrings(reqs []*Requirement) []string {
+	strs := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		strs = append(strs, req.String())
+	}
+	return strs
+}
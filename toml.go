@@ -0,0 +1,354 @@
+package cheerio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A minimal TOML parser, sufficient for the dependency tables of Pipfiles and pyproject.toml files. Tables are returned as map[string]interface{},
+// arrays as []interface{}, strings as string, booleans as bool, and numbers as float64. Dates and times are returned as their raw strings.
+func parseTOML(data string) (map[string]interface{}, error) {
+	p := &tomlParser{data: data}
+	root := make(map[string]interface{})
+	table := root
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+
+		var err error
+		if strings.HasPrefix(p.rest(), "[[") {
+			p.pos += 2
+			table, err = p.arrayTableHeader(root)
+		} else if p.peek() == '[' {
+			p.pos++
+			table, err = p.tableHeader(root)
+		} else {
+			err = p.keyValue(table)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if !p.eof() && p.peek() != '\n' && p.peek() != '#' && p.peek() != '\r' {
+			return nil, p.errorf("unexpected %q after value", p.peek())
+		}
+	}
+}
+
+type tomlParser struct {
+	data string
+	pos  int
+}
+
+func (p *tomlParser) eof() bool    { return p.pos >= len(p.data) }
+func (p *tomlParser) peek() byte   { return p.data[p.pos] }
+func (p *tomlParser) rest() string { return p.data[p.pos:] }
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.data[:p.pos], "\n") + 1
+	return fmt.Errorf("TOML line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// Skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// Skips whitespace, newlines, and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) tableHeader(root map[string]interface{}) (map[string]interface{}, error) {
+	keys, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.eof() || p.peek() != ']' {
+		return nil, p.errorf("expected ']' after table name")
+	}
+	p.pos++
+	return p.subtable(root, keys)
+}
+
+func (p *tomlParser) arrayTableHeader(root map[string]interface{}) (map[string]interface{}, error) {
+	keys, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); !strings.HasPrefix(p.rest(), "]]") {
+		return nil, p.errorf("expected ']]' after array table name")
+	}
+	p.pos += 2
+
+	parent, err := p.subtable(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	array, _ := parent[last].([]interface{})
+	table := make(map[string]interface{})
+	parent[last] = append(array, table)
+	return table, nil
+}
+
+// Returns the table at the dotted key path keys below table, creating intermediate tables as needed
+func (p *tomlParser) subtable(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch child := table[key].(type) {
+		case nil:
+			sub := make(map[string]interface{})
+			table[key] = sub
+			table = sub
+		case map[string]interface{}:
+			table = child
+		case []interface{}:
+			if len(child) == 0 {
+				return nil, p.errorf("key %q is not a table", key)
+			}
+			sub, ok := child[len(child)-1].(map[string]interface{})
+			if !ok {
+				return nil, p.errorf("key %q is not a table", key)
+			}
+			table = sub
+		default:
+			return nil, p.errorf("key %q is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+func (p *tomlParser) keyValue(table map[string]interface{}) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	if p.skipSpace(); p.eof() || p.peek() != '=' {
+		return p.errorf("expected '=' after key")
+	}
+	p.pos++
+	p.skipSpace()
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.subtable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	parent[keys[len(keys)-1]] = value
+	return nil
+}
+
+// Parses a (possibly dotted) key
+func (p *tomlParser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("expected key")
+		}
+		var key string
+		if c := p.peek(); c == '"' || c == '\'' {
+			var err error
+			if key, err = p.str(); err != nil {
+				return nil, err
+			}
+		} else {
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected key, found %q", p.peek())
+			}
+			key = p.data[start:p.pos]
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) value() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("expected value")
+	}
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	case strings.HasPrefix(p.rest(), "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.rest(), "false"):
+		p.pos += 5
+		return false, nil
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(",]}#\r\n", rune(p.peek())) {
+		p.pos++
+	}
+	raw := strings.TrimSpace(p.data[start:p.pos])
+	if raw == "" {
+		return nil, p.errorf("expected value")
+	}
+	if f, err := strconv.ParseFloat(strings.Replace(raw, "_", "", -1), 64); err == nil {
+		return f, nil
+	}
+	return raw, nil
+}
+
+func (p *tomlParser) array() ([]interface{}, error) {
+	p.pos++ // '['
+	array := make([]interface{}, 0)
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		} else if p.peek() == ']' {
+			p.pos++
+			return array, nil
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+
+		p.skipBlank()
+		if !p.eof() && p.peek() == ',' {
+			p.pos++
+		} else if p.eof() || p.peek() != ']' {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) inlineTable() (map[string]interface{}, error) {
+	p.pos++ // '{'
+	table := make(map[string]interface{})
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated inline table")
+		} else if p.peek() == '}' {
+			p.pos++
+			return table, nil
+		}
+
+		if err := p.keyValue(table); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if !p.eof() && p.peek() == ',' {
+			p.pos++
+		} else if p.eof() || p.peek() != '}' {
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// Parses a basic (double-quoted), literal (single-quoted), or multi-line (triple-quoted) string
+func (p *tomlParser) str() (string, error) {
+	quote := p.data[p.pos : p.pos+1]
+	if strings.HasPrefix(p.rest(), strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	p.pos += len(quote)
+	if len(quote) == 3 && strings.HasPrefix(p.rest(), "\n") {
+		p.pos++ // a newline immediately following the opening delimiter is trimmed
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		if strings.HasPrefix(p.rest(), quote) {
+			p.pos += len(quote)
+			return b.String(), nil
+		}
+
+		c := p.peek()
+		if c == '\n' && len(quote) == 1 {
+			return "", p.errorf("newline in string")
+		}
+		if c != '\\' || quote[0] == '\'' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		// Escape sequence in a basic string
+		p.pos++
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		esc := p.peek()
+		p.pos++
+		switch esc {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(esc)
+		case 'u', 'U':
+			n := 4
+			if esc == 'U' {
+				n = 8
+			}
+			if p.pos+n > len(p.data) {
+				return "", p.errorf("invalid unicode escape")
+			}
+			r, err := strconv.ParseUint(p.data[p.pos:p.pos+n], 16, 32)
+			if err != nil {
+				return "", p.errorf("invalid unicode escape")
+			}
+			b.WriteRune(rune(r))
+			p.pos += n
+		case '\n':
+			// line-ending backslash in a multi-line string trims the following whitespace
+			for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+				p.pos++
+			}
+		default:
+			return "", p.errorf("invalid escape \\%c", esc)
+		}
+	}
+}
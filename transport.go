@@ -0,0 +1,219 @@
+package cheerio
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Version of cheerio, sent in the default User-Agent
+const Version = "0.2.0"
+
+// The User-Agent of indexes that don't set one, identifying cheerio to index operators
+const DefaultUserAgent = "cheerio/" + Version + " (+https://github.com/beyang/cheerio)"
+
+// Transports of indexes with a Proxy or TLS settings, shared by the indexes with the same settings so that they share connections
+var transports sync.Map
+
+type transportKey struct {
+	proxy string
+	tls   *tls.Config
+}
+
+// Returns the client of the index's requests, which sets the index's User-Agent on each of them, and its Headers and BasicAuth on those to
+// the index's own host, and paces them with its Throttle.
+// It is based on the index's Client or, if there is none, on http.DefaultClient, which honors the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables, unless the index has a Proxy or TLS settings; requests using those are still counted if http.DefaultClient is
+// instrumented with CrawlMetrics.Transport.
+func (p *PackageIndex) client() *http.Client {
+	base := p.Client
+	if base == nil {
+		base = http.DefaultClient
+	}
+	transport := base.Transport
+	if p.Client == nil && (p.Proxy != "" || p.TLS != nil) {
+		key := transportKey{proxy: p.Proxy, tls: p.TLS}
+		shared, in := transports.Load(key)
+		if !in {
+			shared, _ = transports.LoadOrStore(key, newTransport(p.Proxy, p.TLS))
+		}
+		transport = shared.(http.RoundTripper)
+		if metrics, ok := http.DefaultClient.Transport.(*metricsTransport); ok {
+			transport = metrics.metrics.Transport(transport)
+		}
+	}
+
+	if p.Throttle != nil {
+		transport = p.Throttle.Transport(transport)
+	}
+
+	userAgent := p.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	client := *base
+	if p.Timeout > 0 {
+		client.Timeout = p.Timeout
+	}
+	client.Transport = &headerTransport{base: transport, userAgent: userAgent, headers: p.Headers, auth: p.BasicAuth,
+		host: indexHost(p.URI), ctx: p.ctx}
+	return &client
+}
+
+// Returns a copy of the index whose requests are canceled once ctx is done, e.g., to stop the fetches of a package that timed out in a crawl
+func (p *PackageIndex) withContext(ctx context.Context) Index {
+	index := *p
+	index.ctx = ctx
+	return &index
+}
+
+// Returns the host (and port) of an index URI, or "" if it has none, e.g., that of a local mirror
+func indexHost(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// Sets the User-Agent of requests, and the extra headers and basic auth of those to the index's host
+type headerTransport struct {
+	base      http.RoundTripper // http.DefaultTransport if nil
+	userAgent string
+	headers   http.Header
+	auth      *url.Userinfo
+	ctx       context.Context // if set, requests are canceled once it is done, as well as when their own context is
+	host      string          // only requests to this host get the headers, so that credentials aren't sent to the hosts the index links to
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, done := req.Context(), func() {}
+	if t.ctx != nil {
+		if err := t.ctx.Err(); err != nil {
+			return nil, err
+		}
+		ctx, done = withCancelOf(ctx, t.ctx)
+	}
+	req = req.Clone(ctx) // round trippers must not modify requests
+	req.Header.Set("User-Agent", t.userAgent)
+	if t.host != "" && strings.EqualFold(req.URL.Host, t.host) {
+		for name, values := range t.headers {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+		if t.auth != nil {
+			password, _ := t.auth.Password()
+			req.SetBasicAuth(t.auth.Username(), password)
+		}
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		done()
+		return nil, err
+	}
+	resp.Body = &doneBody{ReadCloser: resp.Body, done: done}
+	return resp, nil
+}
+
+// Returns a context derived from ctx that is also canceled once other is done, and a function that releases it, to be called once it is no
+// longer used
+func withCancelOf(ctx, other context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-other.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() { once.Do(func() { close(stop); cancel() }) }
+}
+
+// A response body that calls done once closed, e.g., to release the context of its request
+type doneBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b *doneBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+// Returns a transport like http.DefaultTransport, but sending every request through proxy, if set, and using tlsConfig, if set. If proxy isn't a
+// supported proxy URL, its requests fail with an error saying so.
+func newTransport(proxy string, tlsConfig *tls.Config) *http.Transport {
+	var transport *http.Transport
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = base.Clone()
+	} else {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.Clone()
+	}
+	if proxy == "" {
+		return transport
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err == nil {
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			err = fmt.Errorf("unsupported scheme '%s' (expected http, https, socks5 or socks5h)", proxyURL.Scheme)
+		}
+	}
+	if err == nil && proxyURL.Host == "" {
+		err = fmt.Errorf("no host")
+	}
+	transport.Proxy = func(*http.Request) (*url.URL, error) {
+		if err != nil {
+			return nil, fmt.Errorf("Invalid proxy %s: %w", proxy, err)
+		}
+		return proxyURL, nil
+	}
+	return transport
+}
+
+// Returns TLS settings (see PackageIndex.TLS) trusting the PEM-encoded CA certificates in caFile in addition to the system's, e.g., the internal
+// CA of a private index, and presenting the client certificate in certFile, with its key in keyFile. Each file is optional.
+func NewTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		if config.RootCAs, err = x509.SystemCertPool(); err != nil {
+			config.RootCAs = x509.NewCertPool()
+		}
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No PEM-encoded certificates found in %s", caFile)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		if keyFile == "" {
+			keyFile = certFile // the key may be in the same file
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,141 @@
+package cheerio
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPackageIndexProxy(t *testing.T) {
+	var mu sync.Mutex
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.URL.String()) // absolute, as requests to a proxy are
+		mu.Unlock()
+		if strings.TrimSuffix(r.URL.Path, "/") == "/simple/foo" {
+			fmt.Fprint(w, `<a href="/files/foo-1.0.tar.gz#sha256=abcd">foo-1.0.tar.gz</a>`)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer proxy.Close()
+
+	index := &PackageIndex{URI: "http://pypi.invalid", Proxy: proxy.URL, Logger: NopLogger{}}
+	releases, err := index.Versions("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 1 || releases[0].Artifacts[0].URL != "http://pypi.invalid/files/foo-1.0.tar.gz" {
+		t.Errorf("unexpected releases %+v", releases)
+	}
+	if _, err := index.FetchMetadata("foo"); err == nil {
+		t.Errorf("expected an error for a missing sdist")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(proxied) != 3 || proxied[0] != "http://pypi.invalid/simple/foo/" || proxied[2] != "http://pypi.invalid/files/foo-1.0.tar.gz" {
+		t.Errorf("expected all requests to go through the proxy, got %v", proxied)
+	}
+
+	index.Proxy = "ftp://proxy.invalid"
+	if _, err := index.Versions("foo"); err == nil || !strings.Contains(err.Error(), "Invalid proxy") {
+		t.Errorf("expected an invalid proxy error, got %v", err)
+	}
+}
+
+func TestPackageIndexTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/files/foo-1.0.tar.gz">foo-1.0.tar.gz</a>`)
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL, Logger: NopLogger{}}
+	if _, err := index.Versions("foo"); err == nil {
+		t.Errorf("expected a certificate error")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, ca, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, err := NewTLSConfig(caFile, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.TLS = tlsConfig
+	if releases, err := index.Versions("foo"); err != nil || len(releases) != 1 {
+		t.Errorf("expected the release to be read with the CA trusted, got %v, %v", releases, err)
+	}
+
+	index.TLS = &tls.Config{InsecureSkipVerify: true}
+	if _, err := index.Versions("foo"); err != nil {
+		t.Errorf("expected verification to be skipped, got %v", err)
+	}
+}
+
+func TestPackageIndexHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var userAgents, tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		userAgents, tokens = append(userAgents, r.UserAgent()), append(tokens, r.Header.Get("X-Token"))
+		mu.Unlock()
+		fmt.Fprint(w, `<a href="/files/foo-1.0.tar.gz">foo-1.0.tar.gz</a>`)
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL, Logger: NopLogger{}}
+	index.Versions("foo")
+	index.UserAgent, index.Headers = "crawler (ops@example.com)", http.Header{"X-Token": {"secret"}}
+	index.Versions("foo")
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []string{DefaultUserAgent, "crawler (ops@example.com)"}; !reflect.DeepEqual(userAgents, exp) {
+		t.Errorf("expected User-Agents %q, got %q", exp, userAgents)
+	}
+	if exp := []string{"", "secret"}; !reflect.DeepEqual(tokens, exp) {
+		t.Errorf("expected headers %q, got %q", exp, tokens)
+	}
+}
+
+func TestPackageIndexHeadersOtherHost(t *testing.T) {
+	var mu sync.Mutex
+	var indexTokens, mirrorTokens []string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		mirrorTokens = append(mirrorTokens, r.Header.Get("X-Token"))
+		mu.Unlock()
+		fmt.Fprint(w, `<a href="/files/foo-1.0.tar.gz">foo-1.0.tar.gz</a>`)
+	}))
+	defer mirror.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		indexTokens = append(indexTokens, r.Header.Get("X-Token"))
+		mu.Unlock()
+		http.Redirect(w, r, mirror.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	index := &PackageIndex{URI: server.URL, Logger: NopLogger{}, Headers: http.Header{"X-Token": {"secret"}}}
+	if _, err := index.Versions("foo"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []string{"secret"}; !reflect.DeepEqual(indexTokens, exp) {
+		t.Errorf("expected the index to get headers %q, got %q", exp, indexTokens)
+	}
+	if exp := []string{""}; !reflect.DeepEqual(mirrorTokens, exp) {
+		t.Errorf("expected the host redirected to to get headers %q, got %q", exp, mirrorTokens)
+	}
+}
@@ -0,0 +1,103 @@
+package cheerio
+
+import (
+	"sort"
+)
+
+// A package whose name is suspiciously close to the name of a popular package
+type TyposquatCandidate struct {
+	Pkg        string
+	Target     string  // the popular package it may imitate
+	Distance   int     // edit distance between the names
+	TargetRank float64 // score of the target, e.g., its number of dependents
+}
+
+// Targets with names shorter than this are skipped, since nearly every short name is within a small edit distance of them
+const minTyposquatTargetLen = 4
+
+// Returns the packages in pkgs whose (normalized) names are within maxDistance edits of one of the targets, e.g., "reqeusts" for the target
+// "requests". Edits are insertions, deletions, substitutions, and transpositions of adjacent characters. Names that only differ from a target in
+// case or in separators ("-", "_", ".") are not reported, since PyPI treats them as the same project. Candidates are sorted by distance, then by
+// target rank (highest first), then by name.
+func FindTyposquats(pkgs []string, targets []PkgScore, maxDistance int) []*TyposquatCandidate {
+	var candidates []*TyposquatCandidate
+	for _, target := range targets {
+		targetName := NormalizedPkgName(target.Pkg)
+		if len(targetName) < minTyposquatTargetLen {
+			continue
+		}
+		targetKey := withoutSeparators(targetName)
+		for _, pkg := range pkgs {
+			name := NormalizedPkgName(pkg)
+			if d := len(name) - len(targetName); d > maxDistance || -d > maxDistance || withoutSeparators(name) == targetKey {
+				continue
+			}
+			if dist := editDistance(name, targetName, maxDistance); dist <= maxDistance {
+				candidates = append(candidates, &TyposquatCandidate{Pkg: name, Target: targetName, Distance: dist, TargetRank: target.Score})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.TargetRank != b.TargetRank {
+			return a.TargetRank > b.TargetRank
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Pkg < b.Pkg
+	})
+	return candidates
+}
+
+// Returns the name without "-", "_", and "." characters
+func withoutSeparators(name string) string {
+	b := make([]byte, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c != '-' && c != '_' && c != '.' {
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+// Returns the optimal string alignment distance between a and b (Levenshtein distance that also counts transposing two adjacent characters as one
+// edit). Stops early and returns max+1 once the distance is known to exceed max.
+func editDistance(a, b string, max int) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = minInt(cur[j], prev2[j-2]+1)
+			}
+			rowMin = minInt(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
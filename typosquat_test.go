@@ -0,0 +1,42 @@
+package cheerio
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		exp  int
+	}{
+		{"requests", "requests", 2, 0},
+		{"reqeusts", "requests", 2, 1},
+		{"request", "requests", 2, 1},
+		{"rquests", "requests", 2, 1},
+		{"requestz", "requests", 2, 1},
+		{"djnago", "django", 2, 1},
+		{"numpyy2", "numpy", 2, 2},
+		{"flask", "django", 2, 3},
+	}
+	for _, test := range tests {
+		if dist := editDistance(test.a, test.b, test.max); dist != test.exp {
+			t.Errorf("editDistance(%s, %s, %d): expected %d, got %d", test.a, test.b, test.max, test.exp, dist)
+		}
+	}
+}
+
+func TestFindTyposquats(t *testing.T) {
+	pkgs := []string{"requests", "reqeusts", "Requests_", "django", "djnago", "six", "sux", "flask", "flaskk", "numpy"}
+	targets := []PkgScore{{"requests", 50}, {"django", 80}, {"six", 100}, {"flask", 10}}
+
+	candidates := FindTyposquats(pkgs, targets, 1)
+	var got []string
+	for _, c := range candidates {
+		got = append(got, c.Pkg+">"+c.Target)
+	}
+	if exp := "djnago>django reqeusts>requests flaskk>flask"; strings.Join(got, " ") != exp {
+		t.Errorf("FindTyposquats: expected %s, got %s", exp, strings.Join(got, " "))
+	}
+}
@@ -2,42 +2,86 @@ package cheerio
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 )
 
-// Normalizes package names so they are comparable
+// Normalizes PyPI package names so they are comparable, following PEP 503: names are lowercased and runs of "-", "_" and "." are replaced by a
+// single "-", e.g., "Foo_Bar", "foo.bar" and "foo--bar" all become "foo-bar". In purls (see PackagePURL), only the names of "pkg:pypi" purls are
+// normalized that way, and other purls are lowercased, as "." and "_" are significant in the names of other ecosystems. Names of other
+// ecosystems are normalized by NormalizedEcosystemPkgName.
 func NormalizedPkgName(pkg string) string {
+	pkg = strings.ToLower(pkg)
+	if !strings.HasPrefix(pkg, "pkg:") {
+		return collapseNameSeparators(pkg)
+	}
+	if !strings.HasPrefix(pkg, "pkg:pypi/") {
+		return pkg
+	}
+	name, version := strings.TrimPrefix(pkg, "pkg:pypi/"), ""
+	if i := strings.IndexAny(name, "@?#"); i >= 0 {
+		name, version = name[:i], name[i:]
+	}
+	return "pkg:pypi/" + collapseNameSeparators(name) + version
+}
+
+// Normalizes the name of a package of an ecosystem (see Ecosystems) so it is comparable: PyPI names follow PEP 503 (see NormalizedPkgName), while
+// names of other ecosystems are only lowercased, e.g., the npm packages "socket.io" and "socket-io" stay distinct. An empty ecosystem is PyPI.
+// purls are normalized by their own type whatever the ecosystem.
+func NormalizedEcosystemPkgName(ecosystem, pkg string) string {
+	if ecosystem == "" || strings.EqualFold(ecosystem, "pypi") || strings.HasPrefix(pkg, "pkg:") {
+		return NormalizedPkgName(pkg)
+	}
 	return strings.ToLower(pkg)
 }
 
-// Convenience functions that get the last instance of a type of file
+// Replaces runs of "-", "_" and "." by a single "-", only allocating if the name isn't already normalized
+func collapseNameSeparators(name string) string {
+	isSep := func(c byte) bool { return c == '-' || c == '_' || c == '.' }
+	normalized := true
+	for i := 0; i < len(name) && normalized; i++ {
+		normalized = !isSep(name[i]) || (name[i] == '-' && (i == 0 || !isSep(name[i-1])))
+	}
+	if normalized {
+		return name
+	}
+	var b strings.Builder
+	b.Grow(len(name))
+	for i := 0; i < len(name); i++ {
+		if !isSep(name[i]) {
+			b.WriteByte(name[i])
+		} else if i == 0 || !isSep(name[i-1]) {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// Names of tar archives, as opposed to zips and eggs
 var tarRegexp = regexp.MustCompile(`[/A-Za-z0-9\._\-]+\.(?:tar\.(?:gz|bz2)|tgz)`)
-var zipRegexp = regexp.MustCompile(`[/A-Za-z0-9\._\-]+\.zip`)
-var eggRegexp = regexp.MustCompile(`[/A-Za-z0-9\._\-]+\.egg`)
 
-func lastTar(files []string) string {
-	for f := len(files) - 1; f >= 0; f-- {
-		if tarRegexp.MatchString(files[f]) {
-			return files[f]
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
 		}
 	}
-	return ""
+	return false
 }
 
-func lastEgg(files []string) string {
-	for f := len(files) - 1; f >= 0; f-- {
-		if eggRegexp.MatchString(files[f]) {
-			return files[f]
-		}
+func stringSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		set[s] = true
 	}
-	return ""
+	return set
 }
 
-func lastZip(files []string) string {
-	for f := len(files) - 1; f >= 0; f-- {
-		if zipRegexp.MatchString(files[f]) {
-			return files[f]
-		}
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
-	return ""
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,181 @@
+package cheerio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Dependency graph in which each release of a package is a separate node, since requirements change between releases. Serialized like a PyPIGraph,
+// but with packages written as "pkg@version" and requirements keeping their version constraints (and extra, if any), e.g.:
+//
+// flask@0.10.1
+// flask@0.10.1:werkzeug>=0.7
+// flask@0.10.1:jinja2>=2.4
+// flask@0.10.1:python-dotenv:dotenv
+type VersionedPyPIGraph struct {
+	Req map[string]map[string][]*Requirement // package -> version -> requirements
+}
+
+func NewVersionedPyPIGraph() *VersionedPyPIGraph {
+	return &VersionedPyPIGraph{Req: make(map[string]map[string][]*Requirement)}
+}
+
+// Deserializes a VersionedPyPIGraph stored in a file
+func LoadVersionedPyPIGraph(file string) (*VersionedPyPIGraph, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	graph := NewVersionedPyPIGraph()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		node, reqStr := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			node, reqStr = line[:i], line[i+1:]
+		}
+		i := strings.LastIndex(node, "@")
+		if i < 0 {
+			return nil, fmt.Errorf("Expected pkg@version in versioned graph line: %s", line)
+		}
+		pkg, ver := node[:i], node[i+1:]
+
+		graph.Add(pkg, ver, nil)
+		if reqStr != "" {
+			extra := ""
+			if i := strings.Index(reqStr, ":"); i >= 0 {
+				reqStr, extra = reqStr[:i], reqStr[i+1:]
+			}
+			req, err := ParseRequirement(reqStr)
+			if err != nil {
+				return nil, err
+			}
+			req.Extra = extra
+			graph.Add(pkg, ver, []*Requirement{req})
+		}
+	}
+	return graph, scanner.Err()
+}
+
+// Records requirements of a release of a package (in addition to any already recorded)
+func (g *VersionedPyPIGraph) Add(pkg, ver string, reqs []*Requirement) {
+	pkg = NormalizedPkgName(pkg)
+	if _, in := g.Req[pkg]; !in {
+		g.Req[pkg] = make(map[string][]*Requirement)
+	}
+	if _, in := g.Req[pkg][ver]; !in {
+		g.Req[pkg][ver] = make([]*Requirement, 0)
+	}
+	g.Req[pkg][ver] = append(g.Req[pkg][ver], reqs...)
+}
+
+// Returns the requirements of a release of a package
+func (g *VersionedPyPIGraph) RequiresAt(pkg, ver string) []*Requirement {
+	return g.Req[NormalizedPkgName(pkg)][ver]
+}
+
+// Returns the recorded releases of a package, oldest first (by PEP 440 ordering)
+func (g *VersionedPyPIGraph) Versions(pkg string) []string {
+	versions := make([]string, 0)
+	for ver := range g.Req[NormalizedPkgName(pkg)] {
+		versions = append(versions, ver)
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return CompareVersions(versions[i], versions[j]) < 0 })
+	return versions
+}
+
+// Collapses the graph into an unversioned PyPIGraph using the latest recorded release of each package
+func (g *VersionedPyPIGraph) Latest() *PyPIGraph {
+	graph := newPyPIGraph()
+	for pkg := range g.Req {
+		graph.addPkg(pkg)
+		versions := g.Versions(pkg)
+		for _, req := range g.Req[pkg][versions[len(versions)-1]] {
+			dep := NormalizedPkgName(req.Name)
+			if req.Extra != "" {
+				graph.addExtraEdge(pkg, dep, req.Extra)
+			} else if !containsString(graph.Req[pkg], dep) || graph.optional[Edge{pkg, dep}] {
+				graph.addEdge(pkg, dep)
+			}
+		}
+	}
+	return graph
+}
+
+// Serializes the graph in the format read by LoadVersionedPyPIGraph, with packages sorted by name and releases oldest first
+func (g *VersionedPyPIGraph) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	pkgs := make([]string, 0, len(g.Req))
+	for pkg := range g.Req {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		for _, ver := range g.Versions(pkg) {
+			c, _ := fmt.Fprintf(bw, "%s@%s\n", pkg, ver)
+			n += int64(c)
+			for _, req := range g.Req[pkg][ver] {
+				c, _ := fmt.Fprintf(bw, "%s@%s:%s%s", pkg, ver, NormalizedPkgName(req.Name), req.Specifier())
+				n += int64(c)
+				if req.Extra != "" {
+					c, _ = fmt.Fprintf(bw, ":%s", req.Extra)
+					n += int64(c)
+				}
+				c, _ = fmt.Fprintln(bw)
+				n += int64(c)
+			}
+		}
+	}
+	return n, bw.Flush()
+}
+
+// A package whose latest recorded release requires another package with a version specifier that a new version of it doesn't satisfy (see
+// BumpImpact)
+type IncompatibleDependent struct {
+	Pkg     string
+	Version string       // the dependent's latest recorded release
+	Req     *Requirement // its requirement on the bumped package
+}
+
+// Previews the impact of releasing version newVersion of pkg: returns the packages whose latest recorded release requires pkg with a specifier
+// that newVersion doesn't satisfy, e.g., those requiring "flask<2.0" when flask 2.0 is planned, sorted by name, and the number of packages whose
+// latest recorded release requires pkg at all. Requirements with specifiers that can't be evaluated are taken to be compatible.
+func (g *VersionedPyPIGraph) BumpImpact(pkg, newVersion string) ([]*IncompatibleDependent, int, error) {
+	if _, err := parseVersion(newVersion); err != nil {
+		return nil, 0, err
+	}
+	pkg = NormalizedPkgName(pkg)
+
+	incompatible := make([]*IncompatibleDependent, 0)
+	dependents := 0
+	for dependent := range g.Req {
+		versions := g.Versions(dependent)
+		latest := versions[len(versions)-1]
+		requires := false
+		for _, req := range g.Req[dependent][latest] {
+			if NormalizedPkgName(req.Name) != pkg {
+				continue
+			}
+			requires = true
+			if match, err := req.Matches(newVersion); err == nil && !match {
+				incompatible = append(incompatible, &IncompatibleDependent{Pkg: dependent, Version: latest, Req: req})
+			}
+		}
+		if requires {
+			dependents++
+		}
+	}
+	sort.SliceStable(incompatible, func(i, j int) bool { return incompatible[i].Pkg < incompatible[j].Pkg })
+	return incompatible, dependents, nil
+}
@@ -0,0 +1,51 @@
+package cheerio
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var archiveExtRegexp = regexp.MustCompile(`\.(?:tar\.gz|tar\.bz2|tgz|zip|egg)$`)
+var eggPythonSuffixRegexp = regexp.MustCompile(`-py[0-9]\.[0-9]+(?:-.*)?$`)
+var versionStartRegexp = regexp.MustCompile(`-([0-9][^-]*)$`)
+
+// Returns the release version of a package archive, e.g., "0.10.1" for "/packages/source/F/Flask/Flask-0.10.1.tar.gz". Returns "" if the file name
+// cannot be parsed.
+func fileVersion(pkg, file string) string {
+	base := path.Base(file)
+	if !archiveExtRegexp.MatchString(base) {
+		return ""
+	}
+	base = archiveExtRegexp.ReplaceAllString(base, "")
+	base = eggPythonSuffixRegexp.ReplaceAllString(base, "")
+
+	// Prefer splitting on the package name, since both names and versions may contain dashes
+	normBase, normPkg := normalizeSeparators(base), normalizeSeparators(pkg)
+	if strings.HasPrefix(normBase, normPkg+"-") {
+		return base[len(pkg)+1:]
+	}
+	if match := versionStartRegexp.FindStringSubmatch(base); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// Lowercases a name and replaces underscores and dots with dashes, since archive names spell package names inconsistently
+func normalizeSeparators(name string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+}
+
+// Returns the distinct release versions of a package, oldest first (by PEP 440 ordering, see Versions). Wheel-only releases are included.
+func (p *PackageIndex) ReleaseVersions(pkg string) ([]string, error) {
+	releases, err := p.Versions(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(releases))
+	for _, release := range releases {
+		versions = append(versions, release.Version)
+	}
+	return versions, nil
+}
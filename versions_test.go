@@ -0,0 +1,201 @@
+package cheerio
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileVersion(t *testing.T) {
+	tests := []struct {
+		pkg, file, want string
+	}{
+		{"flask", "/packages/source/F/Flask/Flask-0.10.1.tar.gz", "0.10.1"},
+		{"flask_cm", "/packages/source/f/flask_cm/flask_cm-0.1.zip", "0.1"},
+		{"zope.interface", "/packages/source/z/zope.interface/zope.interface-4.1.0.tar.gz", "4.1.0"},
+		{"py-3parclient", "/packages/source/p/py-3parclient/py-3parclient-3.0.0.tar.bz2", "3.0.0"},
+		{"python-dateutil", "/packages/2.7/p/python-dateutil/python_dateutil-2.2-py2.7.egg", "2.2"},
+		{"renamed", "/packages/source/o/other/other-1.0.tgz", "1.0"},
+		{"flask", "/packages/source/F/Flask/Flask-0.10.1.exe", ""},
+	}
+
+	for _, test := range tests {
+		if got := fileVersion(test.pkg, test.file); got != test.want {
+			t.Errorf("fileVersion(%q, %q): want %q, got %q", test.pkg, test.file, test.want, got)
+		}
+	}
+}
+
+func TestReleaseVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simple/flask/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<!DOCTYPE html>
+<html><body><h1>Links for flask</h1>
+<a href="https://files.pythonhosted.org/packages/aa/bb/flask-2.1.0-py3-none-any.whl#sha256=3b4f2d1c0a9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c" data-requires-python="&gt;=3.7">flask-2.1.0-py3-none-any.whl</a><br />
+<a href="https://files.pythonhosted.org/packages/cc/dd/Flask-2.0.0.tar.gz#sha256=7b3a1f2e4d5c6b7a8f9e0d1c2b3a4f5e6d7c8b9a0f1e2d3c4b5a6f7e8d9c0b1a">Flask-2.0.0.tar.gz</a><br />
+<a href="https://files.pythonhosted.org/packages/ee/ff/Flask-2.0.0-py3-none-any.whl#sha256=1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b">Flask-2.0.0-py3-none-any.whl</a><br />
+<a href="https://files.pythonhosted.org/packages/11/22/Flask-1.10.0.tar.gz#sha256=0f1e2d3c4b5a6f7e8d9c0b1a2f3e4d5c6b7a8f9e0d1c2b3a4f5e6d7c8b9a0f1e" data-yanked="">Flask-1.10.0.tar.gz</a><br />
+</body></html>`)
+	}))
+	defer server.Close()
+
+	versions, err := (&PackageIndex{URI: server.URL}).ReleaseVersions("flask")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"1.10.0", "2.0.0", "2.1.0"}; !reflect.DeepEqual(versions, exp) {
+		t.Errorf("ReleaseVersions: expected %v, got %v", exp, versions)
+	}
+}
+
+func TestVersionedPyPIGraph(t *testing.T) {
+	g := NewVersionedPyPIGraph()
+	g.Add("Flask", "0.9", []*Requirement{{Name: "Werkzeug", Constraint: ">=", Version: "0.7"}})
+	g.Add("flask", "0.10.1", []*Requirement{{Name: "werkzeug", Constraint: ">=", Version: "0.7"}, {Name: "itsdangerous", Constraint: ">=", Version: "0.21"}})
+	g.Add("werkzeug", "0.9.4", nil)
+
+	if versions := g.Versions("flask"); !reflect.DeepEqual(versions, []string{"0.9", "0.10.1"}) {
+		t.Errorf("Versions: expected [0.9 0.10.1], got %v", versions)
+	}
+	if reqs := g.RequiresAt("flask", "0.9"); len(reqs) != 1 || reqs[0].Name != "Werkzeug" {
+		t.Errorf("RequiresAt: expected Werkzeug, got %v", reqs)
+	}
+	if deps := g.Latest().Requires("flask"); !reflect.DeepEqual(deps, []string{"werkzeug", "itsdangerous"}) {
+		t.Errorf("Latest: expected [werkzeug itsdangerous], got %v", deps)
+	}
+
+	var buf bytes.Buffer
+	g.WriteTo(&buf)
+	exp := `flask@0.9
+flask@0.9:werkzeug>=0.7
+flask@0.10.1
+flask@0.10.1:werkzeug>=0.7
+flask@0.10.1:itsdangerous>=0.21
+werkzeug@0.9.4
+`
+	if buf.String() != exp {
+		t.Errorf("WriteTo: expected %q, got %q", exp, buf.String())
+	}
+
+	f, err := ioutil.TempFile("", "cheerio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(buf.Bytes())
+	f.Close()
+
+	loaded, err := LoadVersionedPyPIGraph(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reqs := loaded.RequiresAt("flask", "0.10.1"); len(reqs) != 2 || reqs[1].Name != "itsdangerous" || reqs[1].Version != "0.21" {
+		t.Errorf("LoadVersionedPyPIGraph: expected flask 0.10.1 requirements to round-trip, got %v", reqs)
+	}
+
+	epochs := NewVersionedPyPIGraph()
+	epochs.Add("calver", "2.0", []*Requirement{{Name: "old"}})
+	epochs.Add("calver", "1!1.0", []*Requirement{{Name: "new"}})
+	epochs.Add("calver", "2.0rc1", nil)
+	if versions, exp := epochs.Versions("calver"), []string{"2.0rc1", "2.0", "1!1.0"}; !reflect.DeepEqual(versions, exp) {
+		t.Errorf("Versions: expected PEP 440 order %v, got %v", exp, versions)
+	}
+	if deps := epochs.Latest().Requires("calver"); !reflect.DeepEqual(deps, []string{"new"}) {
+		t.Errorf("Latest: expected the release with an epoch, got %v", deps)
+	}
+}
+
+func TestBumpImpact(t *testing.T) {
+	g := NewVersionedPyPIGraph()
+	g.Add("flask-admin", "1.0", []*Requirement{{Name: "Flask", Constraint: "<", Version: "2.0"}})
+	g.Add("flask-admin", "1.6", []*Requirement{{Name: "flask", Constraint: ">=", Version: "0.7"}})
+	g.Add("flask-login", "0.5", []*Requirement{{Name: "flask", Constraint: ">=", Version: "1.0", More: []string{"<2.0"}}})
+	g.Add("flask-wtf", "0.14", []*Requirement{{Name: "flask", Constraint: "~=", Version: "1.1"}, {Name: "wtforms"}})
+	g.Add("wtforms", "2.3", nil)
+
+	incompatible, dependents, err := g.BumpImpact("Flask", "2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dependents != 3 || len(incompatible) != 2 || incompatible[0].Pkg != "flask-login" || incompatible[0].Version != "0.5" ||
+		incompatible[1].Pkg != "flask-wtf" || incompatible[1].Req.Specifier() != "~=1.1" {
+		t.Errorf("expected flask-login and flask-wtf of 3 dependents, got %d dependents and %v", dependents, incompatible)
+	}
+	if _, _, err := g.BumpImpact("flask", "not a version"); err == nil {
+		t.Errorf("expected an error for an invalid version")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	g := NewVersionedPyPIGraph()
+	g.Add("flask", "0.9", []*Requirement{{Name: "Werkzeug", Constraint: ">=", Version: "0.7"}, {Name: "Jinja2", Constraint: ">=", Version: "2.4"}})
+	g.Add("flask", "0.10", []*Requirement{{Name: "werkzeug", Constraint: ">=", Version: "0.7"}, {Name: "jinja2", Constraint: ">=", Version: "2.4"},
+		{Name: "itsdangerous", Constraint: ">=", Version: "0.21"}})
+	g.Add("flask", "2.0", []*Requirement{{Name: "werkzeug", Constraint: ">=", Version: "2.0"}, {Name: "itsdangerous", Constraint: ">=", Version: "0.21"},
+		{Name: "asgiref", Constraint: ">=", Version: "3.2", Extra: "async"}})
+
+	uploaded := time.Date(2013, 6, 13, 0, 0, 0, 0, time.UTC)
+	events := g.Timeline("Flask", map[string]time.Time{"0.10": uploaded})
+	if len(events) != 3 {
+		t.Fatalf("expected an event per release, got %d", len(events))
+	}
+	if first := events[0]; first.Version != "0.9" || first.Previous != "" || len(first.Requirements) != 2 || first.Added != nil {
+		t.Errorf("unexpected first event %+v", first)
+	}
+	if second := events[1]; second.Previous != "0.9" || !second.Uploaded.Equal(uploaded) || !reflect.DeepEqual(second.Added, []string{"itsdangerous>=0.21"}) {
+		t.Errorf("unexpected second event %+v", second)
+	}
+	last := events[2]
+	if !reflect.DeepEqual(last.Added, []string{`asgiref>=3.2; extra == "async"`}) || !reflect.DeepEqual(last.Removed, []string{"jinja2>=2.4"}) ||
+		!reflect.DeepEqual(last.Changed, []string{"werkzeug>=2.0"}) {
+		t.Errorf("unexpected last event %+v", last)
+	}
+}
+
+func TestTimelineUploadOrder(t *testing.T) {
+	g := NewVersionedPyPIGraph()
+	g.Add("django", "1.11.29", []*Requirement{{Name: "pytz"}})
+	g.Add("django", "2.0", []*Requirement{{Name: "pytz"}, {Name: "sqlparse", Constraint: ">=", Version: "0.2"},
+		{Name: "sqlparse", Constraint: "<", Version: "1"}})
+	g.Add("django", "2.1", []*Requirement{{Name: "pytz"}, {Name: "sqlparse", Constraint: "<", Version: "1"},
+		{Name: "sqlparse", Constraint: ">=", Version: "0.2"}})
+	g.Add("django", "2.2", []*Requirement{{Name: "pytz"}, {Name: "sqlparse", Constraint: ">=", Version: "0.2"}})
+
+	uploaded := map[string]time.Time{
+		"2.0":     time.Date(2017, 12, 2, 0, 0, 0, 0, time.UTC),
+		"2.1":     time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC),
+		"2.2":     time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC),
+		"1.11.29": time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC),
+	}
+	events := g.Timeline("django", uploaded)
+	var versions []string
+	for _, event := range events {
+		versions = append(versions, event.Version)
+	}
+	if exp := []string{"2.0", "2.1", "2.2", "1.11.29"}; !reflect.DeepEqual(versions, exp) {
+		t.Fatalf("expected releases in upload order %q, got %q", exp, versions)
+	}
+	if second := events[1]; second.Added != nil || second.Removed != nil || second.Changed != nil {
+		t.Errorf("expected no changes from reordered specifiers, got %+v", second)
+	}
+	if third := events[2]; !reflect.DeepEqual(third.Changed, []string{"sqlparse>=0.2"}) || third.Removed != nil {
+		t.Errorf("expected the dropped upper bound to change the requirement, got %+v", third)
+	}
+	if last := events[3]; last.Previous != "2.2" || !reflect.DeepEqual(last.Removed, []string{"sqlparse>=0.2"}) {
+		t.Errorf("expected the backport to follow the last release, got %+v", last)
+	}
+
+	delete(uploaded, "2.0")
+	if events := g.Timeline("django", uploaded); events[0].Version != "1.11.29" {
+		t.Errorf("expected version order without all upload times, got %s first", events[0].Version)
+	}
+}
@@ -0,0 +1,213 @@
+package cheerio
+
+import (
+	"html/template"
+	"io"
+)
+
+// A node of the graph rendered by WriteHTML
+type vizNode struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Root       bool   `json:"root"`
+	Requires   int    `json:"requires"`
+	RequiredBy int    `json:"requiredBy"`
+}
+
+// An edge of the graph rendered by WriteHTML, indexing nodes
+type vizLink struct {
+	Source int    `json:"source"`
+	Target int    `json:"target"`
+	Extra  string `json:"extra,omitempty"`
+}
+
+// Writes a self-contained HTML page rendering the graph as an interactive force-directed diagram: nodes can be dragged, the view panned and
+// zoomed, and hovering a package highlights its dependencies and dependents. The given root packages are emphasized, and edges only required by
+// an extra are dashed. The layout runs in the page without external scripts, so the file can be opened offline or attached to a report; it's
+// meant for subgraphs (see SubgraphOf) of up to a few hundred packages.
+func (p *PyPIGraph) WriteHTML(w io.Writer, title string, roots ...string) error {
+	rootSet := make(map[string]bool)
+	for _, root := range roots {
+		rootSet[p.NormalizedName(root)] = true
+	}
+
+	var data struct {
+		Title string    `json:"title"`
+		Nodes []vizNode `json:"nodes"`
+		Links []vizLink `json:"links"`
+	}
+	data.Title = title
+	index := make(map[string]int)
+	for _, pkg := range p.sortedPkgs() {
+		index[pkg] = len(data.Nodes)
+		data.Nodes = append(data.Nodes, vizNode{ID: pkg, Name: p.DisplayName(pkg), Root: rootSet[pkg], Requires: len(stringSet(p.Requires(pkg))),
+			RequiredBy: len(stringSet(p.RequiredBy(pkg)))})
+	}
+	data.Links = make([]vizLink, 0)
+	p.visitExtraEdges(func(pkg, dep, extra string) {
+		data.Links = append(data.Links, vizLink{Source: index[pkg], Target: index[dep], Extra: extra})
+	})
+	return vizTemplate.Execute(w, data)
+}
+
+var vizTemplate = template.Must(template.New("viz").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  html, body { margin: 0; height: 100%; font: 13px sans-serif; overflow: hidden; }
+  #info { position: absolute; top: 8px; left: 8px; background: rgba(255,255,255,.85); padding: 4px 8px; border-radius: 4px; }
+  svg { width: 100%; height: 100%; cursor: grab; }
+  line { stroke: #999; stroke-opacity: .6; }
+  line.extra { stroke-dasharray: 4 3; }
+  circle { fill: #69b; stroke: #fff; stroke-width: 1.5px; cursor: pointer; }
+  circle.root { fill: #e63; }
+  text { pointer-events: none; fill: #333; }
+  .faded { opacity: .15; }
+  line.out { stroke: #36c; stroke-opacity: 1; }
+  line.in { stroke: #c63; stroke-opacity: 1; }
+</style>
+</head>
+<body>
+<div id="info"><b>{{.Title}}</b> &mdash; drag nodes, scroll to zoom, hover a package to see its dependencies (blue) and dependents (orange)</div>
+<svg id="graph"><defs><marker id="arrow" viewBox="0 -4 8 8" refX="14" markerWidth="6" markerHeight="6" orient="auto">
+<path d="M0,-4L8,0L0,4" fill="#999"></path></marker></defs><g id="view"></g></svg>
+<script>
+(function() {
+  var data = {{.}};
+  var svgNS = "http://www.w3.org/2000/svg";
+  var svg = document.getElementById("graph"), view = document.getElementById("view");
+  var width = window.innerWidth, height = window.innerHeight;
+  var nodes = data.nodes, links = data.links;
+
+  var degree = nodes.map(function() { return 0; });
+  links.forEach(function(l) { degree[l.source]++; degree[l.target]++; });
+  nodes.forEach(function(n, i) {
+    var angle = i * Math.PI * (3 - Math.sqrt(5)), radius = 10 * Math.sqrt(i + 0.5);
+    n.x = width / 2 + radius * Math.cos(angle);
+    n.y = height / 2 + radius * Math.sin(angle);
+    n.vx = n.vy = 0;
+    n.r = 4 + Math.min(12, Math.sqrt(n.requiredBy + n.requires));
+  });
+
+  function el(name, attrs, parent) {
+    var e = document.createElementNS(svgNS, name);
+    for (var k in attrs) e.setAttribute(k, attrs[k]);
+    parent.appendChild(e);
+    return e;
+  }
+  links.forEach(function(l) {
+    l.el = el("line", {"class": l.extra ? "extra" : "", "marker-end": "url(#arrow)"}, view);
+    if (l.extra) el("title", {}, l.el).textContent = "extra: " + l.extra;
+  });
+  nodes.forEach(function(n, i) {
+    n.el = el("circle", {"class": n.root ? "root" : "", r: n.r}, view);
+    el("title", {}, n.el).textContent = n.name + " (" + n.requires + " dependencies, " + n.requiredBy + " dependents)";
+    n.label = el("text", {dx: n.r + 2, dy: 4}, view);
+    n.label.textContent = n.name;
+    n.el.addEventListener("mouseenter", function() { highlight(i); });
+    n.el.addEventListener("mouseleave", function() { highlight(-1); });
+    n.el.addEventListener("pointerdown", function(e) { e.stopPropagation(); dragged = n; reheat(0.3); });
+  });
+
+  function highlight(i) {
+    var near = {};
+    near[i] = true;
+    links.forEach(function(l) {
+      var out = l.source === i, into = l.target === i;
+      if (out) near[l.target] = true;
+      if (into) near[l.source] = true;
+      l.el.setAttribute("class", (l.extra ? "extra " : "") + (i < 0 ? "" : out ? "out" : into ? "in" : "faded"));
+    });
+    nodes.forEach(function(n, j) {
+      var faded = i >= 0 && !near[j];
+      n.el.setAttribute("class", (n.root ? "root " : "") + (faded ? "faded" : ""));
+      n.label.setAttribute("class", faded ? "faded" : "");
+    });
+  }
+
+  // A force simulation in the manner of d3-force: many-body repulsion, link springs, and centering, cooling down as alpha decays
+  var alpha = 1, running = false;
+  function tick() {
+    var i, j, a, b, dx, dy, d2, f;
+    for (i = 0; i < nodes.length; i++) {
+      a = nodes[i];
+      for (j = i + 1; j < nodes.length; j++) {
+        b = nodes[j];
+        dx = b.x - a.x; dy = b.y - a.y;
+        d2 = Math.max(dx * dx + dy * dy, 1);
+        f = 300 * alpha / d2;
+        a.vx -= dx * f; a.vy -= dy * f;
+        b.vx += dx * f; b.vy += dy * f;
+      }
+    }
+    links.forEach(function(l) {
+      a = nodes[l.source]; b = nodes[l.target];
+      dx = b.x - a.x; dy = b.y - a.y;
+      var d = Math.sqrt(dx * dx + dy * dy) || 1;
+      f = (d - 60) / d * alpha / Math.min(degree[l.source], degree[l.target]) / 2;
+      a.vx += dx * f; a.vy += dy * f;
+      b.vx -= dx * f; b.vy -= dy * f;
+    });
+    var cx = 0, cy = 0;
+    nodes.forEach(function(n) { cx += n.x; cy += n.y; });
+    cx = cx / nodes.length - width / 2; cy = cy / nodes.length - height / 2;
+    nodes.forEach(function(n) {
+      if (n === dragged) { n.vx = n.vy = 0; return; }
+      n.vx *= 0.6; n.vy *= 0.6;
+      n.x += n.vx - cx; n.y += n.vy - cy;
+    });
+  }
+  function render() {
+    links.forEach(function(l) {
+      var a = nodes[l.source], b = nodes[l.target];
+      l.el.setAttribute("x1", a.x); l.el.setAttribute("y1", a.y);
+      l.el.setAttribute("x2", b.x); l.el.setAttribute("y2", b.y);
+    });
+    nodes.forEach(function(n) {
+      n.el.setAttribute("cx", n.x); n.el.setAttribute("cy", n.y);
+      n.label.setAttribute("x", n.x); n.label.setAttribute("y", n.y);
+    });
+  }
+  function step() {
+    tick();
+    render();
+    alpha *= 0.977;
+    if (alpha > 0.001 || dragged) requestAnimationFrame(step); else running = false;
+  }
+  function reheat(a) {
+    alpha = Math.max(alpha, a);
+    if (!running) { running = true; requestAnimationFrame(step); }
+  }
+
+  // Dragging nodes, panning, and zooming
+  var dragged = null, panning = null, zoom = {x: 0, y: 0, k: 1};
+  function transform() { view.setAttribute("transform", "translate(" + zoom.x + "," + zoom.y + ") scale(" + zoom.k + ")"); }
+  svg.addEventListener("pointerdown", function(e) { panning = {x: e.clientX - zoom.x, y: e.clientY - zoom.y}; });
+  window.addEventListener("pointermove", function(e) {
+    if (dragged) {
+      dragged.x = (e.clientX - zoom.x) / zoom.k;
+      dragged.y = (e.clientY - zoom.y) / zoom.k;
+      render();
+    } else if (panning) {
+      zoom.x = e.clientX - panning.x; zoom.y = e.clientY - panning.y;
+      transform();
+    }
+  });
+  window.addEventListener("pointerup", function() { dragged = panning = null; });
+  svg.addEventListener("wheel", function(e) {
+    e.preventDefault();
+    var k = Math.min(8, Math.max(0.1, zoom.k * Math.exp(-e.deltaY / 500)));
+    zoom.x = e.clientX - (e.clientX - zoom.x) * k / zoom.k;
+    zoom.y = e.clientY - (e.clientY - zoom.y) * k / zoom.k;
+    zoom.k = k;
+    transform();
+  }, {passive: false});
+
+  reheat(1);
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,30 @@
+package cheerio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	g := testGraph("Flask:werkzeug", "Flask:python-dotenv:dotenv")
+
+	var buf bytes.Buffer
+	if err := g.WriteHTML(&buf, "Flask <deps>", "flask"); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, exp := range []string{
+		`<title>Flask &lt;deps&gt;</title>`,
+		`{"id":"flask","name":"Flask","root":true,"requires":2,"requiredBy":0}`,
+		`{"id":"werkzeug","name":"werkzeug","root":false,"requires":0,"requiredBy":1}`,
+		`"links":[{"source":0,"target":2},{"source":0,"target":1,"extra":"dotenv"}]`,
+	} {
+		if !strings.Contains(html, exp) {
+			t.Errorf("WriteHTML: expected output to contain %s", exp)
+		}
+	}
+	if strings.Contains(html, "<script src") {
+		t.Error("WriteHTML: expected a self-contained page")
+	}
+}
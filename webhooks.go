@@ -0,0 +1,311 @@
+package cheerio
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A webhook registered to be called when the dependencies or dependents of some packages change (see Watches)
+type Watch struct {
+	ID       string   `json:"id"`
+	URL      string   `json:"url"`
+	Packages []string `json:"packages"` // normalized
+}
+
+// How the dependencies and dependents of a watched package changed between two graphs. Lists are sorted.
+type PackageChange struct {
+	Pkg               string   `json:"pkg"`
+	AddedRequires     []string `json:"addedRequires,omitempty"`
+	RemovedRequires   []string `json:"removedRequires,omitempty"`
+	AddedRequiredBy   []string `json:"addedRequiredBy,omitempty"`
+	RemovedRequiredBy []string `json:"removedRequiredBy,omitempty"`
+}
+
+// The JSON body POSTed to the URL of a watch when some of its packages changed
+type WebhookEvent struct {
+	WatchID string          `json:"watchId"`
+	Serial  int64           `json:"serial"` // changelog serial of the new graph
+	Changes []PackageChange `json:"changes"`
+}
+
+// Default timeout of webhook calls
+const DefaultWebhookTimeout = 10 * time.Second
+
+// Environment variable holding the token of the watch API of `cheerio serve` (see Watches.Token)
+const WatchTokenEnv = "CHEERIO_WATCH_TOKEN"
+
+// A registry of webhooks for watched packages. After each refresh of a GraphDaemon, the URL of each watch with packages whose dependencies or
+// dependents changed is called with a WebhookEvent, in the background. Calls that fail aren't retried. Watches are safe for concurrent use.
+type Watches struct {
+	// Client making webhook calls, with a DefaultWebhookTimeout timeout if nil
+	Client *http.Client
+
+	// Bearer token that requests to the watch API must carry ("Authorization: Bearer <token>"), as watches make the server call arbitrary URLs.
+	// The API refuses all requests if it is empty.
+	Token string
+
+	// File the watches are saved to whenever they change, so they survive restarts (see LoadWatches); watches are only kept in memory if empty
+	File string
+
+	Logger Logger
+
+	mu      sync.Mutex
+	watches map[string]*Watch
+	nextID  int
+	calls   sync.WaitGroup // webhook calls in flight
+}
+
+// Returns the watches saved in file, which is created when they are first saved if it doesn't exist
+func LoadWatches(file string) (*Watches, error) {
+	w := &Watches{File: file, watches: make(map[string]*Watch)}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return w, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var watches []*Watch
+	if err := json.Unmarshal(data, &watches); err != nil {
+		return nil, fmt.Errorf("Unable to parse watches in %s: %s", file, err)
+	}
+	for _, watch := range watches {
+		w.watches[watch.ID] = watch
+		if id, _ := strconv.Atoi(watch.ID); id > w.nextID {
+			w.nextID = id
+		}
+	}
+	return w, nil
+}
+
+// Saves the watches to File, replacing it atomically. Must be called with mu held.
+func (w *Watches) save() error {
+	if w.File == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(w.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := w.File + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, w.File)
+}
+
+// Registers a webhook called when the dependencies or dependents of pkgs change
+func (w *Watches) Add(url string, pkgs []string) (*Watch, error) {
+	if url == "" || len(pkgs) == 0 {
+		return nil, fmt.Errorf("A watch needs a URL and at least one package")
+	}
+	watch := &Watch{URL: url, Packages: make([]string, 0, len(pkgs))}
+	for _, pkg := range pkgs {
+		if pkg = NormalizedPkgName(pkg); !containsString(watch.Packages, pkg) {
+			watch.Packages = append(watch.Packages, pkg)
+		}
+	}
+	sort.Strings(watch.Packages)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.watches == nil {
+		w.watches = make(map[string]*Watch)
+	}
+	w.nextID++
+	watch.ID = strconv.Itoa(w.nextID)
+	w.watches[watch.ID] = watch
+	if err := w.save(); err != nil {
+		delete(w.watches, watch.ID)
+		return nil, fmt.Errorf("Unable to save watches: %s", err)
+	}
+	return watch, nil
+}
+
+// Unregisters a watch, returning false if there is no watch with that ID
+func (w *Watches) Remove(id string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	watch, in := w.watches[id]
+	if !in {
+		return false, nil
+	}
+	delete(w.watches, id)
+	if err := w.save(); err != nil {
+		w.watches[id] = watch
+		return true, fmt.Errorf("Unable to save watches: %s", err)
+	}
+	return true, nil
+}
+
+// Returns the registered watches, sorted by ID
+func (w *Watches) List() []*Watch {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sorted()
+}
+
+// Returns the registered watches, sorted by ID. Must be called with mu held.
+func (w *Watches) sorted() []*Watch {
+	watches := make([]*Watch, 0, len(w.watches))
+	for _, watch := range w.watches {
+		watches = append(watches, watch)
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		a, _ := strconv.Atoi(watches[i].ID)
+		b, _ := strconv.Atoi(watches[j].ID)
+		return a < b
+	})
+	return watches
+}
+
+// Calls the webhooks of the watches whose packages changed from graph old to graph new, which has the given changelog serial. The changes are
+// found right away, but the calls are made in the background, so slow webhooks don't hold up refreshes (see Wait).
+func (w *Watches) Notify(old, new *PyPIGraph, serial int64) {
+	type call struct {
+		watch *Watch
+		body  []byte
+	}
+	var calls []call
+	for _, watch := range w.List() {
+		event := WebhookEvent{WatchID: watch.ID, Serial: serial, Changes: make([]PackageChange, 0)}
+		for _, pkg := range watch.Packages {
+			if change := DiffPackage(old, new, pkg); change != nil {
+				event.Changes = append(event.Changes, *change)
+			}
+		}
+		if len(event.Changes) == 0 {
+			continue
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		calls = append(calls, call{watch, body})
+	}
+	if len(calls) == 0 {
+		return
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	w.calls.Add(1)
+	go func() {
+		defer w.calls.Done()
+		for _, c := range calls {
+			resp, err := client.Post(c.watch.URL, "application/json", bytes.NewReader(c.body))
+			if err != nil {
+				loggerOr(w.Logger).Logf("webhook", "unable to call webhook %s of watch %s: %s", c.watch.URL, c.watch.ID, err)
+				continue
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				loggerOr(w.Logger).Logf("webhook", "webhook %s of watch %s returned %s", c.watch.URL, c.watch.ID, resp.Status)
+			}
+		}
+	}()
+}
+
+// Waits for the webhook calls made in the background by Notify to complete
+func (w *Watches) Wait() {
+	w.calls.Wait()
+}
+
+// Returns how the dependencies and dependents of pkg changed from graph old to graph new, or nil if they didn't
+func DiffPackage(old, new *PyPIGraph, pkg string) *PackageChange {
+	pkg = NormalizedPkgName(pkg)
+	change := &PackageChange{Pkg: pkg}
+	change.AddedRequires, change.RemovedRequires = diffStrings(old.Requires(pkg), new.Requires(pkg))
+	change.AddedRequiredBy, change.RemovedRequiredBy = diffStrings(old.RequiredBy(pkg), new.RequiredBy(pkg))
+	if len(change.AddedRequires)+len(change.RemovedRequires)+len(change.AddedRequiredBy)+len(change.RemovedRequiredBy) == 0 {
+		return nil
+	}
+	return change
+}
+
+// Returns the strings of b that aren't in a, and the strings of a that aren't in b, sorted
+func diffStrings(a, b []string) (added, removed []string) {
+	aSet, bSet := stringSet(a), stringSet(b)
+	for s := range bSet {
+		if !aSet[s] {
+			added = append(added, s)
+		}
+	}
+	for s := range aSet {
+		if !bSet[s] {
+			removed = append(removed, s)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// Serves the watch API to requests carrying the Token:
+//
+//	GET /watches            the registered watches
+//	POST /watches           registers a watch from a JSON body like {"url": "https://example.com/hook", "packages": ["flask"]}
+//	DELETE /watches/<id>    unregisters a watch
+func (w *Watches) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if !w.authorized(r) {
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(rw, "The watch API requires a bearer token", http.StatusUnauthorized)
+		return
+	}
+	var resp interface{}
+	switch {
+	case r.URL.Path == "/watches" && r.Method == "GET":
+		resp = w.List()
+	case r.URL.Path == "/watches" && r.Method == "POST":
+		var req Watch
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		watch, err := w.Add(req.URL, req.Packages)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rw).Encode(watch)
+		return
+	case strings.HasPrefix(r.URL.Path, "/watches/") && r.Method == "DELETE":
+		removed, err := w.Remove(strings.TrimPrefix(r.URL.Path, "/watches/"))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		} else if !removed {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.NotFound(rw, r)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(resp)
+}
+
+// Returns whether r carries the Token as a bearer token. Requests are never authorized if there is no token.
+func (w *Watches) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if w.Token == "" || !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(w.Token)) == 1
+}
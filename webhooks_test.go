@@ -0,0 +1,129 @@
+package cheerio
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Returns a request to the watch API carrying the token
+func watchRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer s3cret")
+	return r
+}
+
+func TestWatches(t *testing.T) {
+	var mu sync.Mutex
+	var events []WebhookEvent
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	graph := testGraph("app:flask", "flask:werkzeug", "other:requests")
+	graph.SetInfo(&GraphInfo{Serial: 1})
+	idx := fakeIndex{"flask": {{Name: "werkzeug"}, {Name: "click"}}, "other": {{Name: "requests"}}}
+	d, err := NewGraphDaemon(idx, &fakeChanges{pkgs: []string{"flask", "other"}, last: 2}, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Watches = &Watches{Token: "s3cret"}
+
+	// requests without the token are refused
+	for _, r := range []*http.Request{
+		httptest.NewRequest("POST", "/watches", strings.NewReader(`{"url": "http://example.com", "packages": ["flask"]}`)),
+		httptest.NewRequest("GET", "/watches", nil),
+	} {
+		r.Header.Set("Authorization", "Bearer wrong")
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, r)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected a request with the wrong token to be refused, got %d", r.Method, r.URL, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	(&Watches{}).ServeHTTP(rec, watchRequest("GET", "/watches", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected the watch API to refuse requests without a token configured, got %d", rec.Code)
+	}
+
+	// register watches through the HTTP API
+	for _, body := range []string{
+		`{"url": "` + hook.URL + `", "packages": ["Click", "requests"]}`,
+		`{"url": "` + hook.URL + `", "packages": ["requests"]}`,
+		`{"url": "` + hook.URL + `", "packages": ["app"]}`,
+	} {
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, watchRequest("POST", "/watches", body))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected watch to be created, got %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, watchRequest("DELETE", "/watches/3", ""))
+	if rec.Code != http.StatusNoContent || len(d.Watches.List()) != 2 {
+		t.Errorf("expected watch 3 to be removed, got %d, %v", rec.Code, d.Watches.List())
+	}
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, watchRequest("POST", "/watches", `{"url": "http://example.com"}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a watch without packages to be rejected, got %d", rec.Code)
+	}
+
+	if _, err := d.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d.Watches.Wait()
+	exp := []WebhookEvent{{WatchID: "1", Serial: 2, Changes: []PackageChange{{Pkg: "click", AddedRequiredBy: []string{"flask"}}}}}
+	if !reflect.DeepEqual(events, exp) {
+		t.Errorf("expected events %+v, got %+v", exp, events)
+	}
+}
+
+func TestLoadWatches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheerio-watches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "watches.json")
+
+	watches, err := LoadWatches(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range []string{"flask", "django", "requests"} {
+		if _, err := watches.Add("http://example.com/hook", []string{pkg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if removed, err := watches.Remove("2"); !removed || err != nil {
+		t.Fatalf("expected watch 2 to be removed, got %v, %v", removed, err)
+	}
+
+	// the watches and their IDs survive a restart
+	if watches, err = LoadWatches(file); err != nil {
+		t.Fatal(err)
+	}
+	if list := watches.List(); len(list) != 2 || list[0].ID != "1" || list[1].ID != "3" || list[1].Packages[0] != "requests" {
+		t.Errorf("unexpected watches after reloading: %+v", list)
+	}
+	if watch, err := watches.Add("http://example.com/hook", []string{"click"}); err != nil || watch.ID != "4" {
+		t.Errorf("expected the next watch to get ID 4, got %+v, %v", watch, err)
+	}
+}
@@ -0,0 +1,74 @@
+package cheerio
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/beyang/cheerio/fetch"
+)
+
+var wheelMetadataPattern = regexp.MustCompile(`^[^/]+\.dist-info/METADATA$`)
+
+// Fetches the core metadata of a release of a package from one of its wheels, reading only the zip directory and the METADATA member of the wheel
+// with HTTP range requests rather than downloading it. If ver is empty, uses the latest release that has a wheel that isn't yanked (unless the
+// index's IncludeYanked is set). Returns an error wrapping ErrNoWheel if there is no such wheel.
+func (p *PackageIndex) FetchWheelMetadata(pkg, ver string) (*Metadata, error) {
+	metadata, err := p.Cache.memoize("wheel:"+NormalizedPkgName(pkg)+"@"+ver, func() (interface{}, error) {
+		return p.fetchWheelMetadata(pkg, ver)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metadata.(*Metadata), nil
+}
+
+func (p *PackageIndex) fetchWheelMetadata(pkg, ver string) (*Metadata, error) {
+	artifacts, err := p.pkgArtifacts(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if ver == "" && !p.IncludeYanked {
+		artifacts = withoutYanked(artifacts)
+	}
+	wheel := selectWheel(artifacts, ver)
+	if wheel == nil {
+		if ver != "" {
+			return nil, fmt.Errorf("[no-wheel] %w for pkg %s version %s", ErrNoWheel, pkg, ver)
+		}
+		return nil, fmt.Errorf("[no-wheel] %w for pkg %s", ErrNoWheel, pkg)
+	}
+	return p.readWheelMetadata(wheel)
+}
+
+// Reads the METADATA member of a wheel
+func (p *PackageIndex) readWheelMetadata(wheel *Artifact) (*Metadata, error) {
+	b, err := fetch.RemoteDecompressWith(wheel.URL, wheelMetadataPattern, fetch.Zip, fetch.Options{Client: p.client()})
+	if err != nil {
+		return nil, err
+	}
+	return ParseMetadata(string(b)), nil
+}
+
+// Returns a wheel of release ver, or of the latest release with wheels if ver is empty, preferring pure-Python wheels, which are the same on
+// every platform
+func selectWheel(artifacts []*Artifact, ver string) *Artifact {
+	var wheel *Artifact
+	for _, artifact := range artifacts {
+		if artifact.Type != ArtifactWheel || artifact.Version == "" || (ver != "" && artifact.Version != ver) {
+			continue
+		}
+		if wheel == nil {
+			wheel = artifact
+			continue
+		}
+		if cmp := CompareVersions(artifact.Version, wheel.Version); cmp > 0 || (cmp == 0 && isPureWheel(artifact) && !isPureWheel(wheel)) {
+			wheel = artifact
+		}
+	}
+	return wheel
+}
+
+func isPureWheel(artifact *Artifact) bool {
+	return strings.HasSuffix(artifact.Filename, "-none-any.whl")
+}
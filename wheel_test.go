@@ -0,0 +1,95 @@
+package cheerio
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Counts the body bytes written to a response
+type countingWriter struct {
+	http.ResponseWriter
+	n *int
+}
+
+func (w countingWriter) Write(b []byte) (int, error) {
+	*w.n += len(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func TestFetchWheelMetadata(t *testing.T) {
+	// a wheel with a large incompressible member, so that downloading it whole would show
+	var wheel bytes.Buffer
+	zw := zip.NewWriter(&wheel)
+	big, _ := zw.CreateHeader(&zip.FileHeader{Name: "flask/_big.so", Method: zip.Store})
+	noise := make([]byte, 2<<20)
+	rand.New(rand.NewSource(1)).Read(noise)
+	big.Write(noise)
+	metadata, _ := zw.Create("Flask-2.0.0.dist-info/METADATA")
+	fmt.Fprint(metadata, "Metadata-Version: 2.1\nName: Flask\nVersion: 2.0.0\nRequires-Dist: Werkzeug (>=2.0)\n"+
+		"Requires-Dist: python-dotenv ; extra == 'dotenv'\nRequires-Dist: asgiref (>=3.2) ; (python_version >= \"3.7\") and extra == 'async'\n\nFlask\n")
+	zw.Close()
+
+	ranges := true
+	transferred := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/flask/":
+			fmt.Fprint(w, `<a href="/files/Flask-2.0.0.tar.gz">Flask-2.0.0.tar.gz</a><br/>
+<a href="/files/Flask-1.1.0-py2.py3-none-any.whl">Flask-1.1.0-py2.py3-none-any.whl</a><br/>
+<a href="/files/Flask-2.0.0-cp39-cp39-manylinux1_x86_64.whl">Flask-2.0.0-cp39-cp39-manylinux1_x86_64.whl</a><br/>
+<a href="/files/Flask-2.0.0-py3-none-any.whl">Flask-2.0.0-py3-none-any.whl</a><br/>`)
+		case "/simple/sdistonly/":
+			fmt.Fprint(w, `<a href="/files/sdistonly-1.0.tar.gz">sdistonly-1.0.tar.gz</a><br/>`)
+		case "/files/Flask-2.0.0-py3-none-any.whl":
+			if !ranges {
+				r.Header.Del("Range")
+			}
+			http.ServeContent(countingWriter{w, &transferred}, r, "wheel.whl", time.Time{}, bytes.NewReader(wheel.Bytes()))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	index := &PackageIndex{URI: server.URL}
+
+	for _, ranges = range []bool{true, false} {
+		transferred = 0
+		m, err := index.FetchWheelMetadata("flask", "")
+		if err != nil {
+			t.Fatalf("FetchWheelMetadata (ranges %v): %s", ranges, err)
+		}
+		if m.Name != "Flask" || m.Version != "2.0.0" {
+			t.Errorf("FetchWheelMetadata (ranges %v): unexpected metadata %+v", ranges, m)
+		}
+		if ranges && transferred > 200<<10 {
+			t.Errorf("FetchWheelMetadata: expected range requests to transfer less than 200 KiB, got %d bytes", transferred)
+		} else if !ranges && transferred < wheel.Len() {
+			t.Errorf("FetchWheelMetadata: expected the whole wheel to be downloaded without range support, got %d bytes", transferred)
+		}
+	}
+
+	m, _ := index.FetchWheelMetadata("flask", "2.0.0")
+	var reqs []string
+	for _, req := range m.Requirements() {
+		reqs = append(reqs, strings.Join([]string{req.Name, req.Specifier(), req.Extra, req.Marker}, "|"))
+	}
+	if exp := []string{"Werkzeug|>=2.0||", "python-dotenv||dotenv|", "asgiref|>=3.2|async|python_version >= \"3.7\""}; !reflect.DeepEqual(reqs, exp) {
+		t.Errorf("Requirements: expected %q, got %q", exp, reqs)
+	}
+
+	if _, err := index.FetchWheelMetadata("sdistonly", ""); !errors.Is(err, ErrNoWheel) {
+		t.Errorf("FetchWheelMetadata: expected ErrNoWheel, got %v", err)
+	}
+	if _, err := index.FetchWheelMetadata("flask", "3.0"); !errors.Is(err, ErrNoWheel) {
+		t.Errorf("FetchWheelMetadata: expected ErrNoWheel for a missing version, got %v", err)
+	}
+}